@@ -1,118 +1,123 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"inventario-backend/internal/config"
+	"inventario-backend/internal/database"
 	"log"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"strconv"
 )
 
+const uso = `Uso: go run ./cmd/setup-db [--reset] [comando]
+
+Comandos:
+  up          Aplica las migraciones pendientes (por defecto)
+  down [n]    Revierte las últimas n migraciones (por defecto 1)
+  status      Muestra el estado de cada migración
+
+Opciones:
+  --reset, -r Elimina todo el esquema antes de ejecutar el comando
+`
+
 func main() {
-	// Verificar si se quiere hacer reset
-	reset := len(os.Args) > 1 && (os.Args[1] == "--reset" || os.Args[1] == "-r")
+	var reset bool
+	flag.BoolVar(&reset, "reset", false, "eliminar todo el esquema antes de ejecutar el comando")
+	flag.BoolVar(&reset, "r", false, "alias de --reset")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, uso) }
+	flag.Parse()
+
+	command := "up"
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+	}
 
 	// Cargar configuración desde .env
 	cfg, err := config.LoadConfig()
+	if err == nil {
+		err = cfg.ValidateDB()
+	}
 	if err != nil {
 		log.Fatalf("❌ Error al cargar la configuración: %v", err)
 	}
 
+	fmt.Println("🔌 Conectando a PostgreSQL...")
+	fmt.Printf("   Host: %s\n", cfg.DBHost)
+	fmt.Printf("   Puerto: %s\n", cfg.DBPort)
+	fmt.Printf("   Usuario: %s\n", cfg.DBUser)
+	fmt.Printf("   Base de datos: %s\n", cfg.DBName)
+	fmt.Println()
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
 	if reset {
 		fmt.Println("⚠️  ADVERTENCIA: Esto eliminará todos los datos de la base de datos!")
 		fmt.Println("Presiona Ctrl+C para cancelar, o Enter para continuar...")
 		fmt.Scanln()
 
 		fmt.Println("🔄 Reiniciando base de datos...")
-
-		// Configurar variable de entorno PGPASSWORD
-		os.Setenv("PGPASSWORD", cfg.DBPassword)
-
-		// Ejecutar DROP SCHEMA
-		dropCmd := exec.Command("psql",
-			"-h", cfg.DBHost,
-			"-p", cfg.DBPort,
-			"-U", cfg.DBUser,
-			"-d", cfg.DBName,
-			"-c", "DROP SCHEMA public CASCADE; CREATE SCHEMA public;",
-		)
-
-		dropCmd.Stdout = os.Stdout
-		dropCmd.Stderr = os.Stderr
-
-		if err := dropCmd.Run(); err != nil {
+		if err := database.ResetSchema(ctx, db); err != nil {
 			log.Fatalf("❌ Error al reiniciar el schema: %v", err)
 		}
-
-		fmt.Println("✅ Schema reiniciado. Ejecutando schema.sql...")
+		fmt.Println("✅ Schema reiniciado.")
 		fmt.Println()
 	}
 
-	// Obtener la ruta del schema.sql
-	schemaPath := "database/schema.sql"
-
-	// Si no existe, intentar desde la raíz del proyecto
-	if _, err := os.Stat(schemaPath); os.IsNotExist(err) {
-		// Buscar desde diferentes ubicaciones
-		possiblePaths := []string{
-			"../database/schema.sql",
-			"../../database/schema.sql",
-			"inventario-backend/database/schema.sql",
+	switch command {
+	case "up":
+		applied, err := database.MigrateUp(ctx, db)
+		for _, m := range applied {
+			fmt.Printf("⬆️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("✅ El esquema ya está actualizado")
+		} else {
+			fmt.Printf("✅ %d migración(es) aplicada(s)\n", len(applied))
 		}
 
-		found := false
-		for _, path := range possiblePaths {
-			if _, err := os.Stat(path); err == nil {
-				schemaPath = path
-				found = true
-				break
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps < 1 {
+				log.Fatalf("❌ Número de migraciones inválido: %s", flag.Arg(1))
 			}
 		}
-
-		if !found {
-			// Intentar desde el directorio del ejecutable
-			execPath, _ := os.Executable()
-			execDir := filepath.Dir(execPath)
-			schemaPath = filepath.Join(execDir, "..", "database", "schema.sql")
+		reverted, err := database.MigrateDown(ctx, db, steps)
+		for _, m := range reverted {
+			fmt.Printf("⬇️  %04d_%s\n", m.Version, m.Name)
 		}
-	}
-
-	absPath, _ := filepath.Abs(schemaPath)
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		log.Fatalf("❌ No se encontró el archivo schema.sql en: %s", absPath)
-	}
-
-	fmt.Println("🔌 Conectando a PostgreSQL...")
-	fmt.Printf("   Host: %s\n", cfg.DBHost)
-	fmt.Printf("   Puerto: %s\n", cfg.DBPort)
-	fmt.Printf("   Usuario: %s\n", cfg.DBUser)
-	fmt.Printf("   Base de datos: %s\n", cfg.DBName)
-	fmt.Println()
-
-	fmt.Printf("📋 Ejecutando schema.sql desde: %s\n\n", absPath)
-
-	// Configurar variable de entorno PGPASSWORD
-	os.Setenv("PGPASSWORD", cfg.DBPassword)
-
-	// Construir comando psql
-	cmd := exec.Command("psql",
-		"-h", cfg.DBHost,
-		"-p", cfg.DBPort,
-		"-U", cfg.DBUser,
-		"-d", cfg.DBName,
-		"-f", absPath,
-	)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("✅ %d migración(es) revertida(s)\n", len(reverted))
 
-	// Mostrar la salida en tiempo real
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	case "status":
+		status, err := database.Status(ctx, db)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		for _, s := range status {
+			if s.AppliedAt != nil {
+				fmt.Printf("✅ %04d_%s (aplicada %s)\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("⏳ %04d_%s (pendiente)\n", s.Version, s.Name)
+			}
+		}
 
-	// Ejecutar el comando
-	if err := cmd.Run(); err != nil {
-		log.Fatalf("❌ Error al ejecutar el schema: %v\nAsegúrate de tener PostgreSQL instalado y psql en tu PATH", err)
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
-
-	fmt.Println()
-	fmt.Println("✅ Schema ejecutado correctamente!")
 }
@@ -0,0 +1,64 @@
+// Package alertas evalúa en segundo plano los umbrales de reposición de los
+// productos y registra las alertas de stock bajo.
+package alertas
+
+import (
+	"context"
+	"inventario-backend/internal/repository"
+	"log"
+	"time"
+)
+
+// Revision es cada cuánto se evalúan todos los productos, para cubrir los
+// cambios de umbral y los productos que no entraron en la cola llena
+const Revision = 5 * time.Minute
+
+// Evaluador recibe los productos cuyo stock cambió y los evalúa fuera de la
+// petición, en una sola goroutine, para no demorar la respuesta al cliente.
+type Evaluador struct {
+	repo       repository.AlertaRepository
+	pendientes chan int
+}
+
+func NewEvaluador(repo repository.AlertaRepository) *Evaluador {
+	return &Evaluador{repo: repo, pendientes: make(chan int, 256)}
+}
+
+// Notificar encola el producto para evaluarlo. No bloquea: si la cola está
+// llena, el producto se evaluará en la próxima revisión.
+func (e *Evaluador) Notificar(productoID int) {
+	select {
+	case e.pendientes <- productoID:
+	default:
+		log.Printf("⚠️  Cola de alertas llena; el producto %d se evaluará en la próxima revisión", productoID)
+	}
+}
+
+// Ejecutar evalúa todos los productos al arrancar y luego los notificados y
+// cada Revision, hasta que se cancele ctx
+func (e *Evaluador) Ejecutar(ctx context.Context) {
+	e.evaluar(ctx)
+	ticker := time.NewTicker(Revision)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-e.pendientes:
+			e.evaluar(ctx, id)
+		case <-ticker.C:
+			e.evaluar(ctx)
+		}
+	}
+}
+
+func (e *Evaluador) evaluar(ctx context.Context, productoIDs ...int) {
+	alertas, err := e.repo.Evaluar(ctx, productoIDs...)
+	if err != nil {
+		log.Printf("❌ Error al evaluar las alertas de stock: %v", err)
+	}
+	for _, a := range alertas {
+		log.Printf("🔔 Alerta de stock (%s): %s tiene %d unidades, umbral %d", a.Nivel, a.Producto, a.Stock, a.Umbral)
+	}
+}
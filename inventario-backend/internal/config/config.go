@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -17,6 +18,10 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 	ServerPort string
+	// Storage indica el backend de almacenamiento: "postgres" o "memory"
+	Storage string
+	// RequireMigrations impide arrancar el servidor si hay migraciones pendientes
+	RequireMigrations bool
 }
 
 func LoadConfig() (*Config, error) {
@@ -75,15 +80,21 @@ func LoadConfig() (*Config, error) {
 		DBName:     getEnv("DB_NAME", "inventario_db"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+		Storage:    getEnv("STORAGE", "postgres"),
 	}
-
-	if config.DBPassword == "" {
-		return nil, fmt.Errorf("DB_PASSWORD no está configurada. Por favor, configura las variables de entorno en el archivo .env")
-	}
+	config.RequireMigrations, _ = strconv.ParseBool(getEnv("DB_REQUIRE_MIGRATIONS", "false"))
 
 	return config, nil
 }
 
+// ValidateDB verifica que la configuración de PostgreSQL esté completa
+func (c *Config) ValidateDB() error {
+	if c.DBPassword == "" {
+		return fmt.Errorf("DB_PASSWORD no está configurada. Por favor, configura las variables de entorno en el archivo .env")
+	}
+	return nil
+}
+
 func (c *Config) GetDBConnectionString() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName, c.DBSSLMode)
@@ -8,29 +8,20 @@ import (
 	_ "github.com/lib/pq"
 )
 
-var DB *sql.DB
-
-func InitDB(cfg *config.Config) error {
-	var err error
+// InitDB abre la conexión a PostgreSQL y verifica que esté disponible
+func InitDB(cfg *config.Config) (*sql.DB, error) {
 	connStr := cfg.GetDBConnectionString()
 
-	DB, err = sql.Open("postgres", connStr)
+	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		return fmt.Errorf("error al abrir la conexión a la base de datos: %w", err)
+		return nil, fmt.Errorf("error al abrir la conexión a la base de datos: %w", err)
 	}
 
-	if err = DB.Ping(); err != nil {
-		return fmt.Errorf("error al hacer ping a la base de datos: %w", err)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error al hacer ping a la base de datos: %w", err)
 	}
 
 	fmt.Println("✅ Conexión a la base de datos establecida correctamente")
-	return nil
+	return db, nil
 }
-
-func CloseDB() error {
-	if DB != nil {
-		return DB.Close()
-	}
-	return nil
-}
-
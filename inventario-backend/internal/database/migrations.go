@@ -0,0 +1,313 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID identifica el advisory lock que evita que dos procesos
+// apliquen migraciones al mismo tiempo
+const migrationLockID = 7461736
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration es un cambio versionado del esquema. Los archivos viven en
+// internal/database/migrations con el formato NNNN_nombre.up.sql y
+// NNNN_nombre.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus indica si una migración ya fue aplicada y cuándo
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations devuelve las migraciones embebidas ordenadas por versión
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("nombre de migración inválido: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := migrationsFS.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("la versión %d tiene dos nombres: %s y %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("la migración %04d_%s no tiene archivo .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// legacyVersions son las migraciones que equivalen al antiguo
+// database/schema.sql: el esquema inicial y sus datos de ejemplo
+var legacyVersions = []int{1, 2}
+
+// ensureMigrationsTable crea la tabla de control si todavía no existe. Si la
+// base de datos fue creada con el antiguo database/schema.sql, que no tenía
+// tabla de control, registra como aplicadas las migraciones que lo
+// reemplazan para no volver a crear sus tablas ni duplicar los datos de
+// ejemplo.
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	var existe, legado bool
+	err := db.QueryRowContext(ctx, `
+		SELECT to_regclass('schema_migrations') IS NOT NULL, to_regclass('productos') IS NOT NULL
+	`).Scan(&existe, &legado)
+	if err != nil || existe {
+		return err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			nombre VARCHAR(200) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if legado {
+		for _, m := range migrations {
+			if !slices.Contains(legacyVersions, m.Version) {
+				continue
+			}
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO schema_migrations (version, nombre) VALUES ($1, $2)
+				ON CONFLICT (version) DO NOTHING
+			`, m.Version, m.Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// appliedMigrations devuelve la fecha de aplicación de cada versión
+// aplicada. Solo lee: si la tabla de control no existe, no hay ninguna.
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[int]time.Time, error) {
+	var existe bool
+	err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&existe)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]time.Time)
+	if !existe {
+		return applied, nil
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// withMigrationLock ejecuta fn con el advisory lock de migraciones tomado.
+// Usa una única conexión porque los advisory locks son por sesión.
+func withMigrationLock(ctx context.Context, db *sql.DB, fn func() error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("error al obtener el bloqueo de migraciones: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	return fn()
+}
+
+// Status devuelve todas las migraciones conocidas junto con su estado. No
+// modifica la base de datos: sin tabla de control, todas figuran como
+// pendientes hasta que MigrateUp la cree.
+func Status(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = MigrationStatus{Migration: m}
+		if appliedAt, ok := applied[m.Version]; ok {
+			status[i].AppliedAt = &appliedAt
+		}
+	}
+	return status, nil
+}
+
+// Pending devuelve las migraciones que todavía no se han aplicado
+func Pending(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	status, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateUp aplica en orden todas las migraciones pendientes. Cada una se
+// ejecuta en su propia transacción junto con su registro en schema_migrations.
+func MigrateUp(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	var done []Migration
+	err := withMigrationLock(ctx, db, func() error {
+		if err := ensureMigrationsTable(ctx, db); err != nil {
+			return err
+		}
+		pending, err := Pending(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range pending {
+			err := runInTx(ctx, db, m.Up, `
+				INSERT INTO schema_migrations (version, nombre) VALUES ($1, $2)
+			`, m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("error al aplicar la migración %04d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateDown revierte las últimas `steps` migraciones aplicadas
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	var done []Migration
+	err := withMigrationLock(ctx, db, func() error {
+		if err := ensureMigrationsTable(ctx, db); err != nil {
+			return err
+		}
+		status, err := Status(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		for i := len(status) - 1; i >= 0 && len(done) < steps; i-- {
+			m := status[i].Migration
+			if status[i].AppliedAt == nil {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("la migración %04d_%s no tiene archivo .down.sql", m.Version, m.Name)
+			}
+
+			err := runInTx(ctx, db, m.Down, `
+				DELETE FROM schema_migrations WHERE version = $1
+			`, m.Version)
+			if err != nil {
+				return fmt.Errorf("error al revertir la migración %04d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// ResetSchema elimina todos los objetos del esquema public
+func ResetSchema(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "DROP SCHEMA public CASCADE; CREATE SCHEMA public;")
+	return err
+}
+
+// runInTx ejecuta el script de la migración y la actualización de
+// schema_migrations en una misma transacción
+func runInTx(ctx context.Context, db *sql.DB, script, bookkeeping string, args ...interface{}) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
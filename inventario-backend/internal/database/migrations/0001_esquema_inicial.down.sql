@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS movimientos_inventario;
+DROP TABLE IF EXISTS productos;
+DROP TABLE IF EXISTS categorias;
+DROP FUNCTION IF EXISTS update_updated_at_column();
@@ -0,0 +1,9 @@
+DELETE FROM productos WHERE nombre IN (
+    'Laptop Dell Inspiron 15',
+    'Mouse Inalámbrico Logitech',
+    'Camiseta Básica',
+    'Arroz Integral 1kg'
+);
+DELETE FROM categorias c
+WHERE c.nombre IN ('Electrónica', 'Ropa', 'Alimentos', 'Hogar')
+  AND NOT EXISTS (SELECT 1 FROM productos p WHERE p.categoria_id = c.id);
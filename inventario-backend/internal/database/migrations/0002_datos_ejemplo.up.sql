@@ -0,0 +1,18 @@
+-- Insertar algunas categorías de ejemplo
+INSERT INTO categorias (nombre, descripcion) VALUES
+    ('Electrónica', 'Dispositivos y componentes electrónicos'),
+    ('Ropa', 'Prendas de vestir y accesorios'),
+    ('Alimentos', 'Productos alimenticios y bebidas'),
+    ('Hogar', 'Artículos para el hogar')
+ON CONFLICT (nombre) DO NOTHING;
+
+-- Insertar algunos productos de ejemplo
+INSERT INTO productos (nombre, descripcion, precio, stock, categoria_id)
+SELECT v.nombre, v.descripcion, v.precio, v.stock, c.id
+FROM (VALUES
+    ('Laptop Dell Inspiron 15', 'Laptop Dell con procesador Intel i5, 8GB RAM, 256GB SSD', 899.99, 10, 'Electrónica'),
+    ('Mouse Inalámbrico Logitech', 'Mouse inalámbrico con sensor óptico de alta precisión', 29.99, 50, 'Electrónica'),
+    ('Camiseta Básica', 'Camiseta de algodón 100%, varios colores disponibles', 19.99, 100, 'Ropa'),
+    ('Arroz Integral 1kg', 'Arroz integral de grano largo, empaque de 1kg', 4.99, 200, 'Alimentos')
+) AS v(nombre, descripcion, precio, stock, categoria)
+JOIN categorias c ON c.nombre = v.categoria;
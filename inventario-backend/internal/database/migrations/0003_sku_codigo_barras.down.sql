@@ -0,0 +1,3 @@
+ALTER TABLE productos
+    DROP COLUMN IF EXISTS codigo_barras,
+    DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,4 @@
+-- Identificadores de almacén: SKU interno y código de barras EAN/UPC
+ALTER TABLE productos
+    ADD COLUMN sku VARCHAR(64) UNIQUE,
+    ADD COLUMN codigo_barras VARCHAR(14) UNIQUE;
@@ -0,0 +1,3 @@
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS almacen_id;
+DROP TABLE IF EXISTS stock_almacen;
+DROP TABLE IF EXISTS almacenes;
@@ -0,0 +1,47 @@
+-- Almacenes (bodegas, tienda, etc.)
+CREATE TABLE almacenes (
+    id SERIAL PRIMARY KEY,
+    nombre VARCHAR(100) NOT NULL UNIQUE,
+    descripcion TEXT,
+    principal BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Solo puede haber un almacén principal
+CREATE UNIQUE INDEX idx_almacenes_principal ON almacenes(principal) WHERE principal;
+
+CREATE TRIGGER update_almacenes_updated_at BEFORE UPDATE ON almacenes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Existencias por producto y almacén. productos.stock se mantiene como el
+-- total de todos los almacenes.
+CREATE TABLE stock_almacen (
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    almacen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    cantidad INTEGER NOT NULL DEFAULT 0 CHECK (cantidad >= 0),
+    PRIMARY KEY (producto_id, almacen_id)
+);
+
+CREATE INDEX idx_stock_almacen_almacen ON stock_almacen(almacen_id);
+
+-- El stock existente pasa al almacén principal
+INSERT INTO almacenes (nombre, descripcion, principal)
+VALUES ('Almacén Principal', 'Almacén por defecto', true);
+
+INSERT INTO stock_almacen (producto_id, almacen_id, cantidad)
+SELECT p.id, a.id, p.stock
+FROM productos p
+CROSS JOIN almacenes a
+WHERE a.principal AND p.stock > 0;
+
+-- Cada movimiento registra el almacén afectado
+ALTER TABLE movimientos_inventario
+    ADD COLUMN almacen_id INTEGER REFERENCES almacenes(id) ON DELETE RESTRICT;
+
+UPDATE movimientos_inventario
+SET almacen_id = (SELECT id FROM almacenes WHERE principal);
+
+ALTER TABLE movimientos_inventario ALTER COLUMN almacen_id SET NOT NULL;
+
+CREATE INDEX idx_movimientos_almacen ON movimientos_inventario(almacen_id);
@@ -0,0 +1,2 @@
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS traslado_id;
+DROP TABLE IF EXISTS traslados;
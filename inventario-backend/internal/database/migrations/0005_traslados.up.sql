@@ -0,0 +1,22 @@
+-- Traslados de stock entre almacenes
+CREATE TABLE traslados (
+    id SERIAL PRIMARY KEY,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    almacen_origen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    almacen_destino_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    estado VARCHAR(20) NOT NULL DEFAULT 'en_transito' CHECK (estado IN ('en_transito', 'recibido')),
+    motivo TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    recibido_at TIMESTAMP,
+    CONSTRAINT traslados_almacenes_distintos CHECK (almacen_origen_id <> almacen_destino_id)
+);
+
+CREATE INDEX idx_traslados_producto ON traslados(producto_id);
+CREATE INDEX idx_traslados_estado ON traslados(estado);
+
+-- La salida y la entrada de un traslado comparten traslado_id
+ALTER TABLE movimientos_inventario
+    ADD COLUMN traslado_id INTEGER REFERENCES traslados(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_movimientos_traslado ON movimientos_inventario(traslado_id);
@@ -0,0 +1,17 @@
+ALTER TABLE movimientos_inventario DROP CONSTRAINT IF EXISTS movimientos_inventario_ajuste_motivo_check;
+ALTER TABLE movimientos_inventario DROP CONSTRAINT IF EXISTS movimientos_inventario_codigo_motivo_check;
+ALTER TABLE movimientos_inventario DROP CONSTRAINT IF EXISTS movimientos_inventario_cantidad_check;
+ALTER TABLE movimientos_inventario DROP CONSTRAINT IF EXISTS movimientos_inventario_tipo_check;
+
+-- Los ajustes se conservan como entradas o salidas para no descuadrar el stock
+UPDATE movimientos_inventario
+SET tipo = CASE WHEN cantidad > 0 THEN 'entrada' ELSE 'salida' END,
+    cantidad = ABS(cantidad)
+WHERE tipo = 'ajuste';
+
+DROP INDEX IF EXISTS idx_movimientos_codigo_motivo;
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS codigo_motivo;
+
+ALTER TABLE movimientos_inventario
+    ADD CONSTRAINT movimientos_inventario_tipo_check CHECK (tipo IN ('entrada', 'salida')),
+    ADD CONSTRAINT movimientos_inventario_cantidad_check CHECK (cantidad > 0);
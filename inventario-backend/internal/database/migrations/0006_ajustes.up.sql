@@ -0,0 +1,19 @@
+-- Ajustes de inventario: cantidad con signo y código de motivo obligatorio
+ALTER TABLE movimientos_inventario DROP CONSTRAINT movimientos_inventario_tipo_check;
+ALTER TABLE movimientos_inventario DROP CONSTRAINT movimientos_inventario_cantidad_check;
+
+ALTER TABLE movimientos_inventario ADD COLUMN codigo_motivo VARCHAR(20);
+
+ALTER TABLE movimientos_inventario
+    ADD CONSTRAINT movimientos_inventario_tipo_check
+        CHECK (tipo IN ('entrada', 'salida', 'ajuste')),
+    ADD CONSTRAINT movimientos_inventario_cantidad_check
+        CHECK (cantidad > 0 OR (tipo = 'ajuste' AND cantidad <> 0)),
+    ADD CONSTRAINT movimientos_inventario_codigo_motivo_check
+        CHECK (codigo_motivo IN ('merma', 'dano', 'conteo', 'devolucion', 'hallazgo')),
+    -- Solo los ajustes llevan código de motivo, y todos lo llevan
+    ADD CONSTRAINT movimientos_inventario_ajuste_motivo_check
+        CHECK ((tipo = 'ajuste') = (codigo_motivo IS NOT NULL));
+
+CREATE INDEX idx_movimientos_codigo_motivo ON movimientos_inventario(codigo_motivo)
+    WHERE codigo_motivo IS NOT NULL;
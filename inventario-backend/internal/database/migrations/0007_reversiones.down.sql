@@ -0,0 +1 @@
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS revierte_id;
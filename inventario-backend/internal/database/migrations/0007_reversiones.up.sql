@@ -0,0 +1,6 @@
+-- Un movimiento puede revertir a otro; UNIQUE impide revertirlo dos veces
+ALTER TABLE movimientos_inventario
+    ADD COLUMN revierte_id INTEGER REFERENCES movimientos_inventario(id) ON DELETE CASCADE;
+
+ALTER TABLE movimientos_inventario
+    ADD CONSTRAINT movimientos_inventario_revierte_id_key UNIQUE (revierte_id);
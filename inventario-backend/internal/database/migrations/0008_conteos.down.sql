@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS conteo_registros;
+DROP TABLE IF EXISTS conteo_items;
+DROP TABLE IF EXISTS conteos;
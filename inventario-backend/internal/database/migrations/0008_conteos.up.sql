@@ -0,0 +1,35 @@
+-- Sesiones de conteo físico de un almacén, completo o de una categoría
+CREATE TABLE conteos (
+    id SERIAL PRIMARY KEY,
+    almacen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    categoria_id INTEGER REFERENCES categorias(id) ON DELETE SET NULL,
+    estado VARCHAR(20) NOT NULL DEFAULT 'abierto' CHECK (estado IN ('abierto', 'aprobado', 'cancelado')),
+    descripcion TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    cerrado_at TIMESTAMP
+);
+
+CREATE INDEX idx_conteos_estado ON conteos(estado);
+
+-- Stock esperado de cada producto al abrir la sesión y, tras aprobarla, el
+-- ajuste que corrigió la diferencia
+CREATE TABLE conteo_items (
+    conteo_id INTEGER NOT NULL REFERENCES conteos(id) ON DELETE CASCADE,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    stock_esperado INTEGER NOT NULL,
+    movimiento_id INTEGER REFERENCES movimientos_inventario(id) ON DELETE SET NULL,
+    PRIMARY KEY (conteo_id, producto_id)
+);
+
+-- Cantidades contadas. Cada contador registra una cantidad por producto y
+-- pasada; un nuevo registro del mismo contador en la misma pasada la reemplaza.
+CREATE TABLE conteo_registros (
+    conteo_id INTEGER NOT NULL,
+    producto_id INTEGER NOT NULL,
+    pasada INTEGER NOT NULL CHECK (pasada > 0),
+    contador VARCHAR(100) NOT NULL DEFAULT '',
+    cantidad INTEGER NOT NULL CHECK (cantidad >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (conteo_id, producto_id, pasada, contador),
+    FOREIGN KEY (conteo_id, producto_id) REFERENCES conteo_items(conteo_id, producto_id) ON DELETE CASCADE
+);
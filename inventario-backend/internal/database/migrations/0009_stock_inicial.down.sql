@@ -0,0 +1,8 @@
+ALTER TABLE movimientos_inventario DROP CONSTRAINT IF EXISTS movimientos_inventario_codigo_motivo_check;
+
+-- Los saldos iniciales se conservan como hallazgos para no descuadrar el stock
+UPDATE movimientos_inventario SET codigo_motivo = 'hallazgo' WHERE codigo_motivo = 'inicial';
+
+ALTER TABLE movimientos_inventario
+    ADD CONSTRAINT movimientos_inventario_codigo_motivo_check
+        CHECK (codigo_motivo IN ('merma', 'dano', 'conteo', 'devolucion', 'hallazgo'));
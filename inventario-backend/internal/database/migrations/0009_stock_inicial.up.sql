@@ -0,0 +1,20 @@
+-- El stock inicial de los productos se registra como un ajuste para que el
+-- libro de movimientos explique todo el stock
+ALTER TABLE movimientos_inventario DROP CONSTRAINT movimientos_inventario_codigo_motivo_check;
+ALTER TABLE movimientos_inventario
+    ADD CONSTRAINT movimientos_inventario_codigo_motivo_check
+        CHECK (codigo_motivo IN ('merma', 'dano', 'conteo', 'devolucion', 'hallazgo', 'inicial'));
+
+-- Saldo inicial de los productos existentes: la parte del stock de cada
+-- almacén que sus movimientos no explican (stock inicial y ediciones directas)
+INSERT INTO movimientos_inventario (producto_id, almacen_id, tipo, cantidad, codigo_motivo, motivo, created_at)
+SELECT s.producto_id, s.almacen_id, 'ajuste', s.cantidad - COALESCE(l.cantidad, 0), 'inicial', 'Saldo inicial', p.created_at
+FROM stock_almacen s
+JOIN productos p ON p.id = s.producto_id
+LEFT JOIN (
+    SELECT producto_id, almacen_id,
+           SUM(CASE WHEN tipo = 'salida' THEN -cantidad ELSE cantidad END) AS cantidad
+    FROM movimientos_inventario
+    GROUP BY producto_id, almacen_id
+) l ON l.producto_id = s.producto_id AND l.almacen_id = s.almacen_id
+WHERE s.cantidad <> COALESCE(l.cantidad, 0);
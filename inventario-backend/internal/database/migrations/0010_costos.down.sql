@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_movimientos_producto_fecha;
+ALTER TABLE movimientos_inventario DROP CONSTRAINT IF EXISTS movimientos_inventario_costo_unitario_check;
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS costo_unitario;
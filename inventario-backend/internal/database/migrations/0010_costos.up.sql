@@ -0,0 +1,11 @@
+-- Costo de compra de las entradas y los ajustes positivos, para el kardex
+ALTER TABLE movimientos_inventario ADD COLUMN costo_unitario NUMERIC(14, 4);
+
+ALTER TABLE movimientos_inventario
+    ADD CONSTRAINT movimientos_inventario_costo_unitario_check
+        CHECK (costo_unitario IS NULL OR (
+            costo_unitario >= 0 AND (tipo = 'entrada' OR (tipo = 'ajuste' AND cantidad > 0))
+        ));
+
+-- El kardex recorre los movimientos de un producto en orden cronológico
+CREATE INDEX idx_movimientos_producto_fecha ON movimientos_inventario(producto_id, created_at, id);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS consumos_capa;
+DROP TABLE IF EXISTS capas_costo;
+
+ALTER TABLE movimientos_inventario DROP CONSTRAINT IF EXISTS movimientos_inventario_moneda_check;
+ALTER TABLE movimientos_inventario
+    DROP COLUMN IF EXISTS costo_total,
+    DROP COLUMN IF EXISTS tipo_cambio,
+    DROP COLUMN IF EXISTS moneda;
+
+ALTER TABLE productos
+    DROP COLUMN IF EXISTS valor_inventario,
+    DROP COLUMN IF EXISTS metodo_costeo;
@@ -0,0 +1,58 @@
+-- Método de costeo y valor del inventario de cada producto
+ALTER TABLE productos
+    ADD COLUMN metodo_costeo VARCHAR(10) NOT NULL DEFAULT 'promedio'
+        CONSTRAINT productos_metodo_costeo_check CHECK (metodo_costeo IN ('promedio', 'fifo')),
+    ADD COLUMN valor_inventario NUMERIC(16, 4) NOT NULL DEFAULT 0;
+
+-- Moneda del costo de compra y costo en moneda local de cada movimiento
+ALTER TABLE movimientos_inventario
+    ADD COLUMN moneda CHAR(3),
+    ADD COLUMN tipo_cambio NUMERIC(14, 6),
+    ADD COLUMN costo_total NUMERIC(16, 4),
+    ADD CONSTRAINT movimientos_inventario_moneda_check
+        CHECK ((moneda IS NULL) = (tipo_cambio IS NULL)
+               AND (moneda IS NULL OR costo_unitario IS NOT NULL)
+               AND (tipo_cambio IS NULL OR tipo_cambio > 0));
+
+-- Capas de costo: unidades que entraron juntas al mismo costo (en moneda
+-- local) y cuántas quedan
+CREATE TABLE capas_costo (
+    id SERIAL PRIMARY KEY,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    -- NULL en las capas de apertura creadas por esta migración
+    movimiento_id INTEGER REFERENCES movimientos_inventario(id) ON DELETE CASCADE,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    restante INTEGER NOT NULL CHECK (restante >= 0 AND restante <= cantidad),
+    costo_unitario NUMERIC(14, 4) NOT NULL CHECK (costo_unitario >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_capas_costo_vigentes ON capas_costo(producto_id, id) WHERE restante > 0;
+
+-- Unidades que cada salida tomó de cada capa, para devolverlas al revertirla
+CREATE TABLE consumos_capa (
+    movimiento_id INTEGER NOT NULL REFERENCES movimientos_inventario(id) ON DELETE CASCADE,
+    capa_id INTEGER NOT NULL REFERENCES capas_costo(id) ON DELETE CASCADE,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    PRIMARY KEY (movimiento_id, capa_id)
+);
+
+CREATE INDEX idx_consumos_capa_capa ON consumos_capa(capa_id);
+
+-- Apertura: el stock actual queda en una capa al costo promedio de las
+-- entradas con costo registrado, o a 0 si no hay ninguna
+INSERT INTO capas_costo (producto_id, cantidad, restante, costo_unitario)
+SELECT p.id, p.stock, p.stock, COALESCE(c.costo, 0)
+FROM productos p
+LEFT JOIN (
+    SELECT producto_id, SUM(cantidad * costo_unitario) / SUM(cantidad) AS costo
+    FROM movimientos_inventario
+    WHERE costo_unitario IS NOT NULL
+    GROUP BY producto_id
+) c ON c.producto_id = p.id
+WHERE p.stock > 0;
+
+UPDATE productos p
+SET valor_inventario = c.restante * c.costo_unitario
+FROM capas_costo c
+WHERE c.producto_id = p.id AND c.costo_unitario > 0;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS alertas_stock;
+
+ALTER TABLE productos
+    DROP CONSTRAINT IF EXISTS productos_reorden_check,
+    DROP COLUMN IF EXISTS cantidad_reorden,
+    DROP COLUMN IF EXISTS punto_reorden,
+    DROP COLUMN IF EXISTS stock_minimo;
@@ -0,0 +1,26 @@
+-- Umbrales de reposición de cada producto; 0 desactiva cada uno
+ALTER TABLE productos
+    ADD COLUMN stock_minimo INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN punto_reorden INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN cantidad_reorden INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT productos_reorden_check
+        CHECK (stock_minimo >= 0 AND punto_reorden >= 0 AND cantidad_reorden >= 0);
+
+-- Alertas de stock bajo. El stock y el umbral son los del momento en que se
+-- generó (o escaló) la alerta.
+CREATE TABLE alertas_stock (
+    id SERIAL PRIMARY KEY,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    nivel VARCHAR(10) NOT NULL CHECK (nivel IN ('reorden', 'minimo')),
+    estado VARCHAR(20) NOT NULL DEFAULT 'activa' CHECK (estado IN ('activa', 'reconocida', 'resuelta')),
+    stock INTEGER NOT NULL,
+    umbral INTEGER NOT NULL,
+    cantidad_reorden INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reconocida_at TIMESTAMP,
+    resuelta_at TIMESTAMP
+);
+
+-- A lo sumo una alerta sin resolver por producto
+CREATE UNIQUE INDEX idx_alertas_stock_abierta ON alertas_stock(producto_id) WHERE estado <> 'resuelta';
+CREATE INDEX idx_alertas_stock_estado ON alertas_stock(estado, created_at);
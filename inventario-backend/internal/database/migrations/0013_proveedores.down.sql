@@ -0,0 +1,6 @@
+ALTER TABLE movimientos_inventario
+    DROP CONSTRAINT IF EXISTS movimientos_inventario_proveedor_check,
+    DROP COLUMN IF EXISTS proveedor_id;
+
+DROP TABLE IF EXISTS producto_proveedores;
+DROP TABLE IF EXISTS proveedores;
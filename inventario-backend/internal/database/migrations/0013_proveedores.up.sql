@@ -0,0 +1,36 @@
+-- Proveedores y sus datos de contacto
+CREATE TABLE proveedores (
+    id SERIAL PRIMARY KEY,
+    nombre VARCHAR(255) NOT NULL UNIQUE,
+    contacto VARCHAR(255),
+    email VARCHAR(255),
+    telefono VARCHAR(50),
+    direccion TEXT,
+    notas TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Productos que suministra cada proveedor. ultimo_costo (en moneda local) y
+-- ultima_entrada_at se actualizan con cada entrada del proveedor.
+CREATE TABLE producto_proveedores (
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    proveedor_id INTEGER NOT NULL REFERENCES proveedores(id) ON DELETE CASCADE,
+    sku_proveedor VARCHAR(64),
+    plazo_entrega_dias INTEGER NOT NULL DEFAULT 0 CHECK (plazo_entrega_dias >= 0),
+    ultimo_costo NUMERIC(14, 4) CHECK (ultimo_costo >= 0),
+    ultima_entrada_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (producto_id, proveedor_id)
+);
+
+CREATE INDEX idx_producto_proveedores_proveedor ON producto_proveedores(proveedor_id);
+
+-- Proveedor de cada entrada; un proveedor con entradas no se puede eliminar
+ALTER TABLE movimientos_inventario
+    ADD COLUMN proveedor_id INTEGER REFERENCES proveedores(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT movimientos_inventario_proveedor_check CHECK (proveedor_id IS NULL OR tipo = 'entrada');
+
+CREATE INDEX idx_movimientos_proveedor ON movimientos_inventario(proveedor_id, created_at)
+    WHERE proveedor_id IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS orden_compra_id;
+
+DROP TABLE IF EXISTS orden_compra_lineas;
+DROP TABLE IF EXISTS ordenes_compra;
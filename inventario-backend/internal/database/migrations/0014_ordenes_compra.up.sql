@@ -0,0 +1,34 @@
+-- Órdenes de compra a proveedores
+CREATE TABLE ordenes_compra (
+    id SERIAL PRIMARY KEY,
+    proveedor_id INTEGER NOT NULL REFERENCES proveedores(id) ON DELETE RESTRICT,
+    almacen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    estado VARCHAR(20) NOT NULL DEFAULT 'borrador'
+        CHECK (estado IN ('borrador', 'enviada', 'recibida_parcial', 'recibida', 'cancelada')),
+    notas TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    enviada_at TIMESTAMP,
+    cerrada_at TIMESTAMP
+);
+
+CREATE INDEX idx_ordenes_compra_estado ON ordenes_compra(estado);
+CREATE INDEX idx_ordenes_compra_proveedor ON ordenes_compra(proveedor_id);
+
+-- Productos pedidos y lo recibido de cada uno. Cada producto aparece una sola
+-- vez por orden.
+CREATE TABLE orden_compra_lineas (
+    orden_id INTEGER NOT NULL REFERENCES ordenes_compra(id) ON DELETE CASCADE,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    costo_unitario NUMERIC(14, 4) NOT NULL CHECK (costo_unitario >= 0),
+    cantidad_recibida INTEGER NOT NULL DEFAULT 0 CHECK (cantidad_recibida >= 0),
+    PRIMARY KEY (orden_id, producto_id)
+);
+
+-- Orden de compra de las entradas registradas al recibir
+ALTER TABLE movimientos_inventario
+    ADD COLUMN orden_compra_id INTEGER REFERENCES ordenes_compra(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_movimientos_orden_compra ON movimientos_inventario(orden_compra_id)
+    WHERE orden_compra_id IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS orden_venta_id;
+
+DROP TABLE IF EXISTS orden_venta_lineas;
+DROP TABLE IF EXISTS ordenes_venta;
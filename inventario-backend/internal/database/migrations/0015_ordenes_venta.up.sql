@@ -0,0 +1,35 @@
+-- Órdenes de venta a clientes. Las líneas de las órdenes confirmadas
+-- reservan stock en su almacén hasta que se despachan o cancelan.
+CREATE TABLE ordenes_venta (
+    id SERIAL PRIMARY KEY,
+    cliente VARCHAR(255) NOT NULL,
+    almacen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    estado VARCHAR(20) NOT NULL DEFAULT 'borrador'
+        CHECK (estado IN ('borrador', 'confirmada', 'despachada', 'cancelada')),
+    notas TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    confirmada_at TIMESTAMP,
+    cerrada_at TIMESTAMP
+);
+
+CREATE INDEX idx_ordenes_venta_estado ON ordenes_venta(estado);
+
+-- Cada producto aparece una sola vez por orden
+CREATE TABLE orden_venta_lineas (
+    orden_id INTEGER NOT NULL REFERENCES ordenes_venta(id) ON DELETE CASCADE,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    precio_unitario DECIMAL(10, 2) NOT NULL CHECK (precio_unitario >= 0),
+    PRIMARY KEY (orden_id, producto_id)
+);
+
+CREATE INDEX idx_orden_venta_lineas_producto ON orden_venta_lineas(producto_id);
+
+-- Orden de venta de las salidas registradas al despachar
+ALTER TABLE movimientos_inventario
+    ADD COLUMN orden_venta_id INTEGER REFERENCES ordenes_venta(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT movimientos_inventario_orden_venta_check CHECK (orden_venta_id IS NULL OR tipo = 'salida');
+
+CREATE INDEX idx_movimientos_orden_venta ON movimientos_inventario(orden_venta_id)
+    WHERE orden_venta_id IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS devolucion_id;
+
+DROP TABLE IF EXISTS devolucion_items;
+DROP TABLE IF EXISTS devoluciones;
@@ -0,0 +1,40 @@
+-- Devoluciones de clientes. Cada una remite a la orden de venta despachada o
+-- a la salida suelta de la que proviene.
+CREATE TABLE devoluciones (
+    id SERIAL PRIMARY KEY,
+    orden_venta_id INTEGER REFERENCES ordenes_venta(id) ON DELETE RESTRICT,
+    movimiento_id INTEGER REFERENCES movimientos_inventario(id) ON DELETE CASCADE,
+    almacen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    motivo TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT devoluciones_origen_check CHECK (num_nonnulls(orden_venta_id, movimiento_id) = 1)
+);
+
+CREATE INDEX idx_devoluciones_orden_venta ON devoluciones(orden_venta_id) WHERE orden_venta_id IS NOT NULL;
+CREATE INDEX idx_devoluciones_movimiento ON devoluciones(movimiento_id) WHERE movimiento_id IS NOT NULL;
+
+-- Solo los items repuestos vuelven al stock; los de cuarentena esperan a
+-- resolverse y los de baja o devueltos al proveedor no regresan
+CREATE TABLE devolucion_items (
+    id SERIAL PRIMARY KEY,
+    devolucion_id INTEGER NOT NULL REFERENCES devoluciones(id) ON DELETE CASCADE,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    condicion VARCHAR(20) NOT NULL
+        CHECK (condicion IN ('reutilizable', 'danado', 'devolver_proveedor')),
+    estado VARCHAR(20) NOT NULL
+        CHECK (estado IN ('repuesto', 'cuarentena', 'baja', 'devuelto_proveedor')),
+    movimiento_id INTEGER REFERENCES movimientos_inventario(id) ON DELETE SET NULL,
+    resuelto_at TIMESTAMP
+);
+
+CREATE INDEX idx_devolucion_items_devolucion ON devolucion_items(devolucion_id);
+CREATE INDEX idx_devolucion_items_cuarentena ON devolucion_items(id) WHERE estado = 'cuarentena';
+
+-- Devolución de los ajustes que reponen unidades devueltas
+ALTER TABLE movimientos_inventario
+    ADD COLUMN devolucion_id INTEGER REFERENCES devoluciones(id) ON DELETE CASCADE,
+    ADD CONSTRAINT movimientos_inventario_devolucion_check CHECK (devolucion_id IS NULL OR tipo = 'ajuste');
+
+CREATE INDEX idx_movimientos_devolucion ON movimientos_inventario(devolucion_id)
+    WHERE devolucion_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS movimiento_lotes;
+DROP TABLE IF EXISTS lotes;
+
+ALTER TABLE productos DROP COLUMN IF EXISTS controla_lotes;
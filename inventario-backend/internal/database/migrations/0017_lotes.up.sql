@@ -0,0 +1,30 @@
+-- Control de lotes: el stock de los productos que lo activan se reparte en
+-- lotes con vencimiento opcional, y las salidas toman primero los que vencen
+-- antes. Solo se puede cambiar sin stock.
+ALTER TABLE productos ADD COLUMN controla_lotes BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Un lote es por producto y almacén; un traslado crea en el destino el lote
+-- con el mismo código
+CREATE TABLE lotes (
+    id SERIAL PRIMARY KEY,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    almacen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    codigo VARCHAR(100) NOT NULL,
+    vencimiento DATE,
+    cantidad INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT lotes_cantidad_check CHECK (cantidad >= 0),
+    UNIQUE (producto_id, almacen_id, codigo)
+);
+
+CREATE INDEX idx_lotes_vencimiento ON lotes(vencimiento) WHERE cantidad > 0;
+
+-- Parte de cada movimiento que tocó un lote
+CREATE TABLE movimiento_lotes (
+    movimiento_id INTEGER NOT NULL REFERENCES movimientos_inventario(id) ON DELETE CASCADE,
+    lote_id INTEGER NOT NULL REFERENCES lotes(id) ON DELETE CASCADE,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    PRIMARY KEY (movimiento_id, lote_id)
+);
+
+CREATE INDEX idx_movimiento_lotes_lote ON movimiento_lotes(lote_id);
@@ -0,0 +1,8 @@
+ALTER TABLE devolucion_items DROP COLUMN IF EXISTS series;
+
+DROP TABLE IF EXISTS movimiento_series;
+DROP TABLE IF EXISTS series;
+
+ALTER TABLE productos
+    DROP CONSTRAINT IF EXISTS productos_control_check,
+    DROP COLUMN IF EXISTS controla_series;
@@ -0,0 +1,32 @@
+-- Control de números de serie: cada unidad de los productos que lo activan
+-- tiene un número único y se sigue en cada movimiento. Un producto controla
+-- lotes o series, no ambos, y solo se puede cambiar sin stock.
+ALTER TABLE productos
+    ADD COLUMN controla_series BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT productos_control_check CHECK (NOT (controla_lotes AND controla_series));
+
+-- Una serie es única por producto; almacen_id es NULL mientras la unidad
+-- está fuera del stock (vendida, dada de baja o en tránsito)
+CREATE TABLE series (
+    id SERIAL PRIMARY KEY,
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    numero VARCHAR(100) NOT NULL,
+    almacen_id INTEGER REFERENCES almacenes(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (producto_id, numero)
+);
+
+CREATE INDEX idx_series_numero ON series(numero);
+
+-- Series que movió cada movimiento
+CREATE TABLE movimiento_series (
+    movimiento_id INTEGER NOT NULL REFERENCES movimientos_inventario(id) ON DELETE CASCADE,
+    serie_id INTEGER NOT NULL REFERENCES series(id) ON DELETE CASCADE,
+    PRIMARY KEY (movimiento_id, serie_id)
+);
+
+CREATE INDEX idx_movimiento_series_serie ON movimiento_series(serie_id);
+
+-- Series de las unidades devueltas, también de las que quedan en cuarentena
+ALTER TABLE devolucion_items ADD COLUMN series TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_productos_padre;
+
+ALTER TABLE productos
+    DROP CONSTRAINT IF EXISTS productos_variante_key,
+    DROP CONSTRAINT IF EXISTS productos_variante_check,
+    DROP COLUMN IF EXISTS hereda_precio,
+    DROP COLUMN IF EXISTS atributos,
+    DROP COLUMN IF EXISTS producto_padre_id;
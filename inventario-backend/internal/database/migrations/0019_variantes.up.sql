@@ -0,0 +1,11 @@
+-- Variantes: una variante es un producto más, con su SKU, precio y stock,
+-- que cuelga de un padre y se distingue por sus atributos (talla, color...).
+-- El padre no tiene stock propio; muestra la suma del de sus variantes.
+ALTER TABLE productos
+    ADD COLUMN producto_padre_id INTEGER REFERENCES productos(id) ON DELETE RESTRICT,
+    ADD COLUMN atributos JSONB,
+    ADD COLUMN hereda_precio BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT productos_variante_check CHECK ((producto_padre_id IS NULL) = (atributos IS NULL)),
+    ADD CONSTRAINT productos_variante_key UNIQUE (producto_padre_id, atributos);
+
+CREATE INDEX idx_productos_padre ON productos(producto_padre_id);
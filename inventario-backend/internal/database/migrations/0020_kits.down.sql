@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_movimientos_ensamblaje;
+ALTER TABLE movimientos_inventario DROP COLUMN IF EXISTS ensamblaje_id;
+
+DROP TABLE IF EXISTS ensamblajes;
+DROP TABLE IF EXISTS producto_componentes;
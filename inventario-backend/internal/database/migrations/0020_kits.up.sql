@@ -0,0 +1,32 @@
+-- Kits: la lista de materiales indica cuántas unidades de cada componente
+-- lleva un kit. Los kits no se anidan y sus componentes solo cambian cuando
+-- el kit no tiene stock.
+CREATE TABLE producto_componentes (
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    componente_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE RESTRICT,
+    cantidad INTEGER NOT NULL CONSTRAINT producto_componentes_cantidad_check CHECK (cantidad > 0),
+    PRIMARY KEY (producto_id, componente_id),
+    CONSTRAINT producto_componentes_distintos CHECK (producto_id <> componente_id)
+);
+
+CREATE INDEX idx_producto_componentes_componente ON producto_componentes(componente_id);
+
+-- Ensamblajes y desensamblajes de kits
+CREATE TABLE ensamblajes (
+    id SERIAL PRIMARY KEY,
+    tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('ensamblaje', 'desensamblaje')),
+    producto_id INTEGER NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    almacen_id INTEGER NOT NULL REFERENCES almacenes(id) ON DELETE RESTRICT,
+    cantidad INTEGER NOT NULL CHECK (cantidad > 0),
+    motivo TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ensamblajes_producto ON ensamblajes(producto_id);
+
+-- Las salidas y entradas de un ensamblaje comparten ensamblaje_id. Si se
+-- elimina el kit, los movimientos de sus componentes quedan sin ensamblaje.
+ALTER TABLE movimientos_inventario
+    ADD COLUMN ensamblaje_id INTEGER REFERENCES ensamblajes(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_movimientos_ensamblaje ON movimientos_inventario(ensamblaje_id);
@@ -0,0 +1,4 @@
+ALTER TABLE conteo_registros
+    DROP COLUMN IF EXISTS series,
+    DROP COLUMN IF EXISTS vencimiento,
+    DROP COLUMN IF EXISTS lote;
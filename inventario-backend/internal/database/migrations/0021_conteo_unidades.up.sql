@@ -0,0 +1,6 @@
+-- Lote, vencimiento y series que indica cada registro de conteo para el
+-- ajuste de los productos con control de lotes o de series
+ALTER TABLE conteo_registros
+    ADD COLUMN lote VARCHAR(100),
+    ADD COLUMN vencimiento DATE,
+    ADD COLUMN series TEXT[] NOT NULL DEFAULT '{}';
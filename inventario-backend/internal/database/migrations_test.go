@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrationsEmbebidas(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("error al leer las migraciones: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no hay migraciones embebidas")
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("versión %d en la posición %d: las versiones deben ser consecutivas desde 1", m.Version, i)
+		}
+		if m.Down == "" {
+			t.Errorf("la migración %04d_%s no tiene archivo .down.sql", m.Version, m.Name)
+		}
+	}
+}
+
+// conectarEsquemaPrueba abre la base de datos indicada en TEST_DATABASE_URL
+// sobre un esquema vacío propio, que se elimina al terminar, para no tocar
+// el que usan las demás pruebas. Omite la prueba si no está configurada.
+func conectarEsquemaPrueba(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no está configurada")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("error al abrir la base de datos: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	esquema := fmt.Sprintf("prueba_migraciones_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + esquema); err != nil {
+		t.Fatalf("error al crear el esquema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + esquema + " CASCADE") })
+
+	// lib/pq envía los parámetros desconocidos como parámetros de la sesión
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatalf("TEST_DATABASE_URL inválida: %v", err)
+		}
+		q := u.Query()
+		q.Set("search_path", esquema)
+		u.RawQuery = q.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + esquema
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("error al abrir la base de datos: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateUpSobreEsquemaLegado(t *testing.T) {
+	db := conectarEsquemaPrueba(t)
+	ctx := context.Background()
+
+	legado, err := os.ReadFile("testdata/schema_legado.sql")
+	if err != nil {
+		t.Fatalf("error al leer el esquema legado: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, string(legado)); err != nil {
+		t.Fatalf("error al crear el esquema legado: %v", err)
+	}
+
+	applied, err := MigrateUp(ctx, db)
+	if err != nil {
+		t.Fatalf("error al migrar el esquema legado: %v", err)
+	}
+	for _, m := range applied {
+		if m.Version <= 2 {
+			t.Errorf("se aplicó %04d_%s, que ya estaba en el esquema legado", m.Version, m.Name)
+		}
+	}
+
+	pending, err := Pending(ctx, db)
+	if err != nil {
+		t.Fatalf("error al leer las migraciones pendientes: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("quedaron %d migraciones pendientes", len(pending))
+	}
+
+	var productos int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM productos").Scan(&productos); err != nil {
+		t.Fatalf("error al contar los productos: %v", err)
+	}
+	if productos != 4 {
+		t.Errorf("hay %d productos, se esperaban los 4 de ejemplo sin duplicar", productos)
+	}
+}
+
+func TestStatusNoModificaLaBase(t *testing.T) {
+	db := conectarEsquemaPrueba(t)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("error al leer las migraciones: %v", err)
+	}
+	pending, err := Pending(ctx, db)
+	if err != nil {
+		t.Fatalf("error al leer las migraciones pendientes: %v", err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("hay %d migraciones pendientes, se esperaban las %d", len(pending), len(migrations))
+	}
+
+	var existe bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&existe); err != nil {
+		t.Fatalf("error al consultar el esquema: %v", err)
+	}
+	if existe {
+		t.Error("Pending creó la tabla schema_migrations")
+	}
+}
+
+func TestMigrateUpSobreEsquemaVacio(t *testing.T) {
+	db := conectarEsquemaPrueba(t)
+	ctx := context.Background()
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("error al leer las migraciones: %v", err)
+	}
+	applied, err := MigrateUp(ctx, db)
+	if err != nil {
+		t.Fatalf("error al migrar: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("se aplicaron %d migraciones, se esperaban %d", len(applied), len(migrations))
+	}
+}
@@ -1,8 +1,5 @@
--- Crear base de datos (ejecutar esto primero si la base de datos no existe)
--- CREATE DATABASE inventario_db;
-
--- Conectarse a la base de datos
--- \c inventario_db;
+-- Copia del antiguo database/schema.sql, con el que se creaban las bases de
+-- datos antes de las migraciones versionadas
 
 -- Tabla de Categorías
 CREATE TABLE IF NOT EXISTS categorias (
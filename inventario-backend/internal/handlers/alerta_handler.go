@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const alertaNoEncontrada = "Alerta no encontrada"
+
+// Notificador recibe los productos cuyo stock cambió para evaluar sus
+// alertas de stock bajo
+type Notificador interface {
+	Notificar(productoID int)
+}
+
+type AlertaHandler struct {
+	repo repository.AlertaRepository
+}
+
+func NewAlertaHandler(repo repository.AlertaRepository) *AlertaHandler {
+	return &AlertaHandler{repo: repo}
+}
+
+// GetAlertas lista las alertas de stock de la más reciente a la más antigua.
+// Acepta los filtros estado y producto_id.
+func (h *AlertaHandler) GetAlertas(w http.ResponseWriter, r *http.Request) {
+	var filtro repository.AlertaFiltro
+	var err error
+	q := r.URL.Query()
+
+	filtro.Estado = models.EstadoAlerta(q.Get("estado"))
+	switch filtro.Estado {
+	case "", models.EstadoAlertaActiva, models.EstadoAlertaReconocida, models.EstadoAlertaResuelta:
+	default:
+		respondBadRequest(w, r, &fieldError{Field: "estado", Message: "Debe ser 'activa', 'reconocida' o 'resuelta'"})
+		return
+	}
+	if filtro.ProductoID, err = queryInt(q, "producto_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+
+	alertas, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, alertaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, alertas)
+}
+
+// ReconocerAlerta marca la alerta como vista. La alerta sigue abierta, y no
+// se repite, hasta que el stock se recupere.
+func (h *AlertaHandler) ReconocerAlerta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	a, err := h.repo.Reconocer(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, alertaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, a)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const almacenNoEncontrado = "Almacén no encontrado"
+
+type AlmacenHandler struct {
+	repo repository.AlmacenRepository
+}
+
+func NewAlmacenHandler(repo repository.AlmacenRepository) *AlmacenHandler {
+	return &AlmacenHandler{repo: repo}
+}
+
+// validarAlmacenRequest verifica los campos comunes a la creación y actualización
+func validarAlmacenRequest(req models.AlmacenRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if req.Nombre == "" {
+		details = append(details, ErrorDetail{Field: "nombre", Message: "El nombre es requerido"})
+	}
+	return details
+}
+
+func (h *AlmacenHandler) GetAlmacenes(w http.ResponseWriter, r *http.Request) {
+	almacenes, err := h.repo.List(r.Context())
+	if err != nil {
+		respondRepoError(w, r, err, almacenNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, almacenes)
+}
+
+func (h *AlmacenHandler) GetAlmacen(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	a, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, almacenNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, a)
+}
+
+func (h *AlmacenHandler) CreateAlmacen(w http.ResponseWriter, r *http.Request) {
+	var req models.AlmacenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarAlmacenRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	a, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, almacenNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, a)
+}
+
+func (h *AlmacenHandler) UpdateAlmacen(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.AlmacenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarAlmacenRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	a, err := h.repo.Update(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, almacenNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, a)
+}
+
+func (h *AlmacenHandler) DeleteAlmacen(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		respondRepoError(w, r, err, almacenNoEncontrado)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"testing"
+	"time"
+)
+
+// crearAlmacenPrueba crea un almacén y lo elimina al terminar la prueba. Se
+// debe crear antes que los productos que muevan stock en él.
+func crearAlmacenPrueba(t *testing.T, repos repository.Repositories) *models.Almacen {
+	t.Helper()
+	ctx := context.Background()
+
+	a, err := repos.Almacenes.Create(ctx, models.AlmacenRequest{
+		Nombre: fmt.Sprintf("Prueba %s %d", t.Name(), time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatalf("error al crear el almacén: %v", err)
+	}
+	t.Cleanup(func() { repos.Almacenes.Delete(ctx, a.ID) })
+	return a
+}
+
+// almacenPrincipalPrueba devuelve el almacén principal actual
+func almacenPrincipalPrueba(t *testing.T, repos repository.Repositories) models.Almacen {
+	t.Helper()
+	almacenes, err := repos.Almacenes.List(context.Background())
+	if err != nil {
+		t.Fatalf("error al listar los almacenes: %v", err)
+	}
+	var principales []models.Almacen
+	for _, a := range almacenes {
+		if a.Principal {
+			principales = append(principales, a)
+		}
+	}
+	if len(principales) != 1 {
+		t.Fatalf("hay %d almacenes principales, se esperaba uno", len(principales))
+	}
+	return principales[0]
+}
+
+func TestStockPorAlmacen(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		a := crearAlmacenPrueba(t, repos)
+		principal := almacenPrincipalPrueba(t, repos)
+		p := crearProductoPrueba(t, repos, 5)
+
+		if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: p.ID, AlmacenID: a.ID, Tipo: models.TipoEntrada, Cantidad: 4,
+		}); err != nil {
+			t.Fatalf("error al registrar la entrada: %v", err)
+		}
+
+		actual, err := repos.Productos.GetByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("error al leer el producto: %v", err)
+		}
+		porAlmacen := make(map[int]int)
+		for _, s := range actual.StockAlmacenes {
+			porAlmacen[s.AlmacenID] = s.Cantidad
+		}
+		if actual.Stock != 9 || porAlmacen[principal.ID] != 5 || porAlmacen[a.ID] != 4 {
+			t.Errorf("stock %d con desglose %v, se esperaba 9: 5 en el principal y 4 en el nuevo", actual.Stock, actual.StockAlmacenes)
+		}
+
+		// Cada salida solo puede tomar el stock de su almacén
+		salida := func(almacenID, cantidad int) error {
+			_, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+				ProductoID: p.ID, AlmacenID: almacenID, Tipo: models.TipoSalida, Cantidad: cantidad,
+			})
+			return err
+		}
+		if err := salida(a.ID, 5); err != repository.ErrStockInsuficiente {
+			t.Errorf("salida sobre el stock del almacén devolvió %v, se esperaba ErrStockInsuficiente", err)
+		}
+		if err := salida(999999999, 1); err != repository.ErrAlmacenNoExiste {
+			t.Errorf("salida de un almacén inexistente devolvió %v, se esperaba ErrAlmacenNoExiste", err)
+		}
+		// Sin almacen_id el movimiento va al principal
+		if err := salida(0, 5); err != nil {
+			t.Fatalf("salida del almacén principal: %v", err)
+		}
+		actual, err = repos.Productos.GetByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("error al leer el producto: %v", err)
+		}
+		if actual.Stock != 4 || len(actual.StockAlmacenes) != 1 || actual.StockAlmacenes[0].AlmacenID != a.ID {
+			t.Errorf("stock %d con desglose %v, se esperaban 4 solo en el nuevo almacén", actual.Stock, actual.StockAlmacenes)
+		}
+
+		if err := repos.Almacenes.Delete(ctx, a.ID); err != repository.ErrAlmacenEnUso {
+			t.Errorf("eliminar un almacén con stock devolvió %v, se esperaba ErrAlmacenEnUso", err)
+		}
+		if err := repos.Almacenes.Delete(ctx, principal.ID); err != repository.ErrAlmacenEnUso {
+			t.Errorf("eliminar el almacén principal devolvió %v, se esperaba ErrAlmacenEnUso", err)
+		}
+		if _, err := repos.Almacenes.Create(ctx, models.AlmacenRequest{Nombre: a.Nombre}); err != repository.ErrAlmacenDuplicado {
+			t.Errorf("crear un almacén con el mismo nombre devolvió %v, se esperaba ErrAlmacenDuplicado", err)
+		}
+	})
+}
+
+func TestCambiarAlmacenPrincipal(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		a := crearAlmacenPrueba(t, repos)
+		anterior := almacenPrincipalPrueba(t, repos)
+		t.Cleanup(func() {
+			repos.Almacenes.Update(ctx, anterior.ID, models.AlmacenRequest{
+				Nombre: anterior.Nombre, Descripcion: anterior.Descripcion, Principal: true,
+			})
+		})
+
+		if _, err := repos.Almacenes.Update(ctx, a.ID, models.AlmacenRequest{Nombre: a.Nombre, Principal: true}); err != nil {
+			t.Fatalf("error al cambiar el almacén principal: %v", err)
+		}
+		if principal := almacenPrincipalPrueba(t, repos); principal.ID != a.ID {
+			t.Errorf("el almacén principal es %d, se esperaba %d", principal.ID, a.ID)
+		}
+
+		// Quitar la marca no deja el sistema sin almacén principal
+		if _, err := repos.Almacenes.Update(ctx, a.ID, models.AlmacenRequest{Nombre: a.Nombre}); err != nil {
+			t.Fatalf("error al actualizar el almacén: %v", err)
+		}
+		if principal := almacenPrincipalPrueba(t, repos); principal.ID != a.ID {
+			t.Errorf("el almacén principal es %d, se esperaba que siguiera siendo %d", principal.ID, a.ID)
+		}
+		if err := repos.Almacenes.Delete(ctx, a.ID); err != repository.ErrAlmacenEnUso {
+			t.Errorf("eliminar el almacén principal devolvió %v, se esperaba ErrAlmacenEnUso", err)
+		}
+	})
+}
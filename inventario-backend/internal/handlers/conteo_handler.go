@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+const conteoNoEncontrado = "Conteo no encontrado"
+
+type ConteoHandler struct {
+	repo    repository.ConteoRepository
+	alertas Notificador
+}
+
+// NewConteoHandler crea el handler; alertas recibe los productos que ajusta
+// cada aprobación y puede ser nil
+func NewConteoHandler(repo repository.ConteoRepository, alertas Notificador) *ConteoHandler {
+	return &ConteoHandler{repo: repo, alertas: alertas}
+}
+
+// validarConteoRequest verifica los campos de una nueva sesión de conteo
+func validarConteoRequest(req models.ConteoRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if req.AlmacenID < 0 {
+		details = append(details, ErrorDetail{Field: "almacen_id", Message: "El almacén no es válido"})
+	}
+	if req.CategoriaID != nil && *req.CategoriaID <= 0 {
+		details = append(details, ErrorDetail{Field: "categoria_id", Message: "La categoría no es válida"})
+	}
+	return details
+}
+
+// validarConteoRegistroRequest verifica las cantidades contadas y completa
+// los valores por defecto
+func validarConteoRegistroRequest(req *models.ConteoRegistroRequest) []ErrorDetail {
+	var details []ErrorDetail
+	req.Contador = strings.TrimSpace(req.Contador)
+	if req.Pasada == 0 {
+		req.Pasada = 1
+	}
+	if req.Pasada < 0 {
+		details = append(details, ErrorDetail{Field: "pasada", Message: "La pasada debe ser mayor a 0"})
+	}
+	if len(req.Contador) > 100 {
+		details = append(details, ErrorDetail{Field: "contador", Message: "El contador no puede superar 100 caracteres"})
+	}
+	if len(req.Items) == 0 {
+		details = append(details, ErrorDetail{Field: "items", Message: "Debe incluir al menos un producto"})
+	}
+	for i := range req.Items {
+		req.Items[i].Lote = strings.TrimSpace(req.Items[i].Lote)
+		item := req.Items[i]
+		if item.ProductoID <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].producto_id", i), Message: "El producto es requerido"})
+		}
+		if item.Cantidad < 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].cantidad", i), Message: "La cantidad no puede ser negativa"})
+		}
+		if utf8.RuneCountInString(item.Lote) > 100 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].lote", i), Message: "El lote no puede superar los 100 caracteres"})
+		}
+		if item.Vencimiento != nil && item.Lote == "" {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].vencimiento", i), Message: "El vencimiento requiere el lote"})
+		}
+		details = append(details, validarSeries(item.Series, fmt.Sprintf("items[%d].series", i))...)
+	}
+	return details
+}
+
+// GetConteos lista las sesiones de conteo. Acepta los filtros estado y almacen_id.
+func (h *ConteoHandler) GetConteos(w http.ResponseWriter, r *http.Request) {
+	var filtro repository.ConteoFiltro
+	var err error
+	q := r.URL.Query()
+
+	filtro.Estado = models.EstadoConteo(q.Get("estado"))
+	switch filtro.Estado {
+	case "", models.EstadoConteoAbierto, models.EstadoConteoAprobado, models.EstadoConteoCancelado:
+	default:
+		respondBadRequest(w, r, &fieldError{Field: "estado", Message: "Debe ser 'abierto', 'aprobado' o 'cancelado'"})
+		return
+	}
+	if filtro.AlmacenID, err = queryInt(q, "almacen_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+
+	conteos, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, conteoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conteos)
+}
+
+// GetConteo devuelve la sesión con sus items. Con diferencias=true solo
+// incluye los productos contados cuya cantidad no coincide con la esperada.
+func (h *ConteoHandler) GetConteo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	c, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, conteoNoEncontrado)
+		return
+	}
+
+	if r.URL.Query().Get("diferencias") == "true" {
+		var items []models.ConteoItem
+		for _, item := range c.Items {
+			if item.Diferencia != nil && *item.Diferencia != 0 {
+				items = append(items, item)
+			}
+		}
+		c.Items = items
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+func (h *ConteoHandler) CreateConteo(w http.ResponseWriter, r *http.Request) {
+	var req models.ConteoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarConteoRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	c, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, conteoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, c)
+}
+
+// RegistrarConteo guarda las cantidades que un contador encontró en una pasada
+func (h *ConteoHandler) RegistrarConteo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.ConteoRegistroRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarConteoRegistroRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	c, err := h.repo.Registrar(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, conteoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// AprobarConteo registra los ajustes de las diferencias y cierra la sesión
+func (h *ConteoHandler) AprobarConteo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	c, err := h.repo.Aprobar(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, conteoNoEncontrado)
+		return
+	}
+
+	if h.alertas != nil {
+		for _, item := range c.Items {
+			if item.MovimientoID != nil {
+				h.alertas.Notificar(item.ProductoID)
+			}
+		}
+	}
+	respondJSON(w, http.StatusOK, c)
+}
+
+func (h *ConteoHandler) CancelarConteo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	c, err := h.repo.Cancelar(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, conteoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
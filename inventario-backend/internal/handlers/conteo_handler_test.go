@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// abrirConteoPrueba crea una categoría con dos productos y abre una sesión
+// de conteo sobre ella en el almacén principal
+func abrirConteoPrueba(t *testing.T, repos repository.Repositories, stockA, stockB int) (*models.Conteo, models.Producto, models.Producto) {
+	t.Helper()
+	cat, productos := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+		{Nombre: "Prueba Conteo A", Precio: 1, Stock: stockA},
+		{Nombre: "Prueba Conteo B", Precio: 1, Stock: stockB},
+	})
+	c, err := repos.Conteos.Create(context.Background(), models.ConteoRequest{CategoriaID: &cat.ID})
+	if err != nil {
+		t.Fatalf("error al abrir el conteo: %v", err)
+	}
+	if len(c.Items) != 2 {
+		t.Fatalf("el conteo tiene %d productos, se esperaban 2", len(c.Items))
+	}
+	return c, productos[0], productos[1]
+}
+
+// movimientosConteo devuelve los ajustes que registró la aprobación del conteo
+func movimientosConteo(t *testing.T, repos repository.Repositories, productoID int) []models.MovimientoInventario {
+	t.Helper()
+	movimientos, _, err := repos.Movimientos.List(context.Background(), repository.MovimientoFiltro{
+		ProductoID: &productoID, CodigoMotivo: models.MotivoConteo,
+	})
+	if err != nil {
+		t.Fatalf("error al listar los movimientos: %v", err)
+	}
+	return movimientos
+}
+
+func TestAprobarConteo(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		c, a, b := abrirConteoPrueba(t, repos, 10, 4)
+
+		// Una venta mientras se cuenta no debe perderse al aprobar
+		if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: a.ID, Tipo: models.TipoSalida, Cantidad: 3,
+		}); err != nil {
+			t.Fatalf("error al registrar la salida: %v", err)
+		}
+
+		registros := []models.ConteoRegistroRequest{
+			{Pasada: 1, Contador: "Ana", Items: []models.CantidadContada{{ProductoID: a.ID, Cantidad: 8}, {ProductoID: b.ID, Cantidad: 5}}},
+			// Vale la última pasada, sumando a cada contador
+			{Pasada: 2, Contador: "Ana", Items: []models.CantidadContada{{ProductoID: b.ID, Cantidad: 3}}},
+			{Pasada: 2, Contador: "Luis", Items: []models.CantidadContada{{ProductoID: b.ID, Cantidad: 3}}},
+		}
+		for _, req := range registros {
+			if _, err := repos.Conteos.Registrar(ctx, c.ID, req); err != nil {
+				t.Fatalf("error al registrar el conteo: %v", err)
+			}
+		}
+
+		aprobado, err := repos.Conteos.Aprobar(ctx, c.ID)
+		if err != nil {
+			t.Fatalf("error al aprobar el conteo: %v", err)
+		}
+		if aprobado.Estado != models.EstadoConteoAprobado || aprobado.CerradoAt == nil {
+			t.Errorf("conteo = %+v, se esperaba aprobado", aprobado)
+		}
+
+		// La diferencia es contra la foto tomada al abrir: 8-10 y 6-4
+		esperados := map[int]struct{ diferencia, stock int }{a.ID: {-2, 5}, b.ID: {2, 6}}
+		for _, item := range aprobado.Items {
+			e := esperados[item.ProductoID]
+			if item.Diferencia == nil || *item.Diferencia != e.diferencia || item.MovimientoID == nil {
+				t.Errorf("producto %d: item %+v, se esperaba una diferencia de %d con su ajuste", item.ProductoID, item, e.diferencia)
+			}
+			ajustes := movimientosConteo(t, repos, item.ProductoID)
+			if len(ajustes) != 1 || ajustes[0].Tipo != models.TipoAjuste || ajustes[0].Cantidad != e.diferencia ||
+				ajustes[0].Motivo != repository.MotivoConteo(c.ID) {
+				t.Errorf("producto %d: ajustes %+v, se esperaba uno de %d", item.ProductoID, ajustes, e.diferencia)
+			}
+			p, err := repos.Productos.GetByID(ctx, item.ProductoID)
+			if err != nil {
+				t.Fatalf("error al leer el producto: %v", err)
+			}
+			if p.Stock != e.stock {
+				t.Errorf("producto %d: stock %d, se esperaba %d", item.ProductoID, p.Stock, e.stock)
+			}
+		}
+
+		// Una sesión cerrada no admite más registros ni otra aprobación
+		if _, err := repos.Conteos.Registrar(ctx, c.ID, registros[0]); err != repository.ErrConteoCerrado {
+			t.Errorf("registrar en un conteo aprobado devolvió %v, se esperaba ErrConteoCerrado", err)
+		}
+		if _, err := repos.Conteos.Cancelar(ctx, c.ID); err != repository.ErrConteoCerrado {
+			t.Errorf("cancelar un conteo aprobado devolvió %v, se esperaba ErrConteoCerrado", err)
+		}
+		h := NewConteoHandler(repos.Conteos, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/conteos/aprobar", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(c.ID)})
+		rec := httptest.NewRecorder()
+		h.AprobarConteo(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("aprobar de nuevo devolvió %d, se esperaba 409", rec.Code)
+		}
+		if ajustes := movimientosConteo(t, repos, a.ID); len(ajustes) != 1 {
+			t.Errorf("hay %d ajustes del conteo, se esperaba 1", len(ajustes))
+		}
+	})
+}
+
+func TestAprobarConteoSinStockNoAjustaNada(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		c, a, b := abrirConteoPrueba(t, repos, 10, 4)
+
+		if _, err := repos.Conteos.Registrar(ctx, c.ID, models.ConteoRegistroRequest{
+			Pasada: 1, Items: []models.CantidadContada{{ProductoID: a.ID, Cantidad: 12}, {ProductoID: b.ID, Cantidad: 0}},
+		}); err != nil {
+			t.Fatalf("error al registrar el conteo: %v", err)
+		}
+		// Tras la salida el faltante de 4 supera el stock de B
+		if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: b.ID, Tipo: models.TipoSalida, Cantidad: 2,
+		}); err != nil {
+			t.Fatalf("error al registrar la salida: %v", err)
+		}
+
+		if _, err := repos.Conteos.Aprobar(ctx, c.ID); err != repository.ErrStockInsuficiente {
+			t.Fatalf("aprobar con un faltante mayor al stock devolvió %v, se esperaba ErrStockInsuficiente", err)
+		}
+		// Ni siquiera el sobrante de A se registra
+		for id, stock := range map[int]int{a.ID: 10, b.ID: 2} {
+			p, err := repos.Productos.GetByID(ctx, id)
+			if err != nil {
+				t.Fatalf("error al leer el producto: %v", err)
+			}
+			if p.Stock != stock {
+				t.Errorf("producto %d: stock %d, se esperaba %d", id, p.Stock, stock)
+			}
+			if ajustes := movimientosConteo(t, repos, id); len(ajustes) != 0 {
+				t.Errorf("producto %d: se registraron los ajustes %+v", id, ajustes)
+			}
+		}
+		actual, err := repos.Conteos.GetByID(ctx, c.ID)
+		if err != nil {
+			t.Fatalf("error al leer el conteo: %v", err)
+		}
+		if actual.Estado != models.EstadoConteoAbierto {
+			t.Errorf("estado %s, se esperaba que el conteo siguiera abierto", actual.Estado)
+		}
+	})
+}
+
+func TestAprobarConteoConLotesYSeries(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		si := true
+		_, productos := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+			{Nombre: "Prueba Conteo Lotes", Precio: 1, ControlaLotes: &si},
+			{Nombre: "Prueba Conteo Series", Precio: 1, ControlaSeries: &si},
+		})
+		lotes, series := productos[0], productos[1]
+		for _, req := range []models.MovimientoInventarioRequest{
+			{ProductoID: lotes.ID, Tipo: models.TipoEntrada, Cantidad: 5, Lote: "L1"},
+			{ProductoID: series.ID, Tipo: models.TipoEntrada, Cantidad: 3, Series: []string{"S1", "S2", "S3"}},
+		} {
+			if _, err := repos.Movimientos.Create(ctx, req); err != nil {
+				t.Fatalf("error al registrar la entrada: %v", err)
+			}
+		}
+		c, err := repos.Conteos.Create(ctx, models.ConteoRequest{CategoriaID: &lotes.CategoriaID})
+		if err != nil {
+			t.Fatalf("error al abrir el conteo: %v", err)
+		}
+
+		h := NewConteoHandler(repos.Conteos, nil)
+		registrar := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/conteos/registros", bytes.NewReader([]byte(body)))
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(c.ID)})
+			rec := httptest.NewRecorder()
+			h.RegistrarConteo(rec, req)
+			return rec
+		}
+		if rec := registrar(fmt.Sprintf(`{"items": [{"producto_id": %d, "cantidad": 7, "vencimiento": "2030-01-31"}]}`, lotes.ID)); rec.Code != http.StatusBadRequest {
+			t.Errorf("vencimiento sin lote: código %d, se esperaba 400", rec.Code)
+		}
+
+		// Sin indicar el lote del sobrante ni la serie que falta no se ajusta nada
+		if rec := registrar(fmt.Sprintf(`{"items": [{"producto_id": %d, "cantidad": 7}, {"producto_id": %d, "cantidad": 2}]}`,
+			lotes.ID, series.ID)); rec.Code != http.StatusOK {
+			t.Fatalf("error al registrar el conteo: %d %s", rec.Code, rec.Body)
+		}
+		if _, err := repos.Conteos.Aprobar(ctx, c.ID); err != repository.ErrLoteInvalido {
+			t.Errorf("aprobar un sobrante sin lote devolvió %v, se esperaba ErrLoteInvalido", err)
+		}
+		if rec := registrar(fmt.Sprintf(`{"items": [{"producto_id": %d, "cantidad": 7, "lote": " L2 "}]}`, lotes.ID)); rec.Code != http.StatusOK {
+			t.Fatalf("error al registrar el conteo: %d %s", rec.Code, rec.Body)
+		}
+		if _, err := repos.Conteos.Aprobar(ctx, c.ID); err != repository.ErrSerieInvalida {
+			t.Errorf("aprobar un faltante sin series devolvió %v, se esperaba ErrSerieInvalida", err)
+		}
+		if ajustes := movimientosConteo(t, repos, lotes.ID); len(ajustes) != 0 {
+			t.Errorf("se registraron los ajustes %+v", ajustes)
+		}
+
+		// Dos contadores que indican lotes distintos no se pueden conciliar
+		for _, contador := range []string{"Ana", "Luis"} {
+			if _, err := repos.Conteos.Registrar(ctx, c.ID, models.ConteoRegistroRequest{
+				Pasada: 2, Contador: contador, Items: []models.CantidadContada{{ProductoID: lotes.ID, Cantidad: 1, Lote: "L-" + contador}}},
+			); err != nil {
+				t.Fatalf("error al registrar el conteo: %v", err)
+			}
+		}
+		if _, err := repos.Conteos.Aprobar(ctx, c.ID); err != repository.ErrLoteInvalido {
+			t.Errorf("aprobar con lotes distintos devolvió %v, se esperaba ErrLoteInvalido", err)
+		}
+
+		if _, err := repos.Conteos.Registrar(ctx, c.ID, models.ConteoRegistroRequest{
+			Pasada: 3, Contador: "Ana", Items: []models.CantidadContada{
+				{ProductoID: lotes.ID, Cantidad: 7, Lote: "L2"},
+				{ProductoID: series.ID, Cantidad: 2, Series: []string{"S2"}},
+			}},
+		); err != nil {
+			t.Fatalf("error al registrar el conteo: %v", err)
+		}
+		if _, err := repos.Conteos.Aprobar(ctx, c.ID); err != nil {
+			t.Fatalf("error al aprobar el conteo: %v", err)
+		}
+
+		if ajustes := movimientosConteo(t, repos, lotes.ID); len(ajustes) != 1 || ajustes[0].Cantidad != 2 ||
+			len(ajustes[0].Lotes) != 1 || ajustes[0].Lotes[0].Codigo != "L2" {
+			t.Errorf("ajustes %+v, se esperaba un sobrante de 2 en el lote L2", ajustes)
+		}
+		enLotes, err := repos.Lotes.List(ctx, repository.LoteFiltro{ProductoID: &lotes.ID, ConStock: true})
+		if err != nil {
+			t.Fatalf("error al listar los lotes: %v", err)
+		}
+		cantidades := make(map[string]int)
+		for _, l := range enLotes {
+			cantidades[l.Codigo] = l.Cantidad
+		}
+		if len(cantidades) != 2 || cantidades["L1"] != 5 || cantidades["L2"] != 2 {
+			t.Errorf("lotes %v, se esperaban 5 en L1 y 2 en L2", cantidades)
+		}
+
+		if ajustes := movimientosConteo(t, repos, series.ID); len(ajustes) != 1 || ajustes[0].Cantidad != -1 ||
+			fmt.Sprint(ajustes[0].Series) != "[S2]" {
+			t.Errorf("ajustes %+v, se esperaba un faltante de la serie S2", ajustes)
+		}
+		enStock, err := repos.Series.List(ctx, repository.SerieFiltro{ProductoID: &series.ID, EnStock: true})
+		if err != nil {
+			t.Fatalf("error al listar las series: %v", err)
+		}
+		var numeros []string
+		for _, se := range enStock {
+			numeros = append(numeros, se.Numero)
+		}
+		if fmt.Sprint(numeros) != "[S1 S3]" {
+			t.Errorf("series en stock %v, se esperaban S1 y S3", numeros)
+		}
+	})
+}
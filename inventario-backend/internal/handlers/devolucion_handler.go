@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const devolucionNoEncontrada = "Devolución no encontrada"
+
+type DevolucionHandler struct {
+	repo    repository.DevolucionRepository
+	alertas Notificador
+}
+
+// NewDevolucionHandler crea el handler; alertas recibe los productos que se
+// reponen al stock y puede ser nil
+func NewDevolucionHandler(repo repository.DevolucionRepository, alertas Notificador) *DevolucionHandler {
+	return &DevolucionHandler{repo: repo, alertas: alertas}
+}
+
+func validarDevolucionRequest(req *models.DevolucionRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if (req.OrdenVentaID == nil) == (req.MovimientoID == nil) {
+		details = append(details, ErrorDetail{Field: "orden_venta_id", Message: "Debe indicar la orden de venta o la salida, pero no ambas"})
+	}
+	if len(req.Items) == 0 {
+		details = append(details, ErrorDetail{Field: "items", Message: "Debe incluir al menos un producto"})
+	}
+	for i, item := range req.Items {
+		if item.ProductoID <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].producto_id", i), Message: "El producto es requerido"})
+		}
+		if item.Cantidad <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].cantidad", i), Message: "La cantidad debe ser mayor a 0"})
+		}
+		switch item.Condicion {
+		case models.CondicionReutilizable, models.CondicionDanado, models.CondicionDevolverProveedor:
+			if _, ok := repository.EstadoInicialDevolucion(item.Condicion, item.Destino); !ok {
+				details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].destino", i), Message: "Las unidades reutilizables se reponen, las dañadas van a 'cuarentena' o 'baja' y las del proveedor a 'cuarentena'"})
+			}
+		default:
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].condicion", i), Message: "Debe ser 'reutilizable', 'danado' o 'devolver_proveedor'"})
+		}
+		details = append(details, validarSeries(item.Series, fmt.Sprintf("items[%d].series", i))...)
+	}
+	return details
+}
+
+// notificarRepuestos avisa a las alertas de los productos que volvieron al
+// stock
+func (h *DevolucionHandler) notificarRepuestos(d *models.Devolucion) {
+	if h.alertas == nil {
+		return
+	}
+	for _, i := range d.Items {
+		if i.Estado == models.EstadoItemRepuesto {
+			h.alertas.Notificar(i.ProductoID)
+		}
+	}
+}
+
+// GetDevoluciones lista las devoluciones de la más reciente a la más antigua,
+// sin sus items. Acepta los filtros orden_venta_id y movimiento_id.
+func (h *DevolucionHandler) GetDevoluciones(w http.ResponseWriter, r *http.Request) {
+	var filtro repository.DevolucionFiltro
+	var err error
+	q := r.URL.Query()
+	if filtro.OrdenVentaID, err = queryInt(q, "orden_venta_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	if filtro.MovimientoID, err = queryInt(q, "movimiento_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+
+	devoluciones, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, devolucionNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, devoluciones)
+}
+
+func (h *DevolucionHandler) GetDevolucion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	d, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, devolucionNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, d)
+}
+
+// CreateDevolucion registra lo que devolvió el cliente. Las unidades
+// reutilizables vuelven al stock con un ajuste; las demás quedan en
+// cuarentena o de baja.
+func (h *DevolucionHandler) CreateDevolucion(w http.ResponseWriter, r *http.Request) {
+	var req models.DevolucionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarDevolucionRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	d, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, devolucionNoEncontrada)
+		return
+	}
+
+	h.notificarRepuestos(d)
+	respondJSON(w, http.StatusCreated, d)
+}
+
+// GetCuarentena lista los items devueltos que esperan una resolución
+func (h *DevolucionHandler) GetCuarentena(w http.ResponseWriter, r *http.Request) {
+	items, err := h.repo.Cuarentena(r.Context())
+	if err != nil {
+		respondRepoError(w, r, err, devolucionNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, items)
+}
+
+// ResolverItem saca un item de cuarentena: lo repone al stock, lo da de baja
+// o lo marca como devuelto al proveedor
+func (h *DevolucionHandler) ResolverItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+	itemID, err := strconv.Atoi(vars["item_id"])
+	if err != nil {
+		respondInvalidID(w, r, "item_id")
+		return
+	}
+
+	var req models.ResolucionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+	if !repository.ResolucionValida(req.Estado) {
+		respondBadRequest(w, r, &fieldError{Field: "estado", Message: "Debe ser 'repuesto', 'baja' o 'devuelto_proveedor'"})
+		return
+	}
+
+	d, err := h.repo.Resolver(r.Context(), id, itemID, req.Estado)
+	if err != nil {
+		respondRepoError(w, r, err, "Item de devolución no encontrado")
+		return
+	}
+
+	if req.Estado == models.EstadoItemRepuesto && h.alertas != nil {
+		for _, i := range d.Items {
+			if i.ID == itemID {
+				h.alertas.Notificar(i.ProductoID)
+			}
+		}
+	}
+	respondJSON(w, http.StatusOK, d)
+}
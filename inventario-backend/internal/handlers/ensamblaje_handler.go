@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const ensamblajeNoEncontrado = "Ensamblaje no encontrado"
+
+type EnsamblajeHandler struct {
+	repo    repository.EnsamblajeRepository
+	alertas Notificador
+}
+
+// NewEnsamblajeHandler crea el handler; alertas recibe los productos que
+// mueve cada ensamblaje y puede ser nil
+func NewEnsamblajeHandler(repo repository.EnsamblajeRepository, alertas Notificador) *EnsamblajeHandler {
+	return &EnsamblajeHandler{repo: repo, alertas: alertas}
+}
+
+func tipoEnsamblajeValido(t models.TipoEnsamblaje) bool {
+	return t == models.TipoEnsamblar || t == models.TipoDesensamblar
+}
+
+func validarEnsamblajeRequest(req *models.EnsamblajeRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if !tipoEnsamblajeValido(req.Tipo) {
+		details = append(details, ErrorDetail{Field: "tipo", Message: "El tipo debe ser 'ensamblaje' o 'desensamblaje'"})
+	}
+	if req.ProductoID <= 0 {
+		details = append(details, ErrorDetail{Field: "producto_id", Message: "El producto es requerido"})
+	}
+	if req.AlmacenID < 0 {
+		details = append(details, ErrorDetail{Field: "almacen_id", Message: "El almacén no es válido"})
+	}
+	if req.Cantidad <= 0 {
+		details = append(details, ErrorDetail{Field: "cantidad", Message: "La cantidad debe ser mayor a 0"})
+	}
+	return details
+}
+
+// GetEnsamblajes lista los ensamblajes y desensamblajes del más reciente al
+// más antiguo, sin sus movimientos. Acepta los filtros tipo, producto_id y
+// almacen_id.
+func (h *EnsamblajeHandler) GetEnsamblajes(w http.ResponseWriter, r *http.Request) {
+	var filtro repository.EnsamblajeFiltro
+	var err error
+	q := r.URL.Query()
+	filtro.Tipo = models.TipoEnsamblaje(q.Get("tipo"))
+	if filtro.Tipo != "" && !tipoEnsamblajeValido(filtro.Tipo) {
+		respondBadRequest(w, r, &fieldError{Field: "tipo", Message: "Debe ser 'ensamblaje' o 'desensamblaje'"})
+		return
+	}
+	if filtro.ProductoID, err = queryInt(q, "producto_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	if filtro.AlmacenID, err = queryInt(q, "almacen_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+
+	ensamblajes, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, ensamblajeNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ensamblajes)
+}
+
+func (h *EnsamblajeHandler) GetEnsamblaje(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	e, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, ensamblajeNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, e)
+}
+
+// CreateEnsamblaje arma o desarma kits en un almacén: consume lo indicado por
+// la lista de materiales y registra lo obtenido en una sola operación
+func (h *EnsamblajeHandler) CreateEnsamblaje(w http.ResponseWriter, r *http.Request) {
+	var req models.EnsamblajeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarEnsamblajeRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	e, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, ensamblajeNoEncontrado)
+		return
+	}
+
+	if h.alertas != nil {
+		for _, m := range e.Movimientos {
+			h.alertas.Notificar(m.ProductoID)
+		}
+	}
+	respondJSON(w, http.StatusCreated, e)
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"inventario-backend/internal/repository"
+	"log"
+	"net/http"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Códigos de error estables que el frontend puede usar en lugar de los mensajes
+const (
+	CodeJSONInvalido           = "json_invalido"
+	CodeValidacion             = "validacion"
+	CodeNoEncontrado           = "no_encontrado"
+	CodeRutaNoEncontrada       = "ruta_no_encontrada"
+	CodeMetodoNoPermitido      = "metodo_no_permitido"
+	CodeProductoNoExiste       = "producto_no_existe"
+	CodeCategoriaNoExiste      = "categoria_no_existe"
+	CodeCategoriaDuplicada     = "categoria_duplicada"
+	CodeCategoriaConProductos  = "categoria_con_productos"
+	CodeAlmacenNoExiste        = "almacen_no_existe"
+	CodeAlmacenDuplicado       = "almacen_duplicado"
+	CodeAlmacenEnUso           = "almacen_en_uso"
+	CodeTrasladoRecibido       = "traslado_recibido"
+	CodeMovimientoRevertido    = "movimiento_revertido"
+	CodeReversionNoPermitida   = "reversion_no_permitida"
+	CodeConteoCerrado          = "conteo_cerrado"
+	CodeProductoFueraDeConteo  = "producto_fuera_de_conteo"
+	CodeAlertaResuelta         = "alerta_resuelta"
+	CodeProveedorNoExiste      = "proveedor_no_existe"
+	CodeProveedorDuplicado     = "proveedor_duplicado"
+	CodeProveedorEnUso         = "proveedor_en_uso"
+	CodeOrdenCompraEstado      = "orden_compra_estado"
+	CodeProductoFueraDeOrden   = "producto_fuera_de_orden"
+	CodeRecepcionExcedida      = "recepcion_excedida"
+	CodeOrdenVentaEstado       = "orden_venta_estado"
+	CodeOrigenDevolucion       = "origen_devolucion_invalido"
+	CodeDevolucionExcedida     = "devolucion_excedida"
+	CodeItemResuelto           = "item_resuelto"
+	CodeLoteInvalido           = "lote_invalido"
+	CodeLoteNoExiste           = "lote_no_existe"
+	CodeLotesConStock          = "lotes_con_stock"
+	CodeSerieInvalida          = "serie_invalida"
+	CodeSerieDuplicada         = "serie_duplicada"
+	CodeSerieNoDisponible      = "serie_no_disponible"
+	CodeSeriesConStock         = "series_con_stock"
+	CodeVarianteInvalida       = "variante_invalida"
+	CodeVarianteDuplicada      = "variante_duplicada"
+	CodePadreConStock          = "padre_con_stock"
+	CodeControlVariante        = "control_variante"
+	CodeProductoConVariantes   = "producto_con_variantes"
+	CodeComponenteInvalido     = "componente_invalido"
+	CodeComponentesConStock    = "componentes_con_stock"
+	CodeControlEnKit           = "control_en_kit"
+	CodeProductoEnKit          = "producto_en_kit"
+	CodeProductoSinComponentes = "producto_sin_componentes"
+	CodeStockInsuficiente      = "stock_insuficiente"
+	CodeMetodoCosteoConStock   = "metodo_costeo_con_stock"
+	CodeSKUDuplicado           = "sku_duplicado"
+	CodeCodigoBarrasDuplicado  = "codigo_barras_duplicado"
+	CodeDuplicado              = "duplicado"
+	CodeReferenciaInvalida     = "referencia_invalida"
+	CodeErrorInterno           = "error_interno"
+)
+
+// ErrorDetail describe el problema de un campo concreto de la petición
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse es el cuerpo de todas las respuestas de error de la API
+type ErrorResponse struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// fieldError es un error de validación asociado a un campo o parámetro
+type fieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *fieldError) Error() string {
+	return e.Message
+}
+
+// domainError asocia un error de los repositorios con su respuesta HTTP
+type domainError struct {
+	err     error
+	status  int
+	code    string
+	message string
+}
+
+var domainErrors = []domainError{
+	{repository.ErrProductoNoExiste, http.StatusBadRequest, CodeProductoNoExiste, "El producto especificado no existe"},
+	{repository.ErrCategoriaNoExiste, http.StatusBadRequest, CodeCategoriaNoExiste, "La categoría especificada no existe"},
+	{repository.ErrCategoriaDuplicada, http.StatusConflict, CodeCategoriaDuplicada, "Ya existe una categoría con ese nombre"},
+	{repository.ErrCategoriaConProductos, http.StatusConflict, CodeCategoriaConProductos, "No se puede eliminar la categoría porque tiene productos asociados"},
+	{repository.ErrAlmacenNoExiste, http.StatusBadRequest, CodeAlmacenNoExiste, "El almacén especificado no existe"},
+	{repository.ErrAlmacenDuplicado, http.StatusConflict, CodeAlmacenDuplicado, "Ya existe un almacén con ese nombre"},
+	{repository.ErrAlmacenEnUso, http.StatusConflict, CodeAlmacenEnUso, "No se puede eliminar el almacén principal ni uno con stock, movimientos, traslados, conteos u órdenes de compra o de venta"},
+	{repository.ErrMismoAlmacen, http.StatusBadRequest, CodeValidacion, "El almacén de origen y el de destino deben ser distintos"},
+	{repository.ErrTrasladoRecibido, http.StatusConflict, CodeTrasladoRecibido, "El traslado ya fue recibido"},
+	{repository.ErrMovimientoRevertido, http.StatusConflict, CodeMovimientoRevertido, "El movimiento ya fue revertido"},
+	{repository.ErrReversionNoPermitida, http.StatusConflict, CodeReversionNoPermitida, "No se puede revertir una reversión, un movimiento de un traslado, de una orden, de una devolución o de un ensamblaje, ni una salida con devoluciones"},
+	{repository.ErrConteoCerrado, http.StatusConflict, CodeConteoCerrado, "El conteo ya fue aprobado o cancelado"},
+	{repository.ErrProductoFueraDeConteo, http.StatusBadRequest, CodeProductoFueraDeConteo, "El producto no forma parte del conteo"},
+	{repository.ErrAlertaResuelta, http.StatusConflict, CodeAlertaResuelta, "La alerta ya se resolvió porque el stock se recuperó"},
+	{repository.ErrProveedorNoExiste, http.StatusBadRequest, CodeProveedorNoExiste, "El proveedor especificado no existe"},
+	{repository.ErrProveedorDuplicado, http.StatusConflict, CodeProveedorDuplicado, "Ya existe un proveedor con ese nombre"},
+	{repository.ErrProveedorEnUso, http.StatusConflict, CodeProveedorEnUso, "No se puede eliminar un proveedor con entradas u órdenes de compra registradas"},
+	{repository.ErrOrdenCompraEstado, http.StatusConflict, CodeOrdenCompraEstado, "La orden de compra no admite esa operación en su estado actual"},
+	{repository.ErrProductoFueraDeOrden, http.StatusBadRequest, CodeProductoFueraDeOrden, "El producto no forma parte de la orden de compra"},
+	{repository.ErrRecepcionExcedida, http.StatusConflict, CodeRecepcionExcedida, "La cantidad recibida supera la pendiente; usa permitir_exceso para aceptarla"},
+	{repository.ErrOrdenVentaEstado, http.StatusConflict, CodeOrdenVentaEstado, "La orden de venta no admite esa operación en su estado actual"},
+	{repository.ErrOrigenDevolucion, http.StatusBadRequest, CodeOrigenDevolucion, "La devolución debe remitir a una orden de venta despachada o a una salida suelta vigente que incluya el producto"},
+	{repository.ErrDevolucionExcedida, http.StatusConflict, CodeDevolucionExcedida, "La cantidad devuelta supera la vendida"},
+	{repository.ErrItemResuelto, http.StatusConflict, CodeItemResuelto, "El item de devolución ya salió de cuarentena"},
+	{repository.ErrLoteInvalido, http.StatusBadRequest, CodeLoteInvalido, "El lote es requerido al sumar stock de un producto con control de lotes y no se admite en los demás; un lote existente conserva su vencimiento"},
+	{repository.ErrLoteNoExiste, http.StatusBadRequest, CodeLoteNoExiste, "El lote no existe en el almacén"},
+	{repository.ErrLotesConStock, http.StatusConflict, CodeLotesConStock, "El control de lotes solo se puede cambiar cuando el producto no tiene stock"},
+	{repository.ErrSerieInvalida, http.StatusBadRequest, CodeSerieInvalida, "Un producto con control de series requiere una serie distinta por unidad, entre las del movimiento que vuelve si lo hay; los demás no admiten series"},
+	{repository.ErrSerieDuplicada, http.StatusConflict, CodeSerieDuplicada, "La serie ya está en stock"},
+	{repository.ErrSerieNoDisponible, http.StatusConflict, CodeSerieNoDisponible, "La serie no está en el almacén"},
+	{repository.ErrSeriesConStock, http.StatusConflict, CodeSeriesConStock, "El control de series solo se puede cambiar cuando el producto no tiene stock"},
+	{repository.ErrVarianteInvalida, http.StatusConflict, CodeVarianteInvalida, "Una variante no puede tener variantes"},
+	{repository.ErrVarianteDuplicada, http.StatusConflict, CodeVarianteDuplicada, "El producto ya tiene una variante con esos atributos"},
+	{repository.ErrPadreConStock, http.StatusConflict, CodePadreConStock, "Un producto con stock propio no puede tener variantes; ajústalo a cero antes"},
+	{repository.ErrControlVariante, http.StatusConflict, CodeControlVariante, "Las variantes usan el método de costeo y el control de lotes y series de su padre; cámbialos en el padre"},
+	{repository.ErrProductoConVariantes, http.StatusConflict, CodeProductoConVariantes, "El producto tiene variantes; el stock se mueve en cada una y se eliminan antes que el padre"},
+	{repository.ErrComponenteInvalido, http.StatusConflict, CodeComponenteInvalido, "Un kit no puede incluirse a sí mismo, ni ser componente de otro kit, ni tener variantes o usar productos con variantes"},
+	{repository.ErrComponentesConStock, http.StatusConflict, CodeComponentesConStock, "Los componentes de un kit solo se pueden cambiar cuando no tiene stock"},
+	{repository.ErrControlEnKit, http.StatusConflict, CodeControlEnKit, "Los kits y sus componentes no pueden controlar lotes ni series, porque ensamblar no indica qué lotes o series mueve"},
+	{repository.ErrProductoEnKit, http.StatusConflict, CodeProductoEnKit, "El producto es componente de un kit; quítalo de su lista de materiales antes"},
+	{repository.ErrNoEsKit, http.StatusConflict, CodeProductoSinComponentes, "El producto no tiene componentes que ensamblar"},
+	{repository.ErrStockInsuficiente, http.StatusConflict, CodeStockInsuficiente, "Stock insuficiente"},
+	{repository.ErrMetodoCosteoConStock, http.StatusConflict, CodeMetodoCosteoConStock, "El método de costeo solo se puede cambiar cuando el producto no tiene stock"},
+	{repository.ErrSKUDuplicado, http.StatusConflict, CodeSKUDuplicado, "Ya existe un producto con ese SKU"},
+	{repository.ErrCodigoBarrasDuplicado, http.StatusConflict, CodeCodigoBarrasDuplicado, "Ya existe un producto con ese código de barras"},
+	{repository.ErrValorNegativo, http.StatusBadRequest, CodeValidacion, "El precio y el stock no pueden ser negativos"},
+	{repository.ErrDuplicado, http.StatusConflict, CodeDuplicado, "Ya existe un registro con esos datos"},
+	{repository.ErrReferenciaInvalida, http.StatusConflict, CodeReferenciaInvalida, "El registro referenciado no existe o está en uso"},
+	{repository.ErrValorInvalido, http.StatusBadRequest, CodeValidacion, "Un valor no cumple las restricciones"},
+}
+
+// capitalizar pone en mayúscula la primera letra de los mensajes de error de Go
+func capitalizar(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// respondError escribe el cuerpo de error estándar
+func respondError(w http.ResponseWriter, r *http.Request, status int, code, message string, details ...ErrorDetail) {
+	respondJSON(w, status, ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: RequestIDFromContext(r.Context()),
+	})
+}
+
+// respondValidation responde 400 con un detalle por cada campo inválido
+func respondValidation(w http.ResponseWriter, r *http.Request, details []ErrorDetail) {
+	respondError(w, r, http.StatusBadRequest, CodeValidacion, "La petición contiene datos inválidos", details...)
+}
+
+// respondInvalidJSON responde 400 cuando el cuerpo no se puede decodificar
+func respondInvalidJSON(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, http.StatusBadRequest, CodeJSONInvalido, "El cuerpo de la petición no es un JSON válido")
+}
+
+// respondInvalidID responde 400 cuando un parámetro de ruta no es un ID numérico
+func respondInvalidID(w http.ResponseWriter, r *http.Request, field string) {
+	respondValidation(w, r, []ErrorDetail{{Field: field, Message: "Debe ser un número entero"}})
+}
+
+// respondBadRequest responde a los errores al interpretar la query string
+func respondBadRequest(w http.ResponseWriter, r *http.Request, err error) {
+	var fe *fieldError
+	if errors.As(err, &fe) {
+		respondValidation(w, r, []ErrorDetail{{Field: fe.Field, Message: fe.Message}})
+		return
+	}
+	respondError(w, r, http.StatusBadRequest, CodeValidacion, err.Error())
+}
+
+// respondRepoError traduce un error de los repositorios a la respuesta HTTP.
+// notFound es el mensaje para repository.ErrNotFound. Los errores no
+// reconocidos se registran en el log y no se exponen al cliente.
+func respondRepoError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		respondError(w, r, http.StatusNotFound, CodeNoEncontrado, notFound)
+		return
+	}
+	for _, de := range domainErrors {
+		if errors.Is(err, de.err) {
+			respondError(w, r, de.status, de.code, de.message)
+			return
+		}
+	}
+
+	requestID := RequestIDFromContext(r.Context())
+	log.Printf("❌ [%s] %s %s: %v", requestID, r.Method, r.URL.Path, err)
+	respondError(w, r, http.StatusInternalServerError, CodeErrorInterno, "Error interno del servidor")
+}
+
+// NotFound responde a las rutas que no existen
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, http.StatusNotFound, CodeRutaNoEncontrada, "La ruta solicitada no existe")
+}
+
+// MethodNotAllowed responde a los métodos no soportados por una ruta
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, http.StatusMethodNotAllowed, CodeMetodoNoPermitido, "Método no permitido")
+}
+
+type requestIDKey struct{}
+
+// RequestIDHeader es la cabecera con la que se propaga el ID de la petición
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID asigna un identificador a cada petición (o reutiliza el que envía
+// el cliente si es válido), lo devuelve en la cabecera X-Request-ID y lo
+// guarda en el contexto para incluirlo en las respuestas de error y en los
+// logs.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDValido(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext devuelve el ID asignado por el middleware RequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDValido acepta hasta 128 letras ASCII, dígitos, puntos, guiones y
+// guiones bajos, para que el ID del cliente no pueda inyectar saltos de
+// línea ni caracteres de control en los logs
+func requestIDValido(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
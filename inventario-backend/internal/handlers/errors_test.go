@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// routerErroresPrueba arma un router con el middleware RequestID y las
+// respuestas para rutas inexistentes, como routes.SetupRoutes
+func routerErroresPrueba(repos repository.Repositories) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(RequestID)
+	r.NotFoundHandler = RequestID(http.HandlerFunc(NotFound))
+	r.MethodNotAllowedHandler = RequestID(http.HandlerFunc(MethodNotAllowed))
+
+	productos := NewProductoHandler(repos.Productos)
+	categorias := NewCategoriaHandler(repos.Categorias)
+	movimientos := NewMovimientoHandler(repos.Movimientos, nil)
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/productos/{id}", productos.GetProducto).Methods("GET")
+	api.HandleFunc("/categorias", categorias.CreateCategoria).Methods("POST")
+	api.HandleFunc("/movimientos", movimientos.CreateMovimiento).Methods("POST")
+	return r
+}
+
+func TestRespuestasDeErrorEstructuradas(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		router := routerErroresPrueba(repos)
+		p := crearProductoPrueba(t, repos, 1)
+		c, err := repos.Categorias.GetByID(ctx, p.CategoriaID)
+		if err != nil {
+			t.Fatalf("error al leer la categoría: %v", err)
+		}
+
+		casos := []struct {
+			nombre, metodo, ruta, body string
+			status                     int
+			code, campo                string
+		}{
+			{"producto inexistente", "GET", "/api/productos/999999999", "", http.StatusNotFound, CodeNoEncontrado, ""},
+			{"id inválido", "GET", "/api/productos/abc", "", http.StatusBadRequest, CodeValidacion, "id"},
+			{"JSON inválido", "POST", "/api/movimientos", "{", http.StatusBadRequest, CodeJSONInvalido, ""},
+			{"validación", "POST", "/api/movimientos", `{"producto_id": 1, "tipo": "entrada", "cantidad": 0}`, http.StatusBadRequest, CodeValidacion, "cantidad"},
+			{"referencia inexistente", "POST", "/api/movimientos", `{"producto_id": 999999999, "tipo": "entrada", "cantidad": 1}`, http.StatusBadRequest, CodeProductoNoExiste, ""},
+			{"stock insuficiente", "POST", "/api/movimientos", fmt.Sprintf(`{"producto_id": %d, "tipo": "salida", "cantidad": 5}`, p.ID), http.StatusConflict, CodeStockInsuficiente, ""},
+			{"duplicado", "POST", "/api/categorias", fmt.Sprintf(`{"nombre": %q}`, c.Nombre), http.StatusConflict, CodeCategoriaDuplicada, ""},
+			{"ruta inexistente", "GET", "/api/nada", "", http.StatusNotFound, CodeRutaNoEncontrada, ""},
+			{"método no permitido", "DELETE", "/api/movimientos", "", http.StatusMethodNotAllowed, CodeMetodoNoPermitido, ""},
+		}
+		for _, caso := range casos {
+			req := httptest.NewRequest(caso.metodo, caso.ruta, strings.NewReader(caso.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != caso.status {
+				t.Errorf("%s: código %d, se esperaba %d: %s", caso.nombre, rec.Code, caso.status, rec.Body)
+				continue
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("%s: Content-Type = %q", caso.nombre, ct)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("%s: respuesta inválida: %v", caso.nombre, err)
+			}
+			if body.Code != caso.code || body.Message == "" {
+				t.Errorf("%s: respuesta %+v, se esperaba el código %s", caso.nombre, body, caso.code)
+			}
+			if caso.campo != "" && (len(body.Details) == 0 || body.Details[0].Field != caso.campo) {
+				t.Errorf("%s: detalles %+v, se esperaba el campo %s", caso.nombre, body.Details, caso.campo)
+			}
+			if id := rec.Header().Get(RequestIDHeader); id == "" || body.RequestID != id {
+				t.Errorf("%s: request_id %q y cabecera %q", caso.nombre, body.RequestID, id)
+			}
+		}
+
+		// El ID enviado por el cliente se conserva
+		req := httptest.NewRequest("GET", "/api/productos/999999999", nil)
+		req.Header.Set(RequestIDHeader, "prueba-123")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var body ErrorResponse
+		json.NewDecoder(rec.Body).Decode(&body)
+		if rec.Header().Get(RequestIDHeader) != "prueba-123" || body.RequestID != "prueba-123" {
+			t.Errorf("request_id = %q, se esperaba el enviado por el cliente", body.RequestID)
+		}
+	})
+}
+
+func TestRequestIDDelCliente(t *testing.T) {
+	casos := []struct {
+		id       string
+		conserva bool
+	}{
+		{"prueba-123", true},
+		{"a1.B2_c3", true},
+		{strings.Repeat("a", 128), true},
+		{strings.Repeat("a", 129), false},
+		{"", false},
+		{"linea\r\nfalsa", false},
+		{"con espacio", false},
+		{"tab\t", false},
+		{"ñandú", false},
+	}
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestIDFromContext(r.Context())))
+	}))
+	for _, c := range casos {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, c.id)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(RequestIDHeader)
+		if rec.Body.String() != id || !requestIDValido(id) {
+			t.Errorf("%q: cabecera %q y contexto %q", c.id, id, rec.Body)
+		}
+		if (id == c.id) != c.conserva {
+			t.Errorf("%q: se asignó %q, conservar = %v", c.id, id, c.conserva)
+		}
+	}
+}
+
+func TestRespondRepoError(t *testing.T) {
+	casos := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{repository.ErrNotFound, http.StatusNotFound, CodeNoEncontrado},
+		{fmt.Errorf("al despachar: %w", repository.ErrStockInsuficiente), http.StatusConflict, CodeStockInsuficiente},
+		{repository.ErrValorInvalido, http.StatusBadRequest, CodeValidacion},
+		{errors.New("pq: conexión rechazada por 10.0.0.1"), http.StatusInternalServerError, CodeErrorInterno},
+	}
+	for _, caso := range casos {
+		req := httptest.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+		respondRepoError(rec, req, caso.err, "No encontrado")
+
+		var body ErrorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("%v: respuesta inválida: %v", caso.err, err)
+		}
+		if rec.Code != caso.status || body.Code != caso.code {
+			t.Errorf("%v: %d %s, se esperaba %d %s", caso.err, rec.Code, body.Code, caso.status, caso.code)
+		}
+		// Los errores inesperados no se exponen al cliente
+		if strings.Contains(body.Message, "10.0.0.1") {
+			t.Errorf("%v: el mensaje expone el error interno: %q", caso.err, body.Message)
+		}
+	}
+}
+
+func TestCodigosDeErrorUnicos(t *testing.T) {
+	vistos := make(map[error]bool)
+	for _, de := range domainErrors {
+		if vistos[de.err] {
+			t.Errorf("%v aparece dos veces en domainErrors", de.err)
+		}
+		vistos[de.err] = true
+		if de.status < 400 || de.code == "" || de.message == "" {
+			t.Errorf("%v: respuesta incompleta %+v", de.err, de)
+		}
+	}
+}
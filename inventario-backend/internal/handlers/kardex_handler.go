@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// kardexHTML es la versión imprimible del kardex
+var kardexHTML = template.Must(template.New("kardex").Funcs(template.FuncMap{
+	"fecha":  func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"dinero": func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+	"costo":  func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) },
+	"cant": func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>Kardex - {{.Producto}}</title>
+<style>
+body { font-family: sans-serif; font-size: 12px; margin: 24px; }
+h1 { font-size: 18px; margin: 0 0 4px; }
+p { margin: 0 0 12px; color: #444; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #999; padding: 4px 6px; }
+th { background: #eee; }
+td.n { text-align: right; white-space: nowrap; }
+tr.saldo td { font-weight: bold; background: #f6f6f6; }
+@media print { body { margin: 0; } th { background: none; } }
+</style>
+</head>
+<body>
+<h1>Kardex - {{.Producto}}</h1>
+<p>Producto #{{.ProductoID}} · Método: {{if eq .Metodo "fifo"}}FIFO{{else}}Promedio ponderado{{end}}{{with .Desde}} · Desde {{fecha .}}{{end}}{{with .Hasta}} · Hasta {{fecha .}}{{end}}</p>
+<table>
+<thead>
+<tr><th rowspan="2">Fecha</th><th rowspan="2">Mov.</th><th rowspan="2">Concepto</th><th rowspan="2">Almacén</th>
+<th colspan="2">Entrada</th><th colspan="2">Salida</th><th rowspan="2">Costo unit.</th><th colspan="3">Saldo</th></tr>
+<tr><th>Cant.</th><th>Costo</th><th>Cant.</th><th>Costo</th><th>Cant.</th><th>Costo unit.</th><th>Valor</th></tr>
+</thead>
+<tbody>
+<tr class="saldo"><td colspan="9">Saldo inicial</td><td class="n">{{.SaldoInicial.Cantidad}}</td><td class="n">{{costo .SaldoInicial.CostoUnitario}}</td><td class="n">{{dinero .SaldoInicial.Valor}}</td></tr>
+{{range .Lineas}}<tr><td>{{fecha .Fecha}}</td><td class="n">{{.MovimientoID}}</td><td>{{.Tipo}}{{with .CodigoMotivo}} ({{.}}){{end}}{{with .Motivo}} - {{.}}{{end}}</td><td>{{.Almacen}}</td>
+<td class="n">{{cant .Entrada}}</td><td class="n">{{if .Entrada}}{{dinero .CostoTotal}}{{end}}</td><td class="n">{{cant .Salida}}</td><td class="n">{{if .Salida}}{{dinero .CostoTotal}}{{end}}</td>
+<td class="n">{{costo .CostoUnitario}}{{if .CostoEstimado}}*{{end}}</td><td class="n">{{.Saldo.Cantidad}}</td><td class="n">{{costo .Saldo.CostoUnitario}}</td><td class="n">{{dinero .Saldo.Valor}}</td></tr>
+{{end}}<tr class="saldo"><td colspan="9">Saldo final</td><td class="n">{{.SaldoFinal.Cantidad}}</td><td class="n">{{costo .SaldoFinal.CostoUnitario}}</td><td class="n">{{dinero .SaldoFinal.Valor}}</td></tr>
+</tbody>
+</table>
+<p>* Entrada sin costo registrado, valorada al costo promedio del momento.</p>
+</body>
+</html>
+`))
+
+// GetKardex devuelve la tarjeta de existencias valorada de un producto. Acepta
+// desde y hasta, metodo (promedio o fifo) y formato (json, csv o html).
+func (h *MovimientoHandler) GetKardex(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var filtro repository.KardexFiltro
+	q := r.URL.Query()
+	if filtro.Desde, err = queryTime(q, "desde"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	if filtro.Hasta, err = queryTime(q, "hasta"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	filtro.Metodo = models.MetodoCosteo(q.Get("metodo"))
+	switch filtro.Metodo {
+	case "", models.MetodoPromedio, models.MetodoFIFO:
+	default:
+		respondBadRequest(w, r, &fieldError{Field: "metodo", Message: "Debe ser 'promedio' o 'fifo'"})
+		return
+	}
+	formato := q.Get("formato")
+	switch formato {
+	case "", "json", "csv", "html":
+	default:
+		respondBadRequest(w, r, &fieldError{Field: "formato", Message: "Debe ser 'json', 'csv' o 'html'"})
+		return
+	}
+
+	k, err := h.repo.Kardex(r.Context(), id, filtro)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
+		return
+	}
+
+	switch formato {
+	case "csv":
+		escribirKardexCSV(w, k)
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		kardexHTML.Execute(w, k)
+	default:
+		respondJSON(w, http.StatusOK, k)
+	}
+}
+
+// escribirKardexCSV envía el kardex como CSV con una fila por movimiento,
+// precedidas por el saldo inicial
+func escribirKardexCSV(w http.ResponseWriter, k *models.Kardex) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="kardex-%d.csv"`, k.ProductoID))
+
+	dinero := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	costo := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"fecha", "movimiento_id", "tipo", "codigo_motivo", "motivo", "almacen",
+		"entrada", "salida", "costo_unitario", "costo_total", "costo_estimado",
+		"saldo_cantidad", "saldo_costo_unitario", "saldo_valor",
+	})
+	cw.Write([]string{
+		"", "", "", "", "Saldo inicial", "", "", "", "", "", "",
+		strconv.Itoa(k.SaldoInicial.Cantidad), costo(k.SaldoInicial.CostoUnitario), dinero(k.SaldoInicial.Valor),
+	})
+	for _, l := range k.Lineas {
+		cw.Write([]string{
+			l.Fecha.Format(time.RFC3339),
+			strconv.Itoa(l.MovimientoID),
+			string(l.Tipo),
+			string(l.CodigoMotivo),
+			l.Motivo,
+			l.Almacen,
+			strconv.Itoa(l.Entrada),
+			strconv.Itoa(l.Salida),
+			costo(l.CostoUnitario),
+			dinero(l.CostoTotal),
+			strconv.FormatBool(l.CostoEstimado),
+			strconv.Itoa(l.Saldo.Cantidad),
+			costo(l.Saldo.CostoUnitario),
+			dinero(l.Saldo.Valor),
+		})
+	}
+	cw.Flush()
+}
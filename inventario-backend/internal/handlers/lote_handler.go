@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"inventario-backend/internal/repository"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	loteNoEncontrado = "Lote no encontrado"
+	// diasPorVencer es el horizonte por defecto de los lotes por vencer
+	diasPorVencer = 30
+)
+
+type LoteHandler struct {
+	repo repository.LoteRepository
+}
+
+func NewLoteHandler(repo repository.LoteRepository) *LoteHandler {
+	return &LoteHandler{repo: repo}
+}
+
+// parseLoteFiltro lee los filtros producto_id y almacen_id comunes a los
+// listados de lotes
+func parseLoteFiltro(q url.Values) (repository.LoteFiltro, error) {
+	var f repository.LoteFiltro
+	var err error
+	if f.ProductoID, err = queryInt(q, "producto_id"); err != nil {
+		return f, err
+	}
+	f.AlmacenID, err = queryInt(q, "almacen_id")
+	return f, err
+}
+
+func (h *LoteHandler) listLotes(w http.ResponseWriter, r *http.Request, filtro repository.LoteFiltro) {
+	lotes, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, loteNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, lotes)
+}
+
+// GetLotes lista los lotes del que vence primero al último. Acepta los
+// filtros producto_id, almacen_id y con_stock.
+func (h *LoteHandler) GetLotes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filtro, err := parseLoteFiltro(q)
+	if err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	filtro.ConStock = q.Get("con_stock") == "true"
+
+	h.listLotes(w, r, filtro)
+}
+
+// GetLotesPorVencer lista los lotes con stock que vencen en los próximos
+// dias (30 por defecto), incluidos los ya vencidos. Acepta también los
+// filtros producto_id y almacen_id.
+func (h *LoteHandler) GetLotesPorVencer(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filtro, err := parseLoteFiltro(q)
+	if err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	dias, err := queryInt(q, "dias")
+	if err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	if dias == nil {
+		d := diasPorVencer
+		dias = &d
+	}
+	if *dias < 0 {
+		respondBadRequest(w, r, &fieldError{Field: "dias", Message: "No puede ser negativo"})
+		return
+	}
+
+	// Los vencimientos son fechas sin hora, guardadas a medianoche UTC
+	y, m, d := time.Now().Date()
+	hasta := time.Date(y, m, d+*dias, 0, 0, 0, 0, time.UTC)
+	filtro.ConStock = true
+	filtro.VenceHasta = &hasta
+
+	h.listLotes(w, r, filtro)
+}
@@ -1,224 +1,372 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/json"
-	"inventario-backend/internal/database"
 	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 )
 
-func GetMovimientos(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query(`
-		SELECT m.id, m.producto_id, m.tipo, m.cantidad, m.motivo, m.created_at,
-		       p.id, p.nombre, p.descripcion, p.precio, p.stock
-		FROM movimientos_inventario m
-		LEFT JOIN productos p ON m.producto_id = p.id
-		ORDER BY m.created_at DESC
-	`)
+const movimientoNoEncontrado = "Movimiento no encontrado"
+
+type MovimientoHandler struct {
+	repo    repository.MovimientoRepository
+	alertas Notificador
+}
+
+// NewMovimientoHandler crea el handler; alertas recibe los productos de cada
+// movimiento registrado y puede ser nil
+func NewMovimientoHandler(repo repository.MovimientoRepository, alertas Notificador) *MovimientoHandler {
+	return &MovimientoHandler{repo: repo, alertas: alertas}
+}
+
+// notificar avisa del cambio de stock del producto, si hay a quién
+func (h *MovimientoHandler) notificar(productoID int) {
+	if h.alertas != nil {
+		h.alertas.Notificar(productoID)
+	}
+}
+
+// encodeCursor serializa el cursor de paginación como un token opaco
+func encodeCursor(c *repository.MovimientoCursor) string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor interpreta el token generado por encodeCursor
+func decodeCursor(token string) (*repository.MovimientoCursor, error) {
+	invalid := &fieldError{Field: "cursor", Message: "El cursor es inválido"}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	fecha, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, invalid
+	}
+
+	var c repository.MovimientoCursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, fecha); err != nil {
+		return nil, invalid
+	}
+	if c.ID, err = strconv.Atoi(id); err != nil {
+		return nil, invalid
+	}
+	return &c, nil
+}
+
+// parseMovimientoFiltro construye el filtro del historial a partir de la query string
+func parseMovimientoFiltro(q url.Values) (repository.MovimientoFiltro, error) {
+	var f repository.MovimientoFiltro
+	var err error
+
+	f.Tipo = models.TipoMovimiento(q.Get("tipo"))
+	if f.Tipo != "" && !tipoValido(f.Tipo) {
+		return f, &fieldError{Field: "tipo", Message: "Debe ser 'entrada', 'salida' o 'ajuste'"}
+	}
+	f.CodigoMotivo = models.CodigoMotivo(q.Get("codigo_motivo"))
+	if f.CodigoMotivo != "" && !models.MotivoRegistrable(f.CodigoMotivo) {
+		return f, &fieldError{Field: "codigo_motivo", Message: "No es un motivo de ajuste válido"}
+	}
+	if f.ProductoID, err = queryInt(q, "producto_id"); err != nil {
+		return f, err
+	}
+	if f.CategoriaID, err = queryInt(q, "categoria_id"); err != nil {
+		return f, err
+	}
+	if f.AlmacenID, err = queryInt(q, "almacen_id"); err != nil {
+		return f, err
+	}
+	if f.ProveedorID, err = queryInt(q, "proveedor_id"); err != nil {
+		return f, err
+	}
+	if f.OrdenCompraID, err = queryInt(q, "orden_compra_id"); err != nil {
+		return f, err
+	}
+	if f.OrdenVentaID, err = queryInt(q, "orden_venta_id"); err != nil {
+		return f, err
+	}
+	if f.DevolucionID, err = queryInt(q, "devolucion_id"); err != nil {
+		return f, err
+	}
+	if f.LoteID, err = queryInt(q, "lote_id"); err != nil {
+		return f, err
+	}
+	f.Serie = strings.TrimSpace(q.Get("serie"))
+	if f.Desde, err = queryTime(q, "desde"); err != nil {
+		return f, err
+	}
+	if f.Hasta, err = queryTime(q, "hasta"); err != nil {
+		return f, err
+	}
+	f.Q = strings.TrimSpace(q.Get("q"))
+
+	token := q.Get("cursor")
+	if token != "" {
+		if f.After, err = decodeCursor(token); err != nil {
+			return f, err
+		}
+	}
+	// Sin cursor ni limit se devuelve todo el historial, como en productos
+	if token != "" || q.Has("limit") {
+		f.Limit, err = queryLimit(q)
+	}
+	return f, err
+}
+
+// listMovimientos responde con la página solicitada y publica el cursor de
+// la siguiente en la cabecera X-Next-Cursor
+func (h *MovimientoHandler) listMovimientos(w http.ResponseWriter, r *http.Request, filtro repository.MovimientoFiltro) {
+	movimientos, next, err := h.repo.List(r.Context(), filtro)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondRepoError(w, r, err, movimientoNoEncontrado)
 		return
 	}
-	defer rows.Close()
 
-	var movimientos []models.MovimientoInventario
-	for rows.Next() {
-		var m models.MovimientoInventario
-		var p models.Producto
-		err := rows.Scan(&m.ID, &m.ProductoID, &m.Tipo, &m.Cantidad, &m.Motivo, &m.CreatedAt,
-			&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	if next != nil {
+		w.Header().Set("X-Next-Cursor", encodeCursor(next))
+	}
+	respondJSON(w, http.StatusOK, movimientos)
+}
+
+func tipoValido(t models.TipoMovimiento) bool {
+	return t == models.TipoEntrada || t == models.TipoSalida || t == models.TipoAjuste
+}
+
+// monedaValida indica si el código tiene la forma de ISO 4217 (tres letras
+// mayúsculas)
+func monedaValida(moneda string) bool {
+	if len(moneda) != 3 {
+		return false
+	}
+	for _, c := range moneda {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validarMovimientoRequest verifica los campos de un nuevo movimiento y
+// normaliza el lote y las series
+func validarMovimientoRequest(req *models.MovimientoInventarioRequest) []ErrorDetail {
+	var details []ErrorDetail
+	req.Lote = strings.TrimSpace(req.Lote)
+	if !tipoValido(req.Tipo) {
+		details = append(details, ErrorDetail{Field: "tipo", Message: "El tipo debe ser 'entrada', 'salida' o 'ajuste'"})
+	}
+	if req.Tipo == models.TipoAjuste {
+		if req.Cantidad == 0 {
+			details = append(details, ErrorDetail{Field: "cantidad", Message: "La cantidad del ajuste no puede ser 0"})
+		}
+		if !models.MotivoValido(req.CodigoMotivo) {
+			details = append(details, ErrorDetail{Field: "codigo_motivo", Message: "El ajuste requiere un motivo del catálogo"})
+		}
+	} else {
+		if req.Cantidad <= 0 {
+			details = append(details, ErrorDetail{Field: "cantidad", Message: "La cantidad debe ser mayor a 0"})
+		}
+		if req.CodigoMotivo != "" {
+			details = append(details, ErrorDetail{Field: "codigo_motivo", Message: "Solo se usa en los ajustes"})
+		}
+	}
+	if req.CostoUnitario != nil {
+		if *req.CostoUnitario < 0 {
+			details = append(details, ErrorDetail{Field: "costo_unitario", Message: "El costo no puede ser negativo"})
+		} else if req.Tipo == models.TipoSalida || (req.Tipo == models.TipoAjuste && req.Cantidad < 0) {
+			details = append(details, ErrorDetail{Field: "costo_unitario", Message: "Solo las entradas y los ajustes positivos llevan costo"})
+		}
+	}
+	if req.Moneda != "" {
+		if !monedaValida(req.Moneda) {
+			details = append(details, ErrorDetail{Field: "moneda", Message: "Debe ser un código ISO 4217 de tres letras mayúsculas"})
+		}
+		if req.CostoUnitario == nil {
+			details = append(details, ErrorDetail{Field: "moneda", Message: "La moneda solo se indica junto al costo unitario"})
+		}
+		if req.TipoCambio == nil {
+			details = append(details, ErrorDetail{Field: "tipo_cambio", Message: "El tipo de cambio es requerido con la moneda"})
+		}
+	}
+	if req.TipoCambio != nil {
+		if *req.TipoCambio <= 0 {
+			details = append(details, ErrorDetail{Field: "tipo_cambio", Message: "El tipo de cambio debe ser mayor a 0"})
+		} else if req.Moneda == "" {
+			details = append(details, ErrorDetail{Field: "tipo_cambio", Message: "El tipo de cambio requiere la moneda"})
+		}
+	}
+	if req.AlmacenID < 0 {
+		details = append(details, ErrorDetail{Field: "almacen_id", Message: "El almacén no es válido"})
+	}
+	if req.ProveedorID != nil && req.Tipo != models.TipoEntrada {
+		details = append(details, ErrorDetail{Field: "proveedor_id", Message: "Solo las entradas llevan proveedor"})
+	}
+	if utf8.RuneCountInString(req.Lote) > 100 {
+		details = append(details, ErrorDetail{Field: "lote", Message: "El lote no puede superar los 100 caracteres"})
+	}
+	if req.Vencimiento != nil {
+		if req.Tipo == models.TipoSalida || (req.Tipo == models.TipoAjuste && req.Cantidad < 0) {
+			details = append(details, ErrorDetail{Field: "vencimiento", Message: "Solo las entradas y los ajustes positivos llevan vencimiento"})
+		} else if req.Lote == "" {
+			details = append(details, ErrorDetail{Field: "vencimiento", Message: "El vencimiento requiere el lote"})
 		}
-		m.Producto = &p
-		movimientos = append(movimientos, m)
 	}
+	return append(details, validarSeries(req.Series, "series")...)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(movimientos)
+// GetMovimientos lista el historial del más reciente al más antiguo. Acepta
+// los filtros tipo, codigo_motivo, producto_id, categoria_id, almacen_id,
+// proveedor_id, orden_compra_id, orden_venta_id, devolucion_id, lote_id,
+// serie, desde, hasta y q; y la paginación con limit y cursor.
+func (h *MovimientoHandler) GetMovimientos(w http.ResponseWriter, r *http.Request) {
+	filtro, err := parseMovimientoFiltro(r.URL.Query())
+	if err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+
+	h.listMovimientos(w, r, filtro)
 }
 
-func GetMovimiento(w http.ResponseWriter, r *http.Request) {
+func (h *MovimientoHandler) GetMovimiento(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		respondInvalidID(w, r, "id")
 		return
 	}
 
-	var m models.MovimientoInventario
-	var p models.Producto
-	err = database.DB.QueryRow(`
-		SELECT m.id, m.producto_id, m.tipo, m.cantidad, m.motivo, m.created_at,
-		       p.id, p.nombre, p.descripcion, p.precio, p.stock
-		FROM movimientos_inventario m
-		LEFT JOIN productos p ON m.producto_id = p.id
-		WHERE m.id = $1
-	`, id).Scan(&m.ID, &m.ProductoID, &m.Tipo, &m.Cantidad, &m.Motivo, &m.CreatedAt,
-		&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock)
-
+	m, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Movimiento no encontrado", http.StatusNotFound)
+		respondRepoError(w, r, err, movimientoNoEncontrado)
 		return
 	}
-	m.Producto = &p
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(m)
+	respondJSON(w, http.StatusOK, m)
 }
 
-func CreateMovimiento(w http.ResponseWriter, r *http.Request) {
+func (h *MovimientoHandler) CreateMovimiento(w http.ResponseWriter, r *http.Request) {
 	var req models.MovimientoInventarioRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		respondInvalidJSON(w, r)
 		return
 	}
 
-	if req.Tipo != models.TipoEntrada && req.Tipo != models.TipoSalida {
-		http.Error(w, "El tipo debe ser 'entrada' o 'salida'", http.StatusBadRequest)
+	if details := validarMovimientoRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
 		return
 	}
 
-	if req.Cantidad <= 0 {
-		http.Error(w, "La cantidad debe ser mayor a 0", http.StatusBadRequest)
+	m, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, movimientoNoEncontrado)
 		return
 	}
+	h.notificar(m.ProductoID)
 
-	// Verificar que el producto existe
-	var productoExists bool
-	err := database.DB.QueryRow(`
-		SELECT EXISTS(SELECT 1 FROM productos WHERE id = $1)
-	`, req.ProductoID).Scan(&productoExists)
+	respondJSON(w, http.StatusCreated, m)
+}
 
-	if err != nil || !productoExists {
-		http.Error(w, "El producto especificado no existe", http.StatusBadRequest)
+func (h *MovimientoHandler) GetMovimientosByProducto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productoID, err := strconv.Atoi(vars["producto_id"])
+	if err != nil {
+		respondInvalidID(w, r, "producto_id")
 		return
 	}
 
-	// Verificar stock disponible si es una salida
-	if req.Tipo == models.TipoSalida {
-		var stockActual int
-		err := database.DB.QueryRow(`
-			SELECT stock FROM productos WHERE id = $1
-		`, req.ProductoID).Scan(&stockActual)
-
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		if stockActual < req.Cantidad {
-			http.Error(w, "Stock insuficiente", http.StatusBadRequest)
-			return
-		}
-	}
-
-	// Iniciar transacción
-	tx, err := database.DB.Begin()
+	filtro, err := parseMovimientoFiltro(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondBadRequest(w, r, err)
 		return
 	}
-	defer tx.Rollback()
+	filtro.ProductoID = &productoID
 
-	// Crear el movimiento
-	var movimientoID int
-	err = tx.QueryRow(`
-		INSERT INTO movimientos_inventario (producto_id, tipo, cantidad, motivo) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING id
-	`, req.ProductoID, req.Tipo, req.Cantidad, req.Motivo).Scan(&movimientoID)
+	h.listMovimientos(w, r, filtro)
+}
 
+// RevertirMovimiento registra un movimiento que compensa al indicado. El
+// cuerpo es opcional y puede incluir el motivo de la reversión.
+func (h *MovimientoHandler) RevertirMovimiento(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondInvalidID(w, r, "id")
 		return
 	}
 
-	// Actualizar el stock del producto
-	if req.Tipo == models.TipoEntrada {
-		_, err = tx.Exec(`
-			UPDATE productos 
-			SET stock = stock + $1, updated_at = NOW() 
-			WHERE id = $2
-		`, req.Cantidad, req.ProductoID)
-	} else {
-		_, err = tx.Exec(`
-			UPDATE productos 
-			SET stock = stock - $1, updated_at = NOW() 
-			WHERE id = $2
-		`, req.Cantidad, req.ProductoID)
-	}
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	var req models.ReversionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondInvalidJSON(w, r)
 		return
 	}
 
-	// Confirmar transacción
-	if err = tx.Commit(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	m, err := h.repo.Revertir(r.Context(), id, strings.TrimSpace(req.Motivo))
+	if err != nil {
+		respondRepoError(w, r, err, movimientoNoEncontrado)
 		return
 	}
+	h.notificar(m.ProductoID)
 
-	// Obtener el movimiento creado
-	var m models.MovimientoInventario
-	var p models.Producto
-	database.DB.QueryRow(`
-		SELECT m.id, m.producto_id, m.tipo, m.cantidad, m.motivo, m.created_at,
-		       p.id, p.nombre, p.descripcion, p.precio, p.stock
-		FROM movimientos_inventario m
-		LEFT JOIN productos p ON m.producto_id = p.id
-		WHERE m.id = $1
-	`, movimientoID).Scan(&m.ID, &m.ProductoID, &m.Tipo, &m.Cantidad, &m.Motivo, &m.CreatedAt,
-		&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock)
-	m.Producto = &p
+	respondJSON(w, http.StatusCreated, m)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(m)
+// GetMotivosAjuste devuelve el catálogo de motivos de ajuste
+func (h *MovimientoHandler) GetMotivosAjuste(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, models.MotivosAjuste)
 }
 
-func GetMovimientosByProducto(w http.ResponseWriter, r *http.Request) {
+// GetStockAl reconstruye el stock de un producto a partir de sus movimientos.
+// fecha indica el instante; con solo el día (AAAA-MM-DD) se incluye todo ese
+// día, y sin fecha se usa el momento actual.
+func (h *MovimientoHandler) GetStockAl(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	productoID, err := strconv.Atoi(vars["producto_id"])
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "ID de producto inválido", http.StatusBadRequest)
+		respondInvalidID(w, r, "id")
 		return
 	}
 
-	rows, err := database.DB.Query(`
-		SELECT m.id, m.producto_id, m.tipo, m.cantidad, m.motivo, m.created_at,
-		       p.id, p.nombre, p.descripcion, p.precio, p.stock
-		FROM movimientos_inventario m
-		LEFT JOIN productos p ON m.producto_id = p.id
-		WHERE m.producto_id = $1
-		ORDER BY m.created_at DESC
-	`, productoID)
+	q := r.URL.Query()
+	fecha, err := queryTime(q, "fecha")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondBadRequest(w, r, err)
 		return
 	}
-	defer rows.Close()
-
-	var movimientos []models.MovimientoInventario
-	for rows.Next() {
-		var m models.MovimientoInventario
-		var p models.Producto
-		err := rows.Scan(&m.ID, &m.ProductoID, &m.Tipo, &m.Cantidad, &m.Motivo, &m.CreatedAt,
-			&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	hasta := time.Now()
+	if fecha != nil {
+		hasta = *fecha
+		if len(q.Get("fecha")) == len("2006-01-02") {
+			hasta = hasta.AddDate(0, 0, 1)
 		}
-		m.Producto = &p
-		movimientos = append(movimientos, m)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(movimientos)
+	s, err := h.repo.StockAl(r.Context(), id, hasta)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s)
 }
 
+// GetConciliacion lista los productos cuyo stock no cuadra con sus movimientos
+func (h *MovimientoHandler) GetConciliacion(w http.ResponseWriter, r *http.Request) {
+	conciliacion, err := h.repo.Conciliar(r.Context())
+	if err != nil {
+		respondRepoError(w, r, err, movimientoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, conciliacion)
+}
@@ -0,0 +1,690 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"inventario-backend/internal/repository/memory"
+	"inventario-backend/internal/repository/postgres"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+)
+
+// conectarDBPrueba abre la base de datos indicada en TEST_DATABASE_URL o
+// omite la prueba si no está configurada.
+func conectarDBPrueba(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no está configurada")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("error al abrir la base de datos: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("error al hacer ping a la base de datos: %v", err)
+	}
+	// Permitir suficientes conexiones para que las transacciones compitan de verdad
+	db.SetMaxOpenConns(20)
+
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// backendsPrueba ejecuta fn contra el almacenamiento en memoria y, si está
+// configurado, contra PostgreSQL.
+func backendsPrueba(t *testing.T, fn func(t *testing.T, repos repository.Repositories)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, memory.NewRepositories())
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, postgres.NewRepositories(conectarDBPrueba(t)))
+	})
+}
+
+// crearProductoPrueba crea una categoría y un producto con el stock indicado
+// y los elimina al terminar la prueba.
+func crearProductoPrueba(t *testing.T, repos repository.Repositories, stock int) *models.Producto {
+	t.Helper()
+	ctx := context.Background()
+
+	c, err := repos.Categorias.Create(ctx, models.CategoriaRequest{
+		Nombre: fmt.Sprintf("Prueba %s %d", t.Name(), time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatalf("error al crear la categoría: %v", err)
+	}
+	p, err := repos.Productos.Create(ctx, models.ProductoRequest{
+		Nombre:      "Producto de prueba",
+		Precio:      1,
+		Stock:       stock,
+		CategoriaID: c.ID,
+	})
+	if err != nil {
+		t.Fatalf("error al crear el producto: %v", err)
+	}
+	t.Cleanup(func() {
+		repos.Productos.Delete(ctx, p.ID)
+		repos.Categorias.Delete(ctx, c.ID)
+	})
+	return p
+}
+
+func TestCreateMovimientoSalidasConcurrentes(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		const stockInicial = 10
+		const peticiones = 40
+
+		h := NewMovimientoHandler(repos.Movimientos, nil)
+		p := crearProductoPrueba(t, repos, stockInicial)
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"producto_id": p.ID,
+			"tipo":        "salida",
+			"cantidad":    1,
+			"motivo":      "Venta concurrente",
+		})
+
+		var wg sync.WaitGroup
+		codigos := make(chan int, peticiones)
+		for i := 0; i < peticiones; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/api/movimientos", bytes.NewReader(body))
+				rec := httptest.NewRecorder()
+				h.CreateMovimiento(rec, req)
+				codigos <- rec.Code
+			}()
+		}
+		wg.Wait()
+		close(codigos)
+
+		creados, conflictos := 0, 0
+		for codigo := range codigos {
+			switch codigo {
+			case http.StatusCreated:
+				creados++
+			case http.StatusConflict:
+				conflictos++
+			default:
+				t.Errorf("código de estado inesperado: %d", codigo)
+			}
+		}
+
+		if creados != stockInicial {
+			t.Errorf("salidas registradas = %d, se esperaban %d", creados, stockInicial)
+		}
+		if conflictos != peticiones-stockInicial {
+			t.Errorf("respuestas 409 = %d, se esperaban %d", conflictos, peticiones-stockInicial)
+		}
+
+		ctx := context.Background()
+		actual, err := repos.Productos.GetByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("error al leer el producto: %v", err)
+		}
+		movimientos, _, err := repos.Movimientos.List(ctx, repository.MovimientoFiltro{ProductoID: &p.ID})
+		if err != nil {
+			t.Fatalf("error al leer los movimientos: %v", err)
+		}
+
+		salidas := 0
+		for _, m := range movimientos {
+			if m.Tipo == models.TipoSalida {
+				salidas += m.Cantidad
+			}
+		}
+
+		if actual.Stock != 0 {
+			t.Errorf("stock final = %d, se esperaba 0", actual.Stock)
+		}
+		if stockInicial-salidas != actual.Stock {
+			t.Errorf("el libro de movimientos (%d salidas) no cuadra con el stock %d", salidas, actual.Stock)
+		}
+	})
+}
+
+func TestRevertirMovimientoUnaSolaVez(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		const peticiones = 10
+		ctx := context.Background()
+
+		h := NewMovimientoHandler(repos.Movimientos, nil)
+		p := crearProductoPrueba(t, repos, 10)
+		salida, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: p.ID,
+			Tipo:       models.TipoSalida,
+			Cantidad:   3,
+		})
+		if err != nil {
+			t.Fatalf("error al crear la salida: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		codigos := make(chan int, peticiones)
+		for i := 0; i < peticiones; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/api/movimientos/revertir", nil)
+				req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(salida.ID)})
+				rec := httptest.NewRecorder()
+				h.RevertirMovimiento(rec, req)
+				codigos <- rec.Code
+			}()
+		}
+		wg.Wait()
+		close(codigos)
+
+		creados := 0
+		for codigo := range codigos {
+			switch codigo {
+			case http.StatusCreated:
+				creados++
+			case http.StatusConflict:
+			default:
+				t.Errorf("código de estado inesperado: %d", codigo)
+			}
+		}
+		if creados != 1 {
+			t.Errorf("reversiones registradas = %d, se esperaba 1", creados)
+		}
+
+		actual, err := repos.Productos.GetByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("error al leer el producto: %v", err)
+		}
+		if actual.Stock != 10 {
+			t.Errorf("stock final = %d, se esperaba 10", actual.Stock)
+		}
+
+		original, err := repos.Movimientos.GetByID(ctx, salida.ID)
+		if err != nil {
+			t.Fatalf("error al leer el movimiento: %v", err)
+		}
+		if original.RevertidoPorID == nil {
+			t.Fatal("el movimiento original no indica su reversión")
+		}
+		if _, err := repos.Movimientos.Revertir(ctx, *original.RevertidoPorID, ""); err != repository.ErrReversionNoPermitida {
+			t.Errorf("revertir una reversión devolvió %v, se esperaba ErrReversionNoPermitida", err)
+		}
+	})
+}
+
+func TestLibroCuadraConElStock(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		antes := time.Now().Add(-time.Second)
+
+		a, err := repos.Almacenes.Create(ctx, models.AlmacenRequest{
+			Nombre: fmt.Sprintf("Prueba %s %d", t.Name(), time.Now().UnixNano()),
+		})
+		if err != nil {
+			t.Fatalf("error al crear el almacén: %v", err)
+		}
+		t.Cleanup(func() { repos.Almacenes.Delete(ctx, a.ID) })
+		p := crearProductoPrueba(t, repos, 10)
+
+		operaciones := []func() error{
+			func() error {
+				_, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+					ProductoID: p.ID, Tipo: models.TipoAjuste, Cantidad: -2, CodigoMotivo: models.MotivoDano,
+				})
+				return err
+			},
+			func() error {
+				_, err := repos.Traslados.Create(ctx, models.TrasladoRequest{
+					ProductoID: p.ID, AlmacenDestinoID: a.ID, Cantidad: 3,
+				})
+				return err
+			},
+			func() error {
+				_, err := repos.Traslados.Create(ctx, models.TrasladoRequest{
+					ProductoID: p.ID, AlmacenDestinoID: a.ID, Cantidad: 1, EnTransito: true,
+				})
+				return err
+			},
+		}
+		for i, op := range operaciones {
+			if err := op(); err != nil {
+				t.Fatalf("operación %d: %v", i, err)
+			}
+		}
+
+		// Editar el producto con un stock desactualizado no toca el stock
+		editado, err := repos.Productos.Update(ctx, p.ID, models.ProductoRequest{
+			Nombre: p.Nombre, Precio: 2, Stock: 50, CategoriaID: p.CategoriaID,
+		})
+		if err != nil {
+			t.Fatalf("error al editar el producto: %v", err)
+		}
+		if editado.Precio != 2 || editado.Stock != 7 {
+			t.Errorf("producto editado con precio %v y stock %d, se esperaban 2 y 7", editado.Precio, editado.Stock)
+		}
+
+		conciliacion, err := repos.Movimientos.Conciliar(ctx)
+		if err != nil {
+			t.Fatalf("error al conciliar: %v", err)
+		}
+		for _, c := range conciliacion {
+			if c.ProductoID == p.ID {
+				t.Errorf("el producto no cuadra con sus movimientos: %+v", c)
+			}
+		}
+
+		actual, err := repos.Productos.GetByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("error al leer el producto: %v", err)
+		}
+		ahora, err := repos.Movimientos.StockAl(ctx, p.ID, time.Now().Add(time.Second))
+		if err != nil {
+			t.Fatalf("error al calcular el stock: %v", err)
+		}
+		if actual.Stock != 7 || ahora.Stock != actual.Stock {
+			t.Errorf("stock = %d y según los movimientos %d, se esperaba 7", actual.Stock, ahora.Stock)
+		}
+		inicial, err := repos.Movimientos.StockAl(ctx, p.ID, antes)
+		if err != nil {
+			t.Fatalf("error al calcular el stock: %v", err)
+		}
+		if inicial.Stock != 0 {
+			t.Errorf("stock antes de crear el producto = %d, se esperaba 0", inicial.Stock)
+		}
+	})
+}
+
+func TestValorInventarioCuadraConElKardex(t *testing.T) {
+	costo := func(v float64) *float64 { return &v }
+	for _, metodo := range []models.MetodoCosteo{models.MetodoPromedio, models.MetodoFIFO} {
+		t.Run(string(metodo), func(t *testing.T) {
+			backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+				ctx := context.Background()
+				p := crearProductoPrueba(t, repos, 0)
+				if _, err := repos.Productos.Update(ctx, p.ID, models.ProductoRequest{
+					Nombre: p.Nombre, Precio: p.Precio, CategoriaID: p.CategoriaID, MetodoCosteo: metodo,
+				}); err != nil {
+					t.Fatalf("error al cambiar el método de costeo: %v", err)
+				}
+
+				requests := []models.MovimientoInventarioRequest{
+					{ProductoID: p.ID, Tipo: models.TipoEntrada, Cantidad: 10, CostoUnitario: costo(2)},
+					{ProductoID: p.ID, Tipo: models.TipoEntrada, Cantidad: 10, CostoUnitario: costo(1), Moneda: "USD", TipoCambio: costo(4)},
+					{ProductoID: p.ID, Tipo: models.TipoSalida, Cantidad: 15},
+				}
+				var salida *models.MovimientoInventario
+				for i, req := range requests {
+					m, err := repos.Movimientos.Create(ctx, req)
+					if err != nil {
+						t.Fatalf("movimiento %d: %v", i, err)
+					}
+					salida = m
+				}
+				if _, err := repos.Movimientos.Revertir(ctx, salida.ID, ""); err != nil {
+					t.Fatalf("error al revertir la salida: %v", err)
+				}
+				if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+					ProductoID: p.ID, Tipo: models.TipoSalida, Cantidad: 12,
+				}); err != nil {
+					t.Fatalf("error al registrar la salida: %v", err)
+				}
+
+				actual, err := repos.Productos.GetByID(ctx, p.ID)
+				if err != nil {
+					t.Fatalf("error al leer el producto: %v", err)
+				}
+				k, err := repos.Movimientos.Kardex(ctx, p.ID, repository.KardexFiltro{})
+				if err != nil {
+					t.Fatalf("error al calcular el kardex: %v", err)
+				}
+				if k.Metodo != metodo {
+					t.Errorf("método del kardex = %s, se esperaba %s", k.Metodo, metodo)
+				}
+				if actual.Stock != 8 || k.SaldoFinal.Valor != actual.ValorInventario {
+					t.Errorf("stock %d con valor %v, el kardex da %+v", actual.Stock, actual.ValorInventario, k.SaldoFinal)
+				}
+
+				if _, err := repos.Productos.Update(ctx, p.ID, models.ProductoRequest{
+					Nombre: p.Nombre, Precio: p.Precio, CategoriaID: p.CategoriaID,
+					MetodoCosteo: models.MetodoPromedio,
+				}); metodo == models.MetodoFIFO && err != repository.ErrMetodoCosteoConStock {
+					t.Errorf("cambiar el método con stock devolvió %v, se esperaba ErrMetodoCosteoConStock", err)
+				}
+			})
+		})
+	}
+}
+
+func TestEntradasDelProveedor(t *testing.T) {
+	costo := func(v float64) *float64 { return &v }
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		pr, err := repos.Proveedores.Create(ctx, models.ProveedorRequest{
+			Nombre: fmt.Sprintf("Proveedor %s %d", t.Name(), time.Now().UnixNano()),
+		})
+		if err != nil {
+			t.Fatalf("error al crear el proveedor: %v", err)
+		}
+		// Se registra antes que el producto para eliminarlo después de sus entradas
+		t.Cleanup(func() { repos.Proveedores.Delete(ctx, pr.ID) })
+		p := crearProductoPrueba(t, repos, 0)
+
+		requests := []models.MovimientoInventarioRequest{
+			{ProductoID: p.ID, Tipo: models.TipoEntrada, Cantidad: 5, CostoUnitario: costo(2), Moneda: "USD", TipoCambio: costo(3), ProveedorID: &pr.ID},
+			{ProductoID: p.ID, Tipo: models.TipoEntrada, Cantidad: 4},
+			{ProductoID: p.ID, Tipo: models.TipoEntrada, Cantidad: 1, ProveedorID: &pr.ID},
+		}
+		for i, req := range requests {
+			if _, err := repos.Movimientos.Create(ctx, req); err != nil {
+				t.Fatalf("movimiento %d: %v", i, err)
+			}
+		}
+		if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: p.ID, Tipo: models.TipoSalida, Cantidad: 1, ProveedorID: &pr.ID,
+		}); err != repository.ErrValorInvalido {
+			t.Errorf("salida con proveedor devolvió %v, se esperaba ErrValorInvalido", err)
+		}
+
+		entradas, _, err := repos.Movimientos.List(ctx, repository.MovimientoFiltro{ProveedorID: &pr.ID})
+		if err != nil {
+			t.Fatalf("error al listar las entradas: %v", err)
+		}
+		if len(entradas) != 2 || entradas[0].Proveedor == nil || entradas[0].Proveedor.Nombre != pr.Nombre {
+			t.Errorf("entradas del proveedor = %+v, se esperaban 2 con su nombre", entradas)
+		}
+
+		// La entrada sin costo conserva el último costo conocido
+		vinculos, err := repos.Proveedores.ProveedoresDeProducto(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("error al listar los proveedores: %v", err)
+		}
+		if len(vinculos) != 1 || vinculos[0].UltimoCosto == nil || *vinculos[0].UltimoCosto != 6 || vinculos[0].UltimaEntradaAt == nil {
+			t.Errorf("vínculos = %+v, se esperaba uno con último costo 6", vinculos)
+		}
+
+		if err := repos.Proveedores.Delete(ctx, pr.ID); err != repository.ErrProveedorEnUso {
+			t.Errorf("eliminar el proveedor devolvió %v, se esperaba ErrProveedorEnUso", err)
+		}
+	})
+}
+
+func TestReservaOrdenVenta(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		p := crearProductoPrueba(t, repos, 10)
+
+		crear := func(cantidad int) *models.OrdenVenta {
+			t.Helper()
+			o, err := repos.Ventas.Create(ctx, models.OrdenVentaRequest{
+				Cliente: "Cliente de prueba",
+				Lineas:  []models.OrdenVentaLineaRequest{{ProductoID: p.ID, Cantidad: cantidad}},
+			})
+			if err != nil {
+				t.Fatalf("error al crear la orden: %v", err)
+			}
+			return o
+		}
+		disponible := func(reservado, disponible int) {
+			t.Helper()
+			actual, err := repos.Productos.GetByID(ctx, p.ID)
+			if err != nil {
+				t.Fatalf("error al leer el producto: %v", err)
+			}
+			if actual.Reservado != reservado || actual.Disponible != disponible {
+				t.Errorf("reservado %d y disponible %d, se esperaba %d y %d", actual.Reservado, actual.Disponible, reservado, disponible)
+			}
+		}
+
+		o := crear(6)
+		if _, err := repos.Ventas.Confirmar(ctx, o.ID); err != nil {
+			t.Fatalf("error al confirmar la orden: %v", err)
+		}
+		disponible(6, 4)
+
+		// Una salida suelta solo puede tomar lo no reservado
+		salida := func(cantidad int) error {
+			_, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+				ProductoID: p.ID, Tipo: models.TipoSalida, Cantidad: cantidad,
+			})
+			return err
+		}
+		if err := salida(5); err != repository.ErrStockInsuficiente {
+			t.Errorf("salida sobre lo reservado devolvió %v, se esperaba ErrStockInsuficiente", err)
+		}
+		if err := salida(4); err != nil {
+			t.Fatalf("salida de lo disponible: %v", err)
+		}
+
+		otra := crear(1)
+		if _, err := repos.Ventas.Confirmar(ctx, otra.ID); err != repository.ErrStockInsuficiente {
+			t.Errorf("confirmar sin disponible devolvió %v, se esperaba ErrStockInsuficiente", err)
+		}
+		if _, err := repos.Ventas.Cancelar(ctx, otra.ID); err != nil {
+			t.Errorf("error al cancelar la orden en borrador: %v", err)
+		}
+
+		if _, err := repos.Ventas.Despachar(ctx, o.ID, models.DespachoRequest{}); err != nil {
+			t.Fatalf("error al despachar la orden: %v", err)
+		}
+		disponible(0, 0)
+		salidas, _, err := repos.Movimientos.List(ctx, repository.MovimientoFiltro{OrdenVentaID: &o.ID})
+		if err != nil {
+			t.Fatalf("error al listar las salidas: %v", err)
+		}
+		if len(salidas) != 1 || salidas[0].Cantidad != 6 || salidas[0].Tipo != models.TipoSalida {
+			t.Errorf("salidas de la orden = %+v, se esperaba una de 6", salidas)
+		}
+		if _, err := repos.Ventas.Cancelar(ctx, o.ID); err != repository.ErrOrdenVentaEstado {
+			t.Errorf("cancelar una orden despachada devolvió %v, se esperaba ErrOrdenVentaEstado", err)
+		}
+	})
+}
+
+func TestAjusteConMotivo(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		h := NewMovimientoHandler(repos.Movimientos, nil)
+		p := crearProductoPrueba(t, repos, 5)
+
+		casos := []struct {
+			nombre string
+			campos string
+			status int
+			code   string
+			campo  string
+		}{
+			{"hallazgo", `"tipo": "ajuste", "cantidad": 3, "codigo_motivo": "hallazgo"`, http.StatusCreated, "", ""},
+			{"merma", `"tipo": "ajuste", "cantidad": -2, "codigo_motivo": "merma"`, http.StatusCreated, "", ""},
+			{"merma sobre el stock", `"tipo": "ajuste", "cantidad": -7, "codigo_motivo": "merma"`, http.StatusConflict, CodeStockInsuficiente, ""},
+			{"sin motivo", `"tipo": "ajuste", "cantidad": -1`, http.StatusBadRequest, CodeValidacion, "codigo_motivo"},
+			{"motivo fuera del catálogo", `"tipo": "ajuste", "cantidad": -1, "codigo_motivo": "robo"`, http.StatusBadRequest, CodeValidacion, "codigo_motivo"},
+			{"motivo reservado al sistema", `"tipo": "ajuste", "cantidad": 1, "codigo_motivo": "inicial"`, http.StatusBadRequest, CodeValidacion, "codigo_motivo"},
+			{"cantidad cero", `"tipo": "ajuste", "cantidad": 0, "codigo_motivo": "conteo"`, http.StatusBadRequest, CodeValidacion, "cantidad"},
+			{"costo en un ajuste negativo", `"tipo": "ajuste", "cantidad": -1, "codigo_motivo": "dano", "costo_unitario": 2`, http.StatusBadRequest, CodeValidacion, "costo_unitario"},
+			{"motivo en una salida", `"tipo": "salida", "cantidad": 1, "codigo_motivo": "merma"`, http.StatusBadRequest, CodeValidacion, "codigo_motivo"},
+		}
+		for _, c := range casos {
+			body := fmt.Sprintf(`{"producto_id": %d, %s}`, p.ID, c.campos)
+			req := httptest.NewRequest(http.MethodPost, "/api/movimientos", bytes.NewReader([]byte(body)))
+			rec := httptest.NewRecorder()
+			h.CreateMovimiento(rec, req)
+			if rec.Code != c.status {
+				t.Errorf("%s: código %d, se esperaba %d: %s", c.nombre, rec.Code, c.status, rec.Body)
+				continue
+			}
+			if c.status == http.StatusCreated {
+				continue
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("%s: respuesta inválida: %v", c.nombre, err)
+			}
+			if resp.Code != c.code || (c.campo != "" && (len(resp.Details) == 0 || resp.Details[0].Field != c.campo)) {
+				t.Errorf("%s: respuesta %+v, se esperaba %s en %q", c.nombre, resp, c.code, c.campo)
+			}
+		}
+
+		actual, err := repos.Productos.GetByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("error al leer el producto: %v", err)
+		}
+		if actual.Stock != 6 {
+			t.Errorf("stock = %d, se esperaba 6 tras +3 y -2", actual.Stock)
+		}
+
+		// Las pérdidas se separan por su código sin leer el motivo libre
+		mermas, _, err := repos.Movimientos.List(ctx, repository.MovimientoFiltro{
+			ProductoID: &p.ID, CodigoMotivo: models.MotivoMerma,
+		})
+		if err != nil {
+			t.Fatalf("error al listar los movimientos: %v", err)
+		}
+		if len(mermas) != 1 || mermas[0].Tipo != models.TipoAjuste || mermas[0].Cantidad != -2 {
+			t.Errorf("mermas = %+v, se esperaba un ajuste de -2", mermas)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/movimientos?codigo_motivo=robo", nil)
+		rec := httptest.NewRecorder()
+		h.GetMovimientos(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("filtrar por un motivo fuera del catálogo devolvió %d, se esperaba 400", rec.Code)
+		}
+		// El stock inicial se puede filtrar aunque no esté en el catálogo
+		if _, ids, _ := listarMovimientos(t, h, fmt.Sprintf("producto_id=%d&codigo_motivo=inicial", p.ID)); len(ids) != 1 {
+			t.Errorf("ajustes de stock inicial = %v, se esperaba uno", ids)
+		}
+
+		rec = httptest.NewRecorder()
+		h.GetMotivosAjuste(rec, httptest.NewRequest(http.MethodGet, "/api/motivos-ajuste", nil))
+		var catalogo []models.MotivoAjuste
+		if err := json.NewDecoder(rec.Body).Decode(&catalogo); err != nil {
+			t.Fatalf("respuesta inválida: %v", err)
+		}
+		for _, m := range catalogo {
+			if m.Codigo == models.MotivoInicial {
+				t.Error("el catálogo ofrece el motivo inicial, reservado al sistema")
+			}
+		}
+	})
+}
+
+// listarMovimientos llama a GET /api/movimientos y devuelve los IDs de la
+// página y el cursor de la siguiente
+func listarMovimientos(t *testing.T, h *MovimientoHandler, query string) (*httptest.ResponseRecorder, []int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.GetMovimientos(rec, httptest.NewRequest(http.MethodGet, "/api/movimientos?"+query, nil))
+	if rec.Code != http.StatusOK {
+		return rec, nil, ""
+	}
+	var movimientos []models.MovimientoInventario
+	if err := json.NewDecoder(rec.Body).Decode(&movimientos); err != nil {
+		t.Fatalf("%q: respuesta inválida: %v", query, err)
+	}
+	ids := []int{}
+	for _, m := range movimientos {
+		ids = append(ids, m.ID)
+	}
+	return rec, ids, rec.Header().Get("X-Next-Cursor")
+}
+
+func TestGetMovimientosFiltrosYCursor(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		h := NewMovimientoHandler(repos.Movimientos, nil)
+		a := crearAlmacenPrueba(t, repos)
+		p := crearProductoPrueba(t, repos, 10)
+		otro := crearProductoPrueba(t, repos, 3)
+
+		for i, req := range []models.MovimientoInventarioRequest{
+			{ProductoID: p.ID, AlmacenID: a.ID, Tipo: models.TipoEntrada, Cantidad: 5},
+			{ProductoID: p.ID, Tipo: models.TipoSalida, Cantidad: 2},
+			{ProductoID: p.ID, Tipo: models.TipoAjuste, Cantidad: -1, CodigoMotivo: models.MotivoMerma},
+		} {
+			if _, err := repos.Movimientos.Create(ctx, req); err != nil {
+				t.Fatalf("movimiento %d: %v", i, err)
+			}
+		}
+
+		producto := "producto_id=" + strconv.Itoa(p.ID)
+		futuro := time.Now().UTC().AddDate(0, 0, 2).Format("2006-01-02")
+		casos := []struct {
+			query string
+			total int
+		}{
+			// Sin limit ni cursor se devuelve todo el historial
+			{producto, 4},
+			{"producto_id=" + strconv.Itoa(otro.ID), 1},
+			{producto + "&tipo=entrada", 1},
+			{producto + "&tipo=ajuste", 2},
+			{producto + "&almacen_id=" + strconv.Itoa(a.ID), 1},
+			{producto + "&hasta=" + futuro, 4},
+			{producto + "&desde=" + futuro, 0},
+		}
+		for _, c := range casos {
+			rec, ids, next := listarMovimientos(t, h, c.query)
+			if rec.Code != http.StatusOK {
+				t.Errorf("%q: código %d: %s", c.query, rec.Code, rec.Body)
+				continue
+			}
+			if len(ids) != c.total || next != "" {
+				t.Errorf("%q: %d movimientos con cursor %q, se esperaban %d sin cursor", c.query, len(ids), next, c.total)
+			}
+		}
+
+		// Las páginas no se mueven aunque lleguen movimientos nuevos
+		_, primera, next := listarMovimientos(t, h, producto+"&limit=3")
+		if len(primera) != 3 || next == "" {
+			t.Fatalf("primera página %v con cursor %q, se esperaban 3 y cursor", primera, next)
+		}
+		if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: p.ID, Tipo: models.TipoEntrada, Cantidad: 1,
+		}); err != nil {
+			t.Fatalf("error al registrar la entrada: %v", err)
+		}
+		_, segunda, next := listarMovimientos(t, h, producto+"&cursor="+next)
+		if len(segunda) != 1 || next != "" {
+			t.Errorf("segunda página %v con cursor %q, se esperaba solo el movimiento más antiguo", segunda, next)
+		}
+		vistos := make(map[int]bool)
+		for _, id := range append(primera, segunda...) {
+			if vistos[id] {
+				t.Errorf("el movimiento %d aparece en dos páginas", id)
+			}
+			vistos[id] = true
+		}
+
+		for _, c := range []struct{ query, campo string }{
+			{"cursor=xyz", "cursor"},
+			{"cursor=" + base64.RawURLEncoding.EncodeToString([]byte("ayer|1")), "cursor"},
+			{"limit=0", "limit"},
+			{"limit=501", "limit"},
+			{"tipo=traslado", "tipo"},
+			{"almacen_id=principal", "almacen_id"},
+			{"desde=ayer", "desde"},
+		} {
+			rec, _, _ := listarMovimientos(t, h, c.query)
+			var body ErrorResponse
+			json.NewDecoder(rec.Body).Decode(&body)
+			if rec.Code != http.StatusBadRequest || len(body.Details) != 1 || body.Details[0].Field != c.campo {
+				t.Errorf("%q: código %d con %+v, se esperaba 400 en %s", c.query, rec.Code, body.Details, c.campo)
+			}
+		}
+	})
+}
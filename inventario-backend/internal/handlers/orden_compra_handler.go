@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+const ordenCompraNoEncontrada = "Orden de compra no encontrada"
+
+type OrdenCompraHandler struct {
+	repo    repository.OrdenCompraRepository
+	alertas Notificador
+}
+
+// NewOrdenCompraHandler crea el handler; alertas recibe los productos de cada
+// recepción y puede ser nil
+func NewOrdenCompraHandler(repo repository.OrdenCompraRepository, alertas Notificador) *OrdenCompraHandler {
+	return &OrdenCompraHandler{repo: repo, alertas: alertas}
+}
+
+// validarOrdenCompraRequest verifica los campos comunes a la creación y actualización
+func validarOrdenCompraRequest(req models.OrdenCompraRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if req.ProveedorID <= 0 {
+		details = append(details, ErrorDetail{Field: "proveedor_id", Message: "El proveedor es requerido"})
+	}
+	if req.AlmacenID < 0 {
+		details = append(details, ErrorDetail{Field: "almacen_id", Message: "El almacén no es válido"})
+	}
+	if len(req.Lineas) == 0 {
+		details = append(details, ErrorDetail{Field: "lineas", Message: "Debe incluir al menos un producto"})
+	}
+	vistos := make(map[int]bool, len(req.Lineas))
+	for i, l := range req.Lineas {
+		if l.ProductoID <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].producto_id", i), Message: "El producto es requerido"})
+		} else if vistos[l.ProductoID] {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].producto_id", i), Message: "El producto ya está en otra línea"})
+		}
+		vistos[l.ProductoID] = true
+		if l.Cantidad <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].cantidad", i), Message: "La cantidad debe ser mayor a 0"})
+		}
+		if l.CostoUnitario < 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].costo_unitario", i), Message: "El costo no puede ser negativo"})
+		}
+	}
+	return details
+}
+
+// validarRecepcionRequest verifica las cantidades recibidas y normaliza los
+// lotes y las series
+func validarRecepcionRequest(req *models.RecepcionRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if len(req.Items) == 0 {
+		details = append(details, ErrorDetail{Field: "items", Message: "Debe incluir al menos un producto"})
+	}
+	vistos := make(map[int]bool, len(req.Items))
+	for i := range req.Items {
+		req.Items[i].Lote = strings.TrimSpace(req.Items[i].Lote)
+		item := req.Items[i]
+		if item.ProductoID <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].producto_id", i), Message: "El producto es requerido"})
+		} else if vistos[item.ProductoID] {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].producto_id", i), Message: "El producto ya está en otro item"})
+		}
+		vistos[item.ProductoID] = true
+		if item.Cantidad <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].cantidad", i), Message: "La cantidad debe ser mayor a 0"})
+		}
+		if utf8.RuneCountInString(item.Lote) > 100 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].lote", i), Message: "El lote no puede superar los 100 caracteres"})
+		}
+		if item.Vencimiento != nil && item.Lote == "" {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].vencimiento", i), Message: "El vencimiento requiere el lote"})
+		}
+		details = append(details, validarSeries(item.Series, fmt.Sprintf("items[%d].series", i))...)
+	}
+	return details
+}
+
+// GetOrdenesCompra lista las órdenes de la más reciente a la más antigua, sin
+// sus líneas. Acepta los filtros estado y proveedor_id.
+func (h *OrdenCompraHandler) GetOrdenesCompra(w http.ResponseWriter, r *http.Request) {
+	var filtro repository.OrdenCompraFiltro
+	var err error
+	q := r.URL.Query()
+
+	filtro.Estado = models.EstadoOrdenCompra(q.Get("estado"))
+	switch filtro.Estado {
+	case "", models.EstadoOrdenBorrador, models.EstadoOrdenEnviada, models.EstadoOrdenRecibidaParcial,
+		models.EstadoOrdenRecibida, models.EstadoOrdenCancelada:
+	default:
+		respondBadRequest(w, r, &fieldError{Field: "estado", Message: "Debe ser 'borrador', 'enviada', 'recibida_parcial', 'recibida' o 'cancelada'"})
+		return
+	}
+	if filtro.ProveedorID, err = queryInt(q, "proveedor_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+
+	ordenes, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, ordenCompraNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ordenes)
+}
+
+// GetOrdenCompra devuelve la orden con sus líneas y lo pendiente de cada una
+func (h *OrdenCompraHandler) GetOrdenCompra(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	o, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, ordenCompraNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+// CreateOrdenCompra crea la orden en borrador
+func (h *OrdenCompraHandler) CreateOrdenCompra(w http.ResponseWriter, r *http.Request) {
+	var req models.OrdenCompraRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarOrdenCompraRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	o, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, ordenCompraNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, o)
+}
+
+// UpdateOrdenCompra reemplaza los datos y las líneas de una orden en borrador
+func (h *OrdenCompraHandler) UpdateOrdenCompra(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.OrdenCompraRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarOrdenCompraRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	o, err := h.repo.Update(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, ordenCompraNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+// EnviarOrdenCompra pasa la orden de borrador a enviada; desde entonces
+// admite recepciones y ya no se puede editar
+func (h *OrdenCompraHandler) EnviarOrdenCompra(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	o, err := h.repo.Enviar(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, ordenCompraNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+// RecibirOrdenCompra registra una entrada por cada producto recibido, al
+// costo de su línea. Recibir más de lo pendiente requiere permitir_exceso.
+func (h *OrdenCompraHandler) RecibirOrdenCompra(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.RecepcionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarRecepcionRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	o, err := h.repo.Recibir(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, ordenCompraNoEncontrada)
+		return
+	}
+
+	if h.alertas != nil {
+		for _, item := range req.Items {
+			h.alertas.Notificar(item.ProductoID)
+		}
+	}
+	respondJSON(w, http.StatusOK, o)
+}
+
+// CancelarOrdenCompra cierra la orden; lo ya recibido permanece en stock
+func (h *OrdenCompraHandler) CancelarOrdenCompra(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	o, err := h.repo.Cancelar(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, ordenCompraNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
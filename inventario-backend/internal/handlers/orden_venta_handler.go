@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const ordenVentaNoEncontrada = "Orden de venta no encontrada"
+
+type OrdenVentaHandler struct {
+	repo    repository.OrdenVentaRepository
+	alertas Notificador
+}
+
+// NewOrdenVentaHandler crea el handler; alertas recibe los productos de cada
+// orden despachada y puede ser nil
+func NewOrdenVentaHandler(repo repository.OrdenVentaRepository, alertas Notificador) *OrdenVentaHandler {
+	return &OrdenVentaHandler{repo: repo, alertas: alertas}
+}
+
+// validarOrdenVentaRequest verifica los campos comunes a la creación y
+// actualización
+func validarOrdenVentaRequest(req *models.OrdenVentaRequest) []ErrorDetail {
+	var details []ErrorDetail
+	req.Cliente = strings.TrimSpace(req.Cliente)
+	if req.Cliente == "" {
+		details = append(details, ErrorDetail{Field: "cliente", Message: "El cliente es requerido"})
+	}
+	if len(req.Cliente) > 255 {
+		details = append(details, ErrorDetail{Field: "cliente", Message: "El cliente no puede superar 255 caracteres"})
+	}
+	if req.AlmacenID < 0 {
+		details = append(details, ErrorDetail{Field: "almacen_id", Message: "El almacén no es válido"})
+	}
+	if len(req.Lineas) == 0 {
+		details = append(details, ErrorDetail{Field: "lineas", Message: "Debe incluir al menos un producto"})
+	}
+	vistos := make(map[int]bool, len(req.Lineas))
+	for i, l := range req.Lineas {
+		if l.ProductoID <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].producto_id", i), Message: "El producto es requerido"})
+		} else if vistos[l.ProductoID] {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].producto_id", i), Message: "El producto ya está en otra línea"})
+		}
+		vistos[l.ProductoID] = true
+		if l.Cantidad <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].cantidad", i), Message: "La cantidad debe ser mayor a 0"})
+		}
+		if l.PrecioUnitario != nil && *l.PrecioUnitario < 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("lineas[%d].precio_unitario", i), Message: "El precio no puede ser negativo"})
+		}
+	}
+	return details
+}
+
+// GetOrdenesVenta lista las órdenes de la más reciente a la más antigua, sin
+// sus líneas. Acepta los filtros estado y cliente.
+func (h *OrdenVentaHandler) GetOrdenesVenta(w http.ResponseWriter, r *http.Request) {
+	var filtro repository.OrdenVentaFiltro
+	q := r.URL.Query()
+
+	filtro.Estado = models.EstadoOrdenVenta(q.Get("estado"))
+	switch filtro.Estado {
+	case "", models.EstadoVentaBorrador, models.EstadoVentaConfirmada, models.EstadoVentaDespachada, models.EstadoVentaCancelada:
+	default:
+		respondBadRequest(w, r, &fieldError{Field: "estado", Message: "Debe ser 'borrador', 'confirmada', 'despachada' o 'cancelada'"})
+		return
+	}
+	filtro.Cliente = strings.TrimSpace(q.Get("cliente"))
+
+	ordenes, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, ordenVentaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ordenes)
+}
+
+func (h *OrdenVentaHandler) GetOrdenVenta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	o, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, ordenVentaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+// CreateOrdenVenta crea la orden en borrador; todavía no reserva stock
+func (h *OrdenVentaHandler) CreateOrdenVenta(w http.ResponseWriter, r *http.Request) {
+	var req models.OrdenVentaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarOrdenVentaRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	o, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, ordenVentaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, o)
+}
+
+// UpdateOrdenVenta reemplaza los datos y las líneas de una orden en borrador
+func (h *OrdenVentaHandler) UpdateOrdenVenta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.OrdenVentaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarOrdenVentaRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	o, err := h.repo.Update(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, ordenVentaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+// ConfirmarOrdenVenta reserva el stock de la orden; falla si alguna línea
+// supera lo disponible en su almacén
+func (h *OrdenVentaHandler) ConfirmarOrdenVenta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	o, err := h.repo.Confirmar(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, ordenVentaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
+
+// validarDespachoRequest verifica los items del despacho y normaliza sus
+// series
+func validarDespachoRequest(req *models.DespachoRequest) []ErrorDetail {
+	var details []ErrorDetail
+	for i, item := range req.Items {
+		if item.ProductoID <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("items[%d].producto_id", i), Message: "El producto es requerido"})
+		}
+		details = append(details, validarSeries(item.Series, fmt.Sprintf("items[%d].series", i))...)
+	}
+	return details
+}
+
+// DespacharOrdenVenta convierte la reserva de una orden confirmada en una
+// salida por línea. El cuerpo es opcional y solo se necesita para indicar las
+// series de los productos con control de series.
+func (h *OrdenVentaHandler) DespacharOrdenVenta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.DespachoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondInvalidJSON(w, r)
+		return
+	}
+	if details := validarDespachoRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	o, err := h.repo.Despachar(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, ordenVentaNoEncontrada)
+		return
+	}
+
+	if h.alertas != nil {
+		for _, l := range o.Lineas {
+			h.alertas.Notificar(l.ProductoID)
+		}
+	}
+	respondJSON(w, http.StatusOK, o)
+}
+
+// CancelarOrdenVenta cierra una orden en borrador o confirmada y libera su
+// reserva
+func (h *OrdenVentaHandler) CancelarOrdenVenta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	o, err := h.repo.Cancelar(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, ordenVentaNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, o)
+}
@@ -2,281 +2,465 @@ package handlers
 
 import (
 	"encoding/json"
-	"inventario-backend/internal/database"
+	"fmt"
 	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 )
 
-func GetProductos(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query(`
-		SELECT p.id, p.nombre, p.descripcion, p.precio, p.stock, p.categoria_id, 
-		       p.created_at, p.updated_at,
-		       c.id, c.nombre, c.descripcion
-		FROM productos p
-		LEFT JOIN categorias c ON p.categoria_id = c.id
-		ORDER BY p.nombre
-	`)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+const productoNoEncontrado = "Producto no encontrado"
+
+type ProductoHandler struct {
+	repo repository.ProductoRepository
+}
+
+func NewProductoHandler(repo repository.ProductoRepository) *ProductoHandler {
+	return &ProductoHandler{repo: repo}
+}
+
+// validarProductoRequest verifica los campos comunes a la creación y
+// actualización y normaliza el SKU y el código de barras. El stock solo se
+// valida al crear, porque la actualización lo ignora.
+func validarProductoRequest(req *models.ProductoRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if req.SKU != nil {
+		*req.SKU = strings.TrimSpace(*req.SKU)
+	}
+	if req.CodigoBarras != nil {
+		*req.CodigoBarras = strings.TrimSpace(*req.CodigoBarras)
 	}
-	defer rows.Close()
 
-	var productos []models.Producto
-	for rows.Next() {
-		var p models.Producto
-		var c models.Categoria
-		err := rows.Scan(&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock, &p.CategoriaID,
-			&p.CreatedAt, &p.UpdatedAt,
-			&c.ID, &c.Nombre, &c.Descripcion)
+	if req.Nombre == "" {
+		details = append(details, ErrorDetail{Field: "nombre", Message: "El nombre es requerido"})
+	}
+	if req.Precio < 0 {
+		details = append(details, ErrorDetail{Field: "precio", Message: "El precio no puede ser negativo"})
+	}
+	if req.CostoUnitario != nil && *req.CostoUnitario < 0 {
+		details = append(details, ErrorDetail{Field: "costo_unitario", Message: "El costo no puede ser negativo"})
+	}
+	if req.StockMinimo < 0 {
+		details = append(details, ErrorDetail{Field: "stock_minimo", Message: "El stock mínimo no puede ser negativo"})
+	}
+	if req.PuntoReorden < 0 {
+		details = append(details, ErrorDetail{Field: "punto_reorden", Message: "El punto de reorden no puede ser negativo"})
+	} else if req.PuntoReorden > 0 && req.PuntoReorden < req.StockMinimo {
+		details = append(details, ErrorDetail{Field: "punto_reorden", Message: "El punto de reorden no puede ser menor que el stock mínimo"})
+	}
+	if req.CantidadReorden < 0 {
+		details = append(details, ErrorDetail{Field: "cantidad_reorden", Message: "La cantidad de reorden no puede ser negativa"})
+	}
+	switch req.MetodoCosteo {
+	case "", models.MetodoPromedio, models.MetodoFIFO:
+	default:
+		details = append(details, ErrorDetail{Field: "metodo_costeo", Message: "Debe ser 'promedio' o 'fifo'"})
+	}
+	if req.ControlaLotes != nil && *req.ControlaLotes && req.ControlaSeries != nil && *req.ControlaSeries {
+		details = append(details, ErrorDetail{Field: "controla_series", Message: "Un producto no puede controlar lotes y series a la vez"})
+	}
+	if req.SKU != nil && len(*req.SKU) > 64 {
+		details = append(details, ErrorDetail{Field: "sku", Message: "El SKU no puede superar los 64 caracteres"})
+	}
+	if req.CodigoBarras != nil && *req.CodigoBarras != "" {
+		codigo, err := models.NormalizarCodigoBarras(*req.CodigoBarras)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			details = append(details, ErrorDetail{Field: "codigo_barras", Message: capitalizar(err.Error())})
+		}
+		*req.CodigoBarras = codigo
+	}
+	return details
+}
+
+// validarVarianteRequest verifica los atributos y los campos propios de la
+// variante y normaliza los atributos, el SKU y el código de barras. Las claves
+// de los atributos se guardan en minúsculas para que "Talla" y "talla" no
+// den variantes distintas.
+func validarVarianteRequest(req *models.VarianteRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if len(req.Atributos) == 0 {
+		details = append(details, ErrorDetail{Field: "atributos", Message: "La variante requiere al menos un atributo"})
+	}
+	atributos := make(map[string]string, len(req.Atributos))
+	for k, v := range req.Atributos {
+		clave := strings.ToLower(strings.TrimSpace(k))
+		valor := strings.TrimSpace(v)
+		field := "atributos." + clave
+		switch {
+		case clave == "":
+			details = append(details, ErrorDetail{Field: "atributos", Message: "El nombre del atributo no puede estar vacío"})
+		case utf8.RuneCountInString(clave) > 50:
+			details = append(details, ErrorDetail{Field: field, Message: "El nombre del atributo no puede superar los 50 caracteres"})
+		case valor == "":
+			details = append(details, ErrorDetail{Field: field, Message: "El valor del atributo es requerido"})
+		case utf8.RuneCountInString(valor) > 50:
+			details = append(details, ErrorDetail{Field: field, Message: "El valor del atributo no puede superar los 50 caracteres"})
+		}
+		if _, ok := atributos[clave]; ok && clave != "" {
+			details = append(details, ErrorDetail{Field: field, Message: "El atributo está repetido"})
 		}
-		p.Categoria = &c
-		productos = append(productos, p)
+		atributos[clave] = valor
+	}
+	req.Atributos = atributos
+
+	// El resto se valida como el de cualquier producto, que normaliza el SKU
+	// y el código de barras de req; el nombre lo arma el repositorio a partir
+	// del del padre
+	base := models.ProductoRequest{
+		Nombre:          "-",
+		SKU:             &req.SKU,
+		CodigoBarras:    &req.CodigoBarras,
+		CostoUnitario:   req.CostoUnitario,
+		StockMinimo:     req.StockMinimo,
+		PuntoReorden:    req.PuntoReorden,
+		CantidadReorden: req.CantidadReorden,
+	}
+	if req.Precio != nil {
+		base.Precio = *req.Precio
 	}
+	details = append(details, validarStockInicial(req.Stock)...)
+	return append(details, validarProductoRequest(&base)...)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(productos)
+// validarStockInicial verifica el stock con que se crea un producto
+func validarStockInicial(stock int) []ErrorDetail {
+	if stock < 0 {
+		return []ErrorDetail{{Field: "stock", Message: "El stock no puede ser negativo"}}
+	}
+	return nil
 }
 
-func GetProducto(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+func validarComponentesRequest(req *models.ComponentesRequest) []ErrorDetail {
+	var details []ErrorDetail
+	vistos := make(map[int]bool, len(req.Componentes))
+	for i, c := range req.Componentes {
+		if c.ProductoID <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("componentes[%d].producto_id", i), Message: "El producto es requerido"})
+		} else if vistos[c.ProductoID] {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("componentes[%d].producto_id", i), Message: "El producto ya está en otro componente"})
+		}
+		vistos[c.ProductoID] = true
+		if c.Cantidad <= 0 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("componentes[%d].cantidad", i), Message: "La cantidad debe ser mayor a 0"})
+		}
+	}
+	return details
+}
+
+// parseProductoFiltro construye el filtro del listado a partir de la query string
+func parseProductoFiltro(q url.Values) (repository.ProductoFiltro, error) {
+	var f repository.ProductoFiltro
+	var err error
+
+	if f.CategoriaID, err = queryInt(q, "categoria_id"); err != nil {
+		return f, err
+	}
+	if f.MinPrecio, err = queryFloat(q, "min_precio"); err != nil {
+		return f, err
+	}
+	if f.MaxPrecio, err = queryFloat(q, "max_precio"); err != nil {
+		return f, err
+	}
+	if f.MinStock, err = queryInt(q, "min_stock"); err != nil {
+		return f, err
+	}
+	if f.MaxStock, err = queryInt(q, "max_stock"); err != nil {
+		return f, err
+	}
+	f.Q = strings.TrimSpace(q.Get("q"))
+
+	f.Sort = q.Get("sort")
+	if f.Sort == "" {
+		f.Sort = "nombre"
+	}
+	valid := false
+	for _, campo := range repository.ProductoSortFields {
+		valid = valid || f.Sort == campo
+	}
+	if !valid {
+		return f, &fieldError{Field: "sort", Message: "Debe ser uno de: " + strings.Join(repository.ProductoSortFields, ", ")}
+	}
+	if f.Desc, err = queryOrder(q); err != nil {
+		return f, err
+	}
+
+	f.Page, f.Limit, err = queryPagination(q)
+	return f, err
+}
+
+// listProductos responde con la página solicitada y el total en X-Total-Count
+func (h *ProductoHandler) listProductos(w http.ResponseWriter, r *http.Request, filtro repository.ProductoFiltro) {
+	productos, total, err := h.repo.List(r.Context(), filtro)
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	var p models.Producto
-	var c models.Categoria
-	err = database.DB.QueryRow(`
-		SELECT p.id, p.nombre, p.descripcion, p.precio, p.stock, p.categoria_id, 
-		       p.created_at, p.updated_at,
-		       c.id, c.nombre, c.descripcion
-		FROM productos p
-		LEFT JOIN categorias c ON p.categoria_id = c.id
-		WHERE p.id = $1
-	`, id).Scan(&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock, &p.CategoriaID,
-		&p.CreatedAt, &p.UpdatedAt,
-		&c.ID, &c.Nombre, &c.Descripcion)
+	setTotalCount(w, total)
+	respondJSON(w, http.StatusOK, productos)
+}
 
+// GetProductos lista los productos. Acepta los filtros categoria_id,
+// min_precio, max_precio, min_stock, max_stock y q; el orden con sort y
+// order; y la paginación con page y limit.
+func (h *ProductoHandler) GetProductos(w http.ResponseWriter, r *http.Request) {
+	filtro, err := parseProductoFiltro(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Producto no encontrado", http.StatusNotFound)
+		respondBadRequest(w, r, err)
 		return
 	}
-	p.Categoria = &c
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(p)
+	h.listProductos(w, r, filtro)
 }
 
-func CreateProducto(w http.ResponseWriter, r *http.Request) {
-	var req models.ProductoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func (h *ProductoHandler) GetProducto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
 		return
 	}
 
-	if req.Nombre == "" {
-		http.Error(w, "El nombre es requerido", http.StatusBadRequest)
+	p, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	if req.Precio < 0 {
-		http.Error(w, "El precio no puede ser negativo", http.StatusBadRequest)
+	respondJSON(w, http.StatusOK, p)
+}
+
+// LookupProducto resuelve un producto por su código de barras (?barcode=)
+// o su SKU (?sku=) en una sola llamada, pensado para los lectores de mano
+func (h *ProductoHandler) LookupProducto(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	barcode := strings.TrimSpace(q.Get("barcode"))
+	sku := strings.TrimSpace(q.Get("sku"))
+
+	var p *models.Producto
+	var err error
+	switch {
+	case barcode != "":
+		codigo, errCodigo := models.NormalizarCodigoBarras(barcode)
+		if errCodigo != nil {
+			respondValidation(w, r, []ErrorDetail{{Field: "barcode", Message: capitalizar(errCodigo.Error())}})
+			return
+		}
+		p, err = h.repo.GetByCodigoBarras(r.Context(), codigo)
+	case sku != "":
+		p, err = h.repo.GetBySKU(r.Context(), sku)
+	default:
+		respondValidation(w, r, []ErrorDetail{{Field: "barcode", Message: "Indica 'barcode' o 'sku'"}})
 		return
 	}
-
-	if req.Stock < 0 {
-		http.Error(w, "El stock no puede ser negativo", http.StatusBadRequest)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	// Verificar que la categoría existe
-	var categoriaExists bool
-	err := database.DB.QueryRow(`
-		SELECT EXISTS(SELECT 1 FROM categorias WHERE id = $1)
-	`, req.CategoriaID).Scan(&categoriaExists)
+	respondJSON(w, http.StatusOK, p)
+}
 
-	if err != nil || !categoriaExists {
-		http.Error(w, "La categoría especificada no existe", http.StatusBadRequest)
+func (h *ProductoHandler) CreateProducto(w http.ResponseWriter, r *http.Request) {
+	var req models.ProductoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
 		return
 	}
 
-	var id int
-	err = database.DB.QueryRow(`
-		INSERT INTO productos (nombre, descripcion, precio, stock, categoria_id) 
-		VALUES ($1, $2, $3, $4, $5) 
-		RETURNING id
-	`, req.Nombre, req.Descripcion, req.Precio, req.Stock, req.CategoriaID).Scan(&id)
+	details := validarProductoRequest(&req)
+	if details = append(details, validarStockInicial(req.Stock)...); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
 
+	p, err := h.repo.Create(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	var p models.Producto
-	var c models.Categoria
-	database.DB.QueryRow(`
-		SELECT p.id, p.nombre, p.descripcion, p.precio, p.stock, p.categoria_id, 
-		       p.created_at, p.updated_at,
-		       c.id, c.nombre, c.descripcion
-		FROM productos p
-		LEFT JOIN categorias c ON p.categoria_id = c.id
-		WHERE p.id = $1
-	`, id).Scan(&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock, &p.CategoriaID,
-		&p.CreatedAt, &p.UpdatedAt,
-		&c.ID, &c.Nombre, &c.Descripcion)
-	p.Categoria = &c
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(p)
+	respondJSON(w, http.StatusCreated, p)
 }
 
-func UpdateProducto(w http.ResponseWriter, r *http.Request) {
+func (h *ProductoHandler) UpdateProducto(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		respondInvalidID(w, r, "id")
 		return
 	}
 
 	var req models.ProductoRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		respondInvalidJSON(w, r)
 		return
 	}
 
-	if req.Nombre == "" {
-		http.Error(w, "El nombre es requerido", http.StatusBadRequest)
+	if details := validarProductoRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
 		return
 	}
 
-	if req.Precio < 0 {
-		http.Error(w, "El precio no puede ser negativo", http.StatusBadRequest)
+	p, err := h.repo.Update(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	if req.Stock < 0 {
-		http.Error(w, "El stock no puede ser negativo", http.StatusBadRequest)
+	respondJSON(w, http.StatusOK, p)
+}
+
+func (h *ProductoHandler) DeleteProducto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
 		return
 	}
 
-	// Verificar que la categoría existe
-	var categoriaExists bool
-	err = database.DB.QueryRow(`
-		SELECT EXISTS(SELECT 1 FROM categorias WHERE id = $1)
-	`, req.CategoriaID).Scan(&categoriaExists)
-
-	if err != nil || !categoriaExists {
-		http.Error(w, "La categoría especificada no existe", http.StatusBadRequest)
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	result, err := database.DB.Exec(`
-		UPDATE productos 
-		SET nombre = $1, descripcion = $2, precio = $3, stock = $4, 
-		    categoria_id = $5, updated_at = NOW() 
-		WHERE id = $6
-	`, req.Nombre, req.Descripcion, req.Precio, req.Stock, req.CategoriaID, id)
+	w.WriteHeader(http.StatusNoContent)
+}
 
+// GetVariantes lista las variantes del producto ordenadas por nombre
+func (h *ProductoHandler) GetVariantes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondInvalidID(w, r, "id")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Producto no encontrado", http.StatusNotFound)
+	variantes, err := h.repo.Variantes(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	var p models.Producto
-	var c models.Categoria
-	database.DB.QueryRow(`
-		SELECT p.id, p.nombre, p.descripcion, p.precio, p.stock, p.categoria_id, 
-		       p.created_at, p.updated_at,
-		       c.id, c.nombre, c.descripcion
-		FROM productos p
-		LEFT JOIN categorias c ON p.categoria_id = c.id
-		WHERE p.id = $1
-	`, id).Scan(&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock, &p.CategoriaID,
-		&p.CreatedAt, &p.UpdatedAt,
-		&c.ID, &c.Nombre, &c.Descripcion)
-	p.Categoria = &c
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(p)
+	respondJSON(w, http.StatusOK, variantes)
 }
 
-func DeleteProducto(w http.ResponseWriter, r *http.Request) {
+// CreateVariante crea una variante del producto con los atributos indicados.
+// Sin precio, la variante hereda el del padre.
+func (h *ProductoHandler) CreateVariante(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.VarianteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarVarianteRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
 		return
 	}
 
-	result, err := database.DB.Exec("DELETE FROM productos WHERE id = $1", id)
+	p, err := h.repo.CreateVariante(r.Context(), id, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondRepoError(w, r, err, productoNoEncontrado)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		http.Error(w, "Producto no encontrado", http.StatusNotFound)
+	respondJSON(w, http.StatusCreated, p)
+}
+
+// SetComponentes reemplaza la lista de materiales del producto, que pasa a
+// ser un kit. Una lista vacía lo vuelve un producto común.
+func (h *ProductoHandler) SetComponentes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	var req models.ComponentesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarComponentesRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	p, err := h.repo.SetComponentes(r.Context(), id, req.Componentes)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
 }
 
-func GetProductosByCategoria(w http.ResponseWriter, r *http.Request) {
+// GetProductosByCategoria equivale a GET /productos?categoria_id={categoria_id};
+// se mantiene por compatibilidad.
+func (h *ProductoHandler) GetProductosByCategoria(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	categoriaID, err := strconv.Atoi(vars["categoria_id"])
 	if err != nil {
-		http.Error(w, "ID de categoría inválido", http.StatusBadRequest)
+		respondInvalidID(w, r, "categoria_id")
 		return
 	}
 
-	rows, err := database.DB.Query(`
-		SELECT p.id, p.nombre, p.descripcion, p.precio, p.stock, p.categoria_id, 
-		       p.created_at, p.updated_at,
-		       c.id, c.nombre, c.descripcion
-		FROM productos p
-		LEFT JOIN categorias c ON p.categoria_id = c.id
-		WHERE p.categoria_id = $1
-		ORDER BY p.nombre
-	`, categoriaID)
+	filtro, err := parseProductoFiltro(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondBadRequest(w, r, err)
 		return
 	}
-	defer rows.Close()
+	filtro.CategoriaID = &categoriaID
 
-	var productos []models.Producto
-	for rows.Next() {
-		var p models.Producto
-		var c models.Categoria
-		err := rows.Scan(&p.ID, &p.Nombre, &p.Descripcion, &p.Precio, &p.Stock, &p.CategoriaID,
-			&p.CreatedAt, &p.UpdatedAt,
-			&c.ID, &c.Nombre, &c.Descripcion)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		p.Categoria = &c
-		productos = append(productos, p)
+	h.listProductos(w, r, filtro)
+}
+
+// GetValoracion devuelve el valor del inventario por categoría y producto.
+// Acepta categoria_id para valorar una sola categoría.
+func (h *ProductoHandler) GetValoracion(w http.ResponseWriter, r *http.Request) {
+	categoriaID, err := queryInt(r.URL.Query(), "categoria_id")
+	if err != nil {
+		respondBadRequest(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(productos)
+	valoracion, err := h.repo.Valoracion(r.Context(), categoriaID)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, valoracion)
 }
 
+// GetProductosBajoStock lista los productos que cruzaron su punto de reorden
+// o su stock mínimo. Acepta los mismos filtros que GET /productos y, sin
+// sort, ordena por stock.
+func (h *ProductoHandler) GetProductosBajoStock(w http.ResponseWriter, r *http.Request) {
+	filtro, err := parseProductoFiltro(r.URL.Query())
+	if err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	if r.URL.Query().Get("sort") == "" {
+		filtro.Sort = "stock"
+	}
+	filtro.BajoStock = true
+
+	h.listProductos(w, r, filtro)
+}
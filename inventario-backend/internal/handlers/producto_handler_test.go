@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"inventario-backend/internal/repository/memory"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// crearCategoriaPrueba crea una categoría con los productos indicados y los
+// elimina al terminar la prueba.
+func crearCategoriaPrueba(t *testing.T, repos repository.Repositories, productos []models.ProductoRequest) (*models.Categoria, []models.Producto) {
+	t.Helper()
+	ctx := context.Background()
+
+	c, err := repos.Categorias.Create(ctx, models.CategoriaRequest{
+		Nombre: fmt.Sprintf("Prueba %s %d", t.Name(), time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatalf("error al crear la categoría: %v", err)
+	}
+	t.Cleanup(func() { repos.Categorias.Delete(ctx, c.ID) })
+
+	creados := make([]models.Producto, len(productos))
+	for i, req := range productos {
+		req.CategoriaID = c.ID
+		p, err := repos.Productos.Create(ctx, req)
+		if err != nil {
+			t.Fatalf("error al crear el producto %d: %v", i, err)
+		}
+		t.Cleanup(func() { repos.Productos.Delete(ctx, p.ID) })
+		creados[i] = *p
+	}
+	return c, creados
+}
+
+// listarProductos llama a GET /api/productos con la query indicada
+func listarProductos(t *testing.T, h *ProductoHandler, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/productos?"+query, nil)
+	rec := httptest.NewRecorder()
+	h.GetProductos(rec, req)
+	return rec
+}
+
+func TestGetProductosFiltrosOrdenYPaginacion(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		h := NewProductoHandler(repos.Productos)
+		cat, _ := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+			{Nombre: "Prueba Alfa", Precio: 5, Stock: 1},
+			{Nombre: "Prueba Beta", Precio: 3, Stock: 20},
+			{Nombre: "Prueba Gamma", Precio: 1, Stock: 7, Descripcion: "con lupa"},
+			{Nombre: "Prueba Delta", Precio: 4, Stock: 0},
+			{Nombre: "Prueba Epsilon", Precio: 2, Stock: 12},
+		})
+		categoria := "categoria_id=" + strconv.Itoa(cat.ID)
+
+		casos := []struct {
+			query   string
+			nombres []string
+			total   int
+		}{
+			// Sin page ni limit se devuelven todos
+			{"", []string{"Prueba Alfa", "Prueba Beta", "Prueba Delta", "Prueba Epsilon", "Prueba Gamma"}, 5},
+			{"sort=precio&order=desc&limit=2", []string{"Prueba Alfa", "Prueba Delta"}, 5},
+			{"sort=precio&order=desc&limit=2&page=2", []string{"Prueba Beta", "Prueba Epsilon"}, 5},
+			{"sort=precio&order=desc&limit=2&page=4", []string{}, 5},
+			{"sort=stock&min_stock=7&max_stock=12", []string{"Prueba Gamma", "Prueba Epsilon"}, 2},
+			{"sort=precio&min_precio=2&max_precio=4", []string{"Prueba Epsilon", "Prueba Beta", "Prueba Delta"}, 3},
+			{"q=LUPA", []string{"Prueba Gamma"}, 1},
+			{"q=beta&page=1", []string{"Prueba Beta"}, 1},
+		}
+		for _, c := range casos {
+			rec := listarProductos(t, h, categoria+"&"+c.query)
+			if rec.Code != http.StatusOK {
+				t.Errorf("%q: código %d: %s", c.query, rec.Code, rec.Body)
+				continue
+			}
+			var productos []models.Producto
+			if err := json.NewDecoder(rec.Body).Decode(&productos); err != nil {
+				t.Fatalf("%q: respuesta inválida: %v", c.query, err)
+			}
+			nombres := []string{}
+			for _, p := range productos {
+				nombres = append(nombres, p.Nombre)
+			}
+			if fmt.Sprint(nombres) != fmt.Sprint(c.nombres) {
+				t.Errorf("%q: productos %v, se esperaba %v", c.query, nombres, c.nombres)
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != strconv.Itoa(c.total) {
+				t.Errorf("%q: X-Total-Count = %q, se esperaba %d", c.query, got, c.total)
+			}
+		}
+	})
+}
+
+func TestGetProductosParametrosInvalidos(t *testing.T) {
+	h := NewProductoHandler(memory.NewRepositories().Productos)
+	casos := []struct {
+		query string
+		campo string
+	}{
+		{"sort=sku", "sort"},
+		{"order=arriba", "order"},
+		{"limit=0", "limit"},
+		{"limit=501", "limit"},
+		{"limit=diez", "limit"},
+		{"page=0", "page"},
+		{"page=0&limit=10", "page"},
+		{"min_precio=barato", "min_precio"},
+		{"max_stock=1.5", "max_stock"},
+		{"categoria_id=x", "categoria_id"},
+	}
+	for _, c := range casos {
+		rec := listarProductos(t, h, c.query)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: código %d, se esperaba 400", c.query, rec.Code)
+			continue
+		}
+		var body struct {
+			Code    string        `json:"code"`
+			Details []ErrorDetail `json:"details"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("%q: respuesta inválida: %v", c.query, err)
+		}
+		if body.Code != CodeValidacion || len(body.Details) != 1 || body.Details[0].Field != c.campo {
+			t.Errorf("%q: respuesta %+v, se esperaba un error de validación en %s", c.query, body, c.campo)
+		}
+	}
+
+	// En los límites el tamaño de página es válido
+	for _, query := range []string{"limit=1", "limit=500", "page=3"} {
+		if rec := listarProductos(t, h, query); rec.Code != http.StatusOK {
+			t.Errorf("%q: código %d, se esperaba 200", query, rec.Code)
+		}
+	}
+}
+
+// upcPrueba arma un UPC-A válido y distinto en cada llamada
+func upcPrueba() string {
+	digitos := fmt.Sprintf("%011d", time.Now().UnixNano()%1e11)
+	suma := 0
+	for i := 0; i < len(digitos); i++ {
+		d := int(digitos[len(digitos)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		suma += d
+	}
+	return digitos + strconv.Itoa((10-suma%10)%10)
+}
+
+func TestLookupProducto(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		h := NewProductoHandler(repos.Productos)
+		upc := upcPrueba()
+		sku := fmt.Sprintf("PRUEBA-%d", time.Now().UnixNano())
+		// Los handlers guardan los UPC-A en su forma EAN-13
+		ean := "0" + upc
+		_, productos := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+			{Nombre: "Prueba Lookup", Precio: 1, SKU: &sku, CodigoBarras: &ean},
+		})
+		p := productos[0]
+
+		casos := []struct {
+			query  string
+			status int
+			campo  string
+		}{
+			{"barcode=" + upc, http.StatusOK, ""},
+			{"barcode=0" + upc, http.StatusOK, ""},
+			{"sku=" + sku, http.StatusOK, ""},
+			{"sku=" + sku + "-X", http.StatusNotFound, ""},
+			{"barcode=4006381333931", http.StatusNotFound, ""},
+			{"barcode=4006381333932", http.StatusBadRequest, "barcode"},
+			{"barcode=abc", http.StatusBadRequest, "barcode"},
+			{"", http.StatusBadRequest, "barcode"},
+		}
+		for _, c := range casos {
+			req := httptest.NewRequest(http.MethodGet, "/api/productos/lookup?"+c.query, nil)
+			rec := httptest.NewRecorder()
+			h.LookupProducto(rec, req)
+			if rec.Code != c.status {
+				t.Errorf("%q: código %d, se esperaba %d: %s", c.query, rec.Code, c.status, rec.Body)
+				continue
+			}
+			switch rec.Code {
+			case http.StatusOK:
+				var encontrado models.Producto
+				if err := json.NewDecoder(rec.Body).Decode(&encontrado); err != nil || encontrado.ID != p.ID {
+					t.Errorf("%q: se encontró %+v (%v), se esperaba el producto %d", c.query, encontrado, err, p.ID)
+				}
+			case http.StatusBadRequest:
+				var body ErrorResponse
+				json.NewDecoder(rec.Body).Decode(&body)
+				if len(body.Details) != 1 || body.Details[0].Field != c.campo {
+					t.Errorf("%q: detalles %+v, se esperaba el campo %s", c.query, body.Details, c.campo)
+				}
+			}
+		}
+	})
+}
+
+func TestUpdateProductoConservaIdentificadores(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		h := NewProductoHandler(repos.Productos)
+		sku := fmt.Sprintf("PRUEBA-%d", time.Now().UnixNano())
+		ean := "0" + upcPrueba()
+		cat, productos := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+			{Nombre: "Prueba Identificadores", Precio: 1, SKU: &sku, CodigoBarras: &ean},
+		})
+		p := productos[0]
+
+		actualizar := func(campos string) models.Producto {
+			t.Helper()
+			body := fmt.Sprintf(`{"nombre": "Prueba Editada", "precio": 2, "categoria_id": %d%s}`, cat.ID, campos)
+			req := httptest.NewRequest(http.MethodPut, "/api/productos/"+strconv.Itoa(p.ID), bytes.NewReader([]byte(body)))
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(p.ID)})
+			rec := httptest.NewRecorder()
+			h.UpdateProducto(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: código %d: %s", campos, rec.Code, rec.Body)
+			}
+			var editado models.Producto
+			if err := json.NewDecoder(rec.Body).Decode(&editado); err != nil {
+				t.Fatalf("%s: respuesta inválida: %v", campos, err)
+			}
+			return editado
+		}
+
+		// Sin sku ni codigo_barras se conservan los guardados
+		if e := actualizar(""); e.Nombre != "Prueba Editada" || e.SKU != sku || e.CodigoBarras != ean {
+			t.Errorf("producto %+v, se esperaba conservar el SKU %s y el código %s", e, sku, ean)
+		}
+		if e := actualizar(`, "sku": " ` + sku + `-B "`); e.SKU != sku+"-B" || e.CodigoBarras != ean {
+			t.Errorf("producto %+v, se esperaba el SKU %s-B y conservar el código", e, sku)
+		}
+		// Una cadena vacía los quita
+		if e := actualizar(`, "sku": "", "codigo_barras": ""`); e.SKU != "" || e.CodigoBarras != "" {
+			t.Errorf("producto %+v, se esperaba quitar el SKU y el código", e)
+		}
+	})
+}
+
+func TestUpdateProductoIgnoraStock(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		h := NewProductoHandler(repos.Productos)
+		cat, productos := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+			{Nombre: "Prueba Stock Ignorado", Precio: 1, Stock: 4},
+		})
+		p := productos[0]
+
+		// Un stock negativo no se valida porque no se guarda
+		body := fmt.Sprintf(`{"nombre": "Prueba Stock Ignorado", "precio": 1, "stock": -5, "categoria_id": %d}`, cat.ID)
+		req := httptest.NewRequest(http.MethodPut, "/api/productos/"+strconv.Itoa(p.ID), bytes.NewReader([]byte(body)))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(p.ID)})
+		rec := httptest.NewRecorder()
+		h.UpdateProducto(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("actualizar con stock negativo: código %d: %s", rec.Code, rec.Body)
+		}
+		var editado models.Producto
+		if err := json.NewDecoder(rec.Body).Decode(&editado); err != nil {
+			t.Fatalf("respuesta inválida: %v", err)
+		}
+		if editado.Stock != 4 {
+			t.Errorf("stock %d, se esperaba conservar 4", editado.Stock)
+		}
+
+		// Al crear sí se rechaza
+		body = fmt.Sprintf(`{"nombre": "Prueba Stock Negativo", "precio": 1, "stock": -5, "categoria_id": %d}`, cat.ID)
+		rec = httptest.NewRecorder()
+		h.CreateProducto(rec, httptest.NewRequest(http.MethodPost, "/api/productos", bytes.NewReader([]byte(body))))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("crear con stock negativo: código %d, se esperaba 400", rec.Code)
+		}
+	})
+}
+
+func TestVariantesUsanElControlDelPadre(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		si, no := true, false
+		cat, productos := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+			{Nombre: "Prueba Control Padre", Precio: 1, MetodoCosteo: models.MetodoFIFO, ControlaSeries: &si},
+		})
+		padre := productos[0]
+		var variantes []*models.Producto
+		for _, talla := range []string{"S", "M"} {
+			v, err := repos.Productos.CreateVariante(ctx, padre.ID, models.VarianteRequest{Atributos: map[string]string{"talla": talla}})
+			if err != nil {
+				t.Fatalf("error al crear la variante: %v", err)
+			}
+			t.Cleanup(func() { repos.Productos.Delete(ctx, v.ID) })
+			if v.MetodoCosteo != models.MetodoFIFO || v.ControlaLotes || !v.ControlaSeries {
+				t.Errorf("variante %+v, se esperaba el control del padre", v)
+			}
+			variantes = append(variantes, v)
+		}
+
+		// La variante no cambia su control por su cuenta
+		h := NewProductoHandler(repos.Productos)
+		v := variantes[0]
+		body := fmt.Sprintf(`{"nombre": %q, "precio": 1, "categoria_id": %d, "controla_series": false}`, v.Nombre, cat.ID)
+		req := httptest.NewRequest(http.MethodPut, "/api/productos/"+strconv.Itoa(v.ID), bytes.NewReader([]byte(body)))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(v.ID)})
+		rec := httptest.NewRecorder()
+		h.UpdateProducto(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("cambiar el control de una variante: código %d, se esperaba 409", rec.Code)
+		}
+		// Reenviar el del padre sí se admite
+		if _, err := repos.Productos.Update(ctx, v.ID, models.ProductoRequest{
+			Nombre: v.Nombre, Precio: 1, CategoriaID: cat.ID, MetodoCosteo: models.MetodoFIFO, ControlaLotes: &no, ControlaSeries: &si,
+		}); err != nil {
+			t.Errorf("actualizar la variante con el control del padre devolvió %v", err)
+		}
+
+		// El cambio en el padre pasa a todas las variantes
+		cambiar := models.ProductoRequest{
+			Nombre: padre.Nombre, Precio: 1, CategoriaID: cat.ID, MetodoCosteo: models.MetodoPromedio, ControlaLotes: &si, ControlaSeries: &no,
+		}
+		if _, err := repos.Productos.Update(ctx, padre.ID, cambiar); err != nil {
+			t.Fatalf("error al actualizar el padre: %v", err)
+		}
+		for _, v := range variantes {
+			actual, err := repos.Productos.GetByID(ctx, v.ID)
+			if err != nil {
+				t.Fatalf("error al leer la variante: %v", err)
+			}
+			if actual.MetodoCosteo != models.MetodoPromedio || !actual.ControlaLotes || actual.ControlaSeries {
+				t.Errorf("variante %+v, se esperaba el nuevo control del padre", actual)
+			}
+		}
+
+		// Con stock en una variante el padre ya no cambia su control
+		if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: variantes[1].ID, Tipo: models.TipoEntrada, Cantidad: 2, Lote: "L1",
+		}); err != nil {
+			t.Fatalf("error al registrar la entrada: %v", err)
+		}
+		cambiar.ControlaLotes = &no
+		if _, err := repos.Productos.Update(ctx, padre.ID, cambiar); err != repository.ErrLotesConStock {
+			t.Errorf("cambiar el control de un padre con variantes con stock devolvió %v, se esperaba ErrLotesConStock", err)
+		}
+		for _, v := range variantes {
+			if actual, err := repos.Productos.GetByID(ctx, v.ID); err != nil || !actual.ControlaLotes {
+				t.Errorf("variante %+v (%v), se esperaba que siguiera controlando lotes", actual, err)
+			}
+		}
+	})
+}
+
+func TestKitsSinLotesNiSeries(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		si := true
+		cat, productos := crearCategoriaPrueba(t, repos, []models.ProductoRequest{
+			{Nombre: "Prueba Kit", Precio: 10},
+			{Nombre: "Prueba Kit Componente", Precio: 1},
+			{Nombre: "Prueba Kit Lotes", Precio: 1, ControlaLotes: &si},
+			{Nombre: "Prueba Kit Series", Precio: 1, ControlaSeries: &si},
+		})
+		kit, componente, lotes, series := productos[0], productos[1], productos[2], productos[3]
+
+		h := NewProductoHandler(repos.Productos)
+		setComponentes := func(id int, componentes ...int) *httptest.ResponseRecorder {
+			var req models.ComponentesRequest
+			for _, c := range componentes {
+				req.Componentes = append(req.Componentes, models.ComponenteRequest{ProductoID: c, Cantidad: 2})
+			}
+			body, _ := json.Marshal(req)
+			r := httptest.NewRequest(http.MethodPut, "/api/productos/componentes", bytes.NewReader(body))
+			r = mux.SetURLVars(r, map[string]string{"id": strconv.Itoa(id)})
+			rec := httptest.NewRecorder()
+			h.SetComponentes(rec, r)
+			return rec
+		}
+		codigo := func(rec *httptest.ResponseRecorder) string {
+			var resp ErrorResponse
+			json.NewDecoder(rec.Body).Decode(&resp)
+			return resp.Code
+		}
+
+		// Ni los componentes ni el kit pueden controlar lotes o series
+		for _, c := range []models.Producto{lotes, series} {
+			if rec := setComponentes(kit.ID, componente.ID, c.ID); rec.Code != http.StatusConflict || codigo(rec) != CodeControlEnKit {
+				t.Errorf("componente %s: código %d, se esperaba 409 %s", c.Nombre, rec.Code, CodeControlEnKit)
+			}
+			if rec := setComponentes(c.ID, componente.ID); rec.Code != http.StatusConflict || codigo(rec) != CodeControlEnKit {
+				t.Errorf("kit %s: código %d, se esperaba 409 %s", c.Nombre, rec.Code, CodeControlEnKit)
+			}
+		}
+		// Una lista vacía sí se admite
+		if rec := setComponentes(lotes.ID); rec.Code != http.StatusOK {
+			t.Errorf("vaciar la lista de un producto con lotes: código %d: %s", rec.Code, rec.Body)
+		}
+
+		if rec := setComponentes(kit.ID, componente.ID); rec.Code != http.StatusOK {
+			t.Fatalf("error al definir los componentes: %d %s", rec.Code, rec.Body)
+		}
+		// Tampoco se activa el control después
+		for _, p := range []models.Producto{kit, componente} {
+			if _, err := repos.Productos.Update(ctx, p.ID, models.ProductoRequest{
+				Nombre: p.Nombre, Precio: p.Precio, CategoriaID: cat.ID, ControlaLotes: &si,
+			}); err != repository.ErrControlEnKit {
+				t.Errorf("activar lotes en %s devolvió %v, se esperaba ErrControlEnKit", p.Nombre, err)
+			}
+		}
+
+		if _, err := repos.Movimientos.Create(ctx, models.MovimientoInventarioRequest{
+			ProductoID: componente.ID, Tipo: models.TipoEntrada, Cantidad: 4,
+		}); err != nil {
+			t.Fatalf("error al registrar la entrada: %v", err)
+		}
+		e, err := repos.Ensamblajes.Create(ctx, models.EnsamblajeRequest{Tipo: models.TipoEnsamblar, ProductoID: kit.ID, Cantidad: 2})
+		if err != nil {
+			t.Fatalf("error al ensamblar: %v", err)
+		}
+		if len(e.Movimientos) != 2 {
+			t.Errorf("ensamblaje %+v, se esperaban la salida del componente y la entrada del kit", e)
+		}
+	})
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"net/mail"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	proveedorNoEncontrado = "Proveedor no encontrado"
+	vinculoNoEncontrado   = "El proveedor no suministra ese producto"
+)
+
+type ProveedorHandler struct {
+	repo repository.ProveedorRepository
+}
+
+func NewProveedorHandler(repo repository.ProveedorRepository) *ProveedorHandler {
+	return &ProveedorHandler{repo: repo}
+}
+
+// validarProveedorRequest verifica los campos comunes a la creación y actualización
+func validarProveedorRequest(req models.ProveedorRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if req.Nombre == "" {
+		details = append(details, ErrorDetail{Field: "nombre", Message: "El nombre es requerido"})
+	}
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			details = append(details, ErrorDetail{Field: "email", Message: "El email no es válido"})
+		}
+	}
+	return details
+}
+
+func validarProductoProveedorRequest(req models.ProductoProveedorRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if len(req.SKUProveedor) > 64 {
+		details = append(details, ErrorDetail{Field: "sku_proveedor", Message: "No puede superar los 64 caracteres"})
+	}
+	if req.PlazoEntregaDias < 0 {
+		details = append(details, ErrorDetail{Field: "plazo_entrega_dias", Message: "El plazo de entrega no puede ser negativo"})
+	}
+	if req.UltimoCosto != nil && *req.UltimoCosto < 0 {
+		details = append(details, ErrorDetail{Field: "ultimo_costo", Message: "El costo no puede ser negativo"})
+	}
+	return details
+}
+
+func (h *ProveedorHandler) GetProveedores(w http.ResponseWriter, r *http.Request) {
+	proveedores, err := h.repo.List(r.Context())
+	if err != nil {
+		respondRepoError(w, r, err, proveedorNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, proveedores)
+}
+
+func (h *ProveedorHandler) GetProveedor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	p, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, proveedorNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func (h *ProveedorHandler) CreateProveedor(w http.ResponseWriter, r *http.Request) {
+	var req models.ProveedorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarProveedorRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	p, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, proveedorNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, p)
+}
+
+func (h *ProveedorHandler) UpdateProveedor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	var req models.ProveedorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarProveedorRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	p, err := h.repo.Update(r.Context(), id, req)
+	if err != nil {
+		respondRepoError(w, r, err, proveedorNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func (h *ProveedorHandler) DeleteProveedor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		respondRepoError(w, r, err, proveedorNoEncontrado)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProductosProveedor lista los productos que suministra el proveedor
+func (h *ProveedorHandler) GetProductosProveedor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	productos, err := h.repo.Productos(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, proveedorNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, productos)
+}
+
+// GetProveedoresProducto lista los proveedores de un producto
+func (h *ProveedorHandler) GetProveedoresProducto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	proveedores, err := h.repo.ProveedoresDeProducto(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, productoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, proveedores)
+}
+
+// VincularProducto crea o reemplaza los datos con que el proveedor suministra
+// el producto
+func (h *ProveedorHandler) VincularProducto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+	productoID, err := strconv.Atoi(vars["producto_id"])
+	if err != nil {
+		respondInvalidID(w, r, "producto_id")
+		return
+	}
+
+	var req models.ProductoProveedorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarProductoProveedorRequest(req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	pp, err := h.repo.Vincular(r.Context(), id, productoID, req)
+	if err != nil {
+		respondRepoError(w, r, err, vinculoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, pp)
+}
+
+func (h *ProveedorHandler) DesvincularProducto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+	productoID, err := strconv.Atoi(vars["producto_id"])
+	if err != nil {
+		respondInvalidID(w, r, "producto_id")
+		return
+	}
+
+	if err := h.repo.Desvincular(r.Context(), id, productoID); err != nil {
+		respondRepoError(w, r, err, vinculoNoEncontrado)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultLimit es el tamaño de página cuando no se indica limit
+	defaultLimit = 100
+	// maxLimit es el tamaño de página máximo permitido
+	maxLimit = 500
+)
+
+// queryInt lee un parámetro entero opcional de la query string
+func queryInt(q url.Values, name string) (*int, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, &fieldError{Field: name, Message: "Debe ser un número entero"}
+	}
+	return &v, nil
+}
+
+// queryFloat lee un parámetro decimal opcional de la query string
+func queryFloat(q url.Values, name string) (*float64, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, &fieldError{Field: name, Message: "Debe ser un número"}
+	}
+	return &v, nil
+}
+
+// queryOrder interpreta el parámetro order (asc o desc) y devuelve si es descendente
+func queryOrder(q url.Values) (bool, error) {
+	switch strings.ToLower(q.Get("order")) {
+	case "", "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	}
+	return false, &fieldError{Field: "order", Message: "Debe ser 'asc' o 'desc'"}
+}
+
+// queryPagination lee page y limit aplicando los valores por defecto. Sin
+// ninguno de los dos devuelve limit 0, que pide todos los resultados, como
+// antes de paginar el listado.
+func queryPagination(q url.Values) (page, limit int, err error) {
+	if !q.Has("page") && !q.Has("limit") {
+		return 1, 0, nil
+	}
+	p, err := queryInt(q, "page")
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit, err = queryLimit(q); err != nil {
+		return 0, 0, err
+	}
+
+	page = 1
+	if p != nil {
+		if *p < 1 {
+			return 0, 0, &fieldError{Field: "page", Message: "Debe ser mayor o igual a 1"}
+		}
+		page = *p
+	}
+	return page, limit, nil
+}
+
+// setTotalCount publica el total de resultados sin paginar
+func setTotalCount(w http.ResponseWriter, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+}
+
+// queryTime lee una fecha opcional en formato RFC 3339 o AAAA-MM-DD
+func queryTime(q url.Values, name string) (*time.Time, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, &fieldError{Field: name, Message: "Debe ser una fecha AAAA-MM-DD o RFC 3339"}
+}
+
+// queryLimit lee el tamaño de página aplicando el valor por defecto
+func queryLimit(q url.Values) (int, error) {
+	l, err := queryInt(q, "limit")
+	if err != nil {
+		return 0, err
+	}
+	if l == nil {
+		return defaultLimit, nil
+	}
+	if *l < 1 || *l > maxLimit {
+		return 0, &fieldError{Field: "limit", Message: fmt.Sprintf("Debe estar entre 1 y %d", maxLimit)}
+	}
+	return *l, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const serieNoEncontrada = "Serie no encontrada"
+
+type SerieHandler struct {
+	repo repository.SerieRepository
+}
+
+func NewSerieHandler(repo repository.SerieRepository) *SerieHandler {
+	return &SerieHandler{repo: repo}
+}
+
+// validarSeries normaliza las series de field y verifica que no estén vacías
+// ni superen los 100 caracteres
+func validarSeries(series []string, field string) []ErrorDetail {
+	var details []ErrorDetail
+	for i := range series {
+		series[i] = strings.TrimSpace(series[i])
+		if series[i] == "" {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("%s[%d]", field, i), Message: "La serie no puede estar vacía"})
+		} else if utf8.RuneCountInString(series[i]) > 100 {
+			details = append(details, ErrorDetail{Field: fmt.Sprintf("%s[%d]", field, i), Message: "La serie no puede superar los 100 caracteres"})
+		}
+	}
+	return details
+}
+
+// GetSeries lista las series por producto y número. Acepta los filtros
+// producto_id, almacen_id y en_stock.
+func (h *SerieHandler) GetSeries(w http.ResponseWriter, r *http.Request) {
+	var filtro repository.SerieFiltro
+	var err error
+	q := r.URL.Query()
+	if filtro.ProductoID, err = queryInt(q, "producto_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	if filtro.AlmacenID, err = queryInt(q, "almacen_id"); err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+	filtro.EnStock = q.Get("en_stock") == "true"
+
+	series, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, serieNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, series)
+}
+
+// RastrearSerie devuelve las series con el numero indicado, una por producto,
+// con su ubicación actual y sus movimientos del más antiguo al más reciente
+func (h *SerieHandler) RastrearSerie(w http.ResponseWriter, r *http.Request) {
+	numero := strings.TrimSpace(r.URL.Query().Get("numero"))
+	if numero == "" {
+		respondBadRequest(w, r, &fieldError{Field: "numero", Message: "El número de serie es requerido"})
+		return
+	}
+
+	series, err := h.repo.Rastrear(r.Context(), numero)
+	if err != nil {
+		respondRepoError(w, r, err, serieNoEncontrada)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, series)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const trasladoNoEncontrado = "Traslado no encontrado"
+
+type TrasladoHandler struct {
+	repo    repository.TrasladoRepository
+	alertas Notificador
+}
+
+// NewTrasladoHandler crea el handler; alertas recibe el producto de cada
+// traslado y su recepción y puede ser nil
+func NewTrasladoHandler(repo repository.TrasladoRepository, alertas Notificador) *TrasladoHandler {
+	return &TrasladoHandler{repo: repo, alertas: alertas}
+}
+
+func (h *TrasladoHandler) notificar(productoID int) {
+	if h.alertas != nil {
+		h.alertas.Notificar(productoID)
+	}
+}
+
+// validarTrasladoRequest verifica los campos de un nuevo traslado y
+// normaliza sus series
+func validarTrasladoRequest(req *models.TrasladoRequest) []ErrorDetail {
+	var details []ErrorDetail
+	if req.ProductoID <= 0 {
+		details = append(details, ErrorDetail{Field: "producto_id", Message: "El producto es requerido"})
+	}
+	if req.AlmacenOrigenID <= 0 {
+		details = append(details, ErrorDetail{Field: "almacen_origen_id", Message: "El almacén de origen es requerido"})
+	}
+	if req.AlmacenDestinoID <= 0 {
+		details = append(details, ErrorDetail{Field: "almacen_destino_id", Message: "El almacén de destino es requerido"})
+	} else if req.AlmacenDestinoID == req.AlmacenOrigenID {
+		details = append(details, ErrorDetail{Field: "almacen_destino_id", Message: "Debe ser distinto del almacén de origen"})
+	}
+	if req.Cantidad <= 0 {
+		details = append(details, ErrorDetail{Field: "cantidad", Message: "La cantidad debe ser mayor a 0"})
+	}
+	return append(details, validarSeries(req.Series, "series")...)
+}
+
+// parseTrasladoFiltro construye el filtro del listado a partir de la query string
+func parseTrasladoFiltro(q url.Values) (repository.TrasladoFiltro, error) {
+	var f repository.TrasladoFiltro
+	var err error
+
+	f.Estado = models.EstadoTraslado(q.Get("estado"))
+	if f.Estado != "" && f.Estado != models.EstadoEnTransito && f.Estado != models.EstadoRecibido {
+		return f, &fieldError{Field: "estado", Message: "Debe ser 'en_transito' o 'recibido'"}
+	}
+	if f.ProductoID, err = queryInt(q, "producto_id"); err != nil {
+		return f, err
+	}
+	f.AlmacenID, err = queryInt(q, "almacen_id")
+	return f, err
+}
+
+// GetTraslados lista los traslados del más reciente al más antiguo. Acepta
+// los filtros estado, producto_id y almacen_id.
+func (h *TrasladoHandler) GetTraslados(w http.ResponseWriter, r *http.Request) {
+	filtro, err := parseTrasladoFiltro(r.URL.Query())
+	if err != nil {
+		respondBadRequest(w, r, err)
+		return
+	}
+
+	traslados, err := h.repo.List(r.Context(), filtro)
+	if err != nil {
+		respondRepoError(w, r, err, trasladoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, traslados)
+}
+
+func (h *TrasladoHandler) GetTraslado(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	t, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, trasladoNoEncontrado)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, t)
+}
+
+func (h *TrasladoHandler) CreateTraslado(w http.ResponseWriter, r *http.Request) {
+	var req models.TrasladoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondInvalidJSON(w, r)
+		return
+	}
+
+	if details := validarTrasladoRequest(&req); len(details) > 0 {
+		respondValidation(w, r, details)
+		return
+	}
+
+	t, err := h.repo.Create(r.Context(), req)
+	if err != nil {
+		respondRepoError(w, r, err, trasladoNoEncontrado)
+		return
+	}
+
+	h.notificar(t.ProductoID)
+	respondJSON(w, http.StatusCreated, t)
+}
+
+// RecibirTraslado registra la entrada en destino de un traslado en tránsito
+func (h *TrasladoHandler) RecibirTraslado(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondInvalidID(w, r, "id")
+		return
+	}
+
+	t, err := h.repo.Recibir(r.Context(), id)
+	if err != nil {
+		respondRepoError(w, r, err, trasladoNoEncontrado)
+		return
+	}
+
+	h.notificar(t.ProductoID)
+	respondJSON(w, http.StatusOK, t)
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// stockPorAlmacen devuelve el stock total del producto y su desglose por
+// almacén
+func stockPorAlmacen(t *testing.T, repos repository.Repositories, productoID int) (int, map[int]int) {
+	t.Helper()
+	p, err := repos.Productos.GetByID(context.Background(), productoID)
+	if err != nil {
+		t.Fatalf("error al leer el producto: %v", err)
+	}
+	porAlmacen := make(map[int]int)
+	for _, s := range p.StockAlmacenes {
+		porAlmacen[s.AlmacenID] = s.Cantidad
+	}
+	return p.Stock, porAlmacen
+}
+
+// notificadorPrueba guarda los productos notificados
+type notificadorPrueba struct {
+	mu        sync.Mutex
+	productos []int
+}
+
+func (n *notificadorPrueba) Notificar(productoID int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.productos = append(n.productos, productoID)
+}
+
+func TestTrasladoNotificaAlertas(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		destino := crearAlmacenPrueba(t, repos)
+		principal := almacenPrincipalPrueba(t, repos)
+		p := crearProductoPrueba(t, repos, 5)
+		alertas := &notificadorPrueba{}
+		h := NewTrasladoHandler(repos.Traslados, alertas)
+
+		crear := func(cantidad int) *httptest.ResponseRecorder {
+			body := fmt.Sprintf(`{"producto_id": %d, "almacen_origen_id": %d, "almacen_destino_id": %d, "cantidad": %d, "en_transito": true}`,
+				p.ID, principal.ID, destino.ID, cantidad)
+			rec := httptest.NewRecorder()
+			h.CreateTraslado(rec, httptest.NewRequest(http.MethodPost, "/api/traslados", bytes.NewReader([]byte(body))))
+			return rec
+		}
+		// Un traslado rechazado no notifica
+		if rec := crear(6); rec.Code != http.StatusConflict {
+			t.Fatalf("traslado sin stock: código %d, se esperaba 409", rec.Code)
+		}
+		rec := crear(2)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("error al crear el traslado: %d %s", rec.Code, rec.Body)
+		}
+		var tr models.Traslado
+		if err := json.NewDecoder(rec.Body).Decode(&tr); err != nil {
+			t.Fatalf("respuesta inválida: %v", err)
+		}
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/traslados/recibir", nil),
+			map[string]string{"id": strconv.Itoa(tr.ID)})
+		rec = httptest.NewRecorder()
+		h.RecibirTraslado(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("error al recibir el traslado: %d %s", rec.Code, rec.Body)
+		}
+
+		if fmt.Sprint(alertas.productos) != fmt.Sprint([]int{p.ID, p.ID}) {
+			t.Errorf("productos notificados %v, se esperaba %d al crear y al recibir", alertas.productos, p.ID)
+		}
+	})
+}
+
+func TestTrasladoSinStockNoMueveNada(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		ctx := context.Background()
+		destino := crearAlmacenPrueba(t, repos)
+		principal := almacenPrincipalPrueba(t, repos)
+		p := crearProductoPrueba(t, repos, 5)
+
+		for _, enTransito := range []bool{false, true} {
+			_, err := repos.Traslados.Create(ctx, models.TrasladoRequest{
+				ProductoID: p.ID, AlmacenOrigenID: principal.ID, AlmacenDestinoID: destino.ID, Cantidad: 6, EnTransito: enTransito,
+			})
+			if err != repository.ErrStockInsuficiente {
+				t.Errorf("traslado sin stock (en tránsito: %v) devolvió %v, se esperaba ErrStockInsuficiente", enTransito, err)
+			}
+		}
+		if _, err := repos.Traslados.Create(ctx, models.TrasladoRequest{
+			ProductoID: p.ID, AlmacenOrigenID: principal.ID, AlmacenDestinoID: principal.ID, Cantidad: 1,
+		}); err != repository.ErrMismoAlmacen {
+			t.Errorf("traslado al mismo almacén devolvió %v, se esperaba ErrMismoAlmacen", err)
+		}
+
+		stock, porAlmacen := stockPorAlmacen(t, repos, p.ID)
+		if stock != 5 || porAlmacen[principal.ID] != 5 || porAlmacen[destino.ID] != 0 {
+			t.Errorf("stock %d con desglose %v, se esperaban 5 en el origen y nada en el destino", stock, porAlmacen)
+		}
+		traslados, err := repos.Traslados.List(ctx, repository.TrasladoFiltro{ProductoID: &p.ID})
+		if err != nil {
+			t.Fatalf("error al listar los traslados: %v", err)
+		}
+		movimientos, _, err := repos.Movimientos.List(ctx, repository.MovimientoFiltro{ProductoID: &p.ID})
+		if err != nil {
+			t.Fatalf("error al listar los movimientos: %v", err)
+		}
+		// Solo el ajuste del stock inicial
+		if len(traslados) != 0 || len(movimientos) != 1 {
+			t.Errorf("quedaron %d traslados y %d movimientos, se esperaban 0 y 1", len(traslados), len(movimientos))
+		}
+	})
+}
+
+func TestTrasladoEnTransito(t *testing.T) {
+	backendsPrueba(t, func(t *testing.T, repos repository.Repositories) {
+		const peticiones = 10
+		ctx := context.Background()
+		destino := crearAlmacenPrueba(t, repos)
+		principal := almacenPrincipalPrueba(t, repos)
+		p := crearProductoPrueba(t, repos, 5)
+
+		tr, err := repos.Traslados.Create(ctx, models.TrasladoRequest{
+			ProductoID: p.ID, AlmacenOrigenID: principal.ID, AlmacenDestinoID: destino.ID, Cantidad: 3, EnTransito: true,
+		})
+		if err != nil {
+			t.Fatalf("error al crear el traslado: %v", err)
+		}
+		if tr.Estado != models.EstadoEnTransito || len(tr.Movimientos) != 1 {
+			t.Errorf("traslado = %+v, se esperaba en tránsito con solo la salida", tr)
+		}
+		// Lo que está en tránsito no suma en ningún almacén
+		stock, porAlmacen := stockPorAlmacen(t, repos, p.ID)
+		if stock != 2 || porAlmacen[principal.ID] != 2 || porAlmacen[destino.ID] != 0 {
+			t.Errorf("stock %d con desglose %v, se esperaban 2 en el origen y nada en el destino", stock, porAlmacen)
+		}
+
+		// Varias recepciones simultáneas registran una sola entrada
+		h := NewTrasladoHandler(repos.Traslados, nil)
+		var wg sync.WaitGroup
+		codigos := make(chan int, peticiones)
+		for i := 0; i < peticiones; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/api/traslados/recibir", nil)
+				req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(tr.ID)})
+				rec := httptest.NewRecorder()
+				h.RecibirTraslado(rec, req)
+				codigos <- rec.Code
+			}()
+		}
+		wg.Wait()
+		close(codigos)
+
+		recibidos := 0
+		for codigo := range codigos {
+			switch codigo {
+			case http.StatusOK:
+				recibidos++
+			case http.StatusConflict:
+			default:
+				t.Errorf("código de estado inesperado: %d", codigo)
+			}
+		}
+		if recibidos != 1 {
+			t.Errorf("recepciones registradas = %d, se esperaba 1", recibidos)
+		}
+		if _, err := repos.Traslados.Recibir(ctx, tr.ID); err != repository.ErrTrasladoRecibido {
+			t.Errorf("recibir de nuevo devolvió %v, se esperaba ErrTrasladoRecibido", err)
+		}
+
+		tr, err = repos.Traslados.GetByID(ctx, tr.ID)
+		if err != nil {
+			t.Fatalf("error al leer el traslado: %v", err)
+		}
+		if tr.Estado != models.EstadoRecibido || tr.RecibidoAt == nil || len(tr.Movimientos) != 2 {
+			t.Errorf("traslado = %+v, se esperaba recibido con la salida y la entrada", tr)
+		}
+		stock, porAlmacen = stockPorAlmacen(t, repos, p.ID)
+		if stock != 5 || porAlmacen[principal.ID] != 2 || porAlmacen[destino.ID] != 3 {
+			t.Errorf("stock %d con desglose %v, se esperaban 2 en el origen y 3 en el destino", stock, porAlmacen)
+		}
+
+		// Los movimientos de un traslado no se revierten uno por uno
+		for _, m := range tr.Movimientos {
+			if _, err := repos.Movimientos.Revertir(ctx, m.ID, ""); err != repository.ErrReversionNoPermitida {
+				t.Errorf("revertir el movimiento %s del traslado devolvió %v, se esperaba ErrReversionNoPermitida", m.Tipo, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// NivelAlerta indica qué umbral de reposición cruzó el stock de un producto
+type NivelAlerta string
+
+const (
+	// NivelReorden: el stock llegó al punto de reorden
+	NivelReorden NivelAlerta = "reorden"
+	// NivelMinimo: el stock quedó por debajo del stock mínimo
+	NivelMinimo NivelAlerta = "minimo"
+)
+
+// NivelStock devuelve el umbral que cruzó el stock del producto y su valor,
+// o "" si está por encima de ambos. El mínimo es más grave que el reorden.
+func NivelStock(p Producto) (NivelAlerta, int) {
+	if p.StockMinimo > 0 && p.Stock < p.StockMinimo {
+		return NivelMinimo, p.StockMinimo
+	}
+	if p.PuntoReorden > 0 && p.Stock <= p.PuntoReorden {
+		return NivelReorden, p.PuntoReorden
+	}
+	return "", 0
+}
+
+type EstadoAlerta string
+
+const (
+	EstadoAlertaActiva     EstadoAlerta = "activa"
+	EstadoAlertaReconocida EstadoAlerta = "reconocida"
+	// EstadoAlertaResuelta: el stock volvió a superar el umbral
+	EstadoAlertaResuelta EstadoAlerta = "resuelta"
+)
+
+// Alerta avisa que el stock de un producto cruzó un umbral de reposición.
+// Cada producto tiene a lo sumo una alerta sin resolver: reconocerla la
+// silencia y se resuelve sola cuando el stock se recupera.
+type Alerta struct {
+	ID              int          `json:"id"`
+	ProductoID      int          `json:"producto_id"`
+	Producto        string       `json:"producto"`
+	Nivel           NivelAlerta  `json:"nivel"`
+	Estado          EstadoAlerta `json:"estado"`
+	Stock           int          `json:"stock"`  // al generarse
+	Umbral          int          `json:"umbral"` // stock mínimo o punto de reorden
+	CantidadReorden int          `json:"cantidad_reorden"`
+	CreatedAt       time.Time    `json:"created_at"`
+	ReconocidaAt    *time.Time   `json:"reconocida_at,omitempty"`
+	ResueltaAt      *time.Time   `json:"resuelta_at,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type Almacen struct {
+	ID          int    `json:"id"`
+	Nombre      string `json:"nombre"`
+	Descripcion string `json:"descripcion"`
+	// Principal indica el almacén que se usa cuando un movimiento no indica
+	// almacen_id. Siempre hay exactamente uno.
+	Principal bool      `json:"principal"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AlmacenRequest struct {
+	Nombre      string `json:"nombre"`
+	Descripcion string `json:"descripcion"`
+	// Principal en true convierte este almacén en el principal; en false no
+	// tiene efecto sobre el almacén principal actual
+	Principal bool `json:"principal"`
+}
+
+// StockAlmacen es la existencia de un producto en un almacén
+type StockAlmacen struct {
+	AlmacenID int    `json:"almacen_id"`
+	Almacen   string `json:"almacen"`
+	Cantidad  int    `json:"cantidad"`
+}
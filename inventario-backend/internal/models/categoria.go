@@ -14,4 +14,3 @@ type CategoriaRequest struct {
 	Nombre      string `json:"nombre"`
 	Descripcion string `json:"descripcion"`
 }
-
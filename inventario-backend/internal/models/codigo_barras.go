@@ -0,0 +1,46 @@
+package models
+
+import "errors"
+
+var (
+	ErrCodigoBarrasFormato     = errors.New("el código de barras debe tener 8 (EAN-8), 12 (UPC-A) o 13 (EAN-13) dígitos")
+	ErrCodigoBarrasVerificador = errors.New("el dígito verificador del código de barras no es válido")
+)
+
+// NormalizarCodigoBarras valida un código EAN-8, UPC-A o EAN-13 y lo
+// devuelve en su forma canónica. Los UPC-A se guardan como EAN-13 con un
+// cero inicial, que es como los leen la mayoría de los escáneres.
+func NormalizarCodigoBarras(codigo string) (string, error) {
+	switch len(codigo) {
+	case 8, 13:
+	case 12:
+		codigo = "0" + codigo
+	default:
+		return "", ErrCodigoBarrasFormato
+	}
+
+	for _, c := range codigo {
+		if c < '0' || c > '9' {
+			return "", ErrCodigoBarrasFormato
+		}
+	}
+
+	if digitoVerificadorGTIN(codigo[:len(codigo)-1]) != codigo[len(codigo)-1] {
+		return "", ErrCodigoBarrasVerificador
+	}
+	return codigo, nil
+}
+
+// digitoVerificadorGTIN calcula el dígito de control GS1: recorriendo desde
+// la derecha, los dígitos en posición impar pesan 3 y los pares pesan 1.
+func digitoVerificadorGTIN(digitos string) byte {
+	suma := 0
+	for i := 0; i < len(digitos); i++ {
+		d := int(digitos[len(digitos)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		suma += d
+	}
+	return byte('0' + (10-suma%10)%10)
+}
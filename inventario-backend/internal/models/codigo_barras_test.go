@@ -0,0 +1,57 @@
+package models
+
+import "testing"
+
+func TestNormalizarCodigoBarras(t *testing.T) {
+	casos := []struct {
+		codigo string
+		want   string
+		err    error
+	}{
+		// EAN-13
+		{"4006381333931", "4006381333931", nil},
+		{"4006381333932", "", ErrCodigoBarrasVerificador},
+		{"0036000291452", "0036000291452", nil},
+		// EAN-8
+		{"96385074", "96385074", nil},
+		{"96385075", "", ErrCodigoBarrasVerificador},
+		{"00000000", "00000000", nil},
+		// UPC-A se guarda como EAN-13 con un cero inicial
+		{"036000291452", "0036000291452", nil},
+		{"012345678905", "0012345678905", nil},
+		{"036000291453", "", ErrCodigoBarrasVerificador},
+		// Caracteres que no son dígitos
+		{"40063813339A1", "", ErrCodigoBarrasFormato},
+		{"9638-507", "", ErrCodigoBarrasFormato},
+		{"03600029145 ", "", ErrCodigoBarrasFormato},
+		// Largos que no corresponden a ningún formato
+		{"", "", ErrCodigoBarrasFormato},
+		{"9638507", "", ErrCodigoBarrasFormato},
+		{"40063813339", "", ErrCodigoBarrasFormato},
+		{"40063813339310", "", ErrCodigoBarrasFormato},
+	}
+	for _, c := range casos {
+		got, err := NormalizarCodigoBarras(c.codigo)
+		if got != c.want || err != c.err {
+			t.Errorf("NormalizarCodigoBarras(%q) = %q, %v; se esperaba %q, %v", c.codigo, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestDigitoVerificadorGTIN(t *testing.T) {
+	casos := []struct {
+		digitos string
+		want    byte
+	}{
+		{"400638133393", '1'},
+		{"9638507", '4'},
+		{"03600029145", '2'},
+		// Una suma múltiplo de 10 da 0 y no 10
+		{"000000000000", '0'},
+	}
+	for _, c := range casos {
+		if got := digitoVerificadorGTIN(c.digitos); got != c.want {
+			t.Errorf("digitoVerificadorGTIN(%q) = %c, se esperaba %c", c.digitos, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// StockFecha es el stock de un producto reconstruido a partir del libro de
+// movimientos
+type StockFecha struct {
+	ProductoID int            `json:"producto_id"`
+	Hasta      time.Time      `json:"hasta"` // incluye los movimientos anteriores a este instante
+	Stock      int            `json:"stock"`
+	Almacenes  []StockAlmacen `json:"almacenes"`
+}
+
+// Conciliacion compara el stock guardado de un producto con la suma de sus
+// movimientos
+type Conciliacion struct {
+	ProductoID       int                   `json:"producto_id"`
+	Producto         string                `json:"producto"`
+	StockRegistrado  int                   `json:"stock_registrado"`
+	StockMovimientos int                   `json:"stock_movimientos"`
+	Diferencia       int                   `json:"diferencia"` // registrado - movimientos
+	Almacenes        []ConciliacionAlmacen `json:"almacenes,omitempty"`
+}
+
+// ConciliacionAlmacen es un almacén cuyo stock no cuadra con sus movimientos
+type ConciliacionAlmacen struct {
+	AlmacenID        int    `json:"almacen_id"`
+	Almacen          string `json:"almacen"`
+	StockRegistrado  int    `json:"stock_registrado"`
+	StockMovimientos int    `json:"stock_movimientos"`
+	Diferencia       int    `json:"diferencia"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+type EstadoConteo string
+
+const (
+	EstadoConteoAbierto   EstadoConteo = "abierto"
+	EstadoConteoAprobado  EstadoConteo = "aprobado"
+	EstadoConteoCancelado EstadoConteo = "cancelado"
+)
+
+// Conteo es una sesión de conteo físico de un almacén. Al abrirla se guarda
+// el stock esperado de cada producto (de toda la tienda o de una categoría);
+// al aprobarla se registra un ajuste por cada diferencia.
+type Conteo struct {
+	ID          int          `json:"id"`
+	AlmacenID   int          `json:"almacen_id"`
+	CategoriaID *int         `json:"categoria_id,omitempty"`
+	Estado      EstadoConteo `json:"estado"`
+	Descripcion string       `json:"descripcion"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CerradoAt   *time.Time   `json:"cerrado_at,omitempty"`
+	Items       []ConteoItem `json:"items,omitempty"`
+}
+
+// ConteoItem es un producto de la sesión. CantidadContada es la suma de los
+// registros de la última pasada y queda en nil mientras nadie lo cuente.
+type ConteoItem struct {
+	ProductoID      int              `json:"producto_id"`
+	Producto        *Producto        `json:"producto,omitempty"`
+	StockEsperado   int              `json:"stock_esperado"`
+	CantidadContada *int             `json:"cantidad_contada"`
+	Diferencia      *int             `json:"diferencia"`
+	MovimientoID    *int             `json:"movimiento_id,omitempty"` // ajuste registrado al aprobar
+	Registros       []ConteoRegistro `json:"registros,omitempty"`
+}
+
+// ConteoRegistro es la cantidad que un contador encontró en una pasada, con
+// el lote y las series que indicó para el ajuste
+type ConteoRegistro struct {
+	ProductoID  int       `json:"producto_id"`
+	Pasada      int       `json:"pasada"`
+	Contador    string    `json:"contador"`
+	Cantidad    int       `json:"cantidad"`
+	Lote        string    `json:"lote,omitempty"`
+	Vencimiento *Fecha    `json:"vencimiento,omitempty"`
+	Series      []string  `json:"series,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ConteoRequest struct {
+	AlmacenID   int    `json:"almacen_id"`   // opcional; por defecto el almacén principal
+	CategoriaID *int   `json:"categoria_id"` // opcional; solo los productos de la categoría
+	Descripcion string `json:"descripcion"`
+}
+
+// ConteoRegistroRequest carga las cantidades que un contador encontró en una
+// pasada
+type ConteoRegistroRequest struct {
+	Pasada   int               `json:"pasada"` // opcional; por defecto 1
+	Contador string            `json:"contador"`
+	Items    []CantidadContada `json:"items"`
+}
+
+type CantidadContada struct {
+	ProductoID int `json:"producto_id"`
+	Cantidad   int `json:"cantidad"`
+	// Lote y Vencimiento indican a qué lote va el sobrante o de cuál sale el
+	// faltante en los productos con control de lotes
+	Lote        string `json:"lote"`
+	Vencimiento *Fecha `json:"vencimiento"`
+	// Series son las que sobran o faltan en los productos con control de series
+	Series []string `json:"series"`
+}
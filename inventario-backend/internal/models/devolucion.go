@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// CondicionDevolucion es el estado en que el cliente devuelve las unidades
+type CondicionDevolucion string
+
+const (
+	CondicionReutilizable      CondicionDevolucion = "reutilizable"
+	CondicionDanado            CondicionDevolucion = "danado"
+	CondicionDevolverProveedor CondicionDevolucion = "devolver_proveedor"
+)
+
+// EstadoItemDevolucion indica dónde quedaron las unidades devueltas. Solo las
+// repuestas vuelven al stock; las que están en cuarentena quedan fuera de él
+// hasta resolverse.
+type EstadoItemDevolucion string
+
+const (
+	EstadoItemRepuesto          EstadoItemDevolucion = "repuesto"
+	EstadoItemCuarentena        EstadoItemDevolucion = "cuarentena"
+	EstadoItemBaja              EstadoItemDevolucion = "baja"
+	EstadoItemDevueltoProveedor EstadoItemDevolucion = "devuelto_proveedor"
+)
+
+// Devolucion registra lo que un cliente devolvió de una orden de venta
+// despachada o de una salida suelta. Tiene uno solo de OrdenVentaID y
+// MovimientoID.
+type Devolucion struct {
+	ID           int  `json:"id"`
+	OrdenVentaID *int `json:"orden_venta_id,omitempty"`
+	// MovimientoID es la salida original
+	MovimientoID *int             `json:"movimiento_id,omitempty"`
+	AlmacenID    int              `json:"almacen_id"` // el de la venta original
+	Motivo       string           `json:"motivo"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Items        []DevolucionItem `json:"items,omitempty"`
+}
+
+type DevolucionItem struct {
+	ID           int                  `json:"id"`
+	DevolucionID int                  `json:"devolucion_id"`
+	ProductoID   int                  `json:"producto_id"`
+	Producto     *Producto            `json:"producto,omitempty"`
+	Cantidad     int                  `json:"cantidad"`
+	Condicion    CondicionDevolucion  `json:"condicion"`
+	Estado       EstadoItemDevolucion `json:"estado"`
+	Series       []string             `json:"series,omitempty"`
+	// MovimientoID es el ajuste que repuso las unidades al stock
+	MovimientoID *int       `json:"movimiento_id,omitempty"`
+	ResueltoAt   *time.Time `json:"resuelto_at,omitempty"` // al salir de cuarentena
+}
+
+type DevolucionRequest struct {
+	OrdenVentaID *int                    `json:"orden_venta_id"`
+	MovimientoID *int                    `json:"movimiento_id"`
+	Motivo       string                  `json:"motivo"`
+	Items        []DevolucionItemRequest `json:"items"`
+}
+
+type DevolucionItemRequest struct {
+	ProductoID int                 `json:"producto_id"`
+	Cantidad   int                 `json:"cantidad"`
+	Condicion  CondicionDevolucion `json:"condicion"`
+	// Destino es opcional: las reutilizables se reponen y las dañadas van a
+	// cuarentena salvo que se indique baja (ver EstadoInicialDevolucion)
+	Destino EstadoItemDevolucion `json:"destino"`
+	// Series se requieren en los productos con control de series y deben
+	// haber salido en la venta
+	Series []string `json:"series"`
+}
+
+// ResolucionRequest saca de cuarentena un item de devolución
+type ResolucionRequest struct {
+	Estado EstadoItemDevolucion `json:"estado"`
+}
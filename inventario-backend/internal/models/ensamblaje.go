@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Componente es una línea de la lista de materiales de un kit: cuántas
+// unidades del producto lleva cada kit
+type Componente struct {
+	ProductoID int `json:"producto_id"`
+	// Producto solo trae su ID, nombre y SKU
+	Producto *Producto `json:"producto,omitempty"`
+	Cantidad int       `json:"cantidad"`
+}
+
+// ComponentesRequest es el cuerpo de PUT /api/productos/{id}/componentes;
+// reemplaza la lista de materiales y, vacía, deja de ser un kit
+type ComponentesRequest struct {
+	Componentes []ComponenteRequest `json:"componentes"`
+}
+
+type ComponenteRequest struct {
+	ProductoID int `json:"producto_id"`
+	Cantidad   int `json:"cantidad"`
+}
+
+type TipoEnsamblaje string
+
+const (
+	// TipoEnsamblar consume los componentes y produce el kit
+	TipoEnsamblar TipoEnsamblaje = "ensamblaje"
+	// TipoDesensamblar consume el kit y devuelve sus componentes
+	TipoDesensamblar TipoEnsamblaje = "desensamblaje"
+)
+
+// Ensamblaje arma o desarma unidades de un kit en un almacén. Se registra
+// como una salida por cada producto consumido y una entrada por cada
+// producto obtenido, todas con el mismo ensamblaje_id.
+type Ensamblaje struct {
+	ID         int            `json:"id"`
+	Tipo       TipoEnsamblaje `json:"tipo"`
+	ProductoID int            `json:"producto_id"`
+	Producto   *Producto      `json:"producto,omitempty"`
+	AlmacenID  int            `json:"almacen_id"`
+	Cantidad   int            `json:"cantidad"`
+	Motivo     string         `json:"motivo"`
+	CreatedAt  time.Time      `json:"created_at"`
+	// Movimientos contiene las salidas y las entradas
+	Movimientos []MovimientoInventario `json:"movimientos,omitempty"`
+}
+
+type EnsamblajeRequest struct {
+	Tipo       TipoEnsamblaje `json:"tipo"`
+	ProductoID int            `json:"producto_id"`
+	AlmacenID  int            `json:"almacen_id"` // opcional; por defecto el almacén principal
+	Cantidad   int            `json:"cantidad"`
+	Motivo     string         `json:"motivo"`
+}
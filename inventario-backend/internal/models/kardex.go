@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// MetodoCosteo indica cómo se valoran las salidas en el kardex
+type MetodoCosteo string
+
+const (
+	// MetodoPromedio valora las salidas al costo promedio ponderado
+	MetodoPromedio MetodoCosteo = "promedio"
+	// MetodoFIFO valora las salidas con el costo de las unidades más antiguas
+	MetodoFIFO MetodoCosteo = "fifo"
+)
+
+// Kardex es la tarjeta de existencias de un producto: sus movimientos en
+// orden con el saldo y la valoración después de cada uno
+type Kardex struct {
+	ProductoID   int           `json:"producto_id"`
+	Producto     string        `json:"producto"`
+	Metodo       MetodoCosteo  `json:"metodo"`
+	Desde        *time.Time    `json:"desde,omitempty"`
+	Hasta        *time.Time    `json:"hasta,omitempty"`
+	SaldoInicial KardexSaldo   `json:"saldo_inicial"`
+	Lineas       []KardexLinea `json:"lineas"`
+	SaldoFinal   KardexSaldo   `json:"saldo_final"`
+}
+
+// KardexSaldo es la existencia y su valor en un momento dado
+type KardexSaldo struct {
+	Cantidad      int     `json:"cantidad"`
+	CostoUnitario float64 `json:"costo_unitario"` // valor / cantidad
+	Valor         float64 `json:"valor"`
+}
+
+// KardexLinea es un movimiento del kardex. Entrada y Salida son las unidades
+// que suma o resta; CostoUnitario y CostoTotal valoran esas unidades.
+type KardexLinea struct {
+	MovimientoID  int            `json:"movimiento_id"`
+	Fecha         time.Time      `json:"fecha"`
+	Tipo          TipoMovimiento `json:"tipo"`
+	CodigoMotivo  CodigoMotivo   `json:"codigo_motivo,omitempty"`
+	Motivo        string         `json:"motivo"`
+	AlmacenID     int            `json:"almacen_id"`
+	Almacen       string         `json:"almacen"`
+	Entrada       int            `json:"entrada"`
+	Salida        int            `json:"salida"`
+	CostoUnitario float64        `json:"costo_unitario"`
+	CostoTotal    float64        `json:"costo_total"`
+	// CostoEstimado indica que la entrada no tenía costo y se valoró al
+	// costo promedio del momento
+	CostoEstimado bool        `json:"costo_estimado,omitempty"`
+	Saldo         KardexSaldo `json:"saldo"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FormatoFecha es el formato de las fechas sin hora de la API
+const FormatoFecha = "2006-01-02"
+
+// Fecha es una fecha sin hora, como el vencimiento de un lote. Se serializa
+// como AAAA-MM-DD.
+type Fecha struct {
+	time.Time
+}
+
+func (f Fecha) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Format(FormatoFecha))
+}
+
+func (f *Fecha) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse(FormatoFecha, s)
+	if err != nil {
+		return err
+	}
+	f.Time = t
+	return nil
+}
+
+// Lote agrupa las unidades de un producto con control de lotes que entraron
+// a un almacén con el mismo código. Cantidad es su stock en ese almacén.
+type Lote struct {
+	ID          int       `json:"id"`
+	ProductoID  int       `json:"producto_id"`
+	Producto    *Producto `json:"producto,omitempty"`
+	AlmacenID   int       `json:"almacen_id"`
+	Codigo      string    `json:"codigo"`
+	Vencimiento *Fecha    `json:"vencimiento,omitempty"`
+	Cantidad    int       `json:"cantidad"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MovimientoLote es la parte de un movimiento que tocó un lote. Cantidad es
+// siempre positiva; el movimiento indica si sumó o restó.
+type MovimientoLote struct {
+	LoteID      int    `json:"lote_id"`
+	Codigo      string `json:"codigo"`
+	Vencimiento *Fecha `json:"vencimiento,omitempty"`
+	Cantidad    int    `json:"cantidad"`
+}
@@ -7,22 +7,133 @@ type TipoMovimiento string
 const (
 	TipoEntrada TipoMovimiento = "entrada"
 	TipoSalida  TipoMovimiento = "salida"
+	// TipoAjuste corrige el stock sin ser una compra ni una venta. Su
+	// cantidad es positiva si suma stock y negativa si lo resta.
+	TipoAjuste TipoMovimiento = "ajuste"
 )
 
+// CodigoMotivo clasifica los ajustes para poder separarlos en los reportes
+type CodigoMotivo string
+
+const (
+	MotivoMerma      CodigoMotivo = "merma"
+	MotivoDano       CodigoMotivo = "dano"
+	MotivoConteo     CodigoMotivo = "conteo"
+	MotivoDevolucion CodigoMotivo = "devolucion"
+	MotivoHallazgo   CodigoMotivo = "hallazgo"
+	// MotivoInicial registra el stock con el que se crea un producto. Solo
+	// lo usa el sistema y no forma parte del catálogo.
+	MotivoInicial CodigoMotivo = "inicial"
+)
+
+// MotivoAjuste describe un código del catálogo de motivos de ajuste
+type MotivoAjuste struct {
+	Codigo      CodigoMotivo `json:"codigo"`
+	Nombre      string       `json:"nombre"`
+	Descripcion string       `json:"descripcion"`
+}
+
+// MotivosAjuste es el catálogo cerrado de motivos que los clientes pueden
+// usar en sus ajustes. Junto con MotivoInicial debe coincidir con la
+// restricción movimientos_inventario_codigo_motivo_check.
+var MotivosAjuste = []MotivoAjuste{
+	{MotivoMerma, "Merma", "Pérdida por robo, evaporación o causas desconocidas"},
+	{MotivoDano, "Daño", "Producto dañado o vencido que se da de baja"},
+	{MotivoConteo, "Conteo", "Corrección tras un conteo físico"},
+	{MotivoDevolucion, "Devolución", "Devolución de un cliente o a un proveedor"},
+	{MotivoHallazgo, "Hallazgo", "Stock encontrado que no estaba registrado"},
+}
+
+// MotivoValido indica si el código pertenece al catálogo
+func MotivoValido(c CodigoMotivo) bool {
+	for _, m := range MotivosAjuste {
+		if m.Codigo == c {
+			return true
+		}
+	}
+	return false
+}
+
+// MotivoRegistrable indica si un ajuste puede llevar el código: los del
+// catálogo y MotivoInicial, que el sistema registra al crear un producto
+func MotivoRegistrable(c CodigoMotivo) bool {
+	return c == MotivoInicial || MotivoValido(c)
+}
+
 type MovimientoInventario struct {
-	ID         int            `json:"id"`
-	ProductoID int            `json:"producto_id"`
-	Producto   *Producto      `json:"producto,omitempty"`
-	Tipo       TipoMovimiento `json:"tipo"`
-	Cantidad   int            `json:"cantidad"`
-	Motivo     string         `json:"motivo"`
-	CreatedAt  time.Time      `json:"created_at"`
+	ID           int            `json:"id"`
+	ProductoID   int            `json:"producto_id"`
+	Producto     *Producto      `json:"producto,omitempty"`
+	AlmacenID    int            `json:"almacen_id"`
+	Almacen      *Almacen       `json:"almacen,omitempty"`
+	Tipo         TipoMovimiento `json:"tipo"`
+	Cantidad     int            `json:"cantidad"`
+	CodigoMotivo CodigoMotivo   `json:"codigo_motivo,omitempty"` // solo en los ajustes
+	Motivo       string         `json:"motivo"`
+	// CostoUnitario es el costo de compra en Moneda, que se convierte a la
+	// moneda local con TipoCambio; solo en entradas y ajustes positivos
+	CostoUnitario *float64 `json:"costo_unitario,omitempty"`
+	Moneda        string   `json:"moneda,omitempty"`
+	TipoCambio    *float64 `json:"tipo_cambio,omitempty"`
+	// CostoTotal es el valor en moneda local de las unidades que mueve: el
+	// costo de venta en las salidas
+	CostoTotal *float64 `json:"costo_total,omitempty"`
+	// ProveedorID es el proveedor de una entrada; Proveedor solo trae su ID
+	// y nombre
+	ProveedorID *int       `json:"proveedor_id,omitempty"`
+	Proveedor   *Proveedor `json:"proveedor,omitempty"`
+	TrasladoID  *int       `json:"traslado_id,omitempty"`
+	// OrdenCompraID es la orden de compra de una entrada registrada al recibirla
+	OrdenCompraID *int `json:"orden_compra_id,omitempty"`
+	// OrdenVentaID es la orden de venta de una salida registrada al despacharla
+	OrdenVentaID *int `json:"orden_venta_id,omitempty"`
+	// DevolucionID es la devolución de un ajuste que repuso unidades devueltas
+	DevolucionID *int `json:"devolucion_id,omitempty"`
+	// EnsamblajeID es el ensamblaje o desensamblaje que registró el movimiento
+	EnsamblajeID *int `json:"ensamblaje_id,omitempty"`
+	// RevierteID es el movimiento que este compensa; RevertidoPorID, el que
+	// compensa a este
+	RevierteID     *int `json:"revierte_id,omitempty"`
+	RevertidoPorID *int `json:"revertido_por_id,omitempty"`
+	// Lote y Vencimiento solo se usan al registrar el movimiento (ver
+	// MovimientoInventarioRequest); Lotes detalla los lotes que movió
+	Lote        string           `json:"-"`
+	Vencimiento *Fecha           `json:"-"`
+	Lotes       []MovimientoLote `json:"lotes,omitempty"`
+	// Series son los números de serie de las unidades que movió
+	Series    []string  `json:"series,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReversionRequest es el cuerpo opcional de POST /api/movimientos/{id}/revertir
+type ReversionRequest struct {
+	Motivo string `json:"motivo"`
 }
 
 type MovimientoInventarioRequest struct {
 	ProductoID int            `json:"producto_id"`
+	AlmacenID  int            `json:"almacen_id"` // opcional; por defecto el almacén principal
 	Tipo       TipoMovimiento `json:"tipo"`
-	Cantidad   int            `json:"cantidad"`
-	Motivo     string         `json:"motivo"`
+	// Cantidad es mayor a 0 en entradas y salidas; en los ajustes es
+	// distinta de 0 y su signo indica si suma o resta stock
+	Cantidad     int          `json:"cantidad"`
+	CodigoMotivo CodigoMotivo `json:"codigo_motivo"` // requerido en los ajustes
+	Motivo       string       `json:"motivo"`
+	// CostoUnitario es opcional y solo se admite en entradas y ajustes
+	// positivos; sin él se valora el movimiento al costo promedio
+	CostoUnitario *float64 `json:"costo_unitario"`
+	// Moneda (código ISO 4217) es opcional y requiere TipoCambio, las
+	// unidades de moneda local por unidad de Moneda
+	Moneda     string   `json:"moneda"`
+	TipoCambio *float64 `json:"tipo_cambio"`
+	// ProveedorID es opcional y solo se admite en las entradas
+	ProveedorID *int `json:"proveedor_id"`
+	// Lote es requerido en lo que suma stock de un producto con control de
+	// lotes y Vencimiento, opcional, fecha el lote si es nuevo. En lo que
+	// resta el lote es opcional: sin él se toman los que vencen primero.
+	Lote        string `json:"lote"`
+	Vencimiento *Fecha `json:"vencimiento"`
+	// Series son requeridas en los productos con control de series: una por
+	// unidad, de las que entran o de las que salen del almacén
+	Series []string `json:"series"`
 }
-
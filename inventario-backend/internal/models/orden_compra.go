@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+type EstadoOrdenCompra string
+
+const (
+	EstadoOrdenBorrador        EstadoOrdenCompra = "borrador"
+	EstadoOrdenEnviada         EstadoOrdenCompra = "enviada"
+	EstadoOrdenRecibidaParcial EstadoOrdenCompra = "recibida_parcial"
+	EstadoOrdenRecibida        EstadoOrdenCompra = "recibida"
+	EstadoOrdenCancelada       EstadoOrdenCompra = "cancelada"
+)
+
+// OrdenCompra es un pedido a un proveedor. Solo se edita en borrador; una
+// vez enviada, cada recepción registra una entrada por producto en su almacén.
+type OrdenCompra struct {
+	ID          int               `json:"id"`
+	ProveedorID int               `json:"proveedor_id"`
+	Proveedor   string            `json:"proveedor"`
+	AlmacenID   int               `json:"almacen_id"`
+	Estado      EstadoOrdenCompra `json:"estado"`
+	Notas       string            `json:"notas"`
+	// Total es la suma de cantidad por costo unitario de las líneas
+	Total     float64            `json:"total"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	EnviadaAt *time.Time         `json:"enviada_at,omitempty"`
+	CerradaAt *time.Time         `json:"cerrada_at,omitempty"` // recibida por completo o cancelada
+	Lineas    []OrdenCompraLinea `json:"lineas,omitempty"`
+}
+
+// OrdenCompraLinea es un producto de la orden. CantidadPendiente es lo que
+// falta recibir y nunca es negativa, aunque se haya recibido de más.
+type OrdenCompraLinea struct {
+	ProductoID        int       `json:"producto_id"`
+	Producto          *Producto `json:"producto,omitempty"`
+	Cantidad          int       `json:"cantidad"`
+	CostoUnitario     float64   `json:"costo_unitario"` // en moneda local
+	CantidadRecibida  int       `json:"cantidad_recibida"`
+	CantidadPendiente int       `json:"cantidad_pendiente"`
+}
+
+type OrdenCompraRequest struct {
+	ProveedorID int                       `json:"proveedor_id"`
+	AlmacenID   int                       `json:"almacen_id"` // opcional; por defecto el almacén principal
+	Notas       string                    `json:"notas"`
+	Lineas      []OrdenCompraLineaRequest `json:"lineas"`
+}
+
+type OrdenCompraLineaRequest struct {
+	ProductoID    int     `json:"producto_id"`
+	Cantidad      int     `json:"cantidad"`
+	CostoUnitario float64 `json:"costo_unitario"`
+}
+
+// RecepcionRequest registra la mercadería recibida de una orden.
+// PermitirExceso autoriza recibir más de lo pendiente.
+type RecepcionRequest struct {
+	Items          []CantidadRecibida `json:"items"`
+	PermitirExceso bool               `json:"permitir_exceso"`
+}
+
+type CantidadRecibida struct {
+	ProductoID int `json:"producto_id"`
+	Cantidad   int `json:"cantidad"`
+	// Lote y Vencimiento se requieren en los productos con control de lotes
+	Lote        string `json:"lote"`
+	Vencimiento *Fecha `json:"vencimiento"`
+	// Series se requieren en los productos con control de series
+	Series []string `json:"series"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+type EstadoOrdenVenta string
+
+const (
+	EstadoVentaBorrador   EstadoOrdenVenta = "borrador"
+	EstadoVentaConfirmada EstadoOrdenVenta = "confirmada"
+	EstadoVentaDespachada EstadoOrdenVenta = "despachada"
+	EstadoVentaCancelada  EstadoOrdenVenta = "cancelada"
+)
+
+// OrdenVenta es un pedido de un cliente. Solo se edita en borrador; al
+// confirmarla reserva su stock en el almacén y al despacharla convierte las
+// reservas en salidas.
+type OrdenVenta struct {
+	ID        int              `json:"id"`
+	Cliente   string           `json:"cliente"`
+	AlmacenID int              `json:"almacen_id"`
+	Estado    EstadoOrdenVenta `json:"estado"`
+	Notas     string           `json:"notas"`
+	// Total es la suma de cantidad por precio unitario de las líneas
+	Total        float64           `json:"total"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	ConfirmadaAt *time.Time        `json:"confirmada_at,omitempty"`
+	CerradaAt    *time.Time        `json:"cerrada_at,omitempty"` // despachada o cancelada
+	Lineas       []OrdenVentaLinea `json:"lineas,omitempty"`
+}
+
+type OrdenVentaLinea struct {
+	ProductoID     int       `json:"producto_id"`
+	Producto       *Producto `json:"producto,omitempty"`
+	Cantidad       int       `json:"cantidad"`
+	PrecioUnitario float64   `json:"precio_unitario"`
+}
+
+type OrdenVentaRequest struct {
+	Cliente   string                   `json:"cliente"`
+	AlmacenID int                      `json:"almacen_id"` // opcional; por defecto el almacén principal
+	Notas     string                   `json:"notas"`
+	Lineas    []OrdenVentaLineaRequest `json:"lineas"`
+}
+
+type OrdenVentaLineaRequest struct {
+	ProductoID int `json:"producto_id"`
+	Cantidad   int `json:"cantidad"`
+	// PrecioUnitario es opcional; por defecto el precio actual del producto
+	PrecioUnitario *float64 `json:"precio_unitario"`
+}
+
+// DespachoRequest indica las series que salen en el despacho de cada producto
+// con control de series; los demás productos no necesitan items
+type DespachoRequest struct {
+	Items []DespachoItem `json:"items"`
+}
+
+type DespachoItem struct {
+	ProductoID int      `json:"producto_id"`
+	Series     []string `json:"series"`
+}
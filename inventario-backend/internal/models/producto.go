@@ -3,22 +3,89 @@ package models
 import "time"
 
 type Producto struct {
-	ID          int       `json:"id"`
-	Nombre      string    `json:"nombre"`
-	Descripcion string    `json:"descripcion"`
-	Precio      float64   `json:"precio"`
-	Stock       int       `json:"stock"`
-	CategoriaID int       `json:"categoria_id"`
-	Categoria   *Categoria `json:"categoria,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             int            `json:"id"`
+	Nombre         string         `json:"nombre"`
+	Descripcion    string         `json:"descripcion"`
+	SKU            string         `json:"sku,omitempty"`
+	CodigoBarras   string         `json:"codigo_barras,omitempty"`
+	Precio         float64        `json:"precio"`
+	Stock          int            `json:"stock"` // total de todos los almacenes
+	StockAlmacenes []StockAlmacen `json:"stock_almacenes,omitempty"`
+	// Reservado es lo comprometido por órdenes de venta confirmadas y
+	// Disponible, Stock menos Reservado
+	Reservado  int `json:"reservado"`
+	Disponible int `json:"disponible"`
+	// Umbrales de reposición; 0 desactiva cada uno (ver NivelStock)
+	StockMinimo     int          `json:"stock_minimo"`
+	PuntoReorden    int          `json:"punto_reorden"`
+	CantidadReorden int          `json:"cantidad_reorden"` // sugerida al reponer
+	MetodoCosteo    MetodoCosteo `json:"metodo_costeo"`
+	// ControlaLotes exige un lote en cada movimiento que suma stock (ver Lote)
+	ControlaLotes bool `json:"controla_lotes"`
+	// ControlaSeries exige el número de serie de cada unidad que entra o
+	// sale (ver Serie). Un producto no controla lotes y series a la vez.
+	ControlaSeries bool `json:"controla_series"`
+	// ValorInventario es el costo del stock actual en moneda local
+	ValorInventario float64    `json:"valor_inventario"`
+	CategoriaID     int        `json:"categoria_id"`
+	Categoria       *Categoria `json:"categoria,omitempty"`
+	// ProductoPadreID y Atributos (p. ej. talla y color) identifican a una
+	// variante. El stock se mueve en cada variante; el padre no tiene stock
+	// propio y muestra la suma de sus Variantes.
+	ProductoPadreID *int              `json:"producto_padre_id,omitempty"`
+	Atributos       map[string]string `json:"atributos,omitempty"`
+	Variantes       int               `json:"variantes,omitempty"` // número de variantes del padre
+	// HeredaPrecio indica que la variante tiene el precio del padre y lo
+	// sigue cuando cambia
+	HeredaPrecio bool `json:"hereda_precio,omitempty"`
+	// Componentes es la lista de materiales de un kit. Ensamblables son los
+	// kits que se pueden armar con lo disponible de los componentes, sumando
+	// lo que alcanza en cada almacén; solo se informa en los kits.
+	Componentes  []Componente `json:"componentes,omitempty"`
+	Ensamblables *int         `json:"ensamblables,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
 }
 
 type ProductoRequest struct {
-	Nombre      string  `json:"nombre"`
-	Descripcion string  `json:"descripcion"`
-	Precio      float64 `json:"precio"`
-	Stock       int     `json:"stock"`
-	CategoriaID int     `json:"categoria_id"`
+	Nombre      string `json:"nombre"`
+	Descripcion string `json:"descripcion"`
+	// SKU y CodigoBarras son opcionales: al actualizar nil conserva el
+	// actual y "" lo quita
+	SKU          *string `json:"sku"`
+	CodigoBarras *string `json:"codigo_barras"`
+	Precio       float64 `json:"precio"`
+	// Stock inicial, registrado como ajuste en el almacén principal. Se
+	// ignora al actualizar: el stock solo cambia mediante movimientos.
+	Stock int `json:"stock"`
+	// CostoUnitario del stock inicial; opcional y solo se usa al crear
+	CostoUnitario   *float64 `json:"costo_unitario"`
+	StockMinimo     int      `json:"stock_minimo"`
+	PuntoReorden    int      `json:"punto_reorden"`
+	CantidadReorden int      `json:"cantidad_reorden"`
+	// MetodoCosteo es opcional: al crear es promedio por defecto y al
+	// actualizar vacío conserva el actual. Solo cambia sin stock.
+	MetodoCosteo MetodoCosteo `json:"metodo_costeo"`
+	// ControlaLotes es opcional: al crear es false por defecto y al
+	// actualizar nil conserva el actual. Solo cambia sin stock.
+	ControlaLotes *bool `json:"controla_lotes"`
+	// ControlaSeries sigue las mismas reglas que ControlaLotes
+	ControlaSeries *bool `json:"controla_series"`
+	CategoriaID    int   `json:"categoria_id"`
 }
 
+// VarianteRequest crea una variante de un producto. El nombre, la
+// descripción, la categoría, el método de costeo y el control de lotes y
+// series se toman del padre.
+type VarianteRequest struct {
+	Atributos    map[string]string `json:"atributos"`
+	SKU          string            `json:"sku"`
+	CodigoBarras string            `json:"codigo_barras"`
+	// Precio es opcional; sin él la variante hereda el del padre
+	Precio          *float64 `json:"precio"`
+	Stock           int      `json:"stock"`
+	CostoUnitario   *float64 `json:"costo_unitario"`
+	StockMinimo     int      `json:"stock_minimo"`
+	PuntoReorden    int      `json:"punto_reorden"`
+	CantidadReorden int      `json:"cantidad_reorden"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type Proveedor struct {
+	ID        int       `json:"id"`
+	Nombre    string    `json:"nombre"`
+	Contacto  string    `json:"contacto"` // persona de contacto
+	Email     string    `json:"email"`
+	Telefono  string    `json:"telefono"`
+	Direccion string    `json:"direccion"`
+	Notas     string    `json:"notas"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ProveedorRequest struct {
+	Nombre    string `json:"nombre"`
+	Contacto  string `json:"contacto"`
+	Email     string `json:"email"`
+	Telefono  string `json:"telefono"`
+	Direccion string `json:"direccion"`
+	Notas     string `json:"notas"`
+}
+
+// ProductoProveedor indica que el proveedor suministra el producto. Las
+// entradas de un proveedor crean el vínculo si no existe y actualizan
+// UltimoCosto y UltimaEntradaAt.
+type ProductoProveedor struct {
+	ProductoID   int    `json:"producto_id"`
+	Producto     string `json:"producto"`
+	ProveedorID  int    `json:"proveedor_id"`
+	Proveedor    string `json:"proveedor"`
+	SKUProveedor string `json:"sku_proveedor"` // código del producto en el catálogo del proveedor
+	// PlazoEntregaDias son los días que tarda el proveedor en entregar
+	PlazoEntregaDias int `json:"plazo_entrega_dias"`
+	// UltimoCosto es el costo unitario en moneda local de la última compra
+	UltimoCosto     *float64   `json:"ultimo_costo"`
+	UltimaEntradaAt *time.Time `json:"ultima_entrada_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type ProductoProveedorRequest struct {
+	SKUProveedor     string   `json:"sku_proveedor"`
+	PlazoEntregaDias int      `json:"plazo_entrega_dias"`
+	UltimoCosto      *float64 `json:"ultimo_costo"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Serie es una unidad física de un producto con control de series. AlmacenID
+// es el almacén donde está y es nil mientras está fuera del stock: vendida,
+// dada de baja o en un traslado en tránsito.
+type Serie struct {
+	ID         int       `json:"id"`
+	ProductoID int       `json:"producto_id"`
+	Producto   *Producto `json:"producto,omitempty"`
+	Numero     string    `json:"numero"`
+	AlmacenID  *int      `json:"almacen_id,omitempty"`
+	Almacen    *Almacen  `json:"almacen,omitempty"`
+	EnStock    bool      `json:"en_stock"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Movimientos es la historia de la serie, del más antiguo al más
+	// reciente; solo se incluye al rastrearla
+	Movimientos []MovimientoInventario `json:"movimientos,omitempty"`
+}
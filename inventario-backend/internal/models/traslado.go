@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+type EstadoTraslado string
+
+const (
+	EstadoEnTransito EstadoTraslado = "en_transito"
+	EstadoRecibido   EstadoTraslado = "recibido"
+)
+
+// Traslado mueve stock de un almacén a otro. Se registra como una salida en
+// el origen y una entrada en el destino, ambas con el mismo traslado_id.
+type Traslado struct {
+	ID               int            `json:"id"`
+	ProductoID       int            `json:"producto_id"`
+	Producto         *Producto      `json:"producto,omitempty"`
+	AlmacenOrigenID  int            `json:"almacen_origen_id"`
+	AlmacenDestinoID int            `json:"almacen_destino_id"`
+	Cantidad         int            `json:"cantidad"`
+	Estado           EstadoTraslado `json:"estado"`
+	Motivo           string         `json:"motivo"`
+	CreatedAt        time.Time      `json:"created_at"`
+	RecibidoAt       *time.Time     `json:"recibido_at,omitempty"`
+	// Movimientos contiene la salida y, una vez recibido, la entrada
+	Movimientos []MovimientoInventario `json:"movimientos,omitempty"`
+}
+
+type TrasladoRequest struct {
+	ProductoID       int    `json:"producto_id"`
+	AlmacenOrigenID  int    `json:"almacen_origen_id"`
+	AlmacenDestinoID int    `json:"almacen_destino_id"`
+	Cantidad         int    `json:"cantidad"`
+	Motivo           string `json:"motivo"`
+	// Series se requieren en los productos con control de series
+	Series []string `json:"series"`
+	// EnTransito deja el traslado pendiente de recibir en el destino; si es
+	// false la entrada se registra en la misma operación
+	EnTransito bool `json:"en_transito"`
+}
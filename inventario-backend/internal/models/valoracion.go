@@ -0,0 +1,26 @@
+package models
+
+// Valoracion es el valor del inventario agrupado por categoría
+type Valoracion struct {
+	Valor      float64               `json:"valor"`
+	Categorias []ValoracionCategoria `json:"categorias"`
+}
+
+// ValoracionCategoria agrupa los productos de una categoría. CategoriaID es
+// nil para los productos cuya categoría fue eliminada.
+type ValoracionCategoria struct {
+	CategoriaID *int                 `json:"categoria_id"`
+	Categoria   string               `json:"categoria"`
+	Stock       int                  `json:"stock"`
+	Valor       float64              `json:"valor"`
+	Productos   []ValoracionProducto `json:"productos"`
+}
+
+type ValoracionProducto struct {
+	ProductoID    int          `json:"producto_id"`
+	Producto      string       `json:"producto"`
+	MetodoCosteo  MetodoCosteo `json:"metodo_costeo"`
+	Stock         int          `json:"stock"`
+	CostoPromedio float64      `json:"costo_promedio"` // valor / stock
+	Valor         float64      `json:"valor"`
+}
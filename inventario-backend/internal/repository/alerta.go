@@ -0,0 +1,49 @@
+package repository
+
+import "inventario-backend/internal/models"
+
+// AccionAlerta es lo que hay que hacer con la alerta de un producto tras un
+// cambio de stock
+type AccionAlerta int
+
+const (
+	AlertaSinCambios AccionAlerta = iota
+	// AlertaGenerar: el producto cruzó un umbral y no tenía alerta abierta
+	AlertaGenerar
+	// AlertaEscalar: la alerta abierta pasa de reorden a mínimo y vuelve a
+	// estar activa aunque se hubiera reconocido
+	AlertaEscalar
+	// AlertaResolver: el stock volvió a superar los umbrales
+	AlertaResolver
+)
+
+// EvaluarAlerta compara el stock del producto con sus umbrales teniendo en
+// cuenta su alerta sin resolver (nil si no tiene). Para AlertaGenerar y
+// AlertaEscalar devuelve también la alerta con los datos actuales. Una
+// alerta abierta no se repite mientras el stock siga bajo el mismo umbral.
+func EvaluarAlerta(p models.Producto, abierta *models.Alerta) (AccionAlerta, models.Alerta) {
+	nivel, umbral := models.NivelStock(p)
+	if nivel == "" {
+		if abierta != nil {
+			return AlertaResolver, models.Alerta{}
+		}
+		return AlertaSinCambios, models.Alerta{}
+	}
+
+	accion := AlertaGenerar
+	if abierta != nil {
+		if abierta.Nivel == models.NivelMinimo || nivel == models.NivelReorden {
+			return AlertaSinCambios, models.Alerta{}
+		}
+		accion = AlertaEscalar
+	}
+	return accion, models.Alerta{
+		ProductoID:      p.ID,
+		Producto:        p.Nombre,
+		Nivel:           nivel,
+		Estado:          models.EstadoAlertaActiva,
+		Stock:           p.Stock,
+		Umbral:          umbral,
+		CantidadReorden: p.CantidadReorden,
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"testing"
+)
+
+func TestEvaluarAlerta(t *testing.T) {
+	producto := func(stock int) models.Producto {
+		return models.Producto{ID: 1, Stock: stock, StockMinimo: 10, PuntoReorden: 40, CantidadReorden: 60}
+	}
+	abierta := func(nivel models.NivelAlerta) *models.Alerta {
+		return &models.Alerta{ID: 7, ProductoID: 1, Nivel: nivel, Estado: models.EstadoAlertaReconocida}
+	}
+
+	casos := []struct {
+		nombre  string
+		stock   int
+		abierta *models.Alerta
+		accion  AccionAlerta
+		nivel   models.NivelAlerta
+	}{
+		{"sobre los umbrales", 41, nil, AlertaSinCambios, ""},
+		{"llega al punto de reorden", 40, nil, AlertaGenerar, models.NivelReorden},
+		{"bajo el mínimo sin alerta", 9, nil, AlertaGenerar, models.NivelMinimo},
+		{"sigue en reorden", 30, abierta(models.NivelReorden), AlertaSinCambios, ""},
+		{"pasa de reorden a mínimo", 9, abierta(models.NivelReorden), AlertaEscalar, models.NivelMinimo},
+		{"vuelve de mínimo a reorden", 20, abierta(models.NivelMinimo), AlertaSinCambios, ""},
+		{"se recupera", 41, abierta(models.NivelMinimo), AlertaResolver, ""},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			accion, a := EvaluarAlerta(producto(c.stock), c.abierta)
+			if accion != c.accion || a.Nivel != c.nivel {
+				t.Errorf("acción %v con nivel %q, se esperaba %v con nivel %q", accion, a.Nivel, c.accion, c.nivel)
+			}
+			if accion == AlertaGenerar && (a.Stock != c.stock || a.CantidadReorden != 60 || a.Estado != models.EstadoAlertaActiva) {
+				t.Errorf("alerta generada = %+v", a)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"inventario-backend/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAjusteConteo(t *testing.T) {
+	enero := &models.Fecha{Time: time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)}
+	febrero := &models.Fecha{Time: time.Date(2030, 2, 28, 0, 0, 0, 0, time.UTC)}
+	casos := []struct {
+		nombre      string
+		registros   []models.ConteoRegistro
+		lote        string
+		vencimiento *models.Fecha
+		series      []string
+		err         error
+	}{
+		{"sin lote ni series", []models.ConteoRegistro{{Pasada: 1, Cantidad: 3}}, "", nil, nil, nil},
+		{"lote de un contador", []models.ConteoRegistro{
+			{Pasada: 1, Contador: "ana", Lote: "L1", Vencimiento: enero},
+			{Pasada: 1, Contador: "luis"},
+		}, "L1", enero, nil, nil},
+		{"solo vale la última pasada", []models.ConteoRegistro{
+			{Pasada: 1, Lote: "L1", Series: []string{"A"}},
+			{Pasada: 2, Lote: "L2", Series: []string{"B"}},
+		}, "L2", nil, []string{"B"}, nil},
+		{"une las series de los contadores", []models.ConteoRegistro{
+			{Pasada: 1, Contador: "ana", Series: []string{"A"}},
+			{Pasada: 1, Contador: "luis", Series: []string{"B", "C"}},
+		}, "", nil, []string{"A", "B", "C"}, nil},
+		{"lotes distintos", []models.ConteoRegistro{
+			{Pasada: 1, Contador: "ana", Lote: "L1"},
+			{Pasada: 1, Contador: "luis", Lote: "L2"},
+		}, "", nil, nil, ErrLoteInvalido},
+		{"vencimientos distintos", []models.ConteoRegistro{
+			{Pasada: 1, Contador: "ana", Lote: "L1", Vencimiento: enero},
+			{Pasada: 1, Contador: "luis", Lote: "L1", Vencimiento: febrero},
+		}, "", nil, nil, ErrLoteInvalido},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			diferencia := 2
+			conteo := &models.Conteo{ID: 4, AlmacenID: 2}
+			m, err := AjusteConteo(conteo, models.ConteoItem{ProductoID: 7, Diferencia: &diferencia, Registros: c.registros})
+			if !errors.Is(err, c.err) {
+				t.Fatalf("AjusteConteo devolvió %v, se esperaba %v", err, c.err)
+			}
+			if err != nil {
+				return
+			}
+			if m.ProductoID != 7 || m.AlmacenID != 2 || m.Tipo != models.TipoAjuste || m.Cantidad != 2 ||
+				m.CodigoMotivo != models.MotivoConteo || m.Motivo != MotivoConteo(4) {
+				t.Errorf("ajuste = %+v, se esperaba un ajuste de 2 del conteo 4", m)
+			}
+			if m.Lote != c.lote || !reflect.DeepEqual(m.Vencimiento, c.vencimiento) || !reflect.DeepEqual(m.Series, c.series) {
+				t.Errorf("lote %q, vencimiento %v y series %v; se esperaban %q, %v y %v",
+					m.Lote, m.Vencimiento, m.Series, c.lote, c.vencimiento, c.series)
+			}
+		})
+	}
+}
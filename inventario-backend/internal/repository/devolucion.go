@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"fmt"
+	"inventario-backend/internal/models"
+)
+
+// EstadoInicialDevolucion devuelve el estado en que queda un item devuelto
+// con la condición y el destino indicados, o false si la combinación no es
+// válida. Las unidades reutilizables se reponen al stock; las dañadas van a
+// cuarentena o, si se indica, se dan de baja; las que se devolverán al
+// proveedor esperan en cuarentena.
+func EstadoInicialDevolucion(condicion models.CondicionDevolucion, destino models.EstadoItemDevolucion) (models.EstadoItemDevolucion, bool) {
+	switch condicion {
+	case models.CondicionReutilizable:
+		return models.EstadoItemRepuesto, destino == "" || destino == models.EstadoItemRepuesto
+	case models.CondicionDanado:
+		switch destino {
+		case "", models.EstadoItemCuarentena:
+			return models.EstadoItemCuarentena, true
+		case models.EstadoItemBaja:
+			return models.EstadoItemBaja, true
+		}
+	case models.CondicionDevolverProveedor:
+		return models.EstadoItemCuarentena, destino == "" || destino == models.EstadoItemCuarentena
+	}
+	return "", false
+}
+
+// ResolucionValida indica si un item en cuarentena puede pasar al estado
+func ResolucionValida(estado models.EstadoItemDevolucion) bool {
+	switch estado {
+	case models.EstadoItemRepuesto, models.EstadoItemBaja, models.EstadoItemDevueltoProveedor:
+		return true
+	}
+	return false
+}
+
+// ValidarDevolucion verifica que cada producto devuelto sea de la venta y que
+// lo devuelto, sumando devoluciones anteriores, no supere lo vendido. vendido
+// y devuelto están indexados por producto.
+func ValidarDevolucion(vendido, devuelto map[int]int, items []models.DevolucionItemRequest) error {
+	nuevo := make(map[int]int, len(items))
+	for _, item := range items {
+		if _, ok := vendido[item.ProductoID]; !ok {
+			return ErrOrigenDevolucion
+		}
+		nuevo[item.ProductoID] += item.Cantidad
+	}
+	for productoID, cantidad := range nuevo {
+		if devuelto[productoID]+cantidad > vendido[productoID] {
+			return ErrDevolucionExcedida
+		}
+	}
+	return nil
+}
+
+// CostoVenta devuelve el costo unitario con que salió la venta original, o
+// nil si no se valoró
+func CostoVenta(salida models.MovimientoInventario) *float64 {
+	if salida.CostoTotal == nil || salida.Cantidad == 0 {
+		return nil
+	}
+	costo := *salida.CostoTotal / float64(salida.Cantidad)
+	return &costo
+}
+
+// MotivoDevolucion es el motivo de los ajustes que reponen la devolución
+func MotivoDevolucion(id int) string {
+	return fmt.Sprintf("Devolución #%d", id)
+}
+
+// Reposicion es el ajuste que devuelve al stock las unidades del item, al
+// costo de la venta original si se conoce
+func Reposicion(d models.Devolucion, item models.DevolucionItem, costo *float64) models.MovimientoInventario {
+	return models.MovimientoInventario{
+		ProductoID:    item.ProductoID,
+		AlmacenID:     d.AlmacenID,
+		Tipo:          models.TipoAjuste,
+		Cantidad:      item.Cantidad,
+		CodigoMotivo:  models.MotivoDevolucion,
+		Motivo:        MotivoDevolucion(d.ID),
+		CostoUnitario: costo,
+		DevolucionID:  &d.ID,
+		Series:        item.Series,
+	}
+}
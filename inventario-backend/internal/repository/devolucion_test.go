@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"testing"
+)
+
+func TestValidarDevolucion(t *testing.T) {
+	vendido := map[int]int{1: 10, 2: 3}
+	devuelto := map[int]int{1: 4}
+	item := func(productoID, cantidad int) models.DevolucionItemRequest {
+		return models.DevolucionItemRequest{ProductoID: productoID, Cantidad: cantidad, Condicion: models.CondicionReutilizable}
+	}
+
+	casos := []struct {
+		nombre string
+		items  []models.DevolucionItemRequest
+		err    error
+	}{
+		{"devuelve lo pendiente", []models.DevolucionItemRequest{item(1, 6), item(2, 3)}, nil},
+		{"producto ajeno", []models.DevolucionItemRequest{item(9, 1)}, ErrOrigenDevolucion},
+		{"supera lo vendido", []models.DevolucionItemRequest{item(2, 4)}, ErrDevolucionExcedida},
+		{"suma devoluciones anteriores", []models.DevolucionItemRequest{item(1, 7)}, ErrDevolucionExcedida},
+		// Un producto puede venir en varias condiciones; cuenta el total
+		{"suma items del mismo producto", []models.DevolucionItemRequest{item(1, 4), item(1, 3)}, ErrDevolucionExcedida},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if err := ValidarDevolucion(vendido, devuelto, c.items); err != c.err {
+				t.Errorf("error %v, se esperaba %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestEstadoInicialDevolucion(t *testing.T) {
+	casos := []struct {
+		condicion models.CondicionDevolucion
+		destino   models.EstadoItemDevolucion
+		estado    models.EstadoItemDevolucion
+		ok        bool
+	}{
+		{models.CondicionReutilizable, "", models.EstadoItemRepuesto, true},
+		{models.CondicionReutilizable, models.EstadoItemBaja, "", false},
+		{models.CondicionDanado, "", models.EstadoItemCuarentena, true},
+		{models.CondicionDanado, models.EstadoItemBaja, models.EstadoItemBaja, true},
+		{models.CondicionDanado, models.EstadoItemRepuesto, "", false},
+		{models.CondicionDevolverProveedor, "", models.EstadoItemCuarentena, true},
+		{models.CondicionDevolverProveedor, models.EstadoItemBaja, "", false},
+	}
+	for _, c := range casos {
+		estado, ok := EstadoInicialDevolucion(c.condicion, c.destino)
+		if ok != c.ok || (ok && estado != c.estado) {
+			t.Errorf("%s/%q: estado %q ok %v, se esperaba %q ok %v", c.condicion, c.destino, estado, ok, c.estado, c.ok)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"fmt"
+	"inventario-backend/internal/models"
+)
+
+// MotivoEnsamblaje es el motivo de los movimientos de un ensamblaje o
+// desensamblaje
+func MotivoEnsamblaje(e models.Ensamblaje) string {
+	operacion := "Ensamblaje"
+	if e.Tipo == models.TipoDesensamblar {
+		operacion = "Desensamblaje"
+	}
+	if e.Motivo == "" {
+		return fmt.Sprintf("%s #%d", operacion, e.ID)
+	}
+	return fmt.Sprintf("%s #%d: %s", operacion, e.ID, e.Motivo)
+}
+
+// MovimientosEnsamblaje arma, sin valorar, las salidas de lo que consume el
+// ensamblaje e y las entradas de lo que obtiene: los componentes y el kit al
+// ensamblar y al revés al desensamblar
+func MovimientosEnsamblaje(e models.Ensamblaje, componentes []models.Componente) (salidas, entradas []models.MovimientoInventario) {
+	movimiento := func(tipo models.TipoMovimiento, productoID, cantidad int) models.MovimientoInventario {
+		return models.MovimientoInventario{
+			ProductoID:   productoID,
+			AlmacenID:    e.AlmacenID,
+			Tipo:         tipo,
+			Cantidad:     cantidad,
+			Motivo:       MotivoEnsamblaje(e),
+			EnsamblajeID: &e.ID,
+		}
+	}
+
+	consumido, obtenido := models.TipoSalida, models.TipoEntrada
+	if e.Tipo == models.TipoDesensamblar {
+		consumido, obtenido = obtenido, consumido
+	}
+	var partes []models.MovimientoInventario
+	for _, c := range componentes {
+		partes = append(partes, movimiento(consumido, c.ProductoID, c.Cantidad*e.Cantidad))
+	}
+	kit := movimiento(obtenido, e.ProductoID, e.Cantidad)
+
+	if e.Tipo == models.TipoDesensamblar {
+		return []models.MovimientoInventario{kit}, partes
+	}
+	return partes, []models.MovimientoInventario{kit}
+}
+
+// CostosEnsamblaje asigna a cada entrada un costo unitario que reparte entre
+// ellas el costo total de las salidas en proporción a costos, el costo
+// unitario de referencia de cada producto, para que el ensamblaje no cree ni
+// destruya valor. Si ninguno tiene costo de referencia, todas las unidades
+// valen lo mismo.
+func CostosEnsamblaje(total float64, entradas []models.MovimientoInventario, costos []float64) {
+	var base, unidades float64
+	for i, m := range entradas {
+		base += costos[i] * float64(m.Cantidad)
+		unidades += float64(m.Cantidad)
+	}
+	for i := range entradas {
+		costo := total / unidades
+		if base > 0 {
+			costo = total * costos[i] / base
+		}
+		entradas[i].CostoUnitario = &costo
+	}
+}
+
+// Ensamblables devuelve cuántos kits se pueden armar con componentes sumando
+// lo que alcanza en cada almacén, sin mezclar unidades de almacenes
+// distintos. disponible indica por almacén lo disponible de cada producto.
+func Ensamblables(componentes []models.Componente, disponible map[int]map[int]int) int {
+	total := 0
+	for _, almacen := range disponible {
+		kits := -1
+		for _, c := range componentes {
+			n := max(almacen[c.ProductoID], 0) / c.Cantidad
+			if kits < 0 || n < kits {
+				kits = n
+			}
+		}
+		total += max(kits, 0)
+	}
+	return total
+}
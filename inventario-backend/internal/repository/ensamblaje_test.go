@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"math"
+	"testing"
+)
+
+func TestMotivoEnsamblaje(t *testing.T) {
+	casos := []struct {
+		e    models.Ensamblaje
+		want string
+	}{
+		{models.Ensamblaje{ID: 1, Tipo: models.TipoEnsamblar}, "Ensamblaje #1"},
+		{models.Ensamblaje{ID: 2, Tipo: models.TipoDesensamblar, Motivo: "falla"}, "Desensamblaje #2: falla"},
+	}
+	for _, c := range casos {
+		if got := MotivoEnsamblaje(c.e); got != c.want {
+			t.Errorf("MotivoEnsamblaje = %q, se esperaba %q", got, c.want)
+		}
+	}
+}
+
+func TestMovimientosEnsamblaje(t *testing.T) {
+	componentes := []models.Componente{{ProductoID: 1, Cantidad: 1}, {ProductoID: 2, Cantidad: 2}}
+
+	e := models.Ensamblaje{ID: 7, Tipo: models.TipoEnsamblar, ProductoID: 5, AlmacenID: 3, Cantidad: 3}
+	salidas, entradas := MovimientosEnsamblaje(e, componentes)
+	if len(salidas) != 2 || len(entradas) != 1 {
+		t.Fatalf("MovimientosEnsamblaje = %d salidas y %d entradas", len(salidas), len(entradas))
+	}
+	if s := salidas[1]; s.ProductoID != 2 || s.Tipo != models.TipoSalida || s.Cantidad != 6 || s.AlmacenID != 3 {
+		t.Errorf("salida del componente = %+v", s)
+	}
+	if m := entradas[0]; m.ProductoID != 5 || m.Tipo != models.TipoEntrada || m.Cantidad != 3 || m.EnsamblajeID == nil || *m.EnsamblajeID != 7 {
+		t.Errorf("entrada del kit = %+v", m)
+	}
+
+	e.Tipo = models.TipoDesensamblar
+	salidas, entradas = MovimientosEnsamblaje(e, componentes)
+	if len(salidas) != 1 || salidas[0].ProductoID != 5 || salidas[0].Tipo != models.TipoSalida {
+		t.Errorf("desensamblaje: salidas = %+v", salidas)
+	}
+	if len(entradas) != 2 || entradas[1].Cantidad != 6 || entradas[1].Tipo != models.TipoEntrada {
+		t.Errorf("desensamblaje: entradas = %+v", entradas)
+	}
+}
+
+func TestCostosEnsamblaje(t *testing.T) {
+	casos := []struct {
+		total      float64
+		cantidades []int
+		costos     []float64
+		want       []float64
+	}{
+		// El valor consumido se reparte según el costo de referencia
+		{120, []int{1, 2}, []float64{100, 10}, []float64{100, 10}},
+		{60, []int{1, 2}, []float64{100, 10}, []float64{50, 5}},
+		// Sin costo de referencia todas las unidades valen lo mismo
+		{90, []int{1, 2}, []float64{0, 0}, []float64{30, 30}},
+		{0, []int{3}, []float64{40}, []float64{0}},
+	}
+	for _, c := range casos {
+		entradas := make([]models.MovimientoInventario, len(c.cantidades))
+		for i, n := range c.cantidades {
+			entradas[i].Cantidad = n
+		}
+		CostosEnsamblaje(c.total, entradas, c.costos)
+		for i, m := range entradas {
+			if m.CostoUnitario == nil || math.Abs(*m.CostoUnitario-c.want[i]) > 1e-9 {
+				t.Errorf("CostosEnsamblaje(%v, %v)[%d] = %v, se esperaba %v", c.total, c.costos, i, m.CostoUnitario, c.want[i])
+			}
+		}
+	}
+}
+
+func TestEnsamblables(t *testing.T) {
+	componentes := []models.Componente{{ProductoID: 1, Cantidad: 1}, {ProductoID: 2, Cantidad: 2}}
+	casos := []struct {
+		disponible map[int]map[int]int
+		want       int
+	}{
+		{map[int]map[int]int{1: {1: 10, 2: 50}}, 10},
+		{map[int]map[int]int{1: {1: 10, 2: 5}}, 2},
+		// Las unidades de almacenes distintos no se combinan
+		{map[int]map[int]int{1: {1: 3}, 2: {2: 8}}, 0},
+		{map[int]map[int]int{1: {1: 3, 2: 4}, 2: {1: 1, 2: 9}}, 3},
+		// Lo reservado puede dejar el disponible en negativo
+		{map[int]map[int]int{1: {1: -2, 2: 4}}, 0},
+		{nil, 0},
+	}
+	for _, c := range casos {
+		if got := Ensamblables(componentes, c.disponible); got != c.want {
+			t.Errorf("Ensamblables(%v) = %d, se esperaba %d", c.disponible, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"math"
+	"time"
+)
+
+// KardexFiltro acota el kardex a un rango de fechas; desde es inclusivo y
+// hasta exclusivo. Los movimientos anteriores a desde forman el saldo inicial.
+// Sin Metodo se usa el método de costeo del producto.
+type KardexFiltro struct {
+	Desde  *time.Time
+	Hasta  *time.Time
+	Metodo models.MetodoCosteo
+}
+
+// capaCosto es un grupo de unidades con el mismo costo, en el orden en que
+// entraron
+type capaCosto struct {
+	movimientoID int
+	cantidad     int
+	costo        float64
+}
+
+// costeo lleva la existencia valorada de un producto mientras se recorren sus
+// movimientos en orden
+type costeo struct {
+	metodo   models.MetodoCosteo
+	cantidad int
+	valor    float64
+	capas    []capaCosto // solo con FIFO
+	// costos guarda el costo unitario asignado a cada movimiento y
+	// consumidas las capas que se llevó cada salida con FIFO, para que las
+	// reversiones y los traslados devuelvan las unidades a su costo
+	costos     map[int]float64
+	consumidas map[int][]capaCosto
+	// salidaTraslado es el tramo de salida de cada traslado
+	salidaTraslado map[int]int
+	ultimoCosto    float64
+}
+
+func (c *costeo) costoPromedio() float64 {
+	if c.cantidad <= 0 {
+		return c.ultimoCosto
+	}
+	return c.valor / float64(c.cantidad)
+}
+
+// origen devuelve el movimiento cuyas unidades vuelven con m: el revertido o
+// el tramo de salida del traslado. Devuelve 0 si no hay ninguno.
+func (c *costeo) origen(m models.MovimientoInventario) int {
+	if m.RevierteID != nil {
+		return *m.RevierteID
+	}
+	if m.TrasladoID != nil {
+		return c.salidaTraslado[*m.TrasladoID]
+	}
+	return 0
+}
+
+// entrar suma las unidades y devuelve su costo unitario y si es estimado
+func (c *costeo) entrar(m models.MovimientoInventario, cantidad int) (float64, bool) {
+	costo, estimado := c.costoPromedio(), true
+	origen := c.origen(m)
+	if m.CostoUnitario != nil {
+		costo, estimado = CostoLocal(m), false
+	} else if o, ok := c.costos[origen]; ok {
+		costo, estimado = o, false
+	}
+
+	c.cantidad += cantidad
+	c.valor += float64(cantidad) * costo
+	c.costos[m.ID] = costo
+	if costo > 0 {
+		c.ultimoCosto = costo
+	}
+
+	if c.metodo == models.MetodoFIFO {
+		// Las unidades que vuelven de una salida recuperan sus capas al
+		// frente, porque son las más antiguas
+		if capas, ok := c.consumidas[origen]; ok && m.CostoUnitario == nil && sumaCapas(capas) == cantidad {
+			c.capas = append(append([]capaCosto{}, capas...), c.capas...)
+		} else {
+			c.capas = append(c.capas, capaCosto{movimientoID: m.ID, cantidad: cantidad, costo: costo})
+		}
+	}
+	return costo, estimado
+}
+
+// salir resta las unidades y devuelve su costo unitario
+func (c *costeo) salir(m models.MovimientoInventario, cantidad int) float64 {
+	var total float64
+	switch {
+	case m.RevierteID != nil && c.metodo != models.MetodoFIFO:
+		// Revertir una entrada la retira a su propio costo
+		total = float64(cantidad) * c.costos[*m.RevierteID]
+	case c.metodo == models.MetodoFIFO:
+		total = c.consumir(m, cantidad)
+	default:
+		total = float64(cantidad) * c.costoPromedio()
+	}
+
+	c.cantidad -= cantidad
+	c.valor -= total
+	if c.cantidad == 0 {
+		// Evitar que los redondeos dejen valor sin existencias
+		c.valor = 0
+	}
+	costo := total / float64(cantidad)
+	c.costos[m.ID] = costo
+	if m.TrasladoID != nil {
+		c.salidaTraslado[*m.TrasladoID] = m.ID
+	}
+	return costo
+}
+
+// consumir retira las unidades de las capas más antiguas, o primero de la
+// capa de la entrada que se revierte, y devuelve su costo total
+func (c *costeo) consumir(m models.MovimientoInventario, cantidad int) float64 {
+	var total float64
+	var consumidas []capaCosto
+	tomar := func(i, n int) {
+		total += float64(n) * c.capas[i].costo
+		consumidas = append(consumidas, capaCosto{movimientoID: c.capas[i].movimientoID, cantidad: n, costo: c.capas[i].costo})
+		c.capas[i].cantidad -= n
+		cantidad -= n
+	}
+
+	if m.RevierteID != nil {
+		for i := range c.capas {
+			if c.capas[i].movimientoID == *m.RevierteID {
+				tomar(i, min(cantidad, c.capas[i].cantidad))
+				break
+			}
+		}
+	}
+	for i := 0; i < len(c.capas) && cantidad > 0; i++ {
+		if c.capas[i].cantidad > 0 {
+			tomar(i, min(cantidad, c.capas[i].cantidad))
+		}
+	}
+	// Sin capas suficientes (stock previo al registro de costos) el resto se
+	// valora al último costo conocido
+	if cantidad > 0 {
+		total += float64(cantidad) * c.ultimoCosto
+	}
+
+	vigentes := c.capas[:0]
+	for _, capa := range c.capas {
+		if capa.cantidad > 0 {
+			vigentes = append(vigentes, capa)
+		}
+	}
+	c.capas = vigentes
+	c.consumidas[m.ID] = consumidas
+	return total
+}
+
+func (c *costeo) saldo() models.KardexSaldo {
+	s := models.KardexSaldo{Cantidad: c.cantidad, Valor: redondear(c.valor, 2)}
+	if c.cantidad != 0 {
+		s.CostoUnitario = redondear(c.valor/float64(c.cantidad), 4)
+	}
+	return s
+}
+
+func sumaCapas(capas []capaCosto) int {
+	total := 0
+	for _, c := range capas {
+		total += c.cantidad
+	}
+	return total
+}
+
+func redondear(v float64, decimales int) float64 {
+	f := math.Pow(10, float64(decimales))
+	return math.Round(v*f) / f
+}
+
+// CalcularKardex arma el kardex del producto a partir de todos sus
+// movimientos anteriores a filtro.Hasta, ordenados por fecha e ID. Los
+// movimientos anteriores a filtro.Desde solo cuentan para el saldo inicial.
+func CalcularKardex(p models.Producto, movimientos []models.MovimientoInventario, filtro KardexFiltro) *models.Kardex {
+	metodo := filtro.Metodo
+	if metodo == "" {
+		metodo = p.MetodoCosteo
+	}
+	if metodo == "" {
+		metodo = models.MetodoPromedio
+	}
+	k := &models.Kardex{
+		ProductoID: p.ID,
+		Producto:   p.Nombre,
+		Metodo:     metodo,
+		Desde:      filtro.Desde,
+		Hasta:      filtro.Hasta,
+		Lineas:     []models.KardexLinea{},
+	}
+	c := &costeo{
+		metodo:         metodo,
+		costos:         make(map[int]float64),
+		consumidas:     make(map[int][]capaCosto),
+		salidaTraslado: make(map[int]int),
+	}
+
+	inicial := false
+	for _, m := range movimientos {
+		if filtro.Hasta != nil && !m.CreatedAt.Before(*filtro.Hasta) {
+			break
+		}
+		enRango := filtro.Desde == nil || !m.CreatedAt.Before(*filtro.Desde)
+		if enRango && !inicial {
+			k.SaldoInicial = c.saldo()
+			inicial = true
+		}
+
+		l := models.KardexLinea{
+			MovimientoID: m.ID,
+			Fecha:        m.CreatedAt,
+			Tipo:         m.Tipo,
+			CodigoMotivo: m.CodigoMotivo,
+			Motivo:       m.Motivo,
+			AlmacenID:    m.AlmacenID,
+		}
+		if m.Almacen != nil {
+			l.Almacen = m.Almacen.Nombre
+		}
+
+		var costo float64
+		if efecto := EfectoStock(m); efecto > 0 {
+			l.Entrada = efecto
+			costo, l.CostoEstimado = c.entrar(m, efecto)
+			l.CostoTotal = redondear(float64(efecto)*costo, 2)
+		} else {
+			l.Salida = -efecto
+			costo = c.salir(m, -efecto)
+			l.CostoTotal = redondear(float64(-efecto)*costo, 2)
+		}
+		l.CostoUnitario = redondear(costo, 4)
+		l.Saldo = c.saldo()
+
+		if enRango {
+			k.Lineas = append(k.Lineas, l)
+		}
+	}
+	if !inicial {
+		k.SaldoInicial = c.saldo()
+	}
+	k.SaldoFinal = c.saldo()
+	return k
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCalcularKardex(t *testing.T) {
+	inicio := time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)
+	costo := func(v float64) *float64 { return &v }
+	id := func(v int) *int { return &v }
+	movimientos := []models.MovimientoInventario{
+		{ID: 1, Tipo: models.TipoAjuste, Cantidad: 10, CodigoMotivo: models.MotivoInicial, CostoUnitario: costo(2)},
+		{ID: 2, Tipo: models.TipoEntrada, Cantidad: 10, CostoUnitario: costo(4)},
+		{ID: 3, Tipo: models.TipoSalida, Cantidad: 15},
+		{ID: 4, Tipo: models.TipoEntrada, Cantidad: 15, RevierteID: id(3)},
+		{ID: 5, Tipo: models.TipoSalida, Cantidad: 12, TrasladoID: id(1)},
+		{ID: 6, Tipo: models.TipoEntrada, Cantidad: 12, TrasladoID: id(1)},
+		{ID: 7, Tipo: models.TipoSalida, Cantidad: 8},
+		{ID: 8, Tipo: models.TipoAjuste, Cantidad: 2, CodigoMotivo: models.MotivoHallazgo},
+	}
+	for i := range movimientos {
+		movimientos[i].CreatedAt = inicio.Add(time.Duration(i) * time.Hour)
+	}
+
+	casos := []struct {
+		metodo       models.MetodoCosteo
+		costoSalidas []float64 // costo total de los movimientos 3, 5 y 7
+		final        models.KardexSaldo
+	}{
+		{models.MetodoPromedio, []float64{45, 36, 24}, models.KardexSaldo{Cantidad: 14, CostoUnitario: 3, Valor: 42}},
+		{models.MetodoFIFO, []float64{40, 28, 16}, models.KardexSaldo{Cantidad: 14, CostoUnitario: 3.6667, Valor: 51.33}},
+	}
+	for _, c := range casos {
+		t.Run(string(c.metodo), func(t *testing.T) {
+			k := CalcularKardex(models.Producto{ID: 1}, movimientos, KardexFiltro{Metodo: c.metodo})
+			salidas := []float64{k.Lineas[2].CostoTotal, k.Lineas[4].CostoTotal, k.Lineas[6].CostoTotal}
+			for i := range salidas {
+				if salidas[i] != c.costoSalidas[i] {
+					t.Errorf("costo de las salidas = %v, se esperaba %v", salidas, c.costoSalidas)
+					break
+				}
+			}
+			if !k.Lineas[7].CostoEstimado {
+				t.Error("el hallazgo sin costo debería quedar marcado como estimado")
+			}
+			if k.SaldoFinal != c.final {
+				t.Errorf("saldo final = %+v, se esperaba %+v", k.SaldoFinal, c.final)
+			}
+		})
+	}
+
+	t.Run("rango", func(t *testing.T) {
+		desde, hasta := inicio.Add(2*time.Hour), inicio.Add(4*time.Hour)
+		k := CalcularKardex(models.Producto{ID: 1}, movimientos, KardexFiltro{Desde: &desde, Hasta: &hasta})
+		if len(k.Lineas) != 2 || k.Lineas[0].MovimientoID != 3 {
+			t.Fatalf("líneas = %+v, se esperaban los movimientos 3 y 4", k.Lineas)
+		}
+		if k.SaldoInicial != (models.KardexSaldo{Cantidad: 20, CostoUnitario: 3, Valor: 60}) {
+			t.Errorf("saldo inicial = %+v", k.SaldoInicial)
+		}
+		if k.SaldoFinal != k.Lineas[1].Saldo {
+			t.Errorf("saldo final = %+v, se esperaba el de la última línea", k.SaldoFinal)
+		}
+	})
+}
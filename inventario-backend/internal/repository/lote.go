@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"sort"
+)
+
+// AntesFEFO indica si el lote a sale antes que b: primero el que vence antes,
+// al final los que no vencen y, a igual vencimiento, el más antiguo
+func AntesFEFO(a, b models.Lote) bool {
+	switch {
+	case a.Vencimiento == nil && b.Vencimiento == nil:
+	case a.Vencimiento == nil:
+		return false
+	case b.Vencimiento == nil:
+		return true
+	case !a.Vencimiento.Equal(b.Vencimiento.Time):
+		return a.Vencimiento.Before(b.Vencimiento.Time)
+	}
+	return a.ID < b.ID
+}
+
+// PlanFEFO reparte la cantidad entre los lotes con stock, del que vence
+// primero al último. Devuelve también la cantidad que no cubren.
+func PlanFEFO(lotes []models.Lote, cantidad int) ([]models.MovimientoLote, int) {
+	ordenados := append([]models.Lote(nil), lotes...)
+	sort.Slice(ordenados, func(i, j int) bool { return AntesFEFO(ordenados[i], ordenados[j]) })
+
+	var plan []models.MovimientoLote
+	for _, l := range ordenados {
+		if cantidad == 0 {
+			break
+		}
+		if l.Cantidad <= 0 {
+			continue
+		}
+		n := min(cantidad, l.Cantidad)
+		cantidad -= n
+		plan = append(plan, models.MovimientoLote{LoteID: l.ID, Codigo: l.Codigo, Vencimiento: l.Vencimiento, Cantidad: n})
+	}
+	return plan, cantidad
+}
+
+// PlanOrigen reparte la cantidad entre los lotes que movió el movimiento
+// origen (el revertido, la salida del traslado o la venta devuelta), sin
+// contar lo que ya volvió de cada uno según usado, indexado por lote.
+// Devuelve también la cantidad que no cubren.
+func PlanOrigen(origen []models.MovimientoLote, usado map[int]int, cantidad int) ([]models.MovimientoLote, int) {
+	var plan []models.MovimientoLote
+	for _, l := range origen {
+		if cantidad == 0 {
+			break
+		}
+		n := min(cantidad, l.Cantidad-usado[l.LoteID])
+		if n <= 0 {
+			continue
+		}
+		cantidad -= n
+		l.Cantidad = n
+		plan = append(plan, l)
+	}
+	return plan, cantidad
+}
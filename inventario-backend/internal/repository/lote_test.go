@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanFEFO(t *testing.T) {
+	fecha := func(s string) *models.Fecha {
+		f, _ := time.Parse(models.FormatoFecha, s)
+		return &models.Fecha{Time: f}
+	}
+	lotes := []models.Lote{
+		{ID: 1, Codigo: "SIN-VENC", Cantidad: 10},
+		{ID: 2, Codigo: "L-MAR", Vencimiento: fecha("2026-03-01"), Cantidad: 4},
+		{ID: 3, Codigo: "L-ENE", Vencimiento: fecha("2026-01-15"), Cantidad: 0},
+		{ID: 4, Codigo: "L-FEB", Vencimiento: fecha("2026-02-01"), Cantidad: 3},
+	}
+
+	plan, resto := PlanFEFO(lotes, 9)
+	var got []int
+	for _, l := range plan {
+		got = append(got, l.LoteID, l.Cantidad)
+	}
+	// Salta el lote agotado y deja para el final el que no vence
+	if want := []int{4, 3, 2, 4, 1, 2}; !reflect.DeepEqual(got, want) || resto != 0 {
+		t.Errorf("plan %v resto %d, se esperaba %v resto 0", got, resto, want)
+	}
+
+	if _, resto := PlanFEFO(lotes, 20); resto != 3 {
+		t.Errorf("resto %d, se esperaba 3", resto)
+	}
+}
+
+func TestPlanOrigen(t *testing.T) {
+	origen := []models.MovimientoLote{
+		{LoteID: 1, Codigo: "A", Cantidad: 5},
+		{LoteID: 2, Codigo: "B", Cantidad: 3},
+	}
+
+	casos := []struct {
+		nombre   string
+		usado    map[int]int
+		cantidad int
+		plan     []models.MovimientoLote
+		resto    int
+	}{
+		{"toma en orden", nil, 6, []models.MovimientoLote{{LoteID: 1, Codigo: "A", Cantidad: 5}, {LoteID: 2, Codigo: "B", Cantidad: 1}}, 0},
+		{"descuenta lo ya repuesto", map[int]int{1: 5, 2: 1}, 3, []models.MovimientoLote{{LoteID: 2, Codigo: "B", Cantidad: 2}}, 1},
+		{"no toca el origen", nil, 0, nil, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			plan, resto := PlanOrigen(origen, c.usado, c.cantidad)
+			if !reflect.DeepEqual(plan, c.plan) || resto != c.resto {
+				t.Errorf("plan %+v resto %d, se esperaba %+v resto %d", plan, resto, c.plan, c.resto)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+type AlertaRepository struct {
+	s *store
+}
+
+// alerta devuelve una copia de la alerta con el nombre actual del producto.
+// Debe llamarse con el mutex tomado.
+func (s *store) alerta(a models.Alerta) models.Alerta {
+	a.Producto = s.productos[a.ProductoID].Nombre
+	return a
+}
+
+func (r *AlertaRepository) List(ctx context.Context, filtro repository.AlertaFiltro) ([]models.Alerta, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var alertas []models.Alerta
+	for _, a := range r.s.alertas {
+		if filtro.Estado != "" && a.Estado != filtro.Estado {
+			continue
+		}
+		if filtro.ProductoID != nil && a.ProductoID != *filtro.ProductoID {
+			continue
+		}
+		alertas = append(alertas, r.s.alerta(a))
+	}
+	sort.Slice(alertas, func(i, j int) bool {
+		if !alertas[i].CreatedAt.Equal(alertas[j].CreatedAt) {
+			return alertas[i].CreatedAt.After(alertas[j].CreatedAt)
+		}
+		return alertas[i].ID > alertas[j].ID
+	})
+	return alertas, nil
+}
+
+func (r *AlertaRepository) Evaluar(ctx context.Context, productoIDs ...int) ([]models.Alerta, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if len(productoIDs) == 0 {
+		for id := range r.s.productos {
+			productoIDs = append(productoIDs, id)
+		}
+		sort.Ints(productoIDs)
+	}
+
+	var alertas []models.Alerta
+	for _, id := range productoIDs {
+		p, ok := r.s.productos[id]
+		if !ok {
+			continue
+		}
+		var abierta *models.Alerta
+		for _, a := range r.s.alertas {
+			if a.ProductoID == id && a.Estado != models.EstadoAlertaResuelta {
+				abierta = &a
+				break
+			}
+		}
+
+		accion, a := repository.EvaluarAlerta(p, abierta)
+		ahora := time.Now()
+		switch accion {
+		case repository.AlertaSinCambios:
+			continue
+		case repository.AlertaResolver:
+			abierta.Estado = models.EstadoAlertaResuelta
+			abierta.ResueltaAt = &ahora
+			r.s.alertas[abierta.ID] = *abierta
+			continue
+		case repository.AlertaEscalar:
+			a.ID = abierta.ID
+			a.CreatedAt = abierta.CreatedAt
+		default:
+			r.s.ultimaAlertaID++
+			a.ID = r.s.ultimaAlertaID
+			a.CreatedAt = ahora
+		}
+		r.s.alertas[a.ID] = a
+		alertas = append(alertas, a)
+	}
+	return alertas, nil
+}
+
+func (r *AlertaRepository) Reconocer(ctx context.Context, id int) (*models.Alerta, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	a, ok := r.s.alertas[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	switch a.Estado {
+	case models.EstadoAlertaResuelta:
+		return nil, repository.ErrAlertaResuelta
+	case models.EstadoAlertaActiva:
+		ahora := time.Now()
+		a.Estado = models.EstadoAlertaReconocida
+		a.ReconocidaAt = &ahora
+		r.s.alertas[id] = a
+	}
+	a = r.s.alerta(a)
+	return &a, nil
+}
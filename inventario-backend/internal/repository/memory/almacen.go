@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type AlmacenRepository struct {
+	s *store
+}
+
+// almacenDuplicado replica la restricción UNIQUE de almacenes.nombre.
+// Debe llamarse con el mutex tomado.
+func (s *store) almacenDuplicado(nombre string, excluirID int) bool {
+	for _, a := range s.almacenes {
+		if a.ID != excluirID && a.Nombre == nombre {
+			return true
+		}
+	}
+	return false
+}
+
+// marcarPrincipal quita la marca de principal al resto de almacenes. Debe
+// llamarse con el mutex de escritura tomado.
+func (s *store) marcarPrincipal(id int) {
+	for aid, a := range s.almacenes {
+		a.Principal = aid == id
+		s.almacenes[aid] = a
+	}
+}
+
+func (r *AlmacenRepository) List(ctx context.Context) ([]models.Almacen, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var almacenes []models.Almacen
+	for _, a := range r.s.almacenes {
+		almacenes = append(almacenes, a)
+	}
+	sort.Slice(almacenes, func(i, j int) bool {
+		return strings.Compare(almacenes[i].Nombre, almacenes[j].Nombre) < 0
+	})
+	return almacenes, nil
+}
+
+func (r *AlmacenRepository) GetByID(ctx context.Context, id int) (*models.Almacen, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	a, ok := r.s.almacenes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &a, nil
+}
+
+func (r *AlmacenRepository) Create(ctx context.Context, req models.AlmacenRequest) (*models.Almacen, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if r.s.almacenDuplicado(req.Nombre, 0) {
+		return nil, repository.ErrAlmacenDuplicado
+	}
+
+	r.s.ultimoAlmacenID++
+	ahora := time.Now()
+	a := models.Almacen{
+		ID:          r.s.ultimoAlmacenID,
+		Nombre:      req.Nombre,
+		Descripcion: req.Descripcion,
+		CreatedAt:   ahora,
+		UpdatedAt:   ahora,
+	}
+	r.s.almacenes[a.ID] = a
+	if req.Principal {
+		r.s.marcarPrincipal(a.ID)
+	}
+
+	a = r.s.almacenes[a.ID]
+	return &a, nil
+}
+
+func (r *AlmacenRepository) Update(ctx context.Context, id int, req models.AlmacenRequest) (*models.Almacen, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if r.s.almacenDuplicado(req.Nombre, id) {
+		return nil, repository.ErrAlmacenDuplicado
+	}
+
+	a, ok := r.s.almacenes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	a.Nombre = req.Nombre
+	a.Descripcion = req.Descripcion
+	a.UpdatedAt = time.Now()
+	r.s.almacenes[id] = a
+	if req.Principal {
+		r.s.marcarPrincipal(id)
+	}
+
+	a = r.s.almacenes[id]
+	return &a, nil
+}
+
+func (r *AlmacenRepository) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	a, ok := r.s.almacenes[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	// El principal no se puede eliminar, ni un almacén con existencias,
+	// movimientos, traslados, conteos u órdenes de compra o de venta registrados
+	if a.Principal {
+		return repository.ErrAlmacenEnUso
+	}
+	for k, cantidad := range r.s.stock {
+		if k.almacenID == id && cantidad > 0 {
+			return repository.ErrAlmacenEnUso
+		}
+	}
+	for _, m := range r.s.movimientos {
+		if m.AlmacenID == id {
+			return repository.ErrAlmacenEnUso
+		}
+	}
+	for _, t := range r.s.traslados {
+		if t.AlmacenOrigenID == id || t.AlmacenDestinoID == id {
+			return repository.ErrAlmacenEnUso
+		}
+	}
+	for _, c := range r.s.conteos {
+		if c.AlmacenID == id {
+			return repository.ErrAlmacenEnUso
+		}
+	}
+	for _, o := range r.s.ordenes {
+		if o.AlmacenID == id {
+			return repository.ErrAlmacenEnUso
+		}
+	}
+	for _, o := range r.s.ventas {
+		if o.AlmacenID == id {
+			return repository.ErrAlmacenEnUso
+		}
+	}
+
+	for k := range r.s.stock {
+		if k.almacenID == id {
+			delete(r.s.stock, k)
+		}
+	}
+	delete(r.s.almacenes, id)
+	return nil
+}
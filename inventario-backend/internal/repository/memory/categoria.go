@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type CategoriaRepository struct {
+	s *store
+}
+
+// nombreDuplicado replica la restricción UNIQUE de categorias.nombre.
+// Debe llamarse con el mutex tomado.
+func (s *store) nombreDuplicado(nombre string, excluirID int) bool {
+	for _, c := range s.categorias {
+		if c.ID != excluirID && c.Nombre == nombre {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *CategoriaRepository) List(ctx context.Context) ([]models.Categoria, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var categorias []models.Categoria
+	for _, c := range r.s.categorias {
+		categorias = append(categorias, c)
+	}
+	sort.Slice(categorias, func(i, j int) bool {
+		return strings.Compare(categorias[i].Nombre, categorias[j].Nombre) < 0
+	})
+	return categorias, nil
+}
+
+func (r *CategoriaRepository) GetByID(ctx context.Context, id int) (*models.Categoria, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	c, ok := r.s.categorias[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *CategoriaRepository) Create(ctx context.Context, req models.CategoriaRequest) (*models.Categoria, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if r.s.nombreDuplicado(req.Nombre, 0) {
+		return nil, repository.ErrCategoriaDuplicada
+	}
+
+	r.s.ultimaCategoriaID++
+	ahora := time.Now()
+	c := models.Categoria{
+		ID:          r.s.ultimaCategoriaID,
+		Nombre:      req.Nombre,
+		Descripcion: req.Descripcion,
+		CreatedAt:   ahora,
+		UpdatedAt:   ahora,
+	}
+	r.s.categorias[c.ID] = c
+	return &c, nil
+}
+
+func (r *CategoriaRepository) Update(ctx context.Context, id int, req models.CategoriaRequest) (*models.Categoria, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.categorias[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if r.s.nombreDuplicado(req.Nombre, id) {
+		return nil, repository.ErrCategoriaDuplicada
+	}
+
+	c.Nombre = req.Nombre
+	c.Descripcion = req.Descripcion
+	c.UpdatedAt = time.Now()
+	r.s.categorias[id] = c
+	return &c, nil
+}
+
+func (r *CategoriaRepository) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, p := range r.s.productos {
+		if p.CategoriaID == id {
+			return repository.ErrCategoriaConProductos
+		}
+	}
+
+	if _, ok := r.s.categorias[id]; !ok {
+		return repository.ErrNotFound
+	}
+	r.s.eliminarCategoria(id)
+	return nil
+}
+
+// eliminarCategoria borra la categoría y deja sin categoría a sus productos
+// y conteos (ON DELETE SET NULL). Debe llamarse con el mutex tomado.
+func (s *store) eliminarCategoria(id int) {
+	delete(s.categorias, id)
+	for pid, p := range s.productos {
+		if p.CategoriaID == id {
+			p.CategoriaID = 0
+			s.productos[pid] = p
+		}
+	}
+	for _, c := range s.conteos {
+		if c.CategoriaID != nil && *c.CategoriaID == id {
+			c.CategoriaID = nil
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+// libro suma el efecto de los movimientos anteriores a hasta por producto y
+// almacén. Debe llamarse con el mutex tomado.
+func (s *store) libro(hasta *time.Time) map[stockKey]int {
+	libro := make(map[stockKey]int)
+	for _, m := range s.movimientos {
+		if hasta != nil && !m.CreatedAt.Before(*hasta) {
+			continue
+		}
+		libro[stockKey{m.ProductoID, m.AlmacenID}] += repository.EfectoStock(m)
+	}
+	return libro
+}
+
+func (r *MovimientoRepository) StockAl(ctx context.Context, productoID int, hasta time.Time) (*models.StockFecha, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if _, ok := r.s.productos[productoID]; !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	s := &models.StockFecha{ProductoID: productoID, Hasta: hasta, Almacenes: []models.StockAlmacen{}}
+	for k, cantidad := range r.s.libro(&hasta) {
+		if k.productoID != productoID || cantidad == 0 {
+			continue
+		}
+		s.Stock += cantidad
+		s.Almacenes = append(s.Almacenes, models.StockAlmacen{
+			AlmacenID: k.almacenID,
+			Almacen:   r.s.almacenes[k.almacenID].Nombre,
+			Cantidad:  cantidad,
+		})
+	}
+	sort.Slice(s.Almacenes, func(i, j int) bool {
+		return r.s.antesEnListado(s.Almacenes[i].AlmacenID, s.Almacenes[j].AlmacenID)
+	})
+	return s, nil
+}
+
+func (r *MovimientoRepository) Conciliar(ctx context.Context) ([]models.Conciliacion, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	libro := r.s.libro(nil)
+	claves := make(map[stockKey]bool)
+	for k := range libro {
+		claves[k] = true
+	}
+	for k := range r.s.stock {
+		claves[k] = true
+	}
+
+	porProducto := make(map[int]*models.Conciliacion)
+	for _, p := range r.s.productos {
+		porProducto[p.ID] = &models.Conciliacion{ProductoID: p.ID, Producto: p.Nombre, StockRegistrado: p.Stock}
+	}
+	for k := range claves {
+		c, ok := porProducto[k.productoID]
+		if !ok {
+			continue
+		}
+		registrado, movimientos := r.s.stock[k], libro[k]
+		c.StockMovimientos += movimientos
+		if registrado != movimientos {
+			c.Almacenes = append(c.Almacenes, models.ConciliacionAlmacen{
+				AlmacenID:        k.almacenID,
+				Almacen:          r.s.almacenes[k.almacenID].Nombre,
+				StockRegistrado:  registrado,
+				StockMovimientos: movimientos,
+				Diferencia:       registrado - movimientos,
+			})
+		}
+	}
+
+	conciliacion := []models.Conciliacion{}
+	for _, c := range porProducto {
+		c.Diferencia = c.StockRegistrado - c.StockMovimientos
+		if c.Diferencia == 0 && len(c.Almacenes) == 0 {
+			continue
+		}
+		sort.Slice(c.Almacenes, func(i, j int) bool {
+			return r.s.antesEnListado(c.Almacenes[i].AlmacenID, c.Almacenes[j].AlmacenID)
+		})
+		conciliacion = append(conciliacion, *c)
+	}
+	sort.Slice(conciliacion, func(i, j int) bool {
+		if conciliacion[i].Producto != conciliacion[j].Producto {
+			return conciliacion[i].Producto < conciliacion[j].Producto
+		}
+		return conciliacion[i].ProductoID < conciliacion[j].ProductoID
+	})
+	return conciliacion, nil
+}
+
+func (r *MovimientoRepository) Kardex(ctx context.Context, productoID int, filtro repository.KardexFiltro) (*models.Kardex, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	p, ok := r.s.productos[productoID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	var movimientos []models.MovimientoInventario
+	for _, m := range r.s.movimientos {
+		if m.ProductoID == productoID {
+			movimientos = append(movimientos, r.s.movimiento(m))
+		}
+	}
+	sort.Slice(movimientos, func(i, j int) bool {
+		if c := movimientos[i].CreatedAt.Compare(movimientos[j].CreatedAt); c != 0 {
+			return c < 0
+		}
+		return movimientos[i].ID < movimientos[j].ID
+	})
+	return repository.CalcularKardex(p, movimientos, filtro), nil
+}
@@ -0,0 +1,267 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ConteoRepository struct {
+	s *store
+}
+
+// registroKey identifica un registro de conteo_registros
+type registroKey struct {
+	conteoID   int
+	productoID int
+	pasada     int
+	contador   string
+}
+
+// conteoGuardado es una sesión junto con su foto de stock esperado y el
+// ajuste de cada producto
+type conteoGuardado struct {
+	models.Conteo
+	esperado    map[int]int
+	movimientos map[int]int
+}
+
+// conteo devuelve una copia del conteo con sus items y registros resueltos.
+// Debe llamarse con el mutex tomado.
+func (s *store) conteo(c *conteoGuardado) models.Conteo {
+	conteo := c.Conteo
+	conteo.Items = nil
+	for productoID, esperado := range c.esperado {
+		p, ok := s.productos[productoID]
+		if !ok {
+			continue
+		}
+		item := models.ConteoItem{
+			ProductoID:    productoID,
+			Producto:      &models.Producto{ID: p.ID, Nombre: p.Nombre, SKU: p.SKU},
+			StockEsperado: esperado,
+		}
+		if mid, ok := c.movimientos[productoID]; ok {
+			item.MovimientoID = &mid
+		}
+		for k, reg := range s.registros {
+			if k.conteoID == c.ID && k.productoID == productoID {
+				item.Registros = append(item.Registros, reg)
+			}
+		}
+		sort.Slice(item.Registros, func(i, j int) bool {
+			a, b := item.Registros[i], item.Registros[j]
+			if a.Pasada != b.Pasada {
+				return a.Pasada < b.Pasada
+			}
+			return a.Contador < b.Contador
+		})
+		repository.ResolverConteoItem(&item)
+		conteo.Items = append(conteo.Items, item)
+	}
+	sort.Slice(conteo.Items, func(i, j int) bool {
+		a, b := conteo.Items[i].Producto, conteo.Items[j].Producto
+		if c := strings.Compare(a.Nombre, b.Nombre); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	})
+	return conteo
+}
+
+func (r *ConteoRepository) List(ctx context.Context, filtro repository.ConteoFiltro) ([]models.Conteo, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var conteos []models.Conteo
+	for _, c := range r.s.conteos {
+		if filtro.Estado != "" && c.Estado != filtro.Estado {
+			continue
+		}
+		if filtro.AlmacenID != nil && c.AlmacenID != *filtro.AlmacenID {
+			continue
+		}
+		conteos = append(conteos, c.Conteo)
+	}
+	// Más recientes primero
+	sort.Slice(conteos, func(i, j int) bool {
+		if !conteos[i].CreatedAt.Equal(conteos[j].CreatedAt) {
+			return conteos[i].CreatedAt.After(conteos[j].CreatedAt)
+		}
+		return conteos[i].ID > conteos[j].ID
+	})
+	return conteos, nil
+}
+
+func (r *ConteoRepository) GetByID(ctx context.Context, id int) (*models.Conteo, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	c, ok := r.s.conteos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	conteo := r.s.conteo(c)
+	return &conteo, nil
+}
+
+func (r *ConteoRepository) Create(ctx context.Context, req models.ConteoRequest) (*models.Conteo, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		almacenID = r.s.almacenPrincipal()
+	}
+	if _, ok := r.s.almacenes[almacenID]; !ok {
+		return nil, repository.ErrAlmacenNoExiste
+	}
+	if req.CategoriaID != nil {
+		if _, ok := r.s.categorias[*req.CategoriaID]; !ok {
+			return nil, repository.ErrCategoriaNoExiste
+		}
+	}
+
+	r.s.ultimoConteoID++
+	c := &conteoGuardado{
+		Conteo: models.Conteo{
+			ID:          r.s.ultimoConteoID,
+			AlmacenID:   almacenID,
+			CategoriaID: req.CategoriaID,
+			Estado:      models.EstadoConteoAbierto,
+			Descripcion: req.Descripcion,
+			CreatedAt:   time.Now(),
+		},
+		esperado:    make(map[int]int),
+		movimientos: make(map[int]int),
+	}
+	// Foto del stock esperado de cada producto en el almacén
+	for _, p := range r.s.productos {
+		if req.CategoriaID == nil || p.CategoriaID == *req.CategoriaID {
+			c.esperado[p.ID] = r.s.stock[stockKey{p.ID, almacenID}]
+		}
+	}
+	r.s.conteos[c.ID] = c
+
+	conteo := r.s.conteo(c)
+	return &conteo, nil
+}
+
+// conteoAbierto devuelve la sesión si existe y sigue abierta. Debe llamarse
+// con el mutex tomado.
+func (s *store) conteoAbierto(id int) (*conteoGuardado, error) {
+	c, ok := s.conteos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if c.Estado != models.EstadoConteoAbierto {
+		return nil, repository.ErrConteoCerrado
+	}
+	return c, nil
+}
+
+func (r *ConteoRepository) Registrar(ctx context.Context, id int, req models.ConteoRegistroRequest) (*models.Conteo, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, err := r.s.conteoAbierto(id)
+	if err != nil {
+		return nil, err
+	}
+	// Validar todos los productos antes de guardar para no registrar a medias
+	for _, item := range req.Items {
+		if _, ok := c.esperado[item.ProductoID]; !ok {
+			return nil, repository.ErrProductoFueraDeConteo
+		}
+		if item.Cantidad < 0 || req.Pasada < 1 {
+			return nil, repository.ErrValorInvalido
+		}
+	}
+
+	ahora := time.Now()
+	for _, item := range req.Items {
+		r.s.registros[registroKey{id, item.ProductoID, req.Pasada, req.Contador}] = models.ConteoRegistro{
+			ProductoID:  item.ProductoID,
+			Pasada:      req.Pasada,
+			Contador:    req.Contador,
+			Cantidad:    item.Cantidad,
+			Lote:        item.Lote,
+			Vencimiento: item.Vencimiento,
+			Series:      append([]string(nil), item.Series...),
+			CreatedAt:   ahora,
+		}
+	}
+
+	conteo := r.s.conteo(c)
+	return &conteo, nil
+}
+
+func (r *ConteoRepository) Aprobar(ctx context.Context, id int) (*models.Conteo, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, err := r.s.conteoAbierto(id)
+	if err != nil {
+		return nil, err
+	}
+	conteo := r.s.conteo(c)
+
+	// Verificar todos los ajustes antes de aplicarlos para que la aprobación
+	// sea atómica
+	ajustes := make([]models.MovimientoInventario, 0, len(conteo.Items))
+	for _, item := range conteo.Items {
+		if item.Diferencia == nil || *item.Diferencia == 0 {
+			continue
+		}
+		if r.s.stock[stockKey{item.ProductoID, c.AlmacenID}]+*item.Diferencia < 0 {
+			return nil, repository.ErrStockInsuficiente
+		}
+		m, err := repository.AjusteConteo(&conteo, item)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.s.validarUnidades(m, m.Cantidad); err != nil {
+			return nil, err
+		}
+		ajustes = append(ajustes, m)
+	}
+
+	// La diferencia se calcula contra la foto tomada al abrir la sesión, así
+	// que los movimientos registrados durante el conteo se conservan
+	for _, ajuste := range ajustes {
+		m, err := r.s.aplicarMovimiento(ajuste)
+		if err != nil {
+			return nil, err
+		}
+		c.movimientos[ajuste.ProductoID] = m.ID
+	}
+
+	r.s.cerrarConteo(c, models.EstadoConteoAprobado)
+	conteo = r.s.conteo(c)
+	return &conteo, nil
+}
+
+func (r *ConteoRepository) Cancelar(ctx context.Context, id int) (*models.Conteo, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, err := r.s.conteoAbierto(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.s.cerrarConteo(c, models.EstadoConteoCancelado)
+	conteo := r.s.conteo(c)
+	return &conteo, nil
+}
+
+// cerrarConteo marca la sesión como aprobada o cancelada. Debe llamarse con
+// el mutex de escritura tomado.
+func (s *store) cerrarConteo(c *conteoGuardado, estado models.EstadoConteo) {
+	ahora := time.Now()
+	c.Estado = estado
+	c.CerradoAt = &ahora
+}
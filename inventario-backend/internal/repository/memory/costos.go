@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+)
+
+// capaCosto replica una fila de capas_costo
+type capaCosto struct {
+	id           int
+	productoID   int
+	movimientoID int
+	cantidad     int
+	restante     int
+	costo        float64
+}
+
+// consumo replica una fila de consumos_capa
+type consumo struct {
+	capaID   int
+	cantidad int
+	costo    float64
+}
+
+// origenCapas devuelve el movimiento cuyas unidades vuelven con la entrada m:
+// el revertido o el tramo de salida del traslado. Devuelve 0 si no hay. Debe
+// llamarse con el mutex tomado.
+func (s *store) origenCapas(m models.MovimientoInventario) int {
+	if m.RevierteID != nil {
+		return *m.RevierteID
+	}
+	if m.TrasladoID == nil {
+		return 0
+	}
+	for _, o := range s.movimientos {
+		if o.TrasladoID != nil && *o.TrasladoID == *m.TrasladoID && o.Tipo == models.TipoSalida {
+			return o.ID
+		}
+	}
+	return 0
+}
+
+// costoEntrada devuelve el costo unitario en moneda local de las unidades que
+// suma m y el movimiento cuyas capas restituye. Sin costo propio, las
+// unidades que vuelven conservan el costo con que salieron y las demás se
+// valoran al costo promedio. Debe llamarse con el mutex tomado.
+func (s *store) costoEntrada(m models.MovimientoInventario, p models.Producto) (float64, int) {
+	if m.CostoUnitario != nil {
+		return repository.CostoLocal(m), 0
+	}
+
+	origen := s.origenCapas(m)
+	if o, ok := s.movimientos[origen]; ok && o.CostoTotal != nil {
+		return *o.CostoTotal / float64(o.Cantidad), origen
+	}
+	if p.Stock > 0 {
+		return p.ValorInventario / float64(p.Stock), origen
+	}
+	// Sin existencias, el último costo conocido
+	ultima := 0
+	costo := 0.0
+	for _, c := range s.capas {
+		if c.productoID == m.ProductoID && c.id > ultima {
+			ultima, costo = c.id, c.costo
+		}
+	}
+	return costo, origen
+}
+
+// sumarCapas devuelve a sus capas las unidades que consumió el movimiento
+// origen y crea una capa nueva con el resto de la entrada. Debe llamarse con
+// el mutex de escritura tomado.
+func (s *store) sumarCapas(m models.MovimientoInventario, cantidad int, costo float64, origen int) {
+	if origen != 0 {
+		for _, c := range s.consumos[origen] {
+			s.capas[c.capaID].restante += c.cantidad
+			cantidad -= c.cantidad
+		}
+	}
+	if cantidad <= 0 {
+		return
+	}
+
+	s.ultimaCapaID++
+	s.capas[s.ultimaCapaID] = &capaCosto{
+		id:           s.ultimaCapaID,
+		productoID:   m.ProductoID,
+		movimientoID: m.ID,
+		cantidad:     cantidad,
+		restante:     cantidad,
+		costo:        costo,
+	}
+}
+
+// planConsumo elige las capas de las que sale la cantidad, de la más antigua a
+// la más reciente; al revertir una entrada, primero la capa de esa entrada.
+// Devuelve también las unidades sin capa. Debe llamarse con el mutex tomado.
+func (s *store) planConsumo(m models.MovimientoInventario, cantidad int) ([]consumo, int) {
+	var vigentes []*capaCosto
+	for _, c := range s.capas {
+		if c.productoID == m.ProductoID && c.restante > 0 {
+			vigentes = append(vigentes, c)
+		}
+	}
+	revertida := func(c *capaCosto) bool { return m.RevierteID != nil && c.movimientoID == *m.RevierteID }
+	sort.Slice(vigentes, func(i, j int) bool {
+		if ri, rj := revertida(vigentes[i]), revertida(vigentes[j]); ri != rj {
+			return ri
+		}
+		return vigentes[i].id < vigentes[j].id
+	})
+
+	var plan []consumo
+	for _, c := range vigentes {
+		if cantidad == 0 {
+			break
+		}
+		n := min(cantidad, c.restante)
+		cantidad -= n
+		plan = append(plan, consumo{capaID: c.id, cantidad: n, costo: c.costo})
+	}
+	return plan, cantidad
+}
+
+// consumirCapas descuenta el plan de las capas y lo registra para poder
+// revertirlo. Debe llamarse con el mutex de escritura tomado.
+func (s *store) consumirCapas(movimientoID int, plan []consumo) {
+	for _, c := range plan {
+		s.capas[c.capaID].restante -= c.cantidad
+	}
+	if len(plan) > 0 {
+		s.consumos[movimientoID] = plan
+	}
+}
+
+// costoSalida devuelve el costo en moneda local de las unidades que resta m:
+// el de las capas consumidas con FIFO o el costo promedio, salvo al revertir
+// una entrada, que sale a su propio costo. Debe llamarse con el mutex tomado.
+func (s *store) costoSalida(m models.MovimientoInventario, p models.Producto, cantidad int, plan []consumo, sinCapa int) float64 {
+	promedio := 0.0
+	if p.Stock > 0 {
+		promedio = p.ValorInventario / float64(p.Stock)
+	}
+
+	if p.MetodoCosteo == models.MetodoFIFO {
+		total := float64(sinCapa) * promedio
+		for _, c := range plan {
+			total += float64(c.cantidad) * c.costo
+		}
+		return total
+	}
+
+	if m.RevierteID != nil {
+		if o := s.movimientos[*m.RevierteID]; o.CostoTotal != nil {
+			return float64(cantidad) * *o.CostoTotal / float64(o.Cantidad)
+		}
+	}
+	return float64(cantidad) * promedio
+}
@@ -0,0 +1,281 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+type DevolucionRepository struct {
+	s *store
+}
+
+// devolucion devuelve una copia de la devolución con los datos del producto
+// de cada item. Si items es false los omite, como en el listado. Debe
+// llamarse con el mutex tomado.
+func (s *store) devolucion(d *models.Devolucion, items bool) models.Devolucion {
+	dev := *d
+	dev.Items = nil
+	if !items {
+		return dev
+	}
+	for _, i := range d.Items {
+		dev.Items = append(dev.Items, s.devolucionItem(i))
+	}
+	return dev
+}
+
+// devolucionItem completa el item con los datos de su producto. Debe
+// llamarse con el mutex tomado.
+func (s *store) devolucionItem(i models.DevolucionItem) models.DevolucionItem {
+	p := s.productos[i.ProductoID]
+	i.Producto = &models.Producto{ID: p.ID, Nombre: p.Nombre, SKU: p.SKU}
+	return i
+}
+
+// salidaDevuelta indica si alguna devolución remite a la salida. Debe
+// llamarse con el mutex tomado.
+func (s *store) salidaDevuelta(movimientoID int) bool {
+	for _, d := range s.devoluciones {
+		if d.MovimientoID != nil && *d.MovimientoID == movimientoID {
+			return true
+		}
+	}
+	return false
+}
+
+// origenDevolucion devuelve el almacén de la orden o salida de la devolución
+// y lo vendido de cada producto. Debe llamarse con el mutex tomado.
+func (s *store) origenDevolucion(req models.DevolucionRequest) (int, map[int]int, error) {
+	vendido := make(map[int]int)
+	if req.OrdenVentaID != nil {
+		o, ok := s.ventas[*req.OrdenVentaID]
+		if !ok || o.Estado != models.EstadoVentaDespachada {
+			return 0, nil, repository.ErrOrigenDevolucion
+		}
+		for _, l := range o.Lineas {
+			vendido[l.ProductoID] = l.Cantidad
+		}
+		return o.AlmacenID, vendido, nil
+	}
+
+	// Solo las salidas sueltas vigentes: las de una orden se devuelven por
+	// la orden y las demás no son ventas
+	m, ok := s.movimientos[*req.MovimientoID]
+	if !ok || m.Tipo != models.TipoSalida || m.TrasladoID != nil || m.OrdenCompraID != nil ||
+		m.OrdenVentaID != nil || m.EnsamblajeID != nil || m.RevierteID != nil {
+		return 0, nil, repository.ErrOrigenDevolucion
+	}
+	if _, revertido := s.reversiones[m.ID]; revertido {
+		return 0, nil, repository.ErrOrigenDevolucion
+	}
+	vendido[m.ProductoID] = m.Cantidad
+	return m.AlmacenID, vendido, nil
+}
+
+// mismoOrigen indica si la devolución remite a la orden o salida de req
+func mismoOrigen(d *models.Devolucion, req models.DevolucionRequest) bool {
+	if req.OrdenVentaID != nil {
+		return d.OrdenVentaID != nil && *d.OrdenVentaID == *req.OrdenVentaID
+	}
+	return d.MovimientoID != nil && *d.MovimientoID == *req.MovimientoID
+}
+
+// costoDevolucion devuelve el costo unitario con que salió el producto en la
+// venta original de la devolución, o nil si no se conoce. Debe llamarse con
+// el mutex tomado.
+func (s *store) costoDevolucion(d *models.Devolucion, productoID int) *float64 {
+	if d.MovimientoID != nil {
+		return repository.CostoVenta(s.movimientos[*d.MovimientoID])
+	}
+	for _, m := range s.movimientos {
+		if m.OrdenVentaID != nil && *m.OrdenVentaID == *d.OrdenVentaID && m.ProductoID == productoID &&
+			m.Tipo == models.TipoSalida {
+			return repository.CostoVenta(m)
+		}
+	}
+	return nil
+}
+
+func (r *DevolucionRepository) List(ctx context.Context, filtro repository.DevolucionFiltro) ([]models.Devolucion, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var devoluciones []models.Devolucion
+	for _, d := range r.s.devoluciones {
+		if filtro.OrdenVentaID != nil && (d.OrdenVentaID == nil || *d.OrdenVentaID != *filtro.OrdenVentaID) {
+			continue
+		}
+		if filtro.MovimientoID != nil && (d.MovimientoID == nil || *d.MovimientoID != *filtro.MovimientoID) {
+			continue
+		}
+		devoluciones = append(devoluciones, r.s.devolucion(d, false))
+	}
+	sort.Slice(devoluciones, func(i, j int) bool {
+		if !devoluciones[i].CreatedAt.Equal(devoluciones[j].CreatedAt) {
+			return devoluciones[i].CreatedAt.After(devoluciones[j].CreatedAt)
+		}
+		return devoluciones[i].ID > devoluciones[j].ID
+	})
+	return devoluciones, nil
+}
+
+func (r *DevolucionRepository) GetByID(ctx context.Context, id int) (*models.Devolucion, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	d, ok := r.s.devoluciones[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	dev := r.s.devolucion(d, true)
+	return &dev, nil
+}
+
+func (r *DevolucionRepository) Create(ctx context.Context, req models.DevolucionRequest) (*models.Devolucion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if (req.OrdenVentaID == nil) == (req.MovimientoID == nil) {
+		return nil, repository.ErrValorInvalido
+	}
+	almacenID, vendido, err := r.s.origenDevolucion(req)
+	if err != nil {
+		return nil, err
+	}
+	devuelto := make(map[int]int)
+	for _, d := range r.s.devoluciones {
+		if !mismoOrigen(d, req) {
+			continue
+		}
+		for _, i := range d.Items {
+			devuelto[i.ProductoID] += i.Cantidad
+		}
+	}
+	if err := repository.ValidarDevolucion(vendido, devuelto, req.Items); err != nil {
+		return nil, err
+	}
+
+	// Validar todos los items antes de reponer ninguno para que la
+	// devolución sea atómica
+	items := make([]models.DevolucionItem, 0, len(req.Items))
+	for _, ir := range req.Items {
+		estado, ok := repository.EstadoInicialDevolucion(ir.Condicion, ir.Destino)
+		if !ok || ir.Cantidad <= 0 {
+			return nil, repository.ErrValorInvalido
+		}
+		items = append(items, models.DevolucionItem{
+			ProductoID: ir.ProductoID,
+			Cantidad:   ir.Cantidad,
+			Condicion:  ir.Condicion,
+			Estado:     estado,
+			Series:     ir.Series,
+		})
+	}
+
+	r.s.ultimaDevolucionID++
+	d := &models.Devolucion{
+		ID:           r.s.ultimaDevolucionID,
+		OrdenVentaID: req.OrdenVentaID,
+		MovimientoID: req.MovimientoID,
+		AlmacenID:    almacenID,
+		Motivo:       req.Motivo,
+		CreatedAt:    time.Now(),
+	}
+	// Las series y las reposiciones buscan la venta a partir de la
+	// devolución, que se registra antes de verificarlas
+	r.s.devoluciones[d.ID] = d
+	devueltas := make(map[int]map[string]bool)
+	for _, item := range items {
+		vendidas, antes := r.s.seriesVendidas(d, item.ProductoID)
+		if devueltas[item.ProductoID] == nil {
+			devueltas[item.ProductoID] = antes
+		}
+		err := repository.ValidarSeriesDevolucion(item.Series, item.Cantidad, r.s.productos[item.ProductoID].ControlaSeries,
+			vendidas, devueltas[item.ProductoID])
+		if err == nil && item.Estado == models.EstadoItemRepuesto {
+			err = r.s.validarUnidades(repository.Reposicion(*d, item, nil), item.Cantidad)
+		}
+		if err != nil {
+			delete(r.s.devoluciones, d.ID)
+			return nil, err
+		}
+		// Una serie no se devuelve en dos items
+		for _, numero := range item.Series {
+			devueltas[item.ProductoID][numero] = true
+		}
+	}
+	for _, item := range items {
+		r.s.ultimoItemID++
+		item.ID = r.s.ultimoItemID
+		item.DevolucionID = d.ID
+		if item.Estado == models.EstadoItemRepuesto {
+			m, err := r.s.aplicarMovimiento(repository.Reposicion(*d, item, r.s.costoDevolucion(d, item.ProductoID)))
+			if err != nil {
+				return nil, err
+			}
+			item.MovimientoID = &m.ID
+		}
+		d.Items = append(d.Items, item)
+	}
+
+	dev := r.s.devolucion(d, true)
+	return &dev, nil
+}
+
+func (r *DevolucionRepository) Cuarentena(ctx context.Context) ([]models.DevolucionItem, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var items []models.DevolucionItem
+	for _, d := range r.s.devoluciones {
+		for _, i := range d.Items {
+			if i.Estado == models.EstadoItemCuarentena {
+				items = append(items, r.s.devolucionItem(i))
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (r *DevolucionRepository) Resolver(ctx context.Context, id, itemID int, estado models.EstadoItemDevolucion) (*models.Devolucion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if !repository.ResolucionValida(estado) {
+		return nil, repository.ErrValorInvalido
+	}
+	d, ok := r.s.devoluciones[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	var item *models.DevolucionItem
+	for i := range d.Items {
+		if d.Items[i].ID == itemID {
+			item = &d.Items[i]
+		}
+	}
+	if item == nil {
+		return nil, repository.ErrNotFound
+	}
+	if item.Estado != models.EstadoItemCuarentena {
+		return nil, repository.ErrItemResuelto
+	}
+
+	if estado == models.EstadoItemRepuesto {
+		m, err := r.s.aplicarMovimiento(repository.Reposicion(*d, *item, r.s.costoDevolucion(d, item.ProductoID)))
+		if err != nil {
+			return nil, err
+		}
+		item.MovimientoID = &m.ID
+	}
+	ahora := time.Now()
+	item.Estado = estado
+	item.ResueltoAt = &ahora
+
+	dev := r.s.devolucion(d, true)
+	return &dev, nil
+}
@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+// CargarDatosEjemplo inserta las mismas categorías y productos de ejemplo
+// que la migración 0002_datos_ejemplo.
+func CargarDatosEjemplo(ctx context.Context, repos repository.Repositories) error {
+	categorias := []models.CategoriaRequest{
+		{Nombre: "Electrónica", Descripcion: "Dispositivos y componentes electrónicos"},
+		{Nombre: "Ropa", Descripcion: "Prendas de vestir y accesorios"},
+		{Nombre: "Alimentos", Descripcion: "Productos alimenticios y bebidas"},
+		{Nombre: "Hogar", Descripcion: "Artículos para el hogar"},
+	}
+	ids := make([]int, len(categorias))
+	for i, req := range categorias {
+		c, err := repos.Categorias.Create(ctx, req)
+		if err != nil {
+			return err
+		}
+		ids[i] = c.ID
+	}
+
+	productos := []models.ProductoRequest{
+		{Nombre: "Laptop Dell Inspiron 15", Descripcion: "Laptop Dell con procesador Intel i5, 8GB RAM, 256GB SSD", Precio: 899.99, Stock: 10, CategoriaID: ids[0]},
+		{Nombre: "Mouse Inalámbrico Logitech", Descripcion: "Mouse inalámbrico con sensor óptico de alta precisión", Precio: 29.99, Stock: 50, CategoriaID: ids[0]},
+		{Nombre: "Camiseta Básica", Descripcion: "Camiseta de algodón 100%, varios colores disponibles", Precio: 19.99, Stock: 100, CategoriaID: ids[1]},
+		{Nombre: "Arroz Integral 1kg", Descripcion: "Arroz integral de grano largo, empaque de 1kg", Precio: 4.99, Stock: 200, CategoriaID: ids[2]},
+	}
+	for _, req := range productos {
+		if _, err := repos.Productos.Create(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+type EnsamblajeRepository struct {
+	s *store
+}
+
+// esComponente indica si el producto está en la lista de materiales de algún
+// kit. Debe llamarse con el mutex tomado.
+func (s *store) esComponente(productoID int) bool {
+	for _, componentes := range s.componentes {
+		for _, c := range componentes {
+			if c.ProductoID == productoID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kit completa los componentes del producto, si es un kit, y cuántos se
+// pueden armar con ellos. Debe llamarse con el mutex tomado.
+func (s *store) kit(p *models.Producto) {
+	p.Componentes, p.Ensamblables = nil, nil
+	componentes := s.componentes[p.ID]
+	if len(componentes) == 0 {
+		return
+	}
+
+	disponible := make(map[int]map[int]int)
+	for _, c := range componentes {
+		cp := s.productos[c.ProductoID]
+		c.Producto = &models.Producto{ID: cp.ID, Nombre: cp.Nombre, SKU: cp.SKU}
+		p.Componentes = append(p.Componentes, c)
+		for k, cantidad := range s.stock {
+			if k.productoID != c.ProductoID {
+				continue
+			}
+			if disponible[k.almacenID] == nil {
+				disponible[k.almacenID] = make(map[int]int)
+			}
+			disponible[k.almacenID][c.ProductoID] = cantidad - s.reservado(c.ProductoID, k.almacenID)
+		}
+	}
+	ensamblables := repository.Ensamblables(componentes, disponible)
+	p.Ensamblables = &ensamblables
+}
+
+func (r *ProductoRepository) SetComponentes(ctx context.Context, id int, req []models.ComponenteRequest) (*models.Producto, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	p, ok := r.s.productos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	// Un solo nivel: los kits no se anidan
+	if len(r.s.variantes(id)) > 0 || (len(req) > 0 && r.s.esComponente(id)) {
+		return nil, repository.ErrComponenteInvalido
+	}
+	// Ensamblar no indica qué lotes o series mueve
+	if len(req) > 0 && (p.ControlaLotes || p.ControlaSeries) {
+		return nil, repository.ErrControlEnKit
+	}
+	if p.Stock != 0 {
+		return nil, repository.ErrComponentesConStock
+	}
+
+	var componentes []models.Componente
+	for _, c := range req {
+		componente, ok := r.s.productos[c.ProductoID]
+		if !ok {
+			return nil, repository.ErrProductoNoExiste
+		}
+		if c.ProductoID == id || len(r.s.componentes[c.ProductoID]) > 0 || len(r.s.variantes(c.ProductoID)) > 0 {
+			return nil, repository.ErrComponenteInvalido
+		}
+		if componente.ControlaLotes || componente.ControlaSeries {
+			return nil, repository.ErrControlEnKit
+		}
+		// Replica producto_componentes_cantidad_check y su clave primaria
+		if c.Cantidad <= 0 {
+			return nil, repository.ErrValorInvalido
+		}
+		if componenteRepetido(componentes, c.ProductoID) {
+			return nil, repository.ErrDuplicado
+		}
+		componentes = append(componentes, models.Componente{ProductoID: c.ProductoID, Cantidad: c.Cantidad})
+	}
+	sort.Slice(componentes, func(i, j int) bool {
+		return componentes[i].ProductoID < componentes[j].ProductoID
+	})
+
+	if len(componentes) == 0 {
+		delete(r.s.componentes, id)
+	} else {
+		r.s.componentes[id] = componentes
+	}
+	p.UpdatedAt = time.Now()
+	r.s.productos[id] = p
+
+	p = r.s.producto(p)
+	return &p, nil
+}
+
+func componenteRepetido(componentes []models.Componente, productoID int) bool {
+	for _, c := range componentes {
+		if c.ProductoID == productoID {
+			return true
+		}
+	}
+	return false
+}
+
+// ensamblaje devuelve una copia del ensamblaje con su producto resuelto y,
+// si conMovimientos, sus movimientos. Debe llamarse con el mutex tomado.
+func (s *store) ensamblaje(e models.Ensamblaje, conMovimientos bool) models.Ensamblaje {
+	e.Producto = nil
+	if p, ok := s.productos[e.ProductoID]; ok {
+		e.Producto = &models.Producto{ID: p.ID, Nombre: p.Nombre, SKU: p.SKU}
+	}
+	e.Movimientos = nil
+	if conMovimientos {
+		for _, m := range s.movimientos {
+			if m.EnsamblajeID != nil && *m.EnsamblajeID == e.ID {
+				e.Movimientos = append(e.Movimientos, s.movimiento(m))
+			}
+		}
+		sort.Slice(e.Movimientos, func(i, j int) bool {
+			return e.Movimientos[i].ID < e.Movimientos[j].ID
+		})
+	}
+	return e
+}
+
+func (r *EnsamblajeRepository) List(ctx context.Context, filtro repository.EnsamblajeFiltro) ([]models.Ensamblaje, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var ensamblajes []models.Ensamblaje
+	for _, e := range r.s.ensamblajes {
+		if filtro.Tipo != "" && e.Tipo != filtro.Tipo {
+			continue
+		}
+		if filtro.ProductoID != nil && e.ProductoID != *filtro.ProductoID {
+			continue
+		}
+		if filtro.AlmacenID != nil && e.AlmacenID != *filtro.AlmacenID {
+			continue
+		}
+		ensamblajes = append(ensamblajes, r.s.ensamblaje(e, false))
+	}
+	// Más recientes primero
+	sort.Slice(ensamblajes, func(i, j int) bool {
+		if !ensamblajes[i].CreatedAt.Equal(ensamblajes[j].CreatedAt) {
+			return ensamblajes[i].CreatedAt.After(ensamblajes[j].CreatedAt)
+		}
+		return ensamblajes[i].ID > ensamblajes[j].ID
+	})
+	return ensamblajes, nil
+}
+
+func (r *EnsamblajeRepository) GetByID(ctx context.Context, id int) (*models.Ensamblaje, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	e, ok := r.s.ensamblajes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	e = r.s.ensamblaje(e, true)
+	return &e, nil
+}
+
+func (r *EnsamblajeRepository) Create(ctx context.Context, req models.EnsamblajeRequest) (*models.Ensamblaje, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.productos[req.ProductoID]; !ok {
+		return nil, repository.ErrProductoNoExiste
+	}
+	componentes := r.s.componentes[req.ProductoID]
+	if len(componentes) == 0 {
+		return nil, repository.ErrNoEsKit
+	}
+	if req.AlmacenID == 0 {
+		req.AlmacenID = r.s.almacenPrincipal()
+	}
+	if _, ok := r.s.almacenes[req.AlmacenID]; !ok {
+		return nil, repository.ErrAlmacenNoExiste
+	}
+
+	e := models.Ensamblaje{
+		ID:         r.s.ultimoEnsamblajeID + 1,
+		Tipo:       req.Tipo,
+		ProductoID: req.ProductoID,
+		AlmacenID:  req.AlmacenID,
+		Cantidad:   req.Cantidad,
+		Motivo:     req.Motivo,
+	}
+	salidas, entradas := repository.MovimientosEnsamblaje(e, componentes)
+
+	// Verificar todos los movimientos antes de registrar ninguno para que el
+	// ensamblaje sea atómico
+	for _, m := range append(append([]models.MovimientoInventario{}, salidas...), entradas...) {
+		if len(r.s.variantes(m.ProductoID)) > 0 {
+			return nil, repository.ErrProductoConVariantes
+		}
+		delta := repository.EfectoStock(m)
+		k := stockKey{m.ProductoID, m.AlmacenID}
+		if delta < 0 && r.s.stock[k]-r.s.reservado(m.ProductoID, m.AlmacenID)+delta < 0 {
+			return nil, repository.ErrStockInsuficiente
+		}
+		if err := r.s.validarUnidades(m, delta); err != nil {
+			return nil, err
+		}
+	}
+
+	// Las entradas se valoran con el costo de lo consumido; el costo de
+	// referencia de cada una es el que tendría sin costo propio
+	var total float64
+	for _, m := range salidas {
+		aplicado, err := r.s.aplicarMovimiento(m)
+		if err != nil {
+			return nil, err
+		}
+		total += *aplicado.CostoTotal
+	}
+	costos := make([]float64, len(entradas))
+	for i, m := range entradas {
+		costos[i], _ = r.s.costoEntrada(m, r.s.productos[m.ProductoID])
+	}
+	repository.CostosEnsamblaje(total, entradas, costos)
+	for _, m := range entradas {
+		if _, err := r.s.aplicarMovimiento(m); err != nil {
+			return nil, err
+		}
+	}
+
+	r.s.ultimoEnsamblajeID = e.ID
+	e.CreatedAt = r.s.movimientos[r.s.ultimoMovimientoID].CreatedAt
+	r.s.ensamblajes[e.ID] = e
+
+	e = r.s.ensamblaje(e, true)
+	return &e, nil
+}
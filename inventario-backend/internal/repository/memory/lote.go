@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+)
+
+type LoteRepository struct {
+	s *store
+}
+
+// loteCodigo devuelve el lote del producto con ese código en el almacén, o
+// nil si no existe. Debe llamarse con el mutex tomado.
+func (s *store) loteCodigo(productoID, almacenID int, codigo string) *models.Lote {
+	for _, l := range s.lotes {
+		if l.ProductoID == productoID && l.AlmacenID == almacenID && l.Codigo == codigo {
+			return l
+		}
+	}
+	return nil
+}
+
+// origenUnidades devuelve el movimiento cuyas unidades, con sus lotes y
+// series, recupera m: el de sus capas de costo o, en la reposición de una
+// devolución, la salida de la venta devuelta. Devuelve 0 si no hay. Debe
+// llamarse con el mutex tomado.
+func (s *store) origenUnidades(m models.MovimientoInventario) int {
+	if m.DevolucionID == nil {
+		return s.origenCapas(m)
+	}
+	d, ok := s.devoluciones[*m.DevolucionID]
+	if !ok {
+		return 0
+	}
+	if d.MovimientoID != nil {
+		return *d.MovimientoID
+	}
+	for _, o := range s.movimientos {
+		if o.OrdenVentaID != nil && *o.OrdenVentaID == *d.OrdenVentaID && o.ProductoID == m.ProductoID &&
+			o.Tipo == models.TipoSalida {
+			return o.ID
+		}
+	}
+	return 0
+}
+
+// lotesRepuestos suma por lote lo que ya repusieron las devoluciones de la
+// misma venta que la de m. Debe llamarse con el mutex tomado.
+func (s *store) lotesRepuestos(m models.MovimientoInventario) map[int]int {
+	if m.DevolucionID == nil {
+		return nil
+	}
+	d := s.devoluciones[*m.DevolucionID]
+	usado := make(map[int]int)
+	for _, o := range s.movimientos {
+		if o.DevolucionID == nil || o.ProductoID != m.ProductoID {
+			continue
+		}
+		otra, ok := s.devoluciones[*o.DevolucionID]
+		if !ok || !mismaVenta(d, otra) {
+			continue
+		}
+		for _, l := range o.Lotes {
+			usado[l.LoteID] += l.Cantidad
+		}
+	}
+	return usado
+}
+
+// mismaVenta indica si las dos devoluciones remiten a la misma orden o salida
+func mismaVenta(a, b *models.Devolucion) bool {
+	if a.OrdenVentaID != nil {
+		return b.OrdenVentaID != nil && *a.OrdenVentaID == *b.OrdenVentaID
+	}
+	return b.MovimientoID != nil && *a.MovimientoID == *b.MovimientoID
+}
+
+// planLotes decide qué lotes mueve m, que cambia el stock en delta. Las
+// unidades que vuelven lo hacen a los lotes de su origen; el resto de lo que
+// suma va al lote indicado y lo que resta sale del lote indicado o de los que
+// vencen primero. Debe llamarse con el mutex tomado.
+func (s *store) planLotes(m models.MovimientoInventario, p models.Producto, delta int) ([]models.MovimientoLote, error) {
+	if !p.ControlaLotes {
+		if m.Lote != "" || m.Vencimiento != nil {
+			return nil, repository.ErrLoteInvalido
+		}
+		return nil, nil
+	}
+	if delta < 0 && m.Vencimiento != nil {
+		return nil, repository.ErrLoteInvalido
+	}
+
+	cantidad := max(delta, -delta)
+	var plan []models.MovimientoLote
+	if origen, ok := s.movimientos[s.origenUnidades(m)]; ok {
+		plan, cantidad = repository.PlanOrigen(origen.Lotes, s.lotesRepuestos(m), cantidad)
+		if delta < 0 {
+			for _, l := range plan {
+				if s.lotes[l.LoteID].Cantidad < l.Cantidad {
+					return nil, repository.ErrStockInsuficiente
+				}
+			}
+		}
+	}
+	if cantidad == 0 {
+		return plan, nil
+	}
+
+	if delta > 0 {
+		if m.Lote == "" {
+			return nil, repository.ErrLoteInvalido
+		}
+		l := models.MovimientoLote{Codigo: m.Lote, Vencimiento: m.Vencimiento, Cantidad: cantidad}
+		if lote := s.loteCodigo(m.ProductoID, m.AlmacenID, m.Lote); lote != nil {
+			// Un lote conserva el vencimiento con que se creó
+			if m.Vencimiento != nil && (lote.Vencimiento == nil || !lote.Vencimiento.Equal(m.Vencimiento.Time)) {
+				return nil, repository.ErrLoteInvalido
+			}
+			l.LoteID, l.Vencimiento = lote.ID, lote.Vencimiento
+		}
+		return append(plan, l), nil
+	}
+
+	if m.Lote != "" {
+		lote := s.loteCodigo(m.ProductoID, m.AlmacenID, m.Lote)
+		if lote == nil {
+			return nil, repository.ErrLoteNoExiste
+		}
+		if lote.Cantidad < cantidad {
+			return nil, repository.ErrStockInsuficiente
+		}
+		return append(plan, models.MovimientoLote{LoteID: lote.ID, Codigo: lote.Codigo, Vencimiento: lote.Vencimiento, Cantidad: cantidad}), nil
+	}
+
+	var vigentes []models.Lote
+	for _, l := range s.lotes {
+		if l.ProductoID == m.ProductoID && l.AlmacenID == m.AlmacenID {
+			vigentes = append(vigentes, *l)
+		}
+	}
+	fefo, resto := repository.PlanFEFO(vigentes, cantidad)
+	if resto > 0 {
+		return nil, repository.ErrStockInsuficiente
+	}
+	return append(plan, fefo...), nil
+}
+
+// aplicarLotes suma o resta a cada lote su parte del plan y devuelve el plan
+// con los lotes creados. Debe llamarse con el mutex de escritura tomado.
+func (s *store) aplicarLotes(m models.MovimientoInventario, delta int, plan []models.MovimientoLote) []models.MovimientoLote {
+	for i, l := range plan {
+		if delta < 0 {
+			s.lotes[l.LoteID].Cantidad -= l.Cantidad
+			continue
+		}
+		// Las unidades de un traslado llegan a un almacén distinto del de
+		// su lote de origen, así que se buscan por código
+		lote := s.loteCodigo(m.ProductoID, m.AlmacenID, l.Codigo)
+		if lote == nil {
+			s.ultimoLoteID++
+			lote = &models.Lote{
+				ID:          s.ultimoLoteID,
+				ProductoID:  m.ProductoID,
+				AlmacenID:   m.AlmacenID,
+				Codigo:      l.Codigo,
+				Vencimiento: l.Vencimiento,
+				CreatedAt:   m.CreatedAt,
+			}
+			s.lotes[lote.ID] = lote
+		}
+		lote.Cantidad += l.Cantidad
+		plan[i].LoteID = lote.ID
+	}
+	return plan
+}
+
+func (r *LoteRepository) List(ctx context.Context, filtro repository.LoteFiltro) ([]models.Lote, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var lotes []models.Lote
+	for _, l := range r.s.lotes {
+		if filtro.ProductoID != nil && l.ProductoID != *filtro.ProductoID {
+			continue
+		}
+		if filtro.AlmacenID != nil && l.AlmacenID != *filtro.AlmacenID {
+			continue
+		}
+		if filtro.ConStock && l.Cantidad <= 0 {
+			continue
+		}
+		if filtro.VenceHasta != nil && (l.Vencimiento == nil || l.Vencimiento.After(*filtro.VenceHasta)) {
+			continue
+		}
+		lote := *l
+		p := r.s.productos[l.ProductoID]
+		lote.Producto = &models.Producto{ID: p.ID, Nombre: p.Nombre, SKU: p.SKU}
+		lotes = append(lotes, lote)
+	}
+	sort.Slice(lotes, func(i, j int) bool { return repository.AntesFEFO(lotes[i], lotes[j]) })
+	return lotes, nil
+}
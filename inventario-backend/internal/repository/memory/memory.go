@@ -0,0 +1,164 @@
+// Package memory implementa los repositorios en memoria. Aplica las mismas
+// reglas que el esquema de PostgreSQL para poder ejecutar la API sin PostgreSQL
+// (desarrollo del frontend, CI y demos).
+package memory
+
+import (
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sync"
+	"time"
+)
+
+// store contiene el estado compartido por todos los repositorios. Un único
+// mutex protege todas las tablas para que las operaciones que tocan varias
+// de ellas sean atómicas, igual que una transacción.
+type store struct {
+	mu sync.RWMutex
+
+	categorias  map[int]models.Categoria
+	productos   map[int]models.Producto
+	almacenes   map[int]models.Almacen
+	stock       map[stockKey]int
+	movimientos map[int]models.MovimientoInventario
+	traslados   map[int]models.Traslado
+	// reversiones indexa el movimiento que revierte a cada uno, como la
+	// restricción UNIQUE de revierte_id
+	reversiones map[int]int
+	conteos     map[int]*conteoGuardado
+	registros   map[registroKey]models.ConteoRegistro
+	capas       map[int]*capaCosto
+	// consumos guarda las capas que tomó cada salida
+	consumos map[int][]consumo
+	alertas  map[int]models.Alerta
+	// vinculos replica producto_proveedores, sin los nombres
+	proveedores map[int]models.Proveedor
+	vinculos    map[vinculoKey]models.ProductoProveedor
+	// ordenes guarda las líneas sin los datos del producto
+	ordenes map[int]*models.OrdenCompra
+	ventas  map[int]*models.OrdenVenta
+	// devoluciones guarda los items sin los datos del producto
+	devoluciones map[int]*models.Devolucion
+	// lotes guarda el stock de cada lote; el desglose de cada movimiento
+	// queda en su campo Lotes
+	lotes map[int]*models.Lote
+	// series guarda dónde está cada unidad; las que movió cada movimiento
+	// quedan en su campo Series
+	series map[int]*models.Serie
+	// componentes replica producto_componentes: la lista de materiales de
+	// cada kit por producto, sin los datos del componente
+	componentes map[int][]models.Componente
+	ensamblajes map[int]models.Ensamblaje
+
+	ultimaCategoriaID  int
+	ultimoProductoID   int
+	ultimoAlmacenID    int
+	ultimoMovimientoID int
+	ultimoTrasladoID   int
+	ultimoConteoID     int
+	ultimaCapaID       int
+	ultimaAlertaID     int
+	ultimoProveedorID  int
+	ultimaOrdenID      int
+	ultimaVentaID      int
+	ultimaDevolucionID int
+	ultimoItemID       int
+	ultimoLoteID       int
+	ultimaSerieID      int
+	ultimoEnsamblajeID int
+}
+
+// stockKey identifica una fila de stock_almacen
+type stockKey struct {
+	productoID int
+	almacenID  int
+}
+
+// newStore crea un almacenamiento vacío con el almacén principal que crea la
+// migración 0004_almacenes
+func newStore() *store {
+	ahora := time.Now()
+	return &store{
+		categorias: make(map[int]models.Categoria),
+		productos:  make(map[int]models.Producto),
+		almacenes: map[int]models.Almacen{
+			1: {
+				ID:          1,
+				Nombre:      "Almacén Principal",
+				Descripcion: "Almacén por defecto",
+				Principal:   true,
+				CreatedAt:   ahora,
+				UpdatedAt:   ahora,
+			},
+		},
+		stock:           make(map[stockKey]int),
+		movimientos:     make(map[int]models.MovimientoInventario),
+		traslados:       make(map[int]models.Traslado),
+		reversiones:     make(map[int]int),
+		conteos:         make(map[int]*conteoGuardado),
+		registros:       make(map[registroKey]models.ConteoRegistro),
+		capas:           make(map[int]*capaCosto),
+		consumos:        make(map[int][]consumo),
+		alertas:         make(map[int]models.Alerta),
+		proveedores:     make(map[int]models.Proveedor),
+		vinculos:        make(map[vinculoKey]models.ProductoProveedor),
+		ordenes:         make(map[int]*models.OrdenCompra),
+		ventas:          make(map[int]*models.OrdenVenta),
+		devoluciones:    make(map[int]*models.Devolucion),
+		lotes:           make(map[int]*models.Lote),
+		series:          make(map[int]*models.Serie),
+		componentes:     make(map[int][]models.Componente),
+		ensamblajes:     make(map[int]models.Ensamblaje),
+		ultimoAlmacenID: 1,
+	}
+}
+
+// NewRepositories construye todos los repositorios sobre un almacén vacío.
+func NewRepositories() repository.Repositories {
+	s := newStore()
+	return repository.Repositories{
+		Productos:    &ProductoRepository{s: s},
+		Categorias:   &CategoriaRepository{s: s},
+		Almacenes:    &AlmacenRepository{s: s},
+		Movimientos:  &MovimientoRepository{s: s},
+		Traslados:    &TrasladoRepository{s: s},
+		Conteos:      &ConteoRepository{s: s},
+		Alertas:      &AlertaRepository{s: s},
+		Proveedores:  &ProveedorRepository{s: s},
+		Compras:      &OrdenCompraRepository{s: s},
+		Ventas:       &OrdenVentaRepository{s: s},
+		Devoluciones: &DevolucionRepository{s: s},
+		Lotes:        &LoteRepository{s: s},
+		Series:       &SerieRepository{s: s},
+		Ensamblajes:  &EnsamblajeRepository{s: s},
+	}
+}
+
+// paginar devuelve la página solicitada de items; limit 0 devuelve todos
+func paginar[T any](items []T, page, limit int) []T {
+	if limit <= 0 {
+		return items
+	}
+	if page < 1 {
+		page = 1
+	}
+	inicio := (page - 1) * limit
+	if inicio >= len(items) {
+		return nil
+	}
+	fin := inicio + limit
+	if fin > len(items) {
+		fin = len(items)
+	}
+	return items[inicio:fin]
+}
+
+func compararFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type MovimientoRepository struct {
+	s *store
+}
+
+// movimiento devuelve una copia del movimiento con su producto, su almacén y
+// su reversión resueltos. Debe llamarse con el mutex tomado.
+func (s *store) movimiento(m models.MovimientoInventario) models.MovimientoInventario {
+	m.Producto = nil
+	if p, ok := s.productos[m.ProductoID]; ok {
+		m.Producto = &models.Producto{
+			ID:          p.ID,
+			Nombre:      p.Nombre,
+			Descripcion: p.Descripcion,
+			Precio:      p.Precio,
+			Stock:       p.Stock,
+		}
+	}
+	m.Almacen = nil
+	if a, ok := s.almacenes[m.AlmacenID]; ok {
+		m.Almacen = &models.Almacen{ID: a.ID, Nombre: a.Nombre, Principal: a.Principal}
+	}
+	m.Proveedor = nil
+	if m.ProveedorID != nil {
+		m.Proveedor = &models.Proveedor{ID: *m.ProveedorID, Nombre: s.proveedores[*m.ProveedorID].Nombre}
+	}
+	m.RevertidoPorID = nil
+	if rid, ok := s.reversiones[m.ID]; ok {
+		m.RevertidoPorID = &rid
+	}
+	return m
+}
+
+// movioLote indica si el movimiento tocó el lote
+func movioLote(m models.MovimientoInventario, loteID int) bool {
+	for _, l := range m.Lotes {
+		if l.LoteID == loteID {
+			return true
+		}
+	}
+	return false
+}
+
+// cumpleFiltroMovimiento indica si el movimiento cumple las condiciones del filtro.
+// Debe llamarse con el mutex tomado.
+func (s *store) cumpleFiltroMovimiento(m models.MovimientoInventario, f repository.MovimientoFiltro) bool {
+	if f.Tipo != "" && m.Tipo != f.Tipo {
+		return false
+	}
+	if f.CodigoMotivo != "" && m.CodigoMotivo != f.CodigoMotivo {
+		return false
+	}
+	if f.ProductoID != nil && m.ProductoID != *f.ProductoID {
+		return false
+	}
+	if f.CategoriaID != nil && s.productos[m.ProductoID].CategoriaID != *f.CategoriaID {
+		return false
+	}
+	if f.AlmacenID != nil && m.AlmacenID != *f.AlmacenID {
+		return false
+	}
+	if f.ProveedorID != nil && (m.ProveedorID == nil || *m.ProveedorID != *f.ProveedorID) {
+		return false
+	}
+	if f.OrdenCompraID != nil && (m.OrdenCompraID == nil || *m.OrdenCompraID != *f.OrdenCompraID) {
+		return false
+	}
+	if f.OrdenVentaID != nil && (m.OrdenVentaID == nil || *m.OrdenVentaID != *f.OrdenVentaID) {
+		return false
+	}
+	if f.DevolucionID != nil && (m.DevolucionID == nil || *m.DevolucionID != *f.DevolucionID) {
+		return false
+	}
+	if f.LoteID != nil && !movioLote(m, *f.LoteID) {
+		return false
+	}
+	if f.Serie != "" && !slices.Contains(m.Series, f.Serie) {
+		return false
+	}
+	if f.Desde != nil && m.CreatedAt.Before(*f.Desde) {
+		return false
+	}
+	if f.Hasta != nil && !m.CreatedAt.Before(*f.Hasta) {
+		return false
+	}
+	if f.Q != "" && !strings.Contains(strings.ToLower(m.Motivo), strings.ToLower(f.Q)) {
+		return false
+	}
+	if f.After != nil && !antesDe(m, *f.After) {
+		return false
+	}
+	return true
+}
+
+// antesDe indica si m va después del cursor en orden (created_at, id) descendente
+func antesDe(m models.MovimientoInventario, c repository.MovimientoCursor) bool {
+	if !m.CreatedAt.Equal(c.CreatedAt) {
+		return m.CreatedAt.Before(c.CreatedAt)
+	}
+	return m.ID < c.ID
+}
+
+func (r *MovimientoRepository) List(ctx context.Context, filtro repository.MovimientoFiltro) ([]models.MovimientoInventario, *repository.MovimientoCursor, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var movimientos []models.MovimientoInventario
+	for _, m := range r.s.movimientos {
+		if r.s.cumpleFiltroMovimiento(m, filtro) {
+			movimientos = append(movimientos, r.s.movimiento(m))
+		}
+	}
+	// Más recientes primero; el ID desempata movimientos del mismo instante
+	sort.Slice(movimientos, func(i, j int) bool {
+		return antesDe(movimientos[j], repository.MovimientoCursor{
+			CreatedAt: movimientos[i].CreatedAt,
+			ID:        movimientos[i].ID,
+		})
+	})
+
+	movimientos, next := repository.SiguientePagina(movimientos, filtro.Limit)
+	return movimientos, next, nil
+}
+
+func (r *MovimientoRepository) GetByID(ctx context.Context, id int) (*models.MovimientoInventario, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	m, ok := r.s.movimientos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	m = r.s.movimiento(m)
+	return &m, nil
+}
+
+func (r *MovimientoRepository) Create(ctx context.Context, req models.MovimientoInventarioRequest) (*models.MovimientoInventario, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	m, err := r.s.aplicarMovimiento(models.MovimientoInventario{
+		ProductoID:    req.ProductoID,
+		AlmacenID:     req.AlmacenID,
+		Tipo:          req.Tipo,
+		Cantidad:      req.Cantidad,
+		CodigoMotivo:  req.CodigoMotivo,
+		Motivo:        req.Motivo,
+		CostoUnitario: req.CostoUnitario,
+		Moneda:        req.Moneda,
+		ProveedorID:   req.ProveedorID,
+		TipoCambio:    req.TipoCambio,
+		Lote:          req.Lote,
+		Vencimiento:   req.Vencimiento,
+		Series:        req.Series,
+	})
+	if err != nil {
+		return nil, err
+	}
+	m = r.s.movimiento(m)
+	return &m, nil
+}
+
+func (r *MovimientoRepository) Revertir(ctx context.Context, id int, motivo string) (*models.MovimientoInventario, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	original, ok := r.s.movimientos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	// Una salida con devoluciones tampoco se revierte: lo devuelto ya volvió
+	// o está en cuarentena
+	if original.TrasladoID != nil || original.OrdenCompraID != nil || original.OrdenVentaID != nil ||
+		original.DevolucionID != nil || original.EnsamblajeID != nil || original.RevierteID != nil ||
+		r.s.salidaDevuelta(id) {
+		return nil, repository.ErrReversionNoPermitida
+	}
+	if _, ok := r.s.reversiones[id]; ok {
+		return nil, repository.ErrMovimientoRevertido
+	}
+
+	m, err := r.s.aplicarMovimiento(repository.Reversion(original, motivo))
+	if err != nil {
+		return nil, err
+	}
+	m = r.s.movimiento(m)
+	return &m, nil
+}
@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type OrdenCompraRepository struct {
+	s *store
+}
+
+// ordenCompra devuelve una copia de la orden con el nombre del proveedor y
+// los datos del producto de cada línea. Si lineas es false las omite, como en
+// el listado. Debe llamarse con el mutex tomado.
+func (s *store) ordenCompra(o *models.OrdenCompra, lineas bool) models.OrdenCompra {
+	orden := *o
+	orden.Proveedor = s.proveedores[o.ProveedorID].Nombre
+	orden.Lineas = nil
+	for _, l := range o.Lineas {
+		p, ok := s.productos[l.ProductoID]
+		if !ok {
+			continue
+		}
+		l.Producto = &models.Producto{ID: p.ID, Nombre: p.Nombre, SKU: p.SKU}
+		orden.Lineas = append(orden.Lineas, l)
+	}
+	sort.Slice(orden.Lineas, func(i, j int) bool {
+		a, b := orden.Lineas[i].Producto, orden.Lineas[j].Producto
+		if c := strings.Compare(a.Nombre, b.Nombre); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	})
+	repository.ResolverOrdenCompra(&orden)
+	if !lineas {
+		orden.Lineas = nil
+	}
+	return orden
+}
+
+// validarOrdenCompra replica las restricciones de ordenes_compra y
+// orden_compra_lineas. Debe llamarse con el mutex tomado.
+func (s *store) validarOrdenCompra(req models.OrdenCompraRequest, almacenID int) error {
+	if _, ok := s.proveedores[req.ProveedorID]; !ok {
+		return repository.ErrProveedorNoExiste
+	}
+	if _, ok := s.almacenes[almacenID]; !ok {
+		return repository.ErrAlmacenNoExiste
+	}
+	vistos := make(map[int]bool, len(req.Lineas))
+	for _, l := range req.Lineas {
+		if _, ok := s.productos[l.ProductoID]; !ok {
+			return repository.ErrProductoNoExiste
+		}
+		if vistos[l.ProductoID] {
+			return repository.ErrDuplicado
+		}
+		vistos[l.ProductoID] = true
+		if l.Cantidad <= 0 || l.CostoUnitario < 0 {
+			return repository.ErrValorInvalido
+		}
+	}
+	return nil
+}
+
+func lineasOrden(req []models.OrdenCompraLineaRequest) []models.OrdenCompraLinea {
+	lineas := make([]models.OrdenCompraLinea, 0, len(req))
+	for _, l := range req {
+		lineas = append(lineas, models.OrdenCompraLinea{
+			ProductoID:    l.ProductoID,
+			Cantidad:      l.Cantidad,
+			CostoUnitario: l.CostoUnitario,
+		})
+	}
+	return lineas
+}
+
+// ordenEnEstado devuelve la orden si está en alguno de los estados
+// indicados. Debe llamarse con el mutex tomado.
+func (s *store) ordenEnEstado(id int, estados ...models.EstadoOrdenCompra) (*models.OrdenCompra, error) {
+	o, ok := s.ordenes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	for _, e := range estados {
+		if o.Estado == e {
+			return o, nil
+		}
+	}
+	return nil, repository.ErrOrdenCompraEstado
+}
+
+// cambiarEstadoOrden guarda el nuevo estado de la orden; recibida y cancelada
+// la cierran. Debe llamarse con el mutex de escritura tomado.
+func (s *store) cambiarEstadoOrden(o *models.OrdenCompra, estado models.EstadoOrdenCompra) {
+	ahora := time.Now()
+	o.Estado = estado
+	o.UpdatedAt = ahora
+	switch estado {
+	case models.EstadoOrdenEnviada:
+		o.EnviadaAt = &ahora
+	case models.EstadoOrdenRecibida, models.EstadoOrdenCancelada:
+		o.CerradaAt = &ahora
+	}
+}
+
+func (r *OrdenCompraRepository) List(ctx context.Context, filtro repository.OrdenCompraFiltro) ([]models.OrdenCompra, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var ordenes []models.OrdenCompra
+	for _, o := range r.s.ordenes {
+		if filtro.Estado != "" && o.Estado != filtro.Estado {
+			continue
+		}
+		if filtro.ProveedorID != nil && o.ProveedorID != *filtro.ProveedorID {
+			continue
+		}
+		ordenes = append(ordenes, r.s.ordenCompra(o, false))
+	}
+	sort.Slice(ordenes, func(i, j int) bool {
+		if !ordenes[i].CreatedAt.Equal(ordenes[j].CreatedAt) {
+			return ordenes[i].CreatedAt.After(ordenes[j].CreatedAt)
+		}
+		return ordenes[i].ID > ordenes[j].ID
+	})
+	return ordenes, nil
+}
+
+func (r *OrdenCompraRepository) GetByID(ctx context.Context, id int) (*models.OrdenCompra, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	o, ok := r.s.ordenes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	orden := r.s.ordenCompra(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenCompraRepository) Create(ctx context.Context, req models.OrdenCompraRequest) (*models.OrdenCompra, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		almacenID = r.s.almacenPrincipal()
+	}
+	if err := r.s.validarOrdenCompra(req, almacenID); err != nil {
+		return nil, err
+	}
+
+	r.s.ultimaOrdenID++
+	ahora := time.Now()
+	o := &models.OrdenCompra{
+		ID:          r.s.ultimaOrdenID,
+		ProveedorID: req.ProveedorID,
+		AlmacenID:   almacenID,
+		Estado:      models.EstadoOrdenBorrador,
+		Notas:       req.Notas,
+		CreatedAt:   ahora,
+		UpdatedAt:   ahora,
+		Lineas:      lineasOrden(req.Lineas),
+	}
+	r.s.ordenes[o.ID] = o
+
+	orden := r.s.ordenCompra(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenCompraRepository) Update(ctx context.Context, id int, req models.OrdenCompraRequest) (*models.OrdenCompra, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, err := r.s.ordenEnEstado(id, models.EstadoOrdenBorrador)
+	if err != nil {
+		return nil, err
+	}
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		almacenID = o.AlmacenID
+	}
+	if err := r.s.validarOrdenCompra(req, almacenID); err != nil {
+		return nil, err
+	}
+
+	o.ProveedorID = req.ProveedorID
+	o.AlmacenID = almacenID
+	o.Notas = req.Notas
+	o.Lineas = lineasOrden(req.Lineas)
+	o.UpdatedAt = time.Now()
+
+	orden := r.s.ordenCompra(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenCompraRepository) Enviar(ctx context.Context, id int) (*models.OrdenCompra, error) {
+	return r.cambiarEstado(id, models.EstadoOrdenEnviada, models.EstadoOrdenBorrador)
+}
+
+func (r *OrdenCompraRepository) Cancelar(ctx context.Context, id int) (*models.OrdenCompra, error) {
+	return r.cambiarEstado(id, models.EstadoOrdenCancelada,
+		models.EstadoOrdenBorrador, models.EstadoOrdenEnviada, models.EstadoOrdenRecibidaParcial)
+}
+
+// cambiarEstado pasa la orden a estado si está en alguno de desde
+func (r *OrdenCompraRepository) cambiarEstado(id int, estado models.EstadoOrdenCompra, desde ...models.EstadoOrdenCompra) (*models.OrdenCompra, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, err := r.s.ordenEnEstado(id, desde...)
+	if err != nil {
+		return nil, err
+	}
+	r.s.cambiarEstadoOrden(o, estado)
+
+	orden := r.s.ordenCompra(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenCompraRepository) Recibir(ctx context.Context, id int, req models.RecepcionRequest) (*models.OrdenCompra, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, ok := r.s.ordenes[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if err := repository.ValidarRecepcion(r.s.ordenCompra(o, true), req); err != nil {
+		return nil, err
+	}
+	// Las entradas no pueden fallar por stock; solo verificar que el
+	// almacén siga existiendo para que la recepción sea atómica
+	if _, ok := r.s.almacenes[o.AlmacenID]; !ok {
+		return nil, repository.ErrAlmacenNoExiste
+	}
+
+	indice := make(map[int]int, len(o.Lineas))
+	for i, l := range o.Lineas {
+		indice[l.ProductoID] = i
+	}
+	entradas := make([]models.MovimientoInventario, 0, len(req.Items))
+	for _, item := range req.Items {
+		costo := o.Lineas[indice[item.ProductoID]].CostoUnitario
+		m := models.MovimientoInventario{
+			ProductoID:    item.ProductoID,
+			AlmacenID:     o.AlmacenID,
+			Tipo:          models.TipoEntrada,
+			Cantidad:      item.Cantidad,
+			Motivo:        repository.MotivoOrdenCompra(id),
+			CostoUnitario: &costo,
+			ProveedorID:   &o.ProveedorID,
+			OrdenCompraID: &o.ID,
+			Lote:          item.Lote,
+			Vencimiento:   item.Vencimiento,
+			Series:        item.Series,
+		}
+		// Lo único que puede rechazar una entrada son sus lotes y series
+		if err := r.s.validarUnidades(m, item.Cantidad); err != nil {
+			return nil, err
+		}
+		entradas = append(entradas, m)
+	}
+	for _, m := range entradas {
+		if _, err := r.s.aplicarMovimiento(m); err != nil {
+			return nil, err
+		}
+		o.Lineas[indice[m.ProductoID]].CantidadRecibida += m.Cantidad
+	}
+	r.s.cambiarEstadoOrden(o, repository.EstadoTrasRecepcion(o.Lineas))
+
+	orden := r.s.ordenCompra(o, true)
+	return &orden, nil
+}
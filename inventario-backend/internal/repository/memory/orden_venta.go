@@ -0,0 +1,303 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type OrdenVentaRepository struct {
+	s *store
+}
+
+// reservado devuelve lo reservado del producto por las órdenes de venta
+// confirmadas en el almacén, o en todos si almacenID es 0. Debe llamarse con
+// el mutex tomado.
+func (s *store) reservado(productoID, almacenID int) int {
+	reservado := 0
+	for _, o := range s.ventas {
+		if o.Estado != models.EstadoVentaConfirmada || (almacenID != 0 && o.AlmacenID != almacenID) {
+			continue
+		}
+		for _, l := range o.Lineas {
+			if l.ProductoID == productoID {
+				reservado += l.Cantidad
+			}
+		}
+	}
+	return reservado
+}
+
+// ordenVenta devuelve una copia de la orden con los datos del producto de
+// cada línea. Si lineas es false las omite, como en el listado. Debe llamarse
+// con el mutex tomado.
+func (s *store) ordenVenta(o *models.OrdenVenta, lineas bool) models.OrdenVenta {
+	orden := *o
+	orden.Lineas = nil
+	for _, l := range o.Lineas {
+		p, ok := s.productos[l.ProductoID]
+		if !ok {
+			continue
+		}
+		l.Producto = &models.Producto{ID: p.ID, Nombre: p.Nombre, SKU: p.SKU}
+		orden.Lineas = append(orden.Lineas, l)
+	}
+	sort.Slice(orden.Lineas, func(i, j int) bool {
+		a, b := orden.Lineas[i].Producto, orden.Lineas[j].Producto
+		if c := strings.Compare(a.Nombre, b.Nombre); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	})
+	repository.ResolverOrdenVenta(&orden)
+	if !lineas {
+		orden.Lineas = nil
+	}
+	return orden
+}
+
+// lineasVenta valida las líneas contra las restricciones de
+// orden_venta_lineas y completa el precio de las que no lo traen con el del
+// producto. Debe llamarse con el mutex tomado.
+func (s *store) lineasVenta(req []models.OrdenVentaLineaRequest) ([]models.OrdenVentaLinea, error) {
+	lineas := make([]models.OrdenVentaLinea, 0, len(req))
+	vistos := make(map[int]bool, len(req))
+	for _, l := range req {
+		p, ok := s.productos[l.ProductoID]
+		if !ok {
+			return nil, repository.ErrProductoNoExiste
+		}
+		if vistos[l.ProductoID] {
+			return nil, repository.ErrDuplicado
+		}
+		vistos[l.ProductoID] = true
+		precio := p.Precio
+		if l.PrecioUnitario != nil {
+			precio = *l.PrecioUnitario
+		}
+		if l.Cantidad <= 0 || precio < 0 {
+			return nil, repository.ErrValorInvalido
+		}
+		lineas = append(lineas, models.OrdenVentaLinea{
+			ProductoID:     l.ProductoID,
+			Cantidad:       l.Cantidad,
+			PrecioUnitario: precio,
+		})
+	}
+	return lineas, nil
+}
+
+// ventaEnEstado devuelve la orden si está en alguno de los estados
+// indicados. Debe llamarse con el mutex tomado.
+func (s *store) ventaEnEstado(id int, estados ...models.EstadoOrdenVenta) (*models.OrdenVenta, error) {
+	o, ok := s.ventas[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	for _, e := range estados {
+		if o.Estado == e {
+			return o, nil
+		}
+	}
+	return nil, repository.ErrOrdenVentaEstado
+}
+
+// cambiarEstadoVenta guarda el nuevo estado de la orden; despachada y
+// cancelada la cierran. Debe llamarse con el mutex de escritura tomado.
+func (s *store) cambiarEstadoVenta(o *models.OrdenVenta, estado models.EstadoOrdenVenta) {
+	ahora := time.Now()
+	o.Estado = estado
+	o.UpdatedAt = ahora
+	switch estado {
+	case models.EstadoVentaConfirmada:
+		o.ConfirmadaAt = &ahora
+	case models.EstadoVentaDespachada, models.EstadoVentaCancelada:
+		o.CerradaAt = &ahora
+	}
+}
+
+func (r *OrdenVentaRepository) List(ctx context.Context, filtro repository.OrdenVentaFiltro) ([]models.OrdenVenta, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var ordenes []models.OrdenVenta
+	for _, o := range r.s.ventas {
+		if filtro.Estado != "" && o.Estado != filtro.Estado {
+			continue
+		}
+		if filtro.Cliente != "" && !strings.Contains(strings.ToLower(o.Cliente), strings.ToLower(filtro.Cliente)) {
+			continue
+		}
+		ordenes = append(ordenes, r.s.ordenVenta(o, false))
+	}
+	sort.Slice(ordenes, func(i, j int) bool {
+		if !ordenes[i].CreatedAt.Equal(ordenes[j].CreatedAt) {
+			return ordenes[i].CreatedAt.After(ordenes[j].CreatedAt)
+		}
+		return ordenes[i].ID > ordenes[j].ID
+	})
+	return ordenes, nil
+}
+
+func (r *OrdenVentaRepository) GetByID(ctx context.Context, id int) (*models.OrdenVenta, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	o, ok := r.s.ventas[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	orden := r.s.ordenVenta(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenVentaRepository) Create(ctx context.Context, req models.OrdenVentaRequest) (*models.OrdenVenta, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		almacenID = r.s.almacenPrincipal()
+	}
+	if _, ok := r.s.almacenes[almacenID]; !ok {
+		return nil, repository.ErrAlmacenNoExiste
+	}
+	lineas, err := r.s.lineasVenta(req.Lineas)
+	if err != nil {
+		return nil, err
+	}
+
+	r.s.ultimaVentaID++
+	ahora := time.Now()
+	o := &models.OrdenVenta{
+		ID:        r.s.ultimaVentaID,
+		Cliente:   req.Cliente,
+		AlmacenID: almacenID,
+		Estado:    models.EstadoVentaBorrador,
+		Notas:     req.Notas,
+		CreatedAt: ahora,
+		UpdatedAt: ahora,
+		Lineas:    lineas,
+	}
+	r.s.ventas[o.ID] = o
+
+	orden := r.s.ordenVenta(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenVentaRepository) Update(ctx context.Context, id int, req models.OrdenVentaRequest) (*models.OrdenVenta, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, err := r.s.ventaEnEstado(id, models.EstadoVentaBorrador)
+	if err != nil {
+		return nil, err
+	}
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		almacenID = o.AlmacenID
+	}
+	if _, ok := r.s.almacenes[almacenID]; !ok {
+		return nil, repository.ErrAlmacenNoExiste
+	}
+	lineas, err := r.s.lineasVenta(req.Lineas)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Cliente = req.Cliente
+	o.AlmacenID = almacenID
+	o.Notas = req.Notas
+	o.Lineas = lineas
+	o.UpdatedAt = time.Now()
+
+	orden := r.s.ordenVenta(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenVentaRepository) Confirmar(ctx context.Context, id int) (*models.OrdenVenta, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, err := r.s.ventaEnEstado(id, models.EstadoVentaBorrador)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range o.Lineas {
+		disponible := r.s.stock[stockKey{l.ProductoID, o.AlmacenID}] - r.s.reservado(l.ProductoID, o.AlmacenID)
+		if disponible < l.Cantidad {
+			return nil, repository.ErrStockInsuficiente
+		}
+	}
+	r.s.cambiarEstadoVenta(o, models.EstadoVentaConfirmada)
+
+	orden := r.s.ordenVenta(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenVentaRepository) Despachar(ctx context.Context, id int, req models.DespachoRequest) (*models.OrdenVenta, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	o, err := r.s.ventaEnEstado(id, models.EstadoVentaConfirmada)
+	if err != nil {
+		return nil, err
+	}
+	series, err := repository.SeriesDespacho(*o, req)
+	if err != nil {
+		return nil, err
+	}
+	salida := func(l models.OrdenVentaLinea) models.MovimientoInventario {
+		return models.MovimientoInventario{
+			ProductoID:   l.ProductoID,
+			AlmacenID:    o.AlmacenID,
+			Tipo:         models.TipoSalida,
+			Cantidad:     l.Cantidad,
+			Motivo:       repository.MotivoOrdenVenta(id),
+			OrdenVentaID: &o.ID,
+			Series:       series[l.ProductoID],
+		}
+	}
+	// Verificar todas las líneas antes de registrar ninguna salida para que
+	// el despacho sea atómico. Sin la reserva de esta orden, lo disponible
+	// debe cubrir cada línea.
+	for _, l := range o.Lineas {
+		k := stockKey{l.ProductoID, o.AlmacenID}
+		otras := r.s.reservado(l.ProductoID, o.AlmacenID) - l.Cantidad
+		if r.s.stock[k]-otras < l.Cantidad {
+			return nil, repository.ErrStockInsuficiente
+		}
+		if err := r.s.validarUnidades(salida(l), -l.Cantidad); err != nil {
+			return nil, err
+		}
+	}
+
+	r.s.cambiarEstadoVenta(o, models.EstadoVentaDespachada)
+	for _, l := range o.Lineas {
+		if _, err := r.s.aplicarMovimiento(salida(l)); err != nil {
+			return nil, err
+		}
+	}
+
+	orden := r.s.ordenVenta(o, true)
+	return &orden, nil
+}
+
+func (r *OrdenVentaRepository) Cancelar(ctx context.Context, id int) (*models.OrdenVenta, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// La reserva se deriva de las órdenes confirmadas, así que cambiar el
+	// estado basta para liberarla
+	o, err := r.s.ventaEnEstado(id, models.EstadoVentaBorrador, models.EstadoVentaConfirmada)
+	if err != nil {
+		return nil, err
+	}
+	r.s.cambiarEstadoVenta(o, models.EstadoVentaCancelada)
+
+	orden := r.s.ordenVenta(o, true)
+	return &orden, nil
+}
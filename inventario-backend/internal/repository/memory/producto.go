@@ -0,0 +1,516 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"maps"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ProductoRepository struct {
+	s *store
+}
+
+// producto devuelve una copia del producto con su categoría resuelta, como
+// hace el LEFT JOIN de la implementación PostgreSQL, su desglose de stock por
+// almacén y lo reservado. El stock, el valor y lo reservado de un padre son
+// la suma de los de sus variantes. Debe llamarse con el mutex tomado.
+func (s *store) producto(p models.Producto) models.Producto {
+	p.Categoria = nil
+	if c, ok := s.categorias[p.CategoriaID]; ok {
+		p.Categoria = &models.Categoria{ID: c.ID, Nombre: c.Nombre, Descripcion: c.Descripcion}
+	}
+	p.Atributos = maps.Clone(p.Atributos)
+	ids := []int{p.ID}
+	for _, v := range s.variantes(p.ID) {
+		ids = append(ids, v.ID)
+		p.Variantes++
+		p.Stock += v.Stock
+		p.ValorInventario += v.ValorInventario
+	}
+	p.StockAlmacenes = s.stockAlmacenes(ids...)
+	p.Reservado = 0
+	for _, id := range ids {
+		p.Reservado += s.reservado(id, 0)
+	}
+	p.Disponible = p.Stock - p.Reservado
+	s.kit(&p)
+	return p
+}
+
+// cumpleFiltro indica si el producto cumple las condiciones del filtro
+func cumpleFiltro(p models.Producto, f repository.ProductoFiltro) bool {
+	if f.CategoriaID != nil && p.CategoriaID != *f.CategoriaID {
+		return false
+	}
+	if f.MinPrecio != nil && p.Precio < *f.MinPrecio {
+		return false
+	}
+	if f.MaxPrecio != nil && p.Precio > *f.MaxPrecio {
+		return false
+	}
+	if f.MinStock != nil && p.Stock < *f.MinStock {
+		return false
+	}
+	if f.MaxStock != nil && p.Stock > *f.MaxStock {
+		return false
+	}
+	if f.BajoStock {
+		if nivel, _ := models.NivelStock(p); nivel == "" {
+			return false
+		}
+	}
+	if f.Q != "" {
+		q := strings.ToLower(f.Q)
+		if !strings.Contains(strings.ToLower(p.Nombre), q) && !strings.Contains(strings.ToLower(p.Descripcion), q) {
+			return false
+		}
+	}
+	return true
+}
+
+// compararProductos ordena por el campo indicado; el ID desempata
+func compararProductos(a, b models.Producto, campo string) int {
+	var c int
+	switch campo {
+	case "precio":
+		c = compararFloat(a.Precio, b.Precio)
+	case "stock":
+		c = a.Stock - b.Stock
+	case "created_at":
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	default:
+		c = strings.Compare(a.Nombre, b.Nombre)
+	}
+	if c == 0 {
+		c = a.ID - b.ID
+	}
+	return c
+}
+
+func (r *ProductoRepository) List(ctx context.Context, filtro repository.ProductoFiltro) ([]models.Producto, int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	// Los padres se filtran por el stock de sus variantes
+	var productos []models.Producto
+	for _, p := range r.s.productos {
+		if p = r.s.producto(p); cumpleFiltro(p, filtro) {
+			productos = append(productos, p)
+		}
+	}
+	sort.Slice(productos, func(i, j int) bool {
+		c := compararProductos(productos[i], productos[j], filtro.Sort)
+		if filtro.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	total := len(productos)
+	return paginar(productos, filtro.Page, filtro.Limit), total, nil
+}
+
+func (r *ProductoRepository) GetByID(ctx context.Context, id int) (*models.Producto, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	p, ok := r.s.productos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	p = r.s.producto(p)
+	return &p, nil
+}
+
+func (r *ProductoRepository) getBy(match func(models.Producto) bool) (*models.Producto, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, p := range r.s.productos {
+		if match(p) {
+			p = r.s.producto(p)
+			return &p, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *ProductoRepository) GetBySKU(ctx context.Context, sku string) (*models.Producto, error) {
+	return r.getBy(func(p models.Producto) bool { return sku != "" && p.SKU == sku })
+}
+
+func (r *ProductoRepository) GetByCodigoBarras(ctx context.Context, codigo string) (*models.Producto, error) {
+	return r.getBy(func(p models.Producto) bool { return codigo != "" && p.CodigoBarras == codigo })
+}
+
+// validarProducto replica las restricciones CHECK, UNIQUE y FOREIGN KEY de
+// productos. Debe llamarse con el mutex tomado.
+func (s *store) validarProducto(id int, req models.ProductoRequest) error {
+	if _, ok := s.categorias[req.CategoriaID]; !ok {
+		return repository.ErrCategoriaNoExiste
+	}
+	// El stock solo se guarda al crear; al actualizar se ignora
+	if req.Precio < 0 || (id == 0 && req.Stock < 0) {
+		return repository.ErrValorNegativo
+	}
+	if req.StockMinimo < 0 || req.PuntoReorden < 0 || req.CantidadReorden < 0 {
+		return repository.ErrValorInvalido
+	}
+	for _, p := range s.productos {
+		if p.ID == id {
+			continue
+		}
+		if req.SKU != nil && *req.SKU != "" && p.SKU == *req.SKU {
+			return repository.ErrSKUDuplicado
+		}
+		if req.CodigoBarras != nil && *req.CodigoBarras != "" && p.CodigoBarras == *req.CodigoBarras {
+			return repository.ErrCodigoBarrasDuplicado
+		}
+	}
+	return nil
+}
+
+func (r *ProductoRepository) Create(ctx context.Context, req models.ProductoRequest) (*models.Producto, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	p, err := r.s.crearProducto(req, models.Producto{})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// crearProducto registra el producto de req con los datos de variante de
+// base y su stock inicial. Debe llamarse con el mutex de escritura tomado.
+func (s *store) crearProducto(req models.ProductoRequest, base models.Producto) (models.Producto, error) {
+	if err := s.validarProducto(0, req); err != nil {
+		return models.Producto{}, err
+	}
+
+	metodo := req.MetodoCosteo
+	if metodo == "" {
+		metodo = models.MetodoPromedio
+	}
+	lotes := req.ControlaLotes != nil && *req.ControlaLotes
+	series := req.ControlaSeries != nil && *req.ControlaSeries
+	// Replica productos_control_check
+	if lotes && series {
+		return models.Producto{}, repository.ErrValorInvalido
+	}
+
+	s.ultimoProductoID++
+	ahora := time.Now()
+	p := models.Producto{
+		ID:              s.ultimoProductoID,
+		Nombre:          req.Nombre,
+		Descripcion:     req.Descripcion,
+		Precio:          req.Precio,
+		CategoriaID:     req.CategoriaID,
+		MetodoCosteo:    metodo,
+		ControlaLotes:   lotes,
+		ControlaSeries:  series,
+		StockMinimo:     req.StockMinimo,
+		PuntoReorden:    req.PuntoReorden,
+		CantidadReorden: req.CantidadReorden,
+		ProductoPadreID: base.ProductoPadreID,
+		Atributos:       base.Atributos,
+		HeredaPrecio:    base.HeredaPrecio,
+		CreatedAt:       ahora,
+		UpdatedAt:       ahora,
+	}
+	if req.SKU != nil {
+		p.SKU = *req.SKU
+	}
+	if req.CodigoBarras != nil {
+		p.CodigoBarras = *req.CodigoBarras
+	}
+	s.productos[p.ID] = p
+
+	// El stock inicial queda en el almacén principal como un ajuste
+	if req.Stock != 0 {
+		_, err := s.aplicarMovimiento(models.MovimientoInventario{
+			ProductoID:    p.ID,
+			Tipo:          models.TipoAjuste,
+			Cantidad:      req.Stock,
+			CodigoMotivo:  models.MotivoInicial,
+			Motivo:        "Stock inicial",
+			CostoUnitario: req.CostoUnitario,
+		})
+		if err != nil {
+			delete(s.productos, p.ID)
+			return models.Producto{}, err
+		}
+	}
+
+	return s.producto(s.productos[p.ID]), nil
+}
+
+func (r *ProductoRepository) Update(ctx context.Context, id int, req models.ProductoRequest) (*models.Producto, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.validarProducto(id, req); err != nil {
+		return nil, err
+	}
+
+	p, ok := r.s.productos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	// Una variante usa el control de su padre, que lo cambia para todas
+	if p.ProductoPadreID != nil && repository.CambiaControl(p, req) {
+		return nil, repository.ErrControlVariante
+	}
+	stock := p.Stock
+	enKit := len(r.s.componentes[id]) > 0 || r.s.esComponente(id)
+	for _, v := range r.s.variantes(id) {
+		stock += v.Stock
+		enKit = enKit || r.s.esComponente(v.ID)
+	}
+
+	if req.MetodoCosteo != "" && req.MetodoCosteo != p.MetodoCosteo {
+		if stock != 0 {
+			return nil, repository.ErrMetodoCosteoConStock
+		}
+		p.MetodoCosteo = req.MetodoCosteo
+	}
+	// Los lotes deben cubrir todo el stock del producto
+	if req.ControlaLotes != nil && *req.ControlaLotes != p.ControlaLotes {
+		if stock != 0 {
+			return nil, repository.ErrLotesConStock
+		}
+		p.ControlaLotes = *req.ControlaLotes
+	}
+	// Igual con las series, que deben cubrir cada unidad
+	if req.ControlaSeries != nil && *req.ControlaSeries != p.ControlaSeries {
+		if stock != 0 {
+			return nil, repository.ErrSeriesConStock
+		}
+		p.ControlaSeries = *req.ControlaSeries
+	}
+	if p.ControlaLotes && p.ControlaSeries {
+		return nil, repository.ErrValorInvalido
+	}
+	// Ensamblar no indica qué lotes o series mueve
+	if enKit && ((p.ControlaLotes && !r.s.productos[id].ControlaLotes) || (p.ControlaSeries && !r.s.productos[id].ControlaSeries)) {
+		return nil, repository.ErrControlEnKit
+	}
+
+	ahora := time.Now()
+	p.Nombre = req.Nombre
+	p.Descripcion = req.Descripcion
+	if req.SKU != nil {
+		p.SKU = *req.SKU
+	}
+	if req.CodigoBarras != nil {
+		p.CodigoBarras = *req.CodigoBarras
+	}
+	p.Precio = req.Precio
+	p.CategoriaID = req.CategoriaID
+	p.StockMinimo = req.StockMinimo
+	p.PuntoReorden = req.PuntoReorden
+	p.CantidadReorden = req.CantidadReorden
+	p.UpdatedAt = ahora
+	if p.ProductoPadreID != nil {
+		p.HeredaPrecio = p.Precio == r.s.productos[*p.ProductoPadreID].Precio
+	}
+	r.s.productos[id] = p
+	for _, v := range r.s.variantes(id) {
+		if v.HeredaPrecio {
+			v.Precio = p.Precio
+			v.UpdatedAt = ahora
+		}
+		if repository.CambiaControl(v, models.ProductoRequest{
+			MetodoCosteo: p.MetodoCosteo, ControlaLotes: &p.ControlaLotes, ControlaSeries: &p.ControlaSeries,
+		}) {
+			v.MetodoCosteo, v.ControlaLotes, v.ControlaSeries = p.MetodoCosteo, p.ControlaLotes, p.ControlaSeries
+			v.UpdatedAt = ahora
+		}
+		r.s.productos[v.ID] = v
+	}
+
+	p = r.s.producto(r.s.productos[id])
+	return &p, nil
+}
+
+func (r *ProductoRepository) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.productos[id]; !ok {
+		return repository.ErrNotFound
+	}
+	// Replica productos_producto_padre_id_fkey (ON DELETE RESTRICT)
+	if len(r.s.variantes(id)) > 0 {
+		return repository.ErrProductoConVariantes
+	}
+	// Replica producto_componentes_componente_id_fkey (ON DELETE RESTRICT)
+	if r.s.esComponente(id) {
+		return repository.ErrProductoEnKit
+	}
+	delete(r.s.productos, id)
+	delete(r.s.componentes, id)
+
+	// Eliminar en cascada los movimientos, el stock, los traslados, las capas
+	// de costo, las alertas, los vínculos con proveedores, las líneas de
+	// órdenes de compra y de venta, los items de devolución, los lotes, las
+	// series, los ensamblajes, la lista de materiales y los items de conteo
+	// del producto (ON DELETE CASCADE). Las devoluciones de sus salidas
+	// sueltas caen con ellas.
+	for mid, m := range r.s.movimientos {
+		if m.ProductoID == id {
+			delete(r.s.movimientos, mid)
+			delete(r.s.reversiones, mid)
+			delete(r.s.consumos, mid)
+		}
+	}
+	for cid, c := range r.s.capas {
+		if c.productoID == id {
+			delete(r.s.capas, cid)
+		}
+	}
+	for aid, a := range r.s.alertas {
+		if a.ProductoID == id {
+			delete(r.s.alertas, aid)
+		}
+	}
+	for k := range r.s.vinculos {
+		if k.productoID == id {
+			delete(r.s.vinculos, k)
+		}
+	}
+	for _, o := range r.s.ordenes {
+		lineas := o.Lineas[:0]
+		for _, l := range o.Lineas {
+			if l.ProductoID != id {
+				lineas = append(lineas, l)
+			}
+		}
+		o.Lineas = lineas
+	}
+	for _, o := range r.s.ventas {
+		lineas := o.Lineas[:0]
+		for _, l := range o.Lineas {
+			if l.ProductoID != id {
+				lineas = append(lineas, l)
+			}
+		}
+		o.Lineas = lineas
+	}
+	for did, d := range r.s.devoluciones {
+		if d.MovimientoID != nil {
+			if _, ok := r.s.movimientos[*d.MovimientoID]; !ok {
+				delete(r.s.devoluciones, did)
+				continue
+			}
+		}
+		items := d.Items[:0]
+		for _, i := range d.Items {
+			if i.ProductoID != id {
+				items = append(items, i)
+			}
+		}
+		d.Items = items
+	}
+	for lid, l := range r.s.lotes {
+		if l.ProductoID == id {
+			delete(r.s.lotes, lid)
+		}
+	}
+	for sid, se := range r.s.series {
+		if se.ProductoID == id {
+			delete(r.s.series, sid)
+		}
+	}
+	for k := range r.s.stock {
+		if k.productoID == id {
+			delete(r.s.stock, k)
+		}
+	}
+	for tid, t := range r.s.traslados {
+		if t.ProductoID == id {
+			delete(r.s.traslados, tid)
+		}
+	}
+	// Los movimientos de los componentes quedan sin su ensamblaje
+	// (ON DELETE SET NULL)
+	for eid, e := range r.s.ensamblajes {
+		if e.ProductoID != id {
+			continue
+		}
+		delete(r.s.ensamblajes, eid)
+		for mid, m := range r.s.movimientos {
+			if m.EnsamblajeID != nil && *m.EnsamblajeID == eid {
+				m.EnsamblajeID = nil
+				r.s.movimientos[mid] = m
+			}
+		}
+	}
+	for _, c := range r.s.conteos {
+		delete(c.esperado, id)
+		delete(c.movimientos, id)
+	}
+	for k := range r.s.registros {
+		if k.productoID == id {
+			delete(r.s.registros, k)
+		}
+	}
+	return nil
+}
+
+func (r *ProductoRepository) Valoracion(ctx context.Context, categoriaID *int) (*models.Valoracion, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var productos []models.Producto
+	for _, p := range r.s.productos {
+		if p.Stock != 0 && (categoriaID == nil || p.CategoriaID == *categoriaID) {
+			productos = append(productos, r.s.producto(p))
+		}
+	}
+	// Por categoría y nombre, con los productos sin categoría al final
+	nombre := func(p models.Producto) (string, bool) {
+		if p.Categoria == nil {
+			return "", false
+		}
+		return p.Categoria.Nombre, true
+	}
+	sort.Slice(productos, func(i, j int) bool {
+		a, b := productos[i], productos[j]
+		na, oka := nombre(a)
+		nb, okb := nombre(b)
+		if oka != okb {
+			return oka
+		}
+		if na != nb {
+			return na < nb
+		}
+		if oka && a.CategoriaID != b.CategoriaID {
+			return a.CategoriaID < b.CategoriaID
+		}
+		return compararProductos(a, b, "nombre") < 0
+	})
+
+	v := &models.Valoracion{Categorias: []models.ValoracionCategoria{}}
+	for _, p := range productos {
+		var cID *int
+		categoria := "Sin categoría"
+		if p.Categoria != nil {
+			cID, categoria = &p.Categoria.ID, p.Categoria.Nombre
+		}
+		repository.AgregarValoracion(v, cID, categoria, models.ValoracionProducto{
+			ProductoID:   p.ID,
+			Producto:     p.Nombre,
+			MetodoCosteo: p.MetodoCosteo,
+			Stock:        p.Stock,
+			Valor:        p.ValorInventario,
+		})
+	}
+	return v, nil
+}
@@ -0,0 +1,248 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ProveedorRepository struct {
+	s *store
+}
+
+// vinculoKey identifica una fila de producto_proveedores
+type vinculoKey struct {
+	productoID  int
+	proveedorID int
+}
+
+// proveedorDuplicado replica la restricción UNIQUE de proveedores.nombre.
+// Debe llamarse con el mutex tomado.
+func (s *store) proveedorDuplicado(nombre string, excluirID int) bool {
+	for _, p := range s.proveedores {
+		if p.ID != excluirID && p.Nombre == nombre {
+			return true
+		}
+	}
+	return false
+}
+
+// vinculo devuelve el vínculo con los nombres del producto y del proveedor.
+// Debe llamarse con el mutex tomado.
+func (s *store) vinculo(pp models.ProductoProveedor) models.ProductoProveedor {
+	pp.Producto = s.productos[pp.ProductoID].Nombre
+	pp.Proveedor = s.proveedores[pp.ProveedorID].Nombre
+	return pp
+}
+
+// registrarEntradaProveedor crea o actualiza el vínculo entre el proveedor y
+// el producto de la entrada m, con su costo en moneda local si lo trae. Debe
+// llamarse con el mutex de escritura tomado.
+func (s *store) registrarEntradaProveedor(m models.MovimientoInventario, ahora time.Time) {
+	k := vinculoKey{m.ProductoID, *m.ProveedorID}
+	pp, ok := s.vinculos[k]
+	if !ok {
+		pp = models.ProductoProveedor{ProductoID: m.ProductoID, ProveedorID: *m.ProveedorID, CreatedAt: ahora}
+	}
+	if m.CostoUnitario != nil {
+		costo := repository.CostoLocal(m)
+		pp.UltimoCosto = &costo
+	}
+	pp.UltimaEntradaAt = &ahora
+	pp.UpdatedAt = ahora
+	s.vinculos[k] = pp
+}
+
+func (r *ProveedorRepository) List(ctx context.Context) ([]models.Proveedor, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var proveedores []models.Proveedor
+	for _, p := range r.s.proveedores {
+		proveedores = append(proveedores, p)
+	}
+	sort.Slice(proveedores, func(i, j int) bool {
+		return strings.Compare(proveedores[i].Nombre, proveedores[j].Nombre) < 0
+	})
+	return proveedores, nil
+}
+
+func (r *ProveedorRepository) GetByID(ctx context.Context, id int) (*models.Proveedor, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	p, ok := r.s.proveedores[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *ProveedorRepository) Create(ctx context.Context, req models.ProveedorRequest) (*models.Proveedor, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if r.s.proveedorDuplicado(req.Nombre, 0) {
+		return nil, repository.ErrProveedorDuplicado
+	}
+
+	r.s.ultimoProveedorID++
+	ahora := time.Now()
+	p := models.Proveedor{
+		ID:        r.s.ultimoProveedorID,
+		Nombre:    req.Nombre,
+		Contacto:  req.Contacto,
+		Email:     req.Email,
+		Telefono:  req.Telefono,
+		Direccion: req.Direccion,
+		Notas:     req.Notas,
+		CreatedAt: ahora,
+		UpdatedAt: ahora,
+	}
+	r.s.proveedores[p.ID] = p
+	return &p, nil
+}
+
+func (r *ProveedorRepository) Update(ctx context.Context, id int, req models.ProveedorRequest) (*models.Proveedor, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if r.s.proveedorDuplicado(req.Nombre, id) {
+		return nil, repository.ErrProveedorDuplicado
+	}
+
+	p, ok := r.s.proveedores[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	p.Nombre = req.Nombre
+	p.Contacto = req.Contacto
+	p.Email = req.Email
+	p.Telefono = req.Telefono
+	p.Direccion = req.Direccion
+	p.Notas = req.Notas
+	p.UpdatedAt = time.Now()
+	r.s.proveedores[id] = p
+	return &p, nil
+}
+
+func (r *ProveedorRepository) Delete(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.proveedores[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, m := range r.s.movimientos {
+		if m.ProveedorID != nil && *m.ProveedorID == id {
+			return repository.ErrProveedorEnUso
+		}
+	}
+	for _, o := range r.s.ordenes {
+		if o.ProveedorID == id {
+			return repository.ErrProveedorEnUso
+		}
+	}
+
+	for k := range r.s.vinculos {
+		if k.proveedorID == id {
+			delete(r.s.vinculos, k)
+		}
+	}
+	delete(r.s.proveedores, id)
+	return nil
+}
+
+// listarVinculos devuelve los vínculos que cumplen incluir ordenados por nombre
+// según clave. Debe llamarse con el mutex tomado.
+func (s *store) listarVinculos(incluir func(vinculoKey) bool, clave func(models.ProductoProveedor) (string, int)) []models.ProductoProveedor {
+	var vinculos []models.ProductoProveedor
+	for k, pp := range s.vinculos {
+		if incluir(k) {
+			vinculos = append(vinculos, s.vinculo(pp))
+		}
+	}
+	sort.Slice(vinculos, func(i, j int) bool {
+		ni, idi := clave(vinculos[i])
+		nj, idj := clave(vinculos[j])
+		if c := strings.Compare(ni, nj); c != 0 {
+			return c < 0
+		}
+		return idi < idj
+	})
+	return vinculos
+}
+
+func (r *ProveedorRepository) Productos(ctx context.Context, proveedorID int) ([]models.ProductoProveedor, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if _, ok := r.s.proveedores[proveedorID]; !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.s.listarVinculos(
+		func(k vinculoKey) bool { return k.proveedorID == proveedorID },
+		func(pp models.ProductoProveedor) (string, int) { return pp.Producto, pp.ProductoID },
+	), nil
+}
+
+func (r *ProveedorRepository) ProveedoresDeProducto(ctx context.Context, productoID int) ([]models.ProductoProveedor, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if _, ok := r.s.productos[productoID]; !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.s.listarVinculos(
+		func(k vinculoKey) bool { return k.productoID == productoID },
+		func(pp models.ProductoProveedor) (string, int) { return pp.Proveedor, pp.ProveedorID },
+	), nil
+}
+
+func (r *ProveedorRepository) Vincular(ctx context.Context, proveedorID, productoID int, req models.ProductoProveedorRequest) (*models.ProductoProveedor, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Mismas comprobaciones que las restricciones de producto_proveedores
+	if _, ok := r.s.proveedores[proveedorID]; !ok {
+		return nil, repository.ErrProveedorNoExiste
+	}
+	if _, ok := r.s.productos[productoID]; !ok {
+		return nil, repository.ErrProductoNoExiste
+	}
+	if req.PlazoEntregaDias < 0 || (req.UltimoCosto != nil && *req.UltimoCosto < 0) {
+		return nil, repository.ErrValorInvalido
+	}
+
+	ahora := time.Now()
+	k := vinculoKey{productoID, proveedorID}
+	pp, ok := r.s.vinculos[k]
+	if !ok {
+		pp = models.ProductoProveedor{ProductoID: productoID, ProveedorID: proveedorID, CreatedAt: ahora}
+	}
+	// UltimaEntradaAt solo la actualizan las entradas
+	pp.SKUProveedor = req.SKUProveedor
+	pp.PlazoEntregaDias = req.PlazoEntregaDias
+	pp.UltimoCosto = req.UltimoCosto
+	pp.UpdatedAt = ahora
+	r.s.vinculos[k] = pp
+
+	pp = r.s.vinculo(pp)
+	return &pp, nil
+}
+
+func (r *ProveedorRepository) Desvincular(ctx context.Context, proveedorID, productoID int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	k := vinculoKey{productoID, proveedorID}
+	if _, ok := r.s.vinculos[k]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.vinculos, k)
+	return nil
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+)
+
+type SerieRepository struct {
+	s *store
+}
+
+// serieNumero devuelve la serie del producto con ese número, o nil si nunca
+// entró. Debe llamarse con el mutex tomado.
+func (s *store) serieNumero(productoID int, numero string) *models.Serie {
+	for _, se := range s.series {
+		if se.ProductoID == productoID && se.Numero == numero {
+			return se
+		}
+	}
+	return nil
+}
+
+// planSeries decide qué series mueve m, que cambia el stock en delta, y
+// verifica que las que entran no estén ya en stock y las que salen estén en
+// el almacén. Debe llamarse con el mutex tomado.
+func (s *store) planSeries(m models.MovimientoInventario, p models.Producto, delta int) ([]string, error) {
+	origen, hayOrigen := s.movimientos[s.origenUnidades(m)]
+	series, err := repository.PlanSeries(m, p.ControlaSeries, delta, origen.Series, hayOrigen)
+	if err != nil {
+		return nil, err
+	}
+	for _, numero := range series {
+		se := s.serieNumero(m.ProductoID, numero)
+		if delta > 0 && se != nil && se.AlmacenID != nil {
+			return nil, repository.ErrSerieDuplicada
+		}
+		if delta < 0 && (se == nil || se.AlmacenID == nil || *se.AlmacenID != m.AlmacenID) {
+			return nil, repository.ErrSerieNoDisponible
+		}
+	}
+	return series, nil
+}
+
+// aplicarSeries ubica en el almacén de m las series que entran, creando las
+// nuevas, y saca del stock las que salen. Debe llamarse con el mutex de
+// escritura tomado.
+func (s *store) aplicarSeries(m models.MovimientoInventario, delta int, series []string) {
+	for _, numero := range series {
+		se := s.serieNumero(m.ProductoID, numero)
+		if se == nil {
+			s.ultimaSerieID++
+			se = &models.Serie{ID: s.ultimaSerieID, ProductoID: m.ProductoID, Numero: numero, CreatedAt: m.CreatedAt}
+			s.series[se.ID] = se
+		}
+		se.AlmacenID = nil
+		if delta > 0 {
+			almacenID := m.AlmacenID
+			se.AlmacenID = &almacenID
+		}
+		se.UpdatedAt = m.CreatedAt
+	}
+}
+
+// validarUnidades verifica, sin aplicarlo, que m indique bien sus lotes y
+// series. Las operaciones que registran varios movimientos la usan para
+// validarlos todos antes de aplicar ninguno. Debe llamarse con el mutex
+// tomado.
+func (s *store) validarUnidades(m models.MovimientoInventario, delta int) error {
+	p := s.productos[m.ProductoID]
+	if _, err := s.planLotes(m, p, delta); err != nil {
+		return err
+	}
+	_, err := s.planSeries(m, p, delta)
+	return err
+}
+
+// seriesVendidas devuelve las series del producto que salieron en la venta
+// de la devolución y las que ya devolvieron otras devoluciones de la misma
+// venta. Debe llamarse con el mutex tomado.
+func (s *store) seriesVendidas(d *models.Devolucion, productoID int) ([]string, map[string]bool) {
+	var vendidas []string
+	if venta, ok := s.movimientos[s.origenUnidades(models.MovimientoInventario{ProductoID: productoID, DevolucionID: &d.ID})]; ok {
+		vendidas = venta.Series
+	}
+	devueltas := make(map[string]bool)
+	for _, otra := range s.devoluciones {
+		if otra.ID == d.ID || !mismaVenta(d, otra) {
+			continue
+		}
+		for _, i := range otra.Items {
+			if i.ProductoID != productoID {
+				continue
+			}
+			for _, numero := range i.Series {
+				devueltas[numero] = true
+			}
+		}
+	}
+	return vendidas, devueltas
+}
+
+// serie devuelve una copia de la serie con los datos de su producto y
+// almacén. Debe llamarse con el mutex tomado.
+func (s *store) serie(se *models.Serie) models.Serie {
+	serie := *se
+	p := s.productos[se.ProductoID]
+	serie.Producto = &models.Producto{ID: p.ID, Nombre: p.Nombre, SKU: p.SKU}
+	serie.EnStock = se.AlmacenID != nil
+	if se.AlmacenID != nil {
+		a := s.almacenes[*se.AlmacenID]
+		serie.Almacen = &models.Almacen{ID: a.ID, Nombre: a.Nombre, Principal: a.Principal}
+	}
+	return serie
+}
+
+// ordenarSeries ordena las series por producto y número
+func ordenarSeries(series []models.Serie) {
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].ProductoID != series[j].ProductoID {
+			return series[i].ProductoID < series[j].ProductoID
+		}
+		return series[i].Numero < series[j].Numero
+	})
+}
+
+func (r *SerieRepository) List(ctx context.Context, filtro repository.SerieFiltro) ([]models.Serie, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var series []models.Serie
+	for _, se := range r.s.series {
+		if filtro.ProductoID != nil && se.ProductoID != *filtro.ProductoID {
+			continue
+		}
+		if filtro.AlmacenID != nil && (se.AlmacenID == nil || *se.AlmacenID != *filtro.AlmacenID) {
+			continue
+		}
+		if filtro.EnStock && se.AlmacenID == nil {
+			continue
+		}
+		series = append(series, r.s.serie(se))
+	}
+	ordenarSeries(series)
+	return series, nil
+}
+
+func (r *SerieRepository) Rastrear(ctx context.Context, numero string) ([]models.Serie, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var series []models.Serie
+	for _, se := range r.s.series {
+		if se.Numero != numero {
+			continue
+		}
+		serie := r.s.serie(se)
+		filtro := repository.MovimientoFiltro{ProductoID: &se.ProductoID, Serie: numero}
+		for _, m := range r.s.movimientos {
+			if r.s.cumpleFiltroMovimiento(m, filtro) {
+				serie.Movimientos = append(serie.Movimientos, r.s.movimiento(m))
+			}
+		}
+		// Del más antiguo al más reciente, como se lee una historia
+		sort.Slice(serie.Movimientos, func(i, j int) bool {
+			a, b := serie.Movimientos[i], serie.Movimientos[j]
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		})
+		series = append(series, serie)
+	}
+	if len(series) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	ordenarSeries(series)
+	return series, nil
+}
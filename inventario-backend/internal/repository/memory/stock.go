@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"slices"
+	"sort"
+	"time"
+)
+
+// almacenPrincipal devuelve el ID del almacén principal. Debe llamarse con el
+// mutex tomado.
+func (s *store) almacenPrincipal() int {
+	for _, a := range s.almacenes {
+		if a.Principal {
+			return a.ID
+		}
+	}
+	return 0
+}
+
+// aplicarMovimiento registra el movimiento y actualiza el stock del producto
+// en el almacén y su total. Si m.AlmacenID es 0 se usa el almacén principal.
+// Debe llamarse con el mutex de escritura tomado.
+func (s *store) aplicarMovimiento(m models.MovimientoInventario) (models.MovimientoInventario, error) {
+	if m.AlmacenID == 0 {
+		m.AlmacenID = s.almacenPrincipal()
+	}
+
+	if !movimientoValido(m) {
+		return models.MovimientoInventario{}, repository.ErrValorInvalido
+	}
+	p, ok := s.productos[m.ProductoID]
+	if !ok {
+		return models.MovimientoInventario{}, repository.ErrProductoNoExiste
+	}
+	if len(s.variantes(p.ID)) > 0 {
+		return models.MovimientoInventario{}, repository.ErrProductoConVariantes
+	}
+	if _, ok := s.almacenes[m.AlmacenID]; !ok {
+		return models.MovimientoInventario{}, repository.ErrAlmacenNoExiste
+	}
+	if m.ProveedorID != nil {
+		if _, ok := s.proveedores[*m.ProveedorID]; !ok {
+			return models.MovimientoInventario{}, repository.ErrProveedorNoExiste
+		}
+	}
+
+	delta := repository.EfectoStock(m)
+	stock := s.stock[stockKey{m.ProductoID, m.AlmacenID}]
+	if stock+delta < 0 {
+		return models.MovimientoInventario{}, repository.ErrStockInsuficiente
+	}
+	// Las salidas no pueden tomar lo reservado por órdenes de venta
+	if m.Tipo == models.TipoSalida && stock-s.reservado(m.ProductoID, m.AlmacenID)+delta < 0 {
+		return models.MovimientoInventario{}, repository.ErrStockInsuficiente
+	}
+
+	lotes, err := s.planLotes(m, p, delta)
+	if err != nil {
+		return models.MovimientoInventario{}, err
+	}
+	series, err := s.planSeries(m, p, delta)
+	if err != nil {
+		return models.MovimientoInventario{}, err
+	}
+
+	// Valorar el movimiento en moneda local
+	var costoTotal, costoEntrada float64
+	var origen, sinCapa int
+	var plan []consumo
+	if delta > 0 {
+		costoEntrada, origen = s.costoEntrada(m, p)
+		costoTotal = float64(delta) * costoEntrada
+	} else {
+		plan, sinCapa = s.planConsumo(m, -delta)
+		costoTotal = s.costoSalida(m, p, -delta, plan, sinCapa)
+	}
+	m.CostoTotal = &costoTotal
+
+	// Sin lectura monotónica para que la fecha sobreviva al cursor serializado
+	ahora := time.Now().Round(0)
+	s.ultimoMovimientoID++
+	m.ID = s.ultimoMovimientoID
+	m.CreatedAt = ahora
+	m.Lotes = s.aplicarLotes(m, delta, lotes)
+	m.Lote, m.Vencimiento = "", nil
+	m.Series = series
+	s.aplicarSeries(m, delta, series)
+	s.movimientos[m.ID] = m
+	if m.RevierteID != nil {
+		s.reversiones[*m.RevierteID] = m.ID
+	}
+
+	valor := costoTotal
+	if delta > 0 {
+		s.sumarCapas(m, delta, costoEntrada, origen)
+	} else {
+		s.consumirCapas(m.ID, plan)
+		valor = -costoTotal
+	}
+
+	if m.ProveedorID != nil {
+		s.registrarEntradaProveedor(m, ahora)
+	}
+
+	s.sumarStock(m.ProductoID, m.AlmacenID, delta, valor, ahora)
+	return m, nil
+}
+
+// movimientoValido replica las restricciones CHECK de movimientos_inventario
+func movimientoValido(m models.MovimientoInventario) bool {
+	if m.CostoUnitario != nil && (*m.CostoUnitario < 0 || repository.EfectoStock(m) < 0) {
+		return false
+	}
+	if (m.Moneda == "") != (m.TipoCambio == nil) || (m.Moneda != "" && m.CostoUnitario == nil) ||
+		(m.TipoCambio != nil && *m.TipoCambio <= 0) {
+		return false
+	}
+	if m.ProveedorID != nil && m.Tipo != models.TipoEntrada {
+		return false
+	}
+	if m.OrdenVentaID != nil && m.Tipo != models.TipoSalida {
+		return false
+	}
+	if m.DevolucionID != nil && m.Tipo != models.TipoAjuste {
+		return false
+	}
+	switch m.Tipo {
+	case models.TipoEntrada, models.TipoSalida:
+		return m.Cantidad > 0 && m.CodigoMotivo == ""
+	case models.TipoAjuste:
+		return m.Cantidad != 0 && models.MotivoRegistrable(m.CodigoMotivo)
+	}
+	return false
+}
+
+// sumarStock suma delta al stock del producto en el almacén y a su total, y
+// valor a su valor de inventario. Debe llamarse con el mutex de escritura
+// tomado y después de comprobar que el resultado no queda en negativo.
+func (s *store) sumarStock(productoID, almacenID, delta int, valor float64, ahora time.Time) {
+	s.stock[stockKey{productoID, almacenID}] += delta
+
+	p := s.productos[productoID]
+	p.Stock += delta
+	p.ValorInventario += valor
+	if p.Stock == 0 {
+		// Sin existencias no queda valor, aunque los redondeos dejen restos
+		p.ValorInventario = 0
+	}
+	p.UpdatedAt = ahora
+	s.productos[productoID] = p
+}
+
+// stockAlmacenes devuelve el desglose de stock de los productos sumados sin
+// los almacenes en cero, con el principal primero. Debe llamarse con el
+// mutex tomado.
+func (s *store) stockAlmacenes(productoIDs ...int) []models.StockAlmacen {
+	porAlmacen := make(map[int]int)
+	for k, cantidad := range s.stock {
+		if slices.Contains(productoIDs, k.productoID) {
+			porAlmacen[k.almacenID] += cantidad
+		}
+	}
+	var stock []models.StockAlmacen
+	for almacenID, cantidad := range porAlmacen {
+		if cantidad == 0 {
+			continue
+		}
+		stock = append(stock, models.StockAlmacen{
+			AlmacenID: almacenID,
+			Almacen:   s.almacenes[almacenID].Nombre,
+			Cantidad:  cantidad,
+		})
+	}
+	sort.Slice(stock, func(i, j int) bool {
+		return s.antesEnListado(stock[i].AlmacenID, stock[j].AlmacenID)
+	})
+	return stock
+}
+
+// antesEnListado indica si el almacén a va antes que b en los desgloses de
+// stock: el principal primero y luego por nombre. Debe llamarse con el mutex
+// tomado.
+func (s *store) antesEnListado(a, b int) bool {
+	pa, pb := s.almacenes[a].Principal, s.almacenes[b].Principal
+	if pa != pb {
+		return pa
+	}
+	return s.almacenes[a].Nombre < s.almacenes[b].Nombre
+}
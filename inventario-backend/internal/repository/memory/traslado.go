@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+	"time"
+)
+
+type TrasladoRepository struct {
+	s *store
+}
+
+// traslado devuelve una copia del traslado con su producto resuelto y, si
+// conMovimientos, sus movimientos. Debe llamarse con el mutex tomado.
+func (s *store) traslado(t models.Traslado, conMovimientos bool) models.Traslado {
+	t.Producto = nil
+	if p, ok := s.productos[t.ProductoID]; ok {
+		t.Producto = &models.Producto{
+			ID:          p.ID,
+			Nombre:      p.Nombre,
+			Descripcion: p.Descripcion,
+			Precio:      p.Precio,
+			Stock:       p.Stock,
+		}
+	}
+	t.Movimientos = nil
+	if conMovimientos {
+		for _, m := range s.movimientos {
+			if m.TrasladoID != nil && *m.TrasladoID == t.ID {
+				t.Movimientos = append(t.Movimientos, s.movimiento(m))
+			}
+		}
+		sort.Slice(t.Movimientos, func(i, j int) bool {
+			return t.Movimientos[i].ID < t.Movimientos[j].ID
+		})
+	}
+	return t
+}
+
+func (r *TrasladoRepository) List(ctx context.Context, filtro repository.TrasladoFiltro) ([]models.Traslado, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var traslados []models.Traslado
+	for _, t := range r.s.traslados {
+		if filtro.Estado != "" && t.Estado != filtro.Estado {
+			continue
+		}
+		if filtro.ProductoID != nil && t.ProductoID != *filtro.ProductoID {
+			continue
+		}
+		if filtro.AlmacenID != nil && t.AlmacenOrigenID != *filtro.AlmacenID && t.AlmacenDestinoID != *filtro.AlmacenID {
+			continue
+		}
+		traslados = append(traslados, r.s.traslado(t, false))
+	}
+	// Más recientes primero
+	sort.Slice(traslados, func(i, j int) bool {
+		if !traslados[i].CreatedAt.Equal(traslados[j].CreatedAt) {
+			return traslados[i].CreatedAt.After(traslados[j].CreatedAt)
+		}
+		return traslados[i].ID > traslados[j].ID
+	})
+	return traslados, nil
+}
+
+func (r *TrasladoRepository) GetByID(ctx context.Context, id int) (*models.Traslado, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	t, ok := r.s.traslados[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	t = r.s.traslado(t, true)
+	return &t, nil
+}
+
+// motivoTraslado es el motivo de los movimientos de un traslado
+func motivoTraslado(id int, motivo string) string {
+	if motivo == "" {
+		return fmt.Sprintf("Traslado #%d", id)
+	}
+	return fmt.Sprintf("Traslado #%d: %s", id, motivo)
+}
+
+func (r *TrasladoRepository) Create(ctx context.Context, req models.TrasladoRequest) (*models.Traslado, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if req.AlmacenOrigenID == req.AlmacenDestinoID {
+		return nil, repository.ErrMismoAlmacen
+	}
+	if _, ok := r.s.productos[req.ProductoID]; !ok {
+		return nil, repository.ErrProductoNoExiste
+	}
+	// Validar el destino antes de registrar la salida para no dejar el
+	// traslado a medias
+	if _, ok := r.s.almacenes[req.AlmacenDestinoID]; !ok {
+		return nil, repository.ErrAlmacenNoExiste
+	}
+
+	id := r.s.ultimoTrasladoID + 1
+	_, err := r.s.aplicarMovimiento(models.MovimientoInventario{
+		ProductoID: req.ProductoID,
+		AlmacenID:  req.AlmacenOrigenID,
+		Tipo:       models.TipoSalida,
+		Cantidad:   req.Cantidad,
+		Motivo:     motivoTraslado(id, req.Motivo),
+		TrasladoID: &id,
+		Series:     req.Series,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.s.ultimoTrasladoID = id
+	t := models.Traslado{
+		ID:               id,
+		ProductoID:       req.ProductoID,
+		AlmacenOrigenID:  req.AlmacenOrigenID,
+		AlmacenDestinoID: req.AlmacenDestinoID,
+		Cantidad:         req.Cantidad,
+		Estado:           models.EstadoEnTransito,
+		Motivo:           req.Motivo,
+		CreatedAt:        time.Now(),
+	}
+	r.s.traslados[id] = t
+
+	if !req.EnTransito {
+		if err := r.s.recibirTraslado(id); err != nil {
+			return nil, err
+		}
+	}
+
+	t = r.s.traslado(r.s.traslados[id], true)
+	return &t, nil
+}
+
+func (r *TrasladoRepository) Recibir(ctx context.Context, id int) (*models.Traslado, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := r.s.recibirTraslado(id); err != nil {
+		return nil, err
+	}
+
+	t := r.s.traslado(r.s.traslados[id], true)
+	return &t, nil
+}
+
+// recibirTraslado registra la entrada en el almacén de destino y marca el
+// traslado como recibido. Debe llamarse con el mutex de escritura tomado.
+func (s *store) recibirTraslado(id int) error {
+	t, ok := s.traslados[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if t.Estado == models.EstadoRecibido {
+		return repository.ErrTrasladoRecibido
+	}
+
+	m, err := s.aplicarMovimiento(models.MovimientoInventario{
+		ProductoID: t.ProductoID,
+		AlmacenID:  t.AlmacenDestinoID,
+		Tipo:       models.TipoEntrada,
+		Cantidad:   t.Cantidad,
+		Motivo:     motivoTraslado(id, t.Motivo),
+		TrasladoID: &id,
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Estado = models.EstadoRecibido
+	t.RecibidoAt = &m.CreatedAt
+	s.traslados[id] = t
+	return nil
+}
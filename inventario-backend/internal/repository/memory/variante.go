@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"maps"
+	"sort"
+)
+
+// variantes devuelve las variantes del producto ordenadas por nombre. Debe
+// llamarse con el mutex tomado.
+func (s *store) variantes(padreID int) []models.Producto {
+	var variantes []models.Producto
+	for _, p := range s.productos {
+		if p.ProductoPadreID != nil && *p.ProductoPadreID == padreID {
+			variantes = append(variantes, p)
+		}
+	}
+	sort.Slice(variantes, func(i, j int) bool {
+		if variantes[i].Nombre != variantes[j].Nombre {
+			return variantes[i].Nombre < variantes[j].Nombre
+		}
+		return variantes[i].ID < variantes[j].ID
+	})
+	return variantes
+}
+
+func (r *ProductoRepository) Variantes(ctx context.Context, id int) ([]models.Producto, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if _, ok := r.s.productos[id]; !ok {
+		return nil, repository.ErrNotFound
+	}
+	variantes := r.s.variantes(id)
+	for i, v := range variantes {
+		variantes[i] = r.s.producto(v)
+	}
+	return variantes, nil
+}
+
+func (r *ProductoRepository) CreateVariante(ctx context.Context, padreID int, req models.VarianteRequest) (*models.Producto, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	padre, ok := r.s.productos[padreID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if padre.ProductoPadreID != nil {
+		return nil, repository.ErrVarianteInvalida
+	}
+	// Los kits y sus componentes mueven stock propio
+	if len(r.s.componentes[padreID]) > 0 || r.s.esComponente(padreID) {
+		return nil, repository.ErrComponenteInvalido
+	}
+	// El stock del padre pasa a ser la suma del de sus variantes
+	if padre.Stock != 0 {
+		return nil, repository.ErrPadreConStock
+	}
+	// Replica productos_variante_key
+	for _, v := range r.s.variantes(padreID) {
+		if repository.MismosAtributos(v.Atributos, req.Atributos) {
+			return nil, repository.ErrVarianteDuplicada
+		}
+	}
+
+	pr := repository.RequestVariante(padre, req)
+	p, err := r.s.crearProducto(pr, models.Producto{
+		ProductoPadreID: &padre.ID,
+		Atributos:       maps.Clone(req.Atributos),
+		HeredaPrecio:    pr.Precio == padre.Precio,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+	"inventario-backend/internal/models"
+)
+
+// ResolverOrdenCompra calcula lo pendiente de cada línea y el total de la orden
+func ResolverOrdenCompra(o *models.OrdenCompra) {
+	o.Total = 0
+	for i := range o.Lineas {
+		l := &o.Lineas[i]
+		l.CantidadPendiente = max(l.Cantidad-l.CantidadRecibida, 0)
+		o.Total += float64(l.Cantidad) * l.CostoUnitario
+	}
+	o.Total = redondear(o.Total, 2)
+}
+
+// ValidarRecepcion verifica que la orden admita recibir los items: debe estar
+// enviada o recibida en parte, cada producto debe ser de la orden y, salvo
+// con PermitirExceso, no se puede recibir más de lo pendiente.
+func ValidarRecepcion(o models.OrdenCompra, req models.RecepcionRequest) error {
+	if o.Estado != models.EstadoOrdenEnviada && o.Estado != models.EstadoOrdenRecibidaParcial {
+		return ErrOrdenCompraEstado
+	}
+	pendiente := make(map[int]int, len(o.Lineas))
+	for _, l := range o.Lineas {
+		pendiente[l.ProductoID] = l.Cantidad - l.CantidadRecibida
+	}
+	for _, item := range req.Items {
+		p, ok := pendiente[item.ProductoID]
+		if !ok {
+			return ErrProductoFueraDeOrden
+		}
+		if item.Cantidad > p && !req.PermitirExceso {
+			return ErrRecepcionExcedida
+		}
+	}
+	return nil
+}
+
+// EstadoTrasRecepcion devuelve recibida si todas las líneas se recibieron por
+// completo y recibida_parcial si no
+func EstadoTrasRecepcion(lineas []models.OrdenCompraLinea) models.EstadoOrdenCompra {
+	for _, l := range lineas {
+		if l.CantidadRecibida < l.Cantidad {
+			return models.EstadoOrdenRecibidaParcial
+		}
+	}
+	return models.EstadoOrdenRecibida
+}
+
+// MotivoOrdenCompra es el motivo de las entradas que registra la recepción de
+// una orden de compra
+func MotivoOrdenCompra(id int) string {
+	return fmt.Sprintf("Orden de compra #%d", id)
+}
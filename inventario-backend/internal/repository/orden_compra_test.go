@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"testing"
+)
+
+func TestValidarRecepcion(t *testing.T) {
+	orden := func(estado models.EstadoOrdenCompra) models.OrdenCompra {
+		return models.OrdenCompra{ID: 3, Estado: estado, Lineas: []models.OrdenCompraLinea{
+			{ProductoID: 1, Cantidad: 10, CantidadRecibida: 4},
+			{ProductoID: 2, Cantidad: 5, CantidadRecibida: 5},
+		}}
+	}
+	item := func(productoID, cantidad int) []models.CantidadRecibida {
+		return []models.CantidadRecibida{{ProductoID: productoID, Cantidad: cantidad}}
+	}
+
+	casos := []struct {
+		nombre string
+		estado models.EstadoOrdenCompra
+		req    models.RecepcionRequest
+		err    error
+	}{
+		{"recibe lo pendiente", models.EstadoOrdenRecibidaParcial, models.RecepcionRequest{Items: item(1, 6)}, nil},
+		{"recibe en parte", models.EstadoOrdenEnviada, models.RecepcionRequest{Items: item(1, 2)}, nil},
+		{"en borrador", models.EstadoOrdenBorrador, models.RecepcionRequest{Items: item(1, 2)}, ErrOrdenCompraEstado},
+		{"cancelada", models.EstadoOrdenCancelada, models.RecepcionRequest{Items: item(1, 2)}, ErrOrdenCompraEstado},
+		{"producto ajeno", models.EstadoOrdenEnviada, models.RecepcionRequest{Items: item(9, 1)}, ErrProductoFueraDeOrden},
+		{"excede lo pendiente", models.EstadoOrdenEnviada, models.RecepcionRequest{Items: item(1, 7)}, ErrRecepcionExcedida},
+		{"línea completa", models.EstadoOrdenEnviada, models.RecepcionRequest{Items: item(2, 1)}, ErrRecepcionExcedida},
+		{"exceso permitido", models.EstadoOrdenEnviada, models.RecepcionRequest{Items: item(2, 1), PermitirExceso: true}, nil},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if err := ValidarRecepcion(orden(c.estado), c.req); err != c.err {
+				t.Errorf("error %v, se esperaba %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestEstadoTrasRecepcion(t *testing.T) {
+	lineas := []models.OrdenCompraLinea{
+		{ProductoID: 1, Cantidad: 10, CantidadRecibida: 10},
+		{ProductoID: 2, Cantidad: 5, CantidadRecibida: 3},
+	}
+	if e := EstadoTrasRecepcion(lineas); e != models.EstadoOrdenRecibidaParcial {
+		t.Errorf("estado %q, se esperaba recibida_parcial", e)
+	}
+
+	// Recibir de más en una línea no compensa lo pendiente en otra
+	lineas[0].CantidadRecibida = 12
+	if e := EstadoTrasRecepcion(lineas); e != models.EstadoOrdenRecibidaParcial {
+		t.Errorf("estado %q, se esperaba recibida_parcial", e)
+	}
+
+	lineas[1].CantidadRecibida = 5
+	if e := EstadoTrasRecepcion(lineas); e != models.EstadoOrdenRecibida {
+		t.Errorf("estado %q, se esperaba recibida", e)
+	}
+}
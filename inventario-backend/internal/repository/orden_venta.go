@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"fmt"
+	"inventario-backend/internal/models"
+)
+
+// ResolverOrdenVenta calcula el total de la orden
+func ResolverOrdenVenta(o *models.OrdenVenta) {
+	o.Total = 0
+	for _, l := range o.Lineas {
+		o.Total += float64(l.Cantidad) * l.PrecioUnitario
+	}
+	o.Total = redondear(o.Total, 2)
+}
+
+// MotivoOrdenVenta es el motivo de las salidas que registra el despacho de
+// una orden de venta
+func MotivoOrdenVenta(id int) string {
+	return fmt.Sprintf("Orden de venta #%d", id)
+}
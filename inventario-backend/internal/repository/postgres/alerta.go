@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+const alertaSelect = `
+	SELECT a.id, a.producto_id, p.nombre, a.nivel, a.estado, a.stock, a.umbral, a.cantidad_reorden,
+	       a.created_at, a.reconocida_at, a.resuelta_at
+	FROM alertas_stock a
+	JOIN productos p ON a.producto_id = p.id
+`
+
+type AlertaRepository struct {
+	db *sql.DB
+}
+
+func NewAlertaRepository(db *sql.DB) *AlertaRepository {
+	return &AlertaRepository{db: db}
+}
+
+func scanAlerta(row scanner) (*models.Alerta, error) {
+	var a models.Alerta
+	var reconocidaAt, resueltaAt sql.NullTime
+	err := row.Scan(&a.ID, &a.ProductoID, &a.Producto, &a.Nivel, &a.Estado, &a.Stock, &a.Umbral, &a.CantidadReorden,
+		&a.CreatedAt, &reconocidaAt, &resueltaAt)
+	if err != nil {
+		return nil, err
+	}
+	if reconocidaAt.Valid {
+		a.ReconocidaAt = &reconocidaAt.Time
+	}
+	if resueltaAt.Valid {
+		a.ResueltaAt = &resueltaAt.Time
+	}
+	return &a, nil
+}
+
+func (r *AlertaRepository) List(ctx context.Context, filtro repository.AlertaFiltro) ([]models.Alerta, error) {
+	var where whereBuilder
+	if filtro.Estado != "" {
+		where.add("a.estado = ?", filtro.Estado)
+	}
+	if filtro.ProductoID != nil {
+		where.add("a.producto_id = ?", *filtro.ProductoID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, alertaSelect+where.String()+" ORDER BY a.created_at DESC, a.id DESC", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alertas []models.Alerta
+	for rows.Next() {
+		a, err := scanAlerta(rows)
+		if err != nil {
+			return nil, err
+		}
+		alertas = append(alertas, *a)
+	}
+	return alertas, rows.Err()
+}
+
+func (r *AlertaRepository) Evaluar(ctx context.Context, productoIDs ...int) ([]models.Alerta, error) {
+	if len(productoIDs) == 0 {
+		rows, err := r.db.QueryContext(ctx, "SELECT id FROM productos ORDER BY id")
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			productoIDs = append(productoIDs, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var alertas []models.Alerta
+	for _, id := range productoIDs {
+		a, err := r.evaluar(ctx, id)
+		if err != nil {
+			return alertas, err
+		}
+		if a != nil {
+			alertas = append(alertas, *a)
+		}
+	}
+	return alertas, nil
+}
+
+// evaluar aplica EvaluarAlerta a un producto en una transacción que bloquea
+// su fila, de modo que dos evaluaciones del mismo producto no se pisen.
+// Devuelve la alerta generada o escalada, o nil.
+func (r *AlertaRepository) evaluar(ctx context.Context, productoID int) (*models.Alerta, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var p models.Producto
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, nombre, stock, stock_minimo, punto_reorden, cantidad_reorden
+		FROM productos WHERE id = $1 FOR UPDATE
+	`, productoID).Scan(&p.ID, &p.Nombre, &p.Stock, &p.StockMinimo, &p.PuntoReorden, &p.CantidadReorden)
+	if err == sql.ErrNoRows {
+		// Eliminado después del movimiento
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	abierta, err := scanAlerta(tx.QueryRowContext(ctx, alertaSelect+" WHERE a.producto_id = $1 AND a.estado <> 'resuelta'", productoID))
+	if err == sql.ErrNoRows {
+		abierta = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	accion, a := repository.EvaluarAlerta(p, abierta)
+	switch accion {
+	case repository.AlertaSinCambios:
+		return nil, nil
+	case repository.AlertaResolver:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE alertas_stock SET estado = 'resuelta', resuelta_at = NOW() WHERE id = $1
+		`, abierta.ID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, tx.Commit()
+	case repository.AlertaEscalar:
+		a.ID = abierta.ID
+		_, err = tx.ExecContext(ctx, `
+			UPDATE alertas_stock
+			SET nivel = $1, estado = 'activa', stock = $2, umbral = $3, cantidad_reorden = $4, reconocida_at = NULL
+			WHERE id = $5
+		`, a.Nivel, a.Stock, a.Umbral, a.CantidadReorden, a.ID)
+	default:
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO alertas_stock (producto_id, nivel, stock, umbral, cantidad_reorden)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, a.ProductoID, a.Nivel, a.Stock, a.Umbral, a.CantidadReorden).Scan(&a.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	guardada, err := scanAlerta(tx.QueryRowContext(ctx, alertaSelect+" WHERE a.id = $1", a.ID))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return guardada, nil
+}
+
+func (r *AlertaRepository) Reconocer(ctx context.Context, id int) (*models.Alerta, error) {
+	var estado models.EstadoAlerta
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE alertas_stock
+		SET estado = CASE WHEN estado = 'activa' THEN 'reconocida' ELSE estado END,
+		    reconocida_at = COALESCE(reconocida_at, CASE WHEN estado = 'activa' THEN NOW() END)
+		WHERE id = $1
+		RETURNING estado
+	`, id).Scan(&estado)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if estado == models.EstadoAlertaResuelta {
+		return nil, repository.ErrAlertaResuelta
+	}
+
+	a, err := scanAlerta(r.db.QueryRowContext(ctx, alertaSelect+" WHERE a.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+const almacenSelect = `
+	SELECT id, nombre, descripcion, principal, created_at, updated_at
+	FROM almacenes
+`
+
+type AlmacenRepository struct {
+	db *sql.DB
+}
+
+func NewAlmacenRepository(db *sql.DB) *AlmacenRepository {
+	return &AlmacenRepository{db: db}
+}
+
+func scanAlmacen(row scanner) (*models.Almacen, error) {
+	var a models.Almacen
+	var descripcion sql.NullString
+	if err := row.Scan(&a.ID, &a.Nombre, &descripcion, &a.Principal, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	a.Descripcion = descripcion.String
+	return &a, nil
+}
+
+func (r *AlmacenRepository) List(ctx context.Context) ([]models.Almacen, error) {
+	rows, err := r.db.QueryContext(ctx, almacenSelect+" ORDER BY nombre")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var almacenes []models.Almacen
+	for rows.Next() {
+		a, err := scanAlmacen(rows)
+		if err != nil {
+			return nil, err
+		}
+		almacenes = append(almacenes, *a)
+	}
+	return almacenes, rows.Err()
+}
+
+func (r *AlmacenRepository) GetByID(ctx context.Context, id int) (*models.Almacen, error) {
+	a, err := scanAlmacen(r.db.QueryRowContext(ctx, almacenSelect+" WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return a, err
+}
+
+// marcarPrincipal quita la marca de principal al resto de almacenes
+func marcarPrincipal(ctx context.Context, tx *sql.Tx, id int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE almacenes SET principal = false WHERE principal AND id <> $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE almacenes SET principal = true WHERE id = $1", id)
+	return err
+}
+
+func (r *AlmacenRepository) Create(ctx context.Context, req models.AlmacenRequest) (*models.Almacen, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO almacenes (nombre, descripcion)
+		VALUES ($1, $2)
+		RETURNING id
+	`, req.Nombre, req.Descripcion).Scan(&id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	if req.Principal {
+		if err := marcarPrincipal(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *AlmacenRepository) Update(ctx context.Context, id int, req models.AlmacenRequest) (*models.Almacen, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE almacenes
+		SET nombre = $1, descripcion = $2, updated_at = NOW()
+		WHERE id = $3
+	`, req.Nombre, req.Descripcion, id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	if req.Principal {
+		if err := marcarPrincipal(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *AlmacenRepository) Delete(ctx context.Context, id int) error {
+	// El principal no se puede eliminar, ni un almacén con existencias,
+	// movimientos, traslados, conteos u órdenes de compra o de venta registrados
+	var principal, enUso bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT a.principal,
+		       EXISTS(SELECT 1 FROM stock_almacen s WHERE s.almacen_id = a.id AND s.cantidad > 0)
+		       OR EXISTS(SELECT 1 FROM movimientos_inventario m WHERE m.almacen_id = a.id)
+		       OR EXISTS(SELECT 1 FROM traslados t WHERE a.id IN (t.almacen_origen_id, t.almacen_destino_id))
+		       OR EXISTS(SELECT 1 FROM conteos c WHERE c.almacen_id = a.id)
+		       OR EXISTS(SELECT 1 FROM ordenes_compra o WHERE o.almacen_id = a.id)
+		       OR EXISTS(SELECT 1 FROM ordenes_venta o WHERE o.almacen_id = a.id)
+		FROM almacenes a
+		WHERE a.id = $1
+	`, id).Scan(&principal, &enUso)
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if principal || enUso {
+		return repository.ErrAlmacenEnUso
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Las filas de stock en cero no impiden eliminar el almacén
+	if _, err := tx.ExecContext(ctx, "DELETE FROM stock_almacen WHERE almacen_id = $1", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM almacenes WHERE id = $1", id); err != nil {
+		return traducirError(err)
+	}
+	return tx.Commit()
+}
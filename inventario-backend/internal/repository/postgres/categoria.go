@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+const categoriaSelect = `
+	SELECT id, nombre, descripcion, created_at, updated_at
+	FROM categorias
+`
+
+type CategoriaRepository struct {
+	db *sql.DB
+}
+
+func NewCategoriaRepository(db *sql.DB) *CategoriaRepository {
+	return &CategoriaRepository{db: db}
+}
+
+func scanCategoria(row scanner) (*models.Categoria, error) {
+	var c models.Categoria
+	var descripcion sql.NullString
+	if err := row.Scan(&c.ID, &c.Nombre, &descripcion, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	c.Descripcion = descripcion.String
+	return &c, nil
+}
+
+func (r *CategoriaRepository) List(ctx context.Context) ([]models.Categoria, error) {
+	rows, err := r.db.QueryContext(ctx, categoriaSelect+" ORDER BY nombre")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categorias []models.Categoria
+	for rows.Next() {
+		c, err := scanCategoria(rows)
+		if err != nil {
+			return nil, err
+		}
+		categorias = append(categorias, *c)
+	}
+	return categorias, rows.Err()
+}
+
+func (r *CategoriaRepository) GetByID(ctx context.Context, id int) (*models.Categoria, error) {
+	c, err := scanCategoria(r.db.QueryRowContext(ctx, categoriaSelect+" WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return c, err
+}
+
+func (r *CategoriaRepository) Create(ctx context.Context, req models.CategoriaRequest) (*models.Categoria, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO categorias (nombre, descripcion)
+		VALUES ($1, $2)
+		RETURNING id
+	`, req.Nombre, req.Descripcion).Scan(&id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *CategoriaRepository) Update(ctx context.Context, id int, req models.CategoriaRequest) (*models.Categoria, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE categorias
+		SET nombre = $1, descripcion = $2, updated_at = NOW()
+		WHERE id = $3
+	`, req.Nombre, req.Descripcion, id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *CategoriaRepository) Delete(ctx context.Context, id int) error {
+	// Verificar si hay productos asociados
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM productos WHERE categoria_id = $1
+	`, id).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return repository.ErrCategoriaConProductos
+	}
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM categorias WHERE id = $1", id)
+	if err != nil {
+		return traducirError(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"time"
+)
+
+// efectoSQL es el cambio de stock de cada movimiento m, igual que
+// repository.EfectoStock
+const efectoSQL = "CASE WHEN m.tipo = 'salida' THEN -m.cantidad ELSE m.cantidad END"
+
+func (r *MovimientoRepository) StockAl(ctx context.Context, productoID int, hasta time.Time) (*models.StockFecha, error) {
+	var existe bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM productos WHERE id = $1)
+	`, productoID).Scan(&existe)
+	if err != nil {
+		return nil, err
+	}
+	if !existe {
+		return nil, repository.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.almacen_id, a.nombre, SUM(`+efectoSQL+`)
+		FROM movimientos_inventario m
+		JOIN almacenes a ON m.almacen_id = a.id
+		WHERE m.producto_id = $1 AND m.created_at < $2
+		GROUP BY m.almacen_id, a.nombre, a.principal
+		HAVING SUM(`+efectoSQL+`) <> 0
+		ORDER BY a.principal DESC, a.nombre
+	`, productoID, hasta)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	s := &models.StockFecha{ProductoID: productoID, Hasta: hasta, Almacenes: []models.StockAlmacen{}}
+	for rows.Next() {
+		var a models.StockAlmacen
+		if err := rows.Scan(&a.AlmacenID, &a.Almacen, &a.Cantidad); err != nil {
+			return nil, err
+		}
+		s.Stock += a.Cantidad
+		s.Almacenes = append(s.Almacenes, a)
+	}
+	return s, rows.Err()
+}
+
+func (r *MovimientoRepository) Conciliar(ctx context.Context) ([]models.Conciliacion, error) {
+	// Una fila por producto descuadrado y por cada uno de sus almacenes
+	// descuadrados; los productos que solo difieren en el total traen el
+	// almacén en NULL
+	rows, err := r.db.QueryContext(ctx, `
+		WITH libro AS (
+			SELECT m.producto_id, m.almacen_id, SUM(`+efectoSQL+`) AS cantidad
+			FROM movimientos_inventario m
+			GROUP BY m.producto_id, m.almacen_id
+		), por_almacen AS (
+			SELECT COALESCE(s.producto_id, l.producto_id) AS producto_id,
+			       COALESCE(s.almacen_id, l.almacen_id) AS almacen_id,
+			       COALESCE(s.cantidad, 0) AS registrado,
+			       COALESCE(l.cantidad, 0) AS movimientos
+			FROM stock_almacen s
+			FULL JOIN libro l ON l.producto_id = s.producto_id AND l.almacen_id = s.almacen_id
+		), total AS (
+			SELECT producto_id, SUM(movimientos) AS movimientos
+			FROM por_almacen
+			GROUP BY producto_id
+		)
+		SELECT p.id, p.nombre, p.stock, COALESCE(t.movimientos, 0),
+		       x.almacen_id, a.nombre, x.registrado, x.movimientos
+		FROM productos p
+		LEFT JOIN total t ON t.producto_id = p.id
+		LEFT JOIN por_almacen x ON x.producto_id = p.id AND x.registrado <> x.movimientos
+		LEFT JOIN almacenes a ON a.id = x.almacen_id
+		WHERE p.stock <> COALESCE(t.movimientos, 0) OR x.almacen_id IS NOT NULL
+		ORDER BY p.nombre, p.id, a.principal DESC, a.nombre
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conciliacion := []models.Conciliacion{}
+	for rows.Next() {
+		var c models.Conciliacion
+		var almacenID, registrado, movimientos sql.NullInt64
+		var almacen sql.NullString
+		err := rows.Scan(&c.ProductoID, &c.Producto, &c.StockRegistrado, &c.StockMovimientos,
+			&almacenID, &almacen, &registrado, &movimientos)
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(conciliacion); n == 0 || conciliacion[n-1].ProductoID != c.ProductoID {
+			c.Diferencia = c.StockRegistrado - c.StockMovimientos
+			conciliacion = append(conciliacion, c)
+		}
+		if almacenID.Valid {
+			ultimo := &conciliacion[len(conciliacion)-1]
+			ultimo.Almacenes = append(ultimo.Almacenes, models.ConciliacionAlmacen{
+				AlmacenID:        int(almacenID.Int64),
+				Almacen:          almacen.String,
+				StockRegistrado:  int(registrado.Int64),
+				StockMovimientos: int(movimientos.Int64),
+				Diferencia:       int(registrado.Int64 - movimientos.Int64),
+			})
+		}
+	}
+	return conciliacion, rows.Err()
+}
+
+func (r *MovimientoRepository) Kardex(ctx context.Context, productoID int, filtro repository.KardexFiltro) (*models.Kardex, error) {
+	var p models.Producto
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, nombre FROM productos WHERE id = $1
+	`, productoID).Scan(&p.ID, &p.Nombre)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// El saldo inicial necesita todos los movimientos anteriores a desde
+	var where whereBuilder
+	where.add("m.producto_id = ?", productoID)
+	if filtro.Hasta != nil {
+		where.add("m.created_at < ?", *filtro.Hasta)
+	}
+	movimientos, err := r.query(ctx, movimientoSelect+where.String()+" ORDER BY m.created_at, m.id", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	return repository.CalcularKardex(p, movimientos, filtro), nil
+}
@@ -0,0 +1,315 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const conteoSelect = `
+	SELECT id, almacen_id, categoria_id, estado, descripcion, created_at, cerrado_at
+	FROM conteos
+`
+
+type ConteoRepository struct {
+	db *sql.DB
+}
+
+func NewConteoRepository(db *sql.DB) *ConteoRepository {
+	return &ConteoRepository{db: db}
+}
+
+func scanConteo(row scanner) (*models.Conteo, error) {
+	var c models.Conteo
+	var categoriaID sql.NullInt64
+	var descripcion sql.NullString
+	var cerradoAt sql.NullTime
+	err := row.Scan(&c.ID, &c.AlmacenID, &categoriaID, &c.Estado, &descripcion, &c.CreatedAt, &cerradoAt)
+	if err != nil {
+		return nil, err
+	}
+	c.CategoriaID = nullInt(categoriaID)
+	c.Descripcion = descripcion.String
+	if cerradoAt.Valid {
+		c.CerradoAt = &cerradoAt.Time
+	}
+	return &c, nil
+}
+
+func (r *ConteoRepository) List(ctx context.Context, filtro repository.ConteoFiltro) ([]models.Conteo, error) {
+	var where whereBuilder
+	if filtro.Estado != "" {
+		where.add("estado = ?", filtro.Estado)
+	}
+	if filtro.AlmacenID != nil {
+		where.add("almacen_id = ?", *filtro.AlmacenID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, conteoSelect+where.String()+" ORDER BY created_at DESC, id DESC", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conteos []models.Conteo
+	for rows.Next() {
+		c, err := scanConteo(rows)
+		if err != nil {
+			return nil, err
+		}
+		conteos = append(conteos, *c)
+	}
+	return conteos, rows.Err()
+}
+
+func (r *ConteoRepository) GetByID(ctx context.Context, id int) (*models.Conteo, error) {
+	return getConteo(ctx, r.db, id)
+}
+
+// getConteo lee el conteo con sus items y registros
+func getConteo(ctx context.Context, q querier, id int) (*models.Conteo, error) {
+	c, err := scanConteo(q.QueryRowContext(ctx, conteoSelect+" WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT i.producto_id, i.stock_esperado, i.movimiento_id, p.nombre, p.sku
+		FROM conteo_items i
+		JOIN productos p ON i.producto_id = p.id
+		WHERE i.conteo_id = $1
+		ORDER BY p.nombre, p.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indice := make(map[int]int)
+	for rows.Next() {
+		var item models.ConteoItem
+		var p models.Producto
+		var movimientoID sql.NullInt64
+		var sku sql.NullString
+		if err := rows.Scan(&item.ProductoID, &item.StockEsperado, &movimientoID, &p.Nombre, &sku); err != nil {
+			return nil, err
+		}
+		p.ID = item.ProductoID
+		p.SKU = sku.String
+		item.Producto = &p
+		item.MovimientoID = nullInt(movimientoID)
+		indice[item.ProductoID] = len(c.Items)
+		c.Items = append(c.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT producto_id, pasada, contador, cantidad, lote, vencimiento, series, created_at
+		FROM conteo_registros
+		WHERE conteo_id = $1
+		ORDER BY pasada, contador
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reg models.ConteoRegistro
+		var lote sql.NullString
+		var vencimiento sql.NullTime
+		if err := rows.Scan(&reg.ProductoID, &reg.Pasada, &reg.Contador, &reg.Cantidad, &lote, &vencimiento,
+			pq.Array(&reg.Series), &reg.CreatedAt); err != nil {
+			return nil, err
+		}
+		reg.Lote = lote.String
+		reg.Vencimiento = nullFecha(vencimiento)
+		i := indice[reg.ProductoID]
+		c.Items[i].Registros = append(c.Items[i].Registros, reg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range c.Items {
+		repository.ResolverConteoItem(&c.Items[i])
+	}
+	return c, nil
+}
+
+func (r *ConteoRepository) Create(ctx context.Context, req models.ConteoRequest) (*models.Conteo, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		if almacenID, err = almacenPrincipal(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO conteos (almacen_id, categoria_id, descripcion)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, almacenID, req.CategoriaID, req.Descripcion).Scan(&id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	// Foto del stock esperado de cada producto en el almacén
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO conteo_items (conteo_id, producto_id, stock_esperado)
+		SELECT $1, p.id, COALESCE(s.cantidad, 0)
+		FROM productos p
+		LEFT JOIN stock_almacen s ON s.producto_id = p.id AND s.almacen_id = $2
+		WHERE $3::INTEGER IS NULL OR p.categoria_id = $3
+	`, id, almacenID, req.CategoriaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// bloquearConteo bloquea la sesión y verifica que siga abierta. Los registros
+// usan un bloqueo compartido para que varios contadores trabajen a la vez; la
+// aprobación y la cancelación, uno exclusivo.
+func bloquearConteo(ctx context.Context, tx *sql.Tx, id int, exclusivo bool) (*models.Conteo, error) {
+	bloqueo := " FOR SHARE"
+	if exclusivo {
+		bloqueo = " FOR UPDATE"
+	}
+	c, err := scanConteo(tx.QueryRowContext(ctx, conteoSelect+" WHERE id = $1"+bloqueo, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Estado != models.EstadoConteoAbierto {
+		return nil, repository.ErrConteoCerrado
+	}
+	return c, nil
+}
+
+func (r *ConteoRepository) Registrar(ctx context.Context, id int, req models.ConteoRegistroRequest) (*models.Conteo, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := bloquearConteo(ctx, tx, id, false); err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO conteo_registros (conteo_id, producto_id, pasada, contador, cantidad, lote, vencimiento, series)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, COALESCE($8::TEXT[], '{}'))
+			ON CONFLICT (conteo_id, producto_id, pasada, contador)
+			DO UPDATE SET cantidad = EXCLUDED.cantidad, lote = EXCLUDED.lote, vencimiento = EXCLUDED.vencimiento,
+				series = EXCLUDED.series, created_at = CURRENT_TIMESTAMP
+		`, id, item.ProductoID, req.Pasada, req.Contador, item.Cantidad, item.Lote, fechaArg(item.Vencimiento),
+			pq.Array(item.Series))
+		if err != nil {
+			if traducirError(err) == repository.ErrReferenciaInvalida {
+				return nil, repository.ErrProductoFueraDeConteo
+			}
+			return nil, traducirError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *ConteoRepository) Aprobar(ctx context.Context, id int) (*models.Conteo, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := bloquearConteo(ctx, tx, id, true); err != nil {
+		return nil, err
+	}
+	c, err := getConteo(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// La diferencia se calcula contra la foto tomada al abrir la sesión, así
+	// que los movimientos registrados durante el conteo se conservan
+	for _, item := range c.Items {
+		if item.Diferencia == nil || *item.Diferencia == 0 {
+			continue
+		}
+		ajuste, err := repository.AjusteConteo(c, item)
+		if err != nil {
+			return nil, err
+		}
+		movimientoID, err := aplicarMovimiento(ctx, tx, ajuste)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE conteo_items SET movimiento_id = $1 WHERE conteo_id = $2 AND producto_id = $3
+		`, movimientoID, id, item.ProductoID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cerrarConteo(ctx, tx, id, models.EstadoConteoAprobado); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *ConteoRepository) Cancelar(ctx context.Context, id int) (*models.Conteo, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := bloquearConteo(ctx, tx, id, true); err != nil {
+		return nil, err
+	}
+	if err := cerrarConteo(ctx, tx, id, models.EstadoConteoCancelado); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func cerrarConteo(ctx context.Context, tx *sql.Tx, id int, estado models.EstadoConteo) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE conteos SET estado = $1, cerrado_at = NOW() WHERE id = $2
+	`, estado, id)
+	return err
+}
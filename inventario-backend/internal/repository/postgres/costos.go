@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+// consumo son las unidades que una salida toma de una capa de costo
+type consumo struct {
+	capaID   int
+	cantidad int
+	costo    float64
+}
+
+// origenCapas devuelve el movimiento cuyas unidades vuelven con la entrada m:
+// el revertido o el tramo de salida del traslado. Devuelve 0 si no hay.
+func origenCapas(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario) (int, error) {
+	if m.RevierteID != nil {
+		return *m.RevierteID, nil
+	}
+	if m.TrasladoID == nil {
+		return 0, nil
+	}
+	var id int
+	err := tx.QueryRowContext(ctx, `
+		SELECT id FROM movimientos_inventario WHERE traslado_id = $1 AND tipo = 'salida'
+	`, *m.TrasladoID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// costoEntrada devuelve el costo unitario en moneda local de las unidades que
+// suma m y el movimiento cuyas capas restituye, o 0 si crea una capa nueva.
+// Sin costo propio, las unidades que vuelven conservan el costo con que
+// salieron y las demás se valoran al costo promedio.
+func costoEntrada(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, stock int, valor float64) (float64, int, error) {
+	if m.CostoUnitario != nil {
+		return repository.CostoLocal(m), 0, nil
+	}
+
+	origen, err := origenCapas(ctx, tx, m)
+	if err != nil {
+		return 0, 0, err
+	}
+	if origen != 0 {
+		var cantidad int
+		var costoTotal sql.NullFloat64
+		err := tx.QueryRowContext(ctx, `
+			SELECT cantidad, costo_total FROM movimientos_inventario WHERE id = $1
+		`, origen).Scan(&cantidad, &costoTotal)
+		if err != nil {
+			return 0, 0, err
+		}
+		if costoTotal.Valid {
+			return costoTotal.Float64 / float64(cantidad), origen, nil
+		}
+	}
+
+	if stock > 0 {
+		return valor / float64(stock), origen, nil
+	}
+	// Sin existencias, el último costo conocido
+	var costo float64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT costo_unitario FROM capas_costo WHERE producto_id = $1 ORDER BY id DESC LIMIT 1), 0)
+	`, m.ProductoID).Scan(&costo)
+	return costo, origen, err
+}
+
+// sumarCapas devuelve a sus capas las unidades que consumió el movimiento
+// origen y crea una capa nueva con el resto de la entrada
+func sumarCapas(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, cantidad int, costo float64, origen int) error {
+	if origen != 0 {
+		var restituidas int
+		err := tx.QueryRowContext(ctx, `
+			WITH restituidas AS (
+				UPDATE capas_costo c
+				SET restante = c.restante + x.cantidad
+				FROM consumos_capa x
+				WHERE x.capa_id = c.id AND x.movimiento_id = $1
+				RETURNING x.cantidad
+			)
+			SELECT COALESCE(SUM(cantidad), 0) FROM restituidas
+		`, origen).Scan(&restituidas)
+		if err != nil {
+			return traducirError(err)
+		}
+		cantidad -= restituidas
+	}
+	if cantidad <= 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO capas_costo (producto_id, movimiento_id, cantidad, restante, costo_unitario)
+		VALUES ($1, $2, $3, $3, $4)
+	`, m.ProductoID, m.ID, cantidad, costo)
+	return traducirError(err)
+}
+
+// planConsumo elige las capas de las que sale la cantidad, de la más antigua a
+// la más reciente; al revertir una entrada, primero la capa de esa entrada.
+// Bloquea las capas elegidas. Devuelve también las unidades sin capa, que
+// solo existen si el stock es anterior al registro de costos.
+func planConsumo(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, cantidad int) ([]consumo, int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, restante, costo_unitario
+		FROM capas_costo
+		WHERE producto_id = $1 AND restante > 0
+		ORDER BY COALESCE(movimiento_id = $2, FALSE) DESC, id
+		FOR UPDATE
+	`, m.ProductoID, m.RevierteID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var plan []consumo
+	for cantidad > 0 && rows.Next() {
+		var c consumo
+		var restante int
+		if err := rows.Scan(&c.capaID, &restante, &c.costo); err != nil {
+			return nil, 0, err
+		}
+		c.cantidad = min(cantidad, restante)
+		cantidad -= c.cantidad
+		plan = append(plan, c)
+	}
+	return plan, cantidad, rows.Err()
+}
+
+// consumirCapas descuenta el plan de las capas y lo registra en
+// consumos_capa para poder revertirlo
+func consumirCapas(ctx context.Context, tx *sql.Tx, movimientoID int, plan []consumo) error {
+	for _, c := range plan {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE capas_costo SET restante = restante - $1 WHERE id = $2
+		`, c.cantidad, c.capaID)
+		if err != nil {
+			return traducirError(err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO consumos_capa (movimiento_id, capa_id, cantidad) VALUES ($1, $2, $3)
+		`, movimientoID, c.capaID, c.cantidad)
+		if err != nil {
+			return traducirError(err)
+		}
+	}
+	return nil
+}
+
+// costoSalida devuelve el costo en moneda local de las unidades que resta m:
+// el de las capas consumidas con FIFO o el costo promedio, salvo al revertir
+// una entrada, que sale a su propio costo
+func costoSalida(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, metodo models.MetodoCosteo,
+	cantidad, stock int, valor float64, plan []consumo, sinCapa int) (float64, error) {
+	promedio := 0.0
+	if stock > 0 {
+		promedio = valor / float64(stock)
+	}
+
+	if metodo == models.MetodoFIFO {
+		total := float64(sinCapa) * promedio
+		for _, c := range plan {
+			total += float64(c.cantidad) * c.costo
+		}
+		return total, nil
+	}
+
+	if m.RevierteID != nil {
+		var original int
+		var costoTotal sql.NullFloat64
+		err := tx.QueryRowContext(ctx, `
+			SELECT cantidad, costo_total FROM movimientos_inventario WHERE id = $1
+		`, *m.RevierteID).Scan(&original, &costoTotal)
+		if err != nil {
+			return 0, err
+		}
+		if costoTotal.Valid {
+			return float64(cantidad) * costoTotal.Float64 / float64(original), nil
+		}
+	}
+	return float64(cantidad) * promedio, nil
+}
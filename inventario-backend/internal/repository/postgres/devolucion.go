@@ -0,0 +1,438 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+const devolucionSelect = `
+	SELECT d.id, d.orden_venta_id, d.movimiento_id, d.almacen_id, d.motivo, d.created_at
+	FROM devoluciones d
+`
+
+const devolucionItemSelect = `
+	SELECT i.id, i.devolucion_id, i.producto_id, i.cantidad, i.condicion, i.estado, i.series, i.movimiento_id,
+	       i.resuelto_at, p.nombre, p.sku
+	FROM devolucion_items i
+	JOIN productos p ON i.producto_id = p.id
+`
+
+type DevolucionRepository struct {
+	db *sql.DB
+}
+
+func NewDevolucionRepository(db *sql.DB) *DevolucionRepository {
+	return &DevolucionRepository{db: db}
+}
+
+func scanDevolucion(row scanner) (*models.Devolucion, error) {
+	var d models.Devolucion
+	var ordenVentaID, movimientoID sql.NullInt64
+	var motivo sql.NullString
+	if err := row.Scan(&d.ID, &ordenVentaID, &movimientoID, &d.AlmacenID, &motivo, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	d.OrdenVentaID = nullInt(ordenVentaID)
+	d.MovimientoID = nullInt(movimientoID)
+	d.Motivo = motivo.String
+	return &d, nil
+}
+
+func scanDevolucionItem(row scanner) (*models.DevolucionItem, error) {
+	var i models.DevolucionItem
+	var p models.Producto
+	var movimientoID sql.NullInt64
+	var resueltoAt sql.NullTime
+	var sku sql.NullString
+	err := row.Scan(&i.ID, &i.DevolucionID, &i.ProductoID, &i.Cantidad, &i.Condicion, &i.Estado, pq.Array(&i.Series),
+		&movimientoID, &resueltoAt, &p.Nombre, &sku)
+	if err != nil {
+		return nil, err
+	}
+	i.MovimientoID = nullInt(movimientoID)
+	if resueltoAt.Valid {
+		i.ResueltoAt = &resueltoAt.Time
+	}
+	p.ID = i.ProductoID
+	p.SKU = sku.String
+	i.Producto = &p
+	return &i, nil
+}
+
+func queryDevolucionItems(ctx context.Context, q querier, query string, args ...interface{}) ([]models.DevolucionItem, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.DevolucionItem
+	for rows.Next() {
+		i, err := scanDevolucionItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *i)
+	}
+	return items, rows.Err()
+}
+
+func (r *DevolucionRepository) List(ctx context.Context, filtro repository.DevolucionFiltro) ([]models.Devolucion, error) {
+	var where whereBuilder
+	if filtro.OrdenVentaID != nil {
+		where.add("d.orden_venta_id = ?", *filtro.OrdenVentaID)
+	}
+	if filtro.MovimientoID != nil {
+		where.add("d.movimiento_id = ?", *filtro.MovimientoID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, devolucionSelect+where.String()+" ORDER BY d.created_at DESC, d.id DESC", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devoluciones []models.Devolucion
+	for rows.Next() {
+		d, err := scanDevolucion(rows)
+		if err != nil {
+			return nil, err
+		}
+		devoluciones = append(devoluciones, *d)
+	}
+	return devoluciones, rows.Err()
+}
+
+func (r *DevolucionRepository) GetByID(ctx context.Context, id int) (*models.Devolucion, error) {
+	d, err := scanDevolucion(r.db.QueryRowContext(ctx, devolucionSelect+" WHERE d.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.Items, err = queryDevolucionItems(ctx, r.db, devolucionItemSelect+" WHERE i.devolucion_id = $1 ORDER BY i.id", id); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// origenDevolucion bloquea la orden o la salida de la devolución y devuelve
+// su almacén y lo vendido de cada producto
+func origenDevolucion(ctx context.Context, tx *sql.Tx, req models.DevolucionRequest) (int, map[int]int, error) {
+	vendido := make(map[int]int)
+	if req.OrdenVentaID != nil {
+		var almacenID int
+		var estado models.EstadoOrdenVenta
+		err := tx.QueryRowContext(ctx, `
+			SELECT almacen_id, estado FROM ordenes_venta WHERE id = $1 FOR UPDATE
+		`, *req.OrdenVentaID).Scan(&almacenID, &estado)
+		if err == sql.ErrNoRows {
+			return 0, nil, repository.ErrOrigenDevolucion
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if estado != models.EstadoVentaDespachada {
+			return 0, nil, repository.ErrOrigenDevolucion
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT producto_id, cantidad FROM orden_venta_lineas WHERE orden_id = $1
+		`, *req.OrdenVentaID)
+		if err != nil {
+			return 0, nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var productoID, cantidad int
+			if err := rows.Scan(&productoID, &cantidad); err != nil {
+				return 0, nil, err
+			}
+			vendido[productoID] = cantidad
+		}
+		return almacenID, vendido, rows.Err()
+	}
+
+	// Solo las salidas sueltas vigentes: las de una orden se devuelven por
+	// la orden y las demás no son ventas
+	var m models.MovimientoInventario
+	var trasladoID, ordenCompraID, ordenVentaID, ensamblajeID, revierteID sql.NullInt64
+	var revertido bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT m.producto_id, m.almacen_id, m.tipo, m.cantidad, m.traslado_id, m.orden_compra_id, m.orden_venta_id,
+		       m.ensamblaje_id, m.revierte_id, EXISTS(SELECT 1 FROM movimientos_inventario rv WHERE rv.revierte_id = m.id)
+		FROM movimientos_inventario m
+		WHERE m.id = $1
+		FOR UPDATE
+	`, *req.MovimientoID).Scan(&m.ProductoID, &m.AlmacenID, &m.Tipo, &m.Cantidad, &trasladoID, &ordenCompraID,
+		&ordenVentaID, &ensamblajeID, &revierteID, &revertido)
+	if err == sql.ErrNoRows {
+		return 0, nil, repository.ErrOrigenDevolucion
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if m.Tipo != models.TipoSalida || trasladoID.Valid || ordenCompraID.Valid || ordenVentaID.Valid || ensamblajeID.Valid ||
+		revierteID.Valid || revertido {
+		return 0, nil, repository.ErrOrigenDevolucion
+	}
+	vendido[m.ProductoID] = m.Cantidad
+	return m.AlmacenID, vendido, nil
+}
+
+// devueltoOrigen suma por producto lo devuelto en devoluciones anteriores de
+// la misma orden o salida
+func devueltoOrigen(ctx context.Context, tx *sql.Tx, req models.DevolucionRequest) (map[int]int, error) {
+	columna, id := "d.orden_venta_id", req.OrdenVentaID
+	if id == nil {
+		columna, id = "d.movimiento_id", req.MovimientoID
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT i.producto_id, SUM(i.cantidad)
+		FROM devolucion_items i
+		JOIN devoluciones d ON i.devolucion_id = d.id
+		WHERE `+columna+` = $1
+		GROUP BY i.producto_id
+	`, *id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devuelto := make(map[int]int)
+	for rows.Next() {
+		var productoID, cantidad int
+		if err := rows.Scan(&productoID, &cantidad); err != nil {
+			return nil, err
+		}
+		devuelto[productoID] = cantidad
+	}
+	return devuelto, rows.Err()
+}
+
+// costoDevolucion devuelve el costo unitario con que salió el producto en la
+// venta original de la devolución, o nil si no se conoce
+func costoDevolucion(ctx context.Context, q querier, d models.Devolucion, productoID int) (*float64, error) {
+	query, id := "orden_venta_id = $1", d.OrdenVentaID
+	if id == nil {
+		query, id = "id = $1", d.MovimientoID
+	}
+	var salida models.MovimientoInventario
+	var costoTotal sql.NullFloat64
+	err := q.QueryRowContext(ctx, `
+		SELECT cantidad, costo_total FROM movimientos_inventario
+		WHERE `+query+` AND producto_id = $2 AND tipo = 'salida'
+	`, *id, productoID).Scan(&salida.Cantidad, &costoTotal)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if costoTotal.Valid {
+		salida.CostoTotal = &costoTotal.Float64
+	}
+	return repository.CostoVenta(salida), nil
+}
+
+// validarSeriesItem verifica las series del item contra las que salieron en
+// la venta de la devolución d y las que ya devolvieron sus items, incluidos
+// los anteriores de la propia d
+func validarSeriesItem(ctx context.Context, tx *sql.Tx, d models.Devolucion, item models.DevolucionItem) error {
+	var controlaSeries bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT controla_series FROM productos WHERE id = $1
+	`, item.ProductoID).Scan(&controlaSeries)
+	if err == sql.ErrNoRows {
+		return repository.ErrProductoNoExiste
+	}
+	if err != nil {
+		return err
+	}
+	if !controlaSeries {
+		return repository.ValidarSeriesDevolucion(item.Series, item.Cantidad, false, nil, nil)
+	}
+
+	venta, err := origenUnidades(ctx, tx, repository.Reposicion(d, item, nil))
+	if err != nil {
+		return err
+	}
+	vendidas, err := seriesMovimientos(ctx, tx, []int{venta})
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT unnest(i.series)
+		FROM devoluciones d
+		JOIN devoluciones otra ON otra.orden_venta_id = d.orden_venta_id OR otra.movimiento_id = d.movimiento_id
+		JOIN devolucion_items i ON i.devolucion_id = otra.id AND i.producto_id = $2
+		WHERE d.id = $1
+	`, d.ID, item.ProductoID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	devueltas := make(map[string]bool)
+	for rows.Next() {
+		var numero string
+		if err := rows.Scan(&numero); err != nil {
+			return err
+		}
+		devueltas[numero] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return repository.ValidarSeriesDevolucion(item.Series, item.Cantidad, true, vendidas[venta], devueltas)
+}
+
+// reponerItem registra el ajuste que devuelve al stock las unidades del item
+func reponerItem(ctx context.Context, tx *sql.Tx, d models.Devolucion, item models.DevolucionItem) (int, error) {
+	costo, err := costoDevolucion(ctx, tx, d, item.ProductoID)
+	if err != nil {
+		return 0, err
+	}
+	return aplicarMovimiento(ctx, tx, repository.Reposicion(d, item, costo))
+}
+
+func (r *DevolucionRepository) Create(ctx context.Context, req models.DevolucionRequest) (*models.Devolucion, error) {
+	if (req.OrdenVentaID == nil) == (req.MovimientoID == nil) {
+		return nil, repository.ErrValorInvalido
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Con el origen bloqueado, dos devoluciones simultáneas no pueden pasar
+	// ambas la verificación de cantidades
+	almacenID, vendido, err := origenDevolucion(ctx, tx, req)
+	if err != nil {
+		return nil, err
+	}
+	devuelto, err := devueltoOrigen(ctx, tx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.ValidarDevolucion(vendido, devuelto, req.Items); err != nil {
+		return nil, err
+	}
+
+	d := models.Devolucion{OrdenVentaID: req.OrdenVentaID, MovimientoID: req.MovimientoID, AlmacenID: almacenID}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO devoluciones (orden_venta_id, movimiento_id, almacen_id, motivo)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, req.OrdenVentaID, req.MovimientoID, almacenID, req.Motivo).Scan(&d.ID)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	// Los ajustes bloquean las filas de producto; se aplican en orden de
+	// producto para no provocar interbloqueos
+	items := append([]models.DevolucionItemRequest(nil), req.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ProductoID < items[j].ProductoID })
+	for _, ir := range items {
+		estado, ok := repository.EstadoInicialDevolucion(ir.Condicion, ir.Destino)
+		if !ok {
+			return nil, repository.ErrValorInvalido
+		}
+		item := models.DevolucionItem{
+			ProductoID: ir.ProductoID,
+			Cantidad:   ir.Cantidad,
+			Condicion:  ir.Condicion,
+			Estado:     estado,
+			Series:     ir.Series,
+		}
+		if err := validarSeriesItem(ctx, tx, d, item); err != nil {
+			return nil, err
+		}
+		if estado == models.EstadoItemRepuesto {
+			movimientoID, err := reponerItem(ctx, tx, d, item)
+			if err != nil {
+				return nil, err
+			}
+			item.MovimientoID = &movimientoID
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO devolucion_items (devolucion_id, producto_id, cantidad, condicion, estado, series, movimiento_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, d.ID, item.ProductoID, item.Cantidad, item.Condicion, item.Estado, pq.Array(item.Series), item.MovimientoID)
+		if err != nil {
+			return nil, traducirError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, d.ID)
+}
+
+func (r *DevolucionRepository) Cuarentena(ctx context.Context) ([]models.DevolucionItem, error) {
+	return queryDevolucionItems(ctx, r.db, devolucionItemSelect+" WHERE i.estado = 'cuarentena' ORDER BY i.id")
+}
+
+func (r *DevolucionRepository) Resolver(ctx context.Context, id, itemID int, estado models.EstadoItemDevolucion) (*models.Devolucion, error) {
+	if !repository.ResolucionValida(estado) {
+		return nil, repository.ErrValorInvalido
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	d, err := scanDevolucion(tx.QueryRowContext(ctx, devolucionSelect+" WHERE d.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Bloquear el item para que no se resuelva dos veces
+	item := models.DevolucionItem{ID: itemID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT producto_id, cantidad, estado, series FROM devolucion_items
+		WHERE id = $1 AND devolucion_id = $2
+		FOR UPDATE
+	`, itemID, id).Scan(&item.ProductoID, &item.Cantidad, &item.Estado, pq.Array(&item.Series))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if item.Estado != models.EstadoItemCuarentena {
+		return nil, repository.ErrItemResuelto
+	}
+
+	var movimientoID *int
+	if estado == models.EstadoItemRepuesto {
+		nuevoID, err := reponerItem(ctx, tx, *d, item)
+		if err != nil {
+			return nil, err
+		}
+		movimientoID = &nuevoID
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE devolucion_items SET estado = $1, movimiento_id = $2, resuelto_at = NOW()
+		WHERE id = $3
+	`, estado, movimientoID, itemID)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
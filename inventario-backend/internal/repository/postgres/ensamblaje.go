@@ -0,0 +1,361 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const ensamblajeSelect = `
+	SELECT e.id, e.tipo, e.producto_id, e.almacen_id, e.cantidad, e.motivo, e.created_at, p.nombre, p.sku
+	FROM ensamblajes e
+	JOIN productos p ON e.producto_id = p.id
+`
+
+type EnsamblajeRepository struct {
+	db *sql.DB
+}
+
+func NewEnsamblajeRepository(db *sql.DB) *EnsamblajeRepository {
+	return &EnsamblajeRepository{db: db}
+}
+
+func scanEnsamblaje(row scanner) (*models.Ensamblaje, error) {
+	var e models.Ensamblaje
+	var p models.Producto
+	var motivo, sku sql.NullString
+	err := row.Scan(&e.ID, &e.Tipo, &e.ProductoID, &e.AlmacenID, &e.Cantidad, &motivo, &e.CreatedAt, &p.Nombre, &sku)
+	if err != nil {
+		return nil, err
+	}
+	e.Motivo = motivo.String
+	p.ID = e.ProductoID
+	p.SKU = sku.String
+	e.Producto = &p
+	return &e, nil
+}
+
+// componentesKit devuelve la lista de materiales de cada uno de los kits
+// indicados, por componente
+func componentesKit(ctx context.Context, q querier, kitIDs []int) (map[int][]models.Componente, error) {
+	componentes := make(map[int][]models.Componente)
+	if len(kitIDs) == 0 {
+		return componentes, nil
+	}
+
+	ids := make([]int64, len(kitIDs))
+	for i, id := range kitIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.producto_id, c.componente_id, p.nombre, p.sku, c.cantidad
+		FROM producto_componentes c
+		JOIN productos p ON c.componente_id = p.id
+		WHERE c.producto_id = ANY($1)
+		ORDER BY c.producto_id, c.componente_id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kitID int
+		var c models.Componente
+		var p models.Producto
+		var sku sql.NullString
+		if err := rows.Scan(&kitID, &c.ProductoID, &p.Nombre, &sku, &c.Cantidad); err != nil {
+			return nil, err
+		}
+		p.ID = c.ProductoID
+		p.SKU = sku.String
+		c.Producto = &p
+		componentes[kitID] = append(componentes[kitID], c)
+	}
+	return componentes, rows.Err()
+}
+
+// disponiblePorAlmacen devuelve, por almacén, lo disponible de cada uno de
+// los productos indicados: su stock menos lo reservado en ese almacén
+func disponiblePorAlmacen(ctx context.Context, q querier, productoIDs []int64) (map[int]map[int]int, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT s.producto_id, s.almacen_id,
+		       s.cantidad - (SELECT COALESCE(SUM(l.cantidad), 0)
+		                     FROM orden_venta_lineas l
+		                     JOIN ordenes_venta o ON l.orden_id = o.id
+		                     WHERE l.producto_id = s.producto_id AND o.almacen_id = s.almacen_id
+		                       AND o.estado = 'confirmada')
+		FROM stock_almacen s
+		WHERE s.producto_id = ANY($1)
+	`, pq.Array(productoIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	disponible := make(map[int]map[int]int)
+	for rows.Next() {
+		var productoID, almacenID, cantidad int
+		if err := rows.Scan(&productoID, &almacenID, &cantidad); err != nil {
+			return nil, err
+		}
+		if disponible[almacenID] == nil {
+			disponible[almacenID] = make(map[int]int)
+		}
+		disponible[almacenID][productoID] = cantidad
+	}
+	return disponible, rows.Err()
+}
+
+// cargarComponentes completa la lista de materiales de los kits y cuántos se
+// pueden armar con ella
+func (r *ProductoRepository) cargarComponentes(ctx context.Context, productos []models.Producto) error {
+	ids := make([]int, len(productos))
+	for i, p := range productos {
+		ids[i] = p.ID
+	}
+	componentes, err := componentesKit(ctx, r.db, ids)
+	if err != nil || len(componentes) == 0 {
+		return err
+	}
+
+	var componenteIDs []int64
+	for _, lista := range componentes {
+		for _, c := range lista {
+			componenteIDs = append(componenteIDs, int64(c.ProductoID))
+		}
+	}
+	disponible, err := disponiblePorAlmacen(ctx, r.db, componenteIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range productos {
+		if lista, ok := componentes[productos[i].ID]; ok {
+			ensamblables := repository.Ensamblables(lista, disponible)
+			productos[i].Componentes = lista
+			productos[i].Ensamblables = &ensamblables
+		}
+	}
+	return nil
+}
+
+func (r *ProductoRepository) SetComponentes(ctx context.Context, id int, req []models.ComponenteRequest) (*models.Producto, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Bloquear el kit y sus componentes para que ninguno pase a ser kit o
+	// componente de otro mientras tanto
+	var stock int
+	var conVariantes, esComponente, controla bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT stock,
+		       EXISTS(SELECT 1 FROM productos v WHERE v.producto_padre_id = p.id),
+		       EXISTS(SELECT 1 FROM producto_componentes c WHERE c.componente_id = p.id),
+		       controla_lotes OR controla_series
+		FROM productos p WHERE id = $1 FOR UPDATE
+	`, id).Scan(&stock, &conVariantes, &esComponente, &controla)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Un solo nivel: los kits no se anidan
+	if conVariantes || (len(req) > 0 && esComponente) {
+		return nil, repository.ErrComponenteInvalido
+	}
+	// Ensamblar no indica qué lotes o series mueve
+	if len(req) > 0 && controla {
+		return nil, repository.ErrControlEnKit
+	}
+	if stock != 0 {
+		return nil, repository.ErrComponentesConStock
+	}
+
+	for _, c := range req {
+		var esKit bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM producto_componentes c WHERE c.producto_id = p.id),
+			       EXISTS(SELECT 1 FROM productos v WHERE v.producto_padre_id = p.id),
+			       controla_lotes OR controla_series
+			FROM productos p WHERE id = $1 FOR UPDATE
+		`, c.ProductoID).Scan(&esKit, &conVariantes, &controla)
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrProductoNoExiste
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c.ProductoID == id || esKit || conVariantes {
+			return nil, repository.ErrComponenteInvalido
+		}
+		if controla {
+			return nil, repository.ErrControlEnKit
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM producto_componentes WHERE producto_id = $1", id); err != nil {
+		return nil, err
+	}
+	for _, c := range req {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO producto_componentes (producto_id, componente_id, cantidad) VALUES ($1, $2, $3)
+		`, id, c.ProductoID, c.Cantidad)
+		if err != nil {
+			return nil, traducirError(err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE productos SET updated_at = NOW() WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *EnsamblajeRepository) List(ctx context.Context, filtro repository.EnsamblajeFiltro) ([]models.Ensamblaje, error) {
+	var where whereBuilder
+	if filtro.Tipo != "" {
+		where.add("e.tipo = ?", filtro.Tipo)
+	}
+	if filtro.ProductoID != nil {
+		where.add("e.producto_id = ?", *filtro.ProductoID)
+	}
+	if filtro.AlmacenID != nil {
+		where.add("e.almacen_id = ?", *filtro.AlmacenID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, ensamblajeSelect+where.String()+" ORDER BY e.created_at DESC, e.id DESC", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ensamblajes []models.Ensamblaje
+	for rows.Next() {
+		e, err := scanEnsamblaje(rows)
+		if err != nil {
+			return nil, err
+		}
+		ensamblajes = append(ensamblajes, *e)
+	}
+	return ensamblajes, rows.Err()
+}
+
+func (r *EnsamblajeRepository) GetByID(ctx context.Context, id int) (*models.Ensamblaje, error) {
+	e, err := scanEnsamblaje(r.db.QueryRowContext(ctx, ensamblajeSelect+" WHERE e.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	movimientos := &MovimientoRepository{db: r.db}
+	e.Movimientos, err = movimientos.query(ctx, movimientoSelect+" WHERE m.ensamblaje_id = $1 ORDER BY m.id", id)
+	if err != nil {
+		return nil, err
+	}
+	if err := cargarUnidades(ctx, r.db, e.Movimientos); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *EnsamblajeRepository) Create(ctx context.Context, req models.EnsamblajeRequest) (*models.Ensamblaje, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Bloquear el kit para que su lista de materiales no cambie mientras
+	// tanto
+	var existe int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM productos WHERE id = $1 FOR UPDATE", req.ProductoID).Scan(&existe)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrProductoNoExiste
+	}
+	if err != nil {
+		return nil, err
+	}
+	componentes, err := componentesKit(ctx, tx, []int{req.ProductoID})
+	if err != nil {
+		return nil, err
+	}
+	if len(componentes[req.ProductoID]) == 0 {
+		return nil, repository.ErrNoEsKit
+	}
+	if req.AlmacenID == 0 {
+		if req.AlmacenID, err = almacenPrincipal(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	e := models.Ensamblaje{
+		Tipo:       req.Tipo,
+		ProductoID: req.ProductoID,
+		AlmacenID:  req.AlmacenID,
+		Cantidad:   req.Cantidad,
+		Motivo:     req.Motivo,
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO ensamblajes (tipo, producto_id, almacen_id, cantidad, motivo)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, e.Tipo, e.ProductoID, e.AlmacenID, e.Cantidad, e.Motivo).Scan(&e.ID)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	salidas, entradas := repository.MovimientosEnsamblaje(e, componentes[req.ProductoID])
+
+	// Las entradas se valoran con el costo de lo consumido; el costo de
+	// referencia de cada una es el que tendría sin costo propio
+	for _, m := range salidas {
+		if _, err := aplicarMovimiento(ctx, tx, m); err != nil {
+			return nil, err
+		}
+	}
+	var total float64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(costo_total), 0) FROM movimientos_inventario WHERE ensamblaje_id = $1
+	`, e.ID).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+	costos := make([]float64, len(entradas))
+	for i, m := range entradas {
+		var stock int
+		var valor float64
+		err := tx.QueryRowContext(ctx, `
+			SELECT stock, valor_inventario FROM productos WHERE id = $1
+		`, m.ProductoID).Scan(&stock, &valor)
+		if err != nil {
+			return nil, err
+		}
+		if costos[i], _, err = costoEntrada(ctx, tx, m, stock, valor); err != nil {
+			return nil, err
+		}
+	}
+	repository.CostosEnsamblaje(total, entradas, costos)
+	for _, m := range entradas {
+		if _, err := aplicarMovimiento(ctx, tx, m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, e.ID)
+}
@@ -0,0 +1,338 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const loteSelect = `
+	SELECT l.id, l.producto_id, p.nombre, p.sku, l.almacen_id, l.codigo, l.vencimiento, l.cantidad, l.created_at
+	FROM lotes l
+	JOIN productos p ON l.producto_id = p.id
+`
+
+type LoteRepository struct {
+	db *sql.DB
+}
+
+func NewLoteRepository(db *sql.DB) *LoteRepository {
+	return &LoteRepository{db: db}
+}
+
+// nullFecha convierte una fecha nullable de la base de datos
+func nullFecha(t sql.NullTime) *models.Fecha {
+	if !t.Valid {
+		return nil
+	}
+	return &models.Fecha{Time: t.Time}
+}
+
+// fechaArg prepara una fecha nullable como argumento de una consulta
+func fechaArg(f *models.Fecha) interface{} {
+	if f == nil {
+		return nil
+	}
+	return f.Time
+}
+
+func scanLote(row scanner) (*models.Lote, error) {
+	var l models.Lote
+	var p models.Producto
+	var sku sql.NullString
+	var vencimiento sql.NullTime
+	err := row.Scan(&l.ID, &l.ProductoID, &p.Nombre, &sku, &l.AlmacenID, &l.Codigo, &vencimiento, &l.Cantidad,
+		&l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	l.Vencimiento = nullFecha(vencimiento)
+	p.ID = l.ProductoID
+	p.SKU = sku.String
+	l.Producto = &p
+	return &l, nil
+}
+
+// lotesMovimientos devuelve los lotes que tocó cada uno de los movimientos
+// indicados, del que vence primero al último
+func lotesMovimientos(ctx context.Context, q querier, movimientoIDs []int) (map[int][]models.MovimientoLote, error) {
+	lotes := make(map[int][]models.MovimientoLote)
+	if len(movimientoIDs) == 0 {
+		return lotes, nil
+	}
+
+	ids := make([]int64, len(movimientoIDs))
+	for i, id := range movimientoIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT ml.movimiento_id, ml.lote_id, l.codigo, l.vencimiento, ml.cantidad
+		FROM movimiento_lotes ml
+		JOIN lotes l ON ml.lote_id = l.id
+		WHERE ml.movimiento_id = ANY($1)
+		ORDER BY l.vencimiento NULLS LAST, l.id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movimientoID int
+		var l models.MovimientoLote
+		var vencimiento sql.NullTime
+		if err := rows.Scan(&movimientoID, &l.LoteID, &l.Codigo, &vencimiento, &l.Cantidad); err != nil {
+			return nil, err
+		}
+		l.Vencimiento = nullFecha(vencimiento)
+		lotes[movimientoID] = append(lotes[movimientoID], l)
+	}
+	return lotes, rows.Err()
+}
+
+// origenUnidades devuelve el movimiento cuyas unidades, con sus lotes y
+// series, recupera m: el de sus capas de costo o, en la reposición de una
+// devolución, la salida de la venta devuelta. Devuelve 0 si no hay.
+func origenUnidades(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario) (int, error) {
+	if m.DevolucionID == nil {
+		return origenCapas(ctx, tx, m)
+	}
+	var id sql.NullInt64
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(d.movimiento_id, (
+			SELECT v.id FROM movimientos_inventario v
+			WHERE v.orden_venta_id = d.orden_venta_id AND v.producto_id = $2 AND v.tipo = 'salida'
+			LIMIT 1))
+		FROM devoluciones d
+		WHERE d.id = $1
+	`, *m.DevolucionID, m.ProductoID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return int(id.Int64), err
+}
+
+// lotesRepuestos suma por lote lo que ya repusieron las devoluciones de la
+// misma venta que la de m
+func lotesRepuestos(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario) (map[int]int, error) {
+	if m.DevolucionID == nil {
+		return nil, nil
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT ml.lote_id, SUM(ml.cantidad)
+		FROM devoluciones d
+		JOIN devoluciones otra ON otra.orden_venta_id = d.orden_venta_id OR otra.movimiento_id = d.movimiento_id
+		JOIN movimientos_inventario r ON r.devolucion_id = otra.id AND r.producto_id = $2
+		JOIN movimiento_lotes ml ON ml.movimiento_id = r.id
+		WHERE d.id = $1
+		GROUP BY ml.lote_id
+	`, *m.DevolucionID, m.ProductoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usado := make(map[int]int)
+	for rows.Next() {
+		var loteID, cantidad int
+		if err := rows.Scan(&loteID, &cantidad); err != nil {
+			return nil, err
+		}
+		usado[loteID] = cantidad
+	}
+	return usado, rows.Err()
+}
+
+// loteCodigo devuelve el lote del producto con ese código en el almacén, o
+// nil si no existe
+func loteCodigo(ctx context.Context, tx *sql.Tx, productoID, almacenID int, codigo string) (*models.Lote, error) {
+	l := models.Lote{ProductoID: productoID, AlmacenID: almacenID, Codigo: codigo}
+	var vencimiento sql.NullTime
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, vencimiento, cantidad FROM lotes WHERE producto_id = $1 AND almacen_id = $2 AND codigo = $3
+	`, productoID, almacenID, codigo).Scan(&l.ID, &vencimiento, &l.Cantidad)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.Vencimiento = nullFecha(vencimiento)
+	return &l, nil
+}
+
+// planLotes decide qué lotes mueve m, que cambia el stock en delta. Las
+// unidades que vuelven lo hacen a los lotes de su origen; el resto de lo que
+// suma va al lote indicado y lo que resta sale del lote indicado o de los que
+// vencen primero. La fila del producto debe estar bloqueada por la
+// transacción, lo que también protege sus lotes.
+func planLotes(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, controlaLotes bool, delta int) ([]models.MovimientoLote, error) {
+	if !controlaLotes {
+		if m.Lote != "" || m.Vencimiento != nil {
+			return nil, repository.ErrLoteInvalido
+		}
+		return nil, nil
+	}
+	if delta < 0 && m.Vencimiento != nil {
+		return nil, repository.ErrLoteInvalido
+	}
+
+	cantidad := max(delta, -delta)
+	var plan []models.MovimientoLote
+	origen, err := origenUnidades(ctx, tx, m)
+	if err != nil {
+		return nil, err
+	}
+	if origen != 0 {
+		lotes, err := lotesMovimientos(ctx, tx, []int{origen})
+		if err != nil {
+			return nil, err
+		}
+		usado, err := lotesRepuestos(ctx, tx, m)
+		if err != nil {
+			return nil, err
+		}
+		// Si un lote de origen no alcanza, lotes_cantidad_check rechaza la
+		// resta al aplicarla
+		plan, cantidad = repository.PlanOrigen(lotes[origen], usado, cantidad)
+	}
+	if cantidad == 0 {
+		return plan, nil
+	}
+
+	if delta > 0 {
+		if m.Lote == "" {
+			return nil, repository.ErrLoteInvalido
+		}
+		l := models.MovimientoLote{Codigo: m.Lote, Vencimiento: m.Vencimiento, Cantidad: cantidad}
+		lote, err := loteCodigo(ctx, tx, m.ProductoID, m.AlmacenID, m.Lote)
+		if err != nil {
+			return nil, err
+		}
+		if lote != nil {
+			// Un lote conserva el vencimiento con que se creó
+			if m.Vencimiento != nil && (lote.Vencimiento == nil || !lote.Vencimiento.Equal(m.Vencimiento.Time)) {
+				return nil, repository.ErrLoteInvalido
+			}
+			l.Vencimiento = lote.Vencimiento
+		}
+		return append(plan, l), nil
+	}
+
+	if m.Lote != "" {
+		lote, err := loteCodigo(ctx, tx, m.ProductoID, m.AlmacenID, m.Lote)
+		if err != nil {
+			return nil, err
+		}
+		if lote == nil {
+			return nil, repository.ErrLoteNoExiste
+		}
+		if lote.Cantidad < cantidad {
+			return nil, repository.ErrStockInsuficiente
+		}
+		return append(plan, models.MovimientoLote{LoteID: lote.ID, Codigo: lote.Codigo, Vencimiento: lote.Vencimiento, Cantidad: cantidad}), nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, codigo, vencimiento, cantidad FROM lotes
+		WHERE producto_id = $1 AND almacen_id = $2 AND cantidad > 0
+	`, m.ProductoID, m.AlmacenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vigentes []models.Lote
+	for rows.Next() {
+		var l models.Lote
+		var vencimiento sql.NullTime
+		if err := rows.Scan(&l.ID, &l.Codigo, &vencimiento, &l.Cantidad); err != nil {
+			return nil, err
+		}
+		l.Vencimiento = nullFecha(vencimiento)
+		vigentes = append(vigentes, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	fefo, resto := repository.PlanFEFO(vigentes, cantidad)
+	if resto > 0 {
+		return nil, repository.ErrStockInsuficiente
+	}
+	return append(plan, fefo...), nil
+}
+
+// aplicarLotes suma o resta a cada lote su parte del plan y registra lo que
+// tocó el movimiento m, ya creado
+func aplicarLotes(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, delta int, plan []models.MovimientoLote) error {
+	for _, l := range plan {
+		loteID := l.LoteID
+		if delta < 0 {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE lotes SET cantidad = cantidad - $1 WHERE id = $2
+			`, l.Cantidad, loteID)
+			if err != nil {
+				return traducirError(err)
+			}
+		} else {
+			// Las unidades de un traslado llegan a un almacén distinto del
+			// de su lote de origen, así que se buscan por código
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO lotes (producto_id, almacen_id, codigo, vencimiento, cantidad)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (producto_id, almacen_id, codigo) DO UPDATE SET cantidad = lotes.cantidad + EXCLUDED.cantidad
+				RETURNING id
+			`, m.ProductoID, m.AlmacenID, l.Codigo, fechaArg(l.Vencimiento), l.Cantidad).Scan(&loteID)
+			if err != nil {
+				return traducirError(err)
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO movimiento_lotes (movimiento_id, lote_id, cantidad)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (movimiento_id, lote_id) DO UPDATE SET cantidad = movimiento_lotes.cantidad + EXCLUDED.cantidad
+		`, m.ID, loteID, l.Cantidad)
+		if err != nil {
+			return traducirError(err)
+		}
+	}
+	return nil
+}
+
+// List devuelve los lotes del que vence primero al último
+func (r *LoteRepository) List(ctx context.Context, filtro repository.LoteFiltro) ([]models.Lote, error) {
+	var where whereBuilder
+	if filtro.ProductoID != nil {
+		where.add("l.producto_id = ?", *filtro.ProductoID)
+	}
+	if filtro.AlmacenID != nil {
+		where.add("l.almacen_id = ?", *filtro.AlmacenID)
+	}
+	if filtro.ConStock {
+		where.add("l.cantidad > 0")
+	}
+	if filtro.VenceHasta != nil {
+		where.add("l.vencimiento <= ?", *filtro.VenceHasta)
+	}
+
+	rows, err := r.db.QueryContext(ctx, loteSelect+where.String()+" ORDER BY l.vencimiento NULLS LAST, l.id", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lotes []models.Lote
+	for rows.Next() {
+		l, err := scanLote(rows)
+		if err != nil {
+			return nil, err
+		}
+		lotes = append(lotes, *l)
+	}
+	return lotes, rows.Err()
+}
@@ -0,0 +1,287 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+const movimientoSelect = `
+	SELECT m.id, m.producto_id, m.almacen_id, m.tipo, m.cantidad, m.codigo_motivo, m.motivo, m.costo_unitario,
+	       m.moneda, m.tipo_cambio, m.costo_total, m.proveedor_id, pr.nombre, m.traslado_id, m.orden_compra_id,
+	       m.orden_venta_id, m.devolucion_id, m.ensamblaje_id, m.revierte_id, rv.id, m.created_at,
+	       p.id, p.nombre, p.descripcion, p.precio, p.stock,
+	       a.nombre, a.principal
+	FROM movimientos_inventario m
+	LEFT JOIN productos p ON m.producto_id = p.id
+	JOIN almacenes a ON m.almacen_id = a.id
+	LEFT JOIN proveedores pr ON m.proveedor_id = pr.id
+	LEFT JOIN movimientos_inventario rv ON rv.revierte_id = m.id
+`
+
+type MovimientoRepository struct {
+	db *sql.DB
+}
+
+func NewMovimientoRepository(db *sql.DB) *MovimientoRepository {
+	return &MovimientoRepository{db: db}
+}
+
+func scanMovimiento(row scanner) (*models.MovimientoInventario, error) {
+	var m models.MovimientoInventario
+	var p models.Producto
+	var a models.Almacen
+	var codigoMotivo, motivo, descripcion sql.NullString
+	var moneda, proveedor sql.NullString
+	var costoUnitario, tipoCambio, costoTotal sql.NullFloat64
+	var proveedorID, trasladoID, ordenCompraID, ordenVentaID, devolucionID, ensamblajeID sql.NullInt64
+	var revierteID, revertidoPorID sql.NullInt64
+	err := row.Scan(&m.ID, &m.ProductoID, &m.AlmacenID, &m.Tipo, &m.Cantidad, &codigoMotivo, &motivo, &costoUnitario,
+		&moneda, &tipoCambio, &costoTotal, &proveedorID, &proveedor, &trasladoID, &ordenCompraID,
+		&ordenVentaID, &devolucionID, &ensamblajeID, &revierteID, &revertidoPorID, &m.CreatedAt,
+		&p.ID, &p.Nombre, &descripcion, &p.Precio, &p.Stock,
+		&a.Nombre, &a.Principal)
+	if err != nil {
+		return nil, err
+	}
+	m.CodigoMotivo = models.CodigoMotivo(codigoMotivo.String)
+	m.Motivo = motivo.String
+	if costoUnitario.Valid {
+		m.CostoUnitario = &costoUnitario.Float64
+	}
+	m.Moneda = moneda.String
+	if tipoCambio.Valid {
+		m.TipoCambio = &tipoCambio.Float64
+	}
+	if costoTotal.Valid {
+		m.CostoTotal = &costoTotal.Float64
+	}
+	m.ProveedorID = nullInt(proveedorID)
+	if m.ProveedorID != nil {
+		m.Proveedor = &models.Proveedor{ID: *m.ProveedorID, Nombre: proveedor.String}
+	}
+	m.TrasladoID = nullInt(trasladoID)
+	m.OrdenCompraID = nullInt(ordenCompraID)
+	m.OrdenVentaID = nullInt(ordenVentaID)
+	m.DevolucionID = nullInt(devolucionID)
+	m.EnsamblajeID = nullInt(ensamblajeID)
+	m.RevierteID = nullInt(revierteID)
+	m.RevertidoPorID = nullInt(revertidoPorID)
+	p.Descripcion = descripcion.String
+	m.Producto = &p
+	a.ID = m.AlmacenID
+	m.Almacen = &a
+	return &m, nil
+}
+
+func (r *MovimientoRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.MovimientoInventario, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movimientos []models.MovimientoInventario
+	for rows.Next() {
+		m, err := scanMovimiento(rows)
+		if err != nil {
+			return nil, err
+		}
+		movimientos = append(movimientos, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := cargarUnidades(ctx, r.db, movimientos); err != nil {
+		return nil, err
+	}
+	return movimientos, nil
+}
+
+// cargarUnidades completa los lotes y las series de los movimientos con una
+// consulta para cada uno
+func cargarUnidades(ctx context.Context, q querier, movimientos []models.MovimientoInventario) error {
+	ids := make([]int, len(movimientos))
+	for i, m := range movimientos {
+		ids[i] = m.ID
+	}
+	lotes, err := lotesMovimientos(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	series, err := seriesMovimientos(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range movimientos {
+		movimientos[i].Lotes = lotes[movimientos[i].ID]
+		movimientos[i].Series = series[movimientos[i].ID]
+	}
+	return nil
+}
+
+func (r *MovimientoRepository) List(ctx context.Context, filtro repository.MovimientoFiltro) ([]models.MovimientoInventario, *repository.MovimientoCursor, error) {
+	var where whereBuilder
+	if filtro.Tipo != "" {
+		where.add("m.tipo = ?", filtro.Tipo)
+	}
+	if filtro.CodigoMotivo != "" {
+		where.add("m.codigo_motivo = ?", filtro.CodigoMotivo)
+	}
+	if filtro.ProductoID != nil {
+		where.add("m.producto_id = ?", *filtro.ProductoID)
+	}
+	if filtro.CategoriaID != nil {
+		where.add("p.categoria_id = ?", *filtro.CategoriaID)
+	}
+	if filtro.AlmacenID != nil {
+		where.add("m.almacen_id = ?", *filtro.AlmacenID)
+	}
+	if filtro.ProveedorID != nil {
+		where.add("m.proveedor_id = ?", *filtro.ProveedorID)
+	}
+	if filtro.OrdenCompraID != nil {
+		where.add("m.orden_compra_id = ?", *filtro.OrdenCompraID)
+	}
+	if filtro.OrdenVentaID != nil {
+		where.add("m.orden_venta_id = ?", *filtro.OrdenVentaID)
+	}
+	if filtro.DevolucionID != nil {
+		where.add("m.devolucion_id = ?", *filtro.DevolucionID)
+	}
+	if filtro.LoteID != nil {
+		where.add("EXISTS(SELECT 1 FROM movimiento_lotes ml WHERE ml.movimiento_id = m.id AND ml.lote_id = ?)", *filtro.LoteID)
+	}
+	if filtro.Serie != "" {
+		where.add(`EXISTS(SELECT 1 FROM movimiento_series ms JOIN series s ON ms.serie_id = s.id
+			WHERE ms.movimiento_id = m.id AND s.numero = ?)`, filtro.Serie)
+	}
+	if filtro.Desde != nil {
+		where.add("m.created_at >= ?", *filtro.Desde)
+	}
+	if filtro.Hasta != nil {
+		where.add("m.created_at < ?", *filtro.Hasta)
+	}
+	if filtro.Q != "" {
+		where.add("m.motivo ILIKE ?", "%"+escapeLike(filtro.Q)+"%")
+	}
+	if filtro.After != nil {
+		// La primera condición acota el rango sobre idx_movimientos_fecha; la
+		// segunda descarta los movimientos del mismo instante ya devueltos
+		createdAt := where.arg(filtro.After.CreatedAt)
+		id := where.arg(filtro.After.ID)
+		where.add("m.created_at <= " + createdAt + " AND (m.created_at < " + createdAt + " OR m.id < " + id + ")")
+	}
+
+	query := movimientoSelect + where.String() + " ORDER BY m.created_at DESC, m.id DESC"
+	if filtro.Limit > 0 {
+		// Se pide un registro extra para saber si existe una página siguiente
+		query += " LIMIT " + where.arg(filtro.Limit+1)
+	}
+
+	movimientos, err := r.query(ctx, query, where.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	movimientos, next := repository.SiguientePagina(movimientos, filtro.Limit)
+	return movimientos, next, nil
+}
+
+func (r *MovimientoRepository) GetByID(ctx context.Context, id int) (*models.MovimientoInventario, error) {
+	m, err := scanMovimiento(r.db.QueryRowContext(ctx, movimientoSelect+" WHERE m.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	movimientos := []models.MovimientoInventario{*m}
+	if err := cargarUnidades(ctx, r.db, movimientos); err != nil {
+		return nil, err
+	}
+	return &movimientos[0], nil
+}
+
+func (r *MovimientoRepository) Create(ctx context.Context, req models.MovimientoInventarioRequest) (*models.MovimientoInventario, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := aplicarMovimiento(ctx, tx, models.MovimientoInventario{
+		ProductoID:    req.ProductoID,
+		AlmacenID:     req.AlmacenID,
+		Tipo:          req.Tipo,
+		Cantidad:      req.Cantidad,
+		CodigoMotivo:  req.CodigoMotivo,
+		Motivo:        req.Motivo,
+		CostoUnitario: req.CostoUnitario,
+		Moneda:        req.Moneda,
+		TipoCambio:    req.TipoCambio,
+		ProveedorID:   req.ProveedorID,
+		Lote:          req.Lote,
+		Vencimiento:   req.Vencimiento,
+		Series:        req.Series,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *MovimientoRepository) Revertir(ctx context.Context, id int, motivo string) (*models.MovimientoInventario, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Bloquear el movimiento original para que dos reversiones simultáneas
+	// no pasen ambas la verificación
+	var original models.MovimientoInventario
+	var codigoMotivo sql.NullString
+	var trasladoID, ordenCompraID, ordenVentaID, devolucionID, ensamblajeID, revierteID sql.NullInt64
+	var revertido, devuelto bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT m.id, m.producto_id, m.almacen_id, m.tipo, m.cantidad, m.codigo_motivo, m.traslado_id, m.orden_compra_id,
+		       m.orden_venta_id, m.devolucion_id, m.ensamblaje_id, m.revierte_id,
+		       EXISTS(SELECT 1 FROM movimientos_inventario rv WHERE rv.revierte_id = m.id),
+		       EXISTS(SELECT 1 FROM devoluciones d WHERE d.movimiento_id = m.id)
+		FROM movimientos_inventario m
+		WHERE m.id = $1
+		FOR UPDATE
+	`, id).Scan(&original.ID, &original.ProductoID, &original.AlmacenID, &original.Tipo, &original.Cantidad,
+		&codigoMotivo, &trasladoID, &ordenCompraID, &ordenVentaID, &devolucionID, &ensamblajeID, &revierteID, &revertido, &devuelto)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Una salida con devoluciones tampoco se revierte: lo devuelto ya volvió
+	// o está en cuarentena
+	if trasladoID.Valid || ordenCompraID.Valid || ordenVentaID.Valid || devolucionID.Valid || ensamblajeID.Valid ||
+		revierteID.Valid || devuelto {
+		return nil, repository.ErrReversionNoPermitida
+	}
+	if revertido {
+		return nil, repository.ErrMovimientoRevertido
+	}
+	original.CodigoMotivo = models.CodigoMotivo(codigoMotivo.String)
+
+	nuevoID, err := aplicarMovimiento(ctx, tx, repository.Reversion(original, motivo))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, nuevoID)
+}
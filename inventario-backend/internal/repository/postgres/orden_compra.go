@@ -0,0 +1,328 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+const ordenCompraSelect = `
+	SELECT o.id, o.proveedor_id, pr.nombre, o.almacen_id, o.estado, o.notas, o.created_at, o.updated_at,
+	       o.enviada_at, o.cerrada_at,
+	       ROUND(COALESCE((SELECT SUM(l.cantidad * l.costo_unitario) FROM orden_compra_lineas l WHERE l.orden_id = o.id), 0), 2)
+	FROM ordenes_compra o
+	JOIN proveedores pr ON o.proveedor_id = pr.id
+`
+
+type OrdenCompraRepository struct {
+	db *sql.DB
+}
+
+func NewOrdenCompraRepository(db *sql.DB) *OrdenCompraRepository {
+	return &OrdenCompraRepository{db: db}
+}
+
+func scanOrdenCompra(row scanner) (*models.OrdenCompra, error) {
+	var o models.OrdenCompra
+	var notas sql.NullString
+	var enviadaAt, cerradaAt sql.NullTime
+	err := row.Scan(&o.ID, &o.ProveedorID, &o.Proveedor, &o.AlmacenID, &o.Estado, &notas, &o.CreatedAt, &o.UpdatedAt,
+		&enviadaAt, &cerradaAt, &o.Total)
+	if err != nil {
+		return nil, err
+	}
+	o.Notas = notas.String
+	if enviadaAt.Valid {
+		o.EnviadaAt = &enviadaAt.Time
+	}
+	if cerradaAt.Valid {
+		o.CerradaAt = &cerradaAt.Time
+	}
+	return &o, nil
+}
+
+func (r *OrdenCompraRepository) List(ctx context.Context, filtro repository.OrdenCompraFiltro) ([]models.OrdenCompra, error) {
+	var where whereBuilder
+	if filtro.Estado != "" {
+		where.add("o.estado = ?", filtro.Estado)
+	}
+	if filtro.ProveedorID != nil {
+		where.add("o.proveedor_id = ?", *filtro.ProveedorID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, ordenCompraSelect+where.String()+" ORDER BY o.created_at DESC, o.id DESC", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ordenes []models.OrdenCompra
+	for rows.Next() {
+		o, err := scanOrdenCompra(rows)
+		if err != nil {
+			return nil, err
+		}
+		ordenes = append(ordenes, *o)
+	}
+	return ordenes, rows.Err()
+}
+
+func (r *OrdenCompraRepository) GetByID(ctx context.Context, id int) (*models.OrdenCompra, error) {
+	return getOrdenCompra(ctx, r.db, id)
+}
+
+// getOrdenCompra lee la orden con sus líneas
+func getOrdenCompra(ctx context.Context, q querier, id int) (*models.OrdenCompra, error) {
+	o, err := scanOrdenCompra(q.QueryRowContext(ctx, ordenCompraSelect+" WHERE o.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT l.producto_id, l.cantidad, l.costo_unitario, l.cantidad_recibida, p.nombre, p.sku
+		FROM orden_compra_lineas l
+		JOIN productos p ON l.producto_id = p.id
+		WHERE l.orden_id = $1
+		ORDER BY p.nombre, p.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l models.OrdenCompraLinea
+		var p models.Producto
+		var sku sql.NullString
+		if err := rows.Scan(&l.ProductoID, &l.Cantidad, &l.CostoUnitario, &l.CantidadRecibida, &p.Nombre, &sku); err != nil {
+			return nil, err
+		}
+		p.ID = l.ProductoID
+		p.SKU = sku.String
+		l.Producto = &p
+		o.Lineas = append(o.Lineas, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	repository.ResolverOrdenCompra(o)
+	return o, nil
+}
+
+// guardarLineas reemplaza las líneas de la orden
+func guardarLineas(ctx context.Context, tx *sql.Tx, id int, lineas []models.OrdenCompraLineaRequest) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM orden_compra_lineas WHERE orden_id = $1", id); err != nil {
+		return err
+	}
+	for _, l := range lineas {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO orden_compra_lineas (orden_id, producto_id, cantidad, costo_unitario)
+			VALUES ($1, $2, $3, $4)
+		`, id, l.ProductoID, l.Cantidad, l.CostoUnitario)
+		if err != nil {
+			return traducirError(err)
+		}
+	}
+	return nil
+}
+
+func (r *OrdenCompraRepository) Create(ctx context.Context, req models.OrdenCompraRequest) (*models.OrdenCompra, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		if almacenID, err = almacenPrincipal(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO ordenes_compra (proveedor_id, almacen_id, notas)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, req.ProveedorID, almacenID, req.Notas).Scan(&id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	if err := guardarLineas(ctx, tx, id, req.Lineas); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// bloquearOrdenCompra bloquea la orden y la devuelve con sus líneas. Si se
+// indican estados, verifica que esté en alguno de ellos.
+func bloquearOrdenCompra(ctx context.Context, tx *sql.Tx, id int, estados ...models.EstadoOrdenCompra) (*models.OrdenCompra, error) {
+	var bloqueada int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM ordenes_compra WHERE id = $1 FOR UPDATE", id).Scan(&bloqueada)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	o, err := getOrdenCompra(ctx, tx, id)
+	if err != nil || len(estados) == 0 {
+		return o, err
+	}
+	for _, e := range estados {
+		if o.Estado == e {
+			return o, nil
+		}
+	}
+	return nil, repository.ErrOrdenCompraEstado
+}
+
+func (r *OrdenCompraRepository) Update(ctx context.Context, id int, req models.OrdenCompraRequest) (*models.OrdenCompra, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	o, err := bloquearOrdenCompra(ctx, tx, id, models.EstadoOrdenBorrador)
+	if err != nil {
+		return nil, err
+	}
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		almacenID = o.AlmacenID
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ordenes_compra
+		SET proveedor_id = $1, almacen_id = $2, notas = $3, updated_at = NOW()
+		WHERE id = $4
+	`, req.ProveedorID, almacenID, req.Notas, id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	if err := guardarLineas(ctx, tx, id, req.Lineas); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// cambiarEstadoOrden guarda el nuevo estado de la orden; recibida y cancelada
+// la cierran
+func cambiarEstadoOrden(ctx context.Context, tx *sql.Tx, id int, estado models.EstadoOrdenCompra) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE ordenes_compra
+		SET estado = $1,
+		    enviada_at = CASE WHEN $1::VARCHAR = 'enviada' THEN NOW() ELSE enviada_at END,
+		    cerrada_at = CASE WHEN $1::VARCHAR IN ('recibida', 'cancelada') THEN NOW() ELSE cerrada_at END,
+		    updated_at = NOW()
+		WHERE id = $2
+	`, estado, id)
+	return err
+}
+
+func (r *OrdenCompraRepository) Enviar(ctx context.Context, id int) (*models.OrdenCompra, error) {
+	return r.cambiarEstado(ctx, id, models.EstadoOrdenEnviada, models.EstadoOrdenBorrador)
+}
+
+func (r *OrdenCompraRepository) Cancelar(ctx context.Context, id int) (*models.OrdenCompra, error) {
+	return r.cambiarEstado(ctx, id, models.EstadoOrdenCancelada,
+		models.EstadoOrdenBorrador, models.EstadoOrdenEnviada, models.EstadoOrdenRecibidaParcial)
+}
+
+// cambiarEstado pasa la orden a estado si está en alguno de desde
+func (r *OrdenCompraRepository) cambiarEstado(ctx context.Context, id int, estado models.EstadoOrdenCompra, desde ...models.EstadoOrdenCompra) (*models.OrdenCompra, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := bloquearOrdenCompra(ctx, tx, id, desde...); err != nil {
+		return nil, err
+	}
+	if err := cambiarEstadoOrden(ctx, tx, id, estado); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *OrdenCompraRepository) Recibir(ctx context.Context, id int, req models.RecepcionRequest) (*models.OrdenCompra, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// El bloqueo de la orden serializa las recepciones, así que lo pendiente
+	// no cambia entre la verificación y el registro
+	o, err := bloquearOrdenCompra(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.ValidarRecepcion(*o, req); err != nil {
+		return nil, err
+	}
+
+	costos := make(map[int]float64, len(o.Lineas))
+	for _, l := range o.Lineas {
+		costos[l.ProductoID] = l.CostoUnitario
+	}
+	for _, item := range req.Items {
+		costo := costos[item.ProductoID]
+		_, err := aplicarMovimiento(ctx, tx, models.MovimientoInventario{
+			ProductoID:    item.ProductoID,
+			AlmacenID:     o.AlmacenID,
+			Tipo:          models.TipoEntrada,
+			Cantidad:      item.Cantidad,
+			Motivo:        repository.MotivoOrdenCompra(id),
+			CostoUnitario: &costo,
+			ProveedorID:   &o.ProveedorID,
+			OrdenCompraID: &id,
+			Lote:          item.Lote,
+			Vencimiento:   item.Vencimiento,
+			Series:        item.Series,
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE orden_compra_lineas SET cantidad_recibida = cantidad_recibida + $1
+			WHERE orden_id = $2 AND producto_id = $3
+		`, item.Cantidad, id, item.ProductoID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	o, err = getOrdenCompra(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := cambiarEstadoOrden(ctx, tx, id, repository.EstadoTrasRecepcion(o.Lineas)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
@@ -0,0 +1,360 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+	"sort"
+)
+
+const ordenVentaSelect = `
+	SELECT o.id, o.cliente, o.almacen_id, o.estado, o.notas, o.created_at, o.updated_at,
+	       o.confirmada_at, o.cerrada_at,
+	       ROUND(COALESCE((SELECT SUM(l.cantidad * l.precio_unitario) FROM orden_venta_lineas l WHERE l.orden_id = o.id), 0), 2)
+	FROM ordenes_venta o
+`
+
+type OrdenVentaRepository struct {
+	db *sql.DB
+}
+
+func NewOrdenVentaRepository(db *sql.DB) *OrdenVentaRepository {
+	return &OrdenVentaRepository{db: db}
+}
+
+func scanOrdenVenta(row scanner) (*models.OrdenVenta, error) {
+	var o models.OrdenVenta
+	var notas sql.NullString
+	var confirmadaAt, cerradaAt sql.NullTime
+	err := row.Scan(&o.ID, &o.Cliente, &o.AlmacenID, &o.Estado, &notas, &o.CreatedAt, &o.UpdatedAt,
+		&confirmadaAt, &cerradaAt, &o.Total)
+	if err != nil {
+		return nil, err
+	}
+	o.Notas = notas.String
+	if confirmadaAt.Valid {
+		o.ConfirmadaAt = &confirmadaAt.Time
+	}
+	if cerradaAt.Valid {
+		o.CerradaAt = &cerradaAt.Time
+	}
+	return &o, nil
+}
+
+func (r *OrdenVentaRepository) List(ctx context.Context, filtro repository.OrdenVentaFiltro) ([]models.OrdenVenta, error) {
+	var where whereBuilder
+	if filtro.Estado != "" {
+		where.add("o.estado = ?", filtro.Estado)
+	}
+	if filtro.Cliente != "" {
+		where.add("o.cliente ILIKE ?", "%"+escapeLike(filtro.Cliente)+"%")
+	}
+
+	rows, err := r.db.QueryContext(ctx, ordenVentaSelect+where.String()+" ORDER BY o.created_at DESC, o.id DESC", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ordenes []models.OrdenVenta
+	for rows.Next() {
+		o, err := scanOrdenVenta(rows)
+		if err != nil {
+			return nil, err
+		}
+		ordenes = append(ordenes, *o)
+	}
+	return ordenes, rows.Err()
+}
+
+func (r *OrdenVentaRepository) GetByID(ctx context.Context, id int) (*models.OrdenVenta, error) {
+	return getOrdenVenta(ctx, r.db, id)
+}
+
+// getOrdenVenta lee la orden con sus líneas
+func getOrdenVenta(ctx context.Context, q querier, id int) (*models.OrdenVenta, error) {
+	o, err := scanOrdenVenta(q.QueryRowContext(ctx, ordenVentaSelect+" WHERE o.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT l.producto_id, l.cantidad, l.precio_unitario, p.nombre, p.sku
+		FROM orden_venta_lineas l
+		JOIN productos p ON l.producto_id = p.id
+		WHERE l.orden_id = $1
+		ORDER BY p.nombre, p.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l models.OrdenVentaLinea
+		var p models.Producto
+		var sku sql.NullString
+		if err := rows.Scan(&l.ProductoID, &l.Cantidad, &l.PrecioUnitario, &p.Nombre, &sku); err != nil {
+			return nil, err
+		}
+		p.ID = l.ProductoID
+		p.SKU = sku.String
+		l.Producto = &p
+		o.Lineas = append(o.Lineas, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	repository.ResolverOrdenVenta(o)
+	return o, nil
+}
+
+// guardarLineasVenta reemplaza las líneas de la orden. Las líneas sin precio
+// toman el precio actual del producto.
+func guardarLineasVenta(ctx context.Context, tx *sql.Tx, id int, lineas []models.OrdenVentaLineaRequest) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM orden_venta_lineas WHERE orden_id = $1", id); err != nil {
+		return err
+	}
+	for _, l := range lineas {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO orden_venta_lineas (orden_id, producto_id, cantidad, precio_unitario)
+			SELECT $1, p.id, $3, COALESCE($4, p.precio)
+			FROM productos p
+			WHERE p.id = $2
+		`, id, l.ProductoID, l.Cantidad, l.PrecioUnitario)
+		if err != nil {
+			return traducirError(err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return repository.ErrProductoNoExiste
+		}
+	}
+	return nil
+}
+
+func (r *OrdenVentaRepository) Create(ctx context.Context, req models.OrdenVentaRequest) (*models.OrdenVenta, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		if almacenID, err = almacenPrincipal(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO ordenes_venta (cliente, almacen_id, notas)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, req.Cliente, almacenID, req.Notas).Scan(&id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	if err := guardarLineasVenta(ctx, tx, id, req.Lineas); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// bloquearOrdenVenta bloquea la orden y la devuelve con sus líneas si está en
+// alguno de los estados indicados
+func bloquearOrdenVenta(ctx context.Context, tx *sql.Tx, id int, estados ...models.EstadoOrdenVenta) (*models.OrdenVenta, error) {
+	var bloqueada int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM ordenes_venta WHERE id = $1 FOR UPDATE", id).Scan(&bloqueada)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	o, err := getOrdenVenta(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range estados {
+		if o.Estado == e {
+			return o, nil
+		}
+	}
+	return nil, repository.ErrOrdenVentaEstado
+}
+
+func (r *OrdenVentaRepository) Update(ctx context.Context, id int, req models.OrdenVentaRequest) (*models.OrdenVenta, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	o, err := bloquearOrdenVenta(ctx, tx, id, models.EstadoVentaBorrador)
+	if err != nil {
+		return nil, err
+	}
+	almacenID := req.AlmacenID
+	if almacenID == 0 {
+		almacenID = o.AlmacenID
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ordenes_venta
+		SET cliente = $1, almacen_id = $2, notas = $3, updated_at = NOW()
+		WHERE id = $4
+	`, req.Cliente, almacenID, req.Notas, id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	if err := guardarLineasVenta(ctx, tx, id, req.Lineas); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// cambiarEstadoOrdenVenta guarda el nuevo estado de la orden; despachada y
+// cancelada la cierran
+func cambiarEstadoOrdenVenta(ctx context.Context, tx *sql.Tx, id int, estado models.EstadoOrdenVenta) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE ordenes_venta
+		SET estado = $1,
+		    confirmada_at = CASE WHEN $1::VARCHAR = 'confirmada' THEN NOW() ELSE confirmada_at END,
+		    cerrada_at = CASE WHEN $1::VARCHAR IN ('despachada', 'cancelada') THEN NOW() ELSE cerrada_at END,
+		    updated_at = NOW()
+		WHERE id = $2
+	`, estado, id)
+	return err
+}
+
+// porProducto ordena las líneas por producto, el orden en que se bloquean
+// sus filas para no provocar interbloqueos entre órdenes concurrentes
+func porProducto(lineas []models.OrdenVentaLinea) []models.OrdenVentaLinea {
+	ordenadas := append([]models.OrdenVentaLinea(nil), lineas...)
+	sort.Slice(ordenadas, func(i, j int) bool { return ordenadas[i].ProductoID < ordenadas[j].ProductoID })
+	return ordenadas
+}
+
+func (r *OrdenVentaRepository) Confirmar(ctx context.Context, id int) (*models.OrdenVenta, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	o, err := bloquearOrdenVenta(ctx, tx, id, models.EstadoVentaBorrador)
+	if err != nil {
+		return nil, err
+	}
+
+	// Con la fila del producto bloqueada, como en aplicarMovimiento, ni las
+	// salidas ni otras confirmaciones cambian lo disponible hasta el commit
+	for _, l := range porProducto(o.Lineas) {
+		var stockAlmacen int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE((SELECT cantidad FROM stock_almacen WHERE producto_id = p.id AND almacen_id = $2), 0)
+			FROM productos p
+			WHERE p.id = $1
+			FOR UPDATE OF p
+		`, l.ProductoID, o.AlmacenID).Scan(&stockAlmacen)
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrProductoNoExiste
+		}
+		if err != nil {
+			return nil, err
+		}
+		reservado, err := reservadoAlmacen(ctx, tx, l.ProductoID, o.AlmacenID)
+		if err != nil {
+			return nil, err
+		}
+		if stockAlmacen-reservado < l.Cantidad {
+			return nil, repository.ErrStockInsuficiente
+		}
+	}
+	if err := cambiarEstadoOrdenVenta(ctx, tx, id, models.EstadoVentaConfirmada); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *OrdenVentaRepository) Despachar(ctx context.Context, id int, req models.DespachoRequest) (*models.OrdenVenta, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	o, err := bloquearOrdenVenta(ctx, tx, id, models.EstadoVentaConfirmada)
+	if err != nil {
+		return nil, err
+	}
+	series, err := repository.SeriesDespacho(*o, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cerrar la orden primero libera su reserva, así las salidas pueden
+	// tomar ese stock sin competir con ella
+	if err := cambiarEstadoOrdenVenta(ctx, tx, id, models.EstadoVentaDespachada); err != nil {
+		return nil, err
+	}
+	for _, l := range porProducto(o.Lineas) {
+		_, err := aplicarMovimiento(ctx, tx, models.MovimientoInventario{
+			ProductoID:   l.ProductoID,
+			AlmacenID:    o.AlmacenID,
+			Tipo:         models.TipoSalida,
+			Cantidad:     l.Cantidad,
+			Motivo:       repository.MotivoOrdenVenta(id),
+			OrdenVentaID: &id,
+			Series:       series[l.ProductoID],
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *OrdenVentaRepository) Cancelar(ctx context.Context, id int) (*models.OrdenVenta, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// La reserva se deriva de las órdenes confirmadas, así que cambiar el
+	// estado basta para liberarla
+	if _, err := bloquearOrdenVenta(ctx, tx, id, models.EstadoVentaBorrador, models.EstadoVentaConfirmada); err != nil {
+		return nil, err
+	}
+	if err := cambiarEstadoOrdenVenta(ctx, tx, id, models.EstadoVentaCancelada); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"inventario-backend/internal/repository"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// scanner abstrae *sql.Row y *sql.Rows para reutilizar las funciones de escaneo.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// querier abstrae *sql.DB y *sql.Tx para consultar dentro o fuera de una transacción.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// nullInt convierte un entero opcional de la base de datos en un puntero
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	id := int(v.Int64)
+	return &id
+}
+
+// NewRepositories construye todos los repositorios sobre la misma conexión.
+func NewRepositories(db *sql.DB) repository.Repositories {
+	return repository.Repositories{
+		Productos:    NewProductoRepository(db),
+		Categorias:   NewCategoriaRepository(db),
+		Almacenes:    NewAlmacenRepository(db),
+		Movimientos:  NewMovimientoRepository(db),
+		Traslados:    NewTrasladoRepository(db),
+		Conteos:      NewConteoRepository(db),
+		Alertas:      NewAlertaRepository(db),
+		Proveedores:  NewProveedorRepository(db),
+		Compras:      NewOrdenCompraRepository(db),
+		Ventas:       NewOrdenVentaRepository(db),
+		Devoluciones: NewDevolucionRepository(db),
+		Lotes:        NewLoteRepository(db),
+		Series:       NewSerieRepository(db),
+		Ensamblajes:  NewEnsamblajeRepository(db),
+	}
+}
+
+// Códigos SQLSTATE de PostgreSQL que se traducen a errores de dominio
+const (
+	codigoForeignKeyViolation = "23503"
+	codigoUniqueViolation     = "23505"
+	codigoCheckViolation      = "23514"
+)
+
+// erroresPorRestriccion traduce las restricciones con nombre conocido a
+// errores de dominio
+var erroresPorRestriccion = map[string]error{
+	"categorias_nombre_key":                    repository.ErrCategoriaDuplicada,
+	"productos_precio_check":                   repository.ErrValorNegativo,
+	"productos_stock_check":                    repository.ErrStockInsuficiente,
+	"productos_reorden_check":                  repository.ErrValorInvalido,
+	"productos_categoria_id_fkey":              repository.ErrCategoriaNoExiste,
+	"productos_sku_key":                        repository.ErrSKUDuplicado,
+	"productos_codigo_barras_key":              repository.ErrCodigoBarrasDuplicado,
+	"almacenes_nombre_key":                     repository.ErrAlmacenDuplicado,
+	"stock_almacen_cantidad_check":             repository.ErrStockInsuficiente,
+	"stock_almacen_almacen_id_fkey":            repository.ErrAlmacenNoExiste,
+	"movimientos_inventario_almacen_id_fkey":   repository.ErrAlmacenNoExiste,
+	"movimientos_inventario_producto_id_fkey":  repository.ErrProductoNoExiste,
+	"movimientos_inventario_cantidad_check":    repository.ErrValorInvalido,
+	"movimientos_inventario_revierte_id_key":   repository.ErrMovimientoRevertido,
+	"movimientos_inventario_proveedor_id_fkey": repository.ErrProveedorNoExiste,
+	"movimientos_inventario_proveedor_check":   repository.ErrValorInvalido,
+	"proveedores_nombre_key":                   repository.ErrProveedorDuplicado,
+	"producto_proveedores_producto_id_fkey":    repository.ErrProductoNoExiste,
+	"producto_proveedores_proveedor_id_fkey":   repository.ErrProveedorNoExiste,
+	"traslados_producto_id_fkey":               repository.ErrProductoNoExiste,
+	"traslados_almacen_origen_id_fkey":         repository.ErrAlmacenNoExiste,
+	"traslados_almacen_destino_id_fkey":        repository.ErrAlmacenNoExiste,
+	"traslados_almacenes_distintos":            repository.ErrMismoAlmacen,
+	"ordenes_compra_proveedor_id_fkey":         repository.ErrProveedorNoExiste,
+	"ordenes_compra_almacen_id_fkey":           repository.ErrAlmacenNoExiste,
+	"orden_compra_lineas_producto_id_fkey":     repository.ErrProductoNoExiste,
+	"ordenes_venta_almacen_id_fkey":            repository.ErrAlmacenNoExiste,
+	"orden_venta_lineas_producto_id_fkey":      repository.ErrProductoNoExiste,
+	"movimientos_inventario_orden_venta_check": repository.ErrValorInvalido,
+	"movimientos_inventario_devolucion_check":  repository.ErrValorInvalido,
+	"devolucion_items_producto_id_fkey":        repository.ErrProductoNoExiste,
+	"devolucion_items_cantidad_check":          repository.ErrValorInvalido,
+	"lotes_cantidad_check":                     repository.ErrStockInsuficiente,
+	"lotes_almacen_id_fkey":                    repository.ErrAlmacenNoExiste,
+	"productos_control_check":                  repository.ErrValorInvalido,
+	"series_almacen_id_fkey":                   repository.ErrAlmacenNoExiste,
+	"conteos_almacen_id_fkey":                  repository.ErrAlmacenNoExiste,
+	"conteos_categoria_id_fkey":                repository.ErrCategoriaNoExiste,
+	"productos_variante_key":                   repository.ErrVarianteDuplicada,
+	"productos_variante_check":                 repository.ErrValorInvalido,
+	"productos_producto_padre_id_fkey":         repository.ErrProductoConVariantes,
+	"producto_componentes_componente_id_fkey":  repository.ErrProductoEnKit,
+	"producto_componentes_cantidad_check":      repository.ErrValorInvalido,
+	"producto_componentes_distintos":           repository.ErrComponenteInvalido,
+	"ensamblajes_almacen_id_fkey":              repository.ErrAlmacenNoExiste,
+}
+
+// traducirError convierte las violaciones de restricciones de PostgreSQL en
+// errores de dominio; el resto de errores se devuelven sin cambios
+func traducirError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	if domainErr, ok := erroresPorRestriccion[pqErr.Constraint]; ok {
+		return domainErr
+	}
+	switch pqErr.Code {
+	case codigoUniqueViolation:
+		return repository.ErrDuplicado
+	case codigoForeignKeyViolation:
+		return repository.ErrReferenciaInvalida
+	case codigoCheckViolation:
+		return repository.ErrValorInvalido
+	}
+	return err
+}
+
+// whereBuilder arma cláusulas WHERE con parámetros numerados ($1, $2, ...)
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// add agrega una condición; cada "?" se reemplaza por el siguiente parámetro
+func (b *whereBuilder) add(cond string, args ...interface{}) {
+	for _, arg := range args {
+		b.args = append(b.args, arg)
+		cond = strings.Replace(cond, "?", "$"+strconv.Itoa(len(b.args)), 1)
+	}
+	b.conds = append(b.conds, cond)
+}
+
+// arg registra un parámetro adicional y devuelve su marcador
+func (b *whereBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *whereBuilder) String() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// escapeLike escapa los comodines de LIKE para buscar el texto literal
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,452 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+// productoStock es el stock del producto sumado al de sus variantes, que es
+// el que se muestra, se filtra y se ordena
+const productoStock = `(p.stock + COALESCE((SELECT SUM(v.stock) FROM productos v WHERE v.producto_padre_id = p.id), 0))`
+
+const productoSelect = `
+	SELECT p.id, p.nombre, p.descripcion, p.sku, p.codigo_barras, p.precio, ` + productoStock + `,
+	       p.stock_minimo, p.punto_reorden, p.cantidad_reorden, p.categoria_id,
+	       p.metodo_costeo, p.controla_lotes, p.controla_series,
+	       p.valor_inventario + COALESCE((SELECT SUM(v.valor_inventario) FROM productos v WHERE v.producto_padre_id = p.id), 0),
+	       p.producto_padre_id, p.atributos, p.hereda_precio,
+	       (SELECT COUNT(*) FROM productos v WHERE v.producto_padre_id = p.id),
+	       p.created_at, p.updated_at,
+	       c.id, c.nombre, c.descripcion,
+	       (SELECT COALESCE(SUM(l.cantidad), 0)
+	        FROM orden_venta_lineas l
+	        JOIN ordenes_venta o ON l.orden_id = o.id
+	        JOIN productos lp ON l.producto_id = lp.id
+	        WHERE (lp.id = p.id OR lp.producto_padre_id = p.id) AND o.estado = 'confirmada')
+	FROM productos p
+	LEFT JOIN categorias c ON p.categoria_id = c.id
+`
+
+type ProductoRepository struct {
+	db *sql.DB
+}
+
+func NewProductoRepository(db *sql.DB) *ProductoRepository {
+	return &ProductoRepository{db: db}
+}
+
+func scanProducto(row scanner) (*models.Producto, error) {
+	var p models.Producto
+	var descripcion, sku, codigoBarras sql.NullString
+	var categoriaID, padreID, cID sql.NullInt64
+	var cNombre, cDescripcion sql.NullString
+	var atributos []byte
+	err := row.Scan(&p.ID, &p.Nombre, &descripcion, &sku, &codigoBarras, &p.Precio, &p.Stock,
+		&p.StockMinimo, &p.PuntoReorden, &p.CantidadReorden, &categoriaID,
+		&p.MetodoCosteo, &p.ControlaLotes, &p.ControlaSeries, &p.ValorInventario,
+		&padreID, &atributos, &p.HeredaPrecio, &p.Variantes, &p.CreatedAt, &p.UpdatedAt,
+		&cID, &cNombre, &cDescripcion, &p.Reservado)
+	if err != nil {
+		return nil, err
+	}
+	if atributos != nil {
+		if err := json.Unmarshal(atributos, &p.Atributos); err != nil {
+			return nil, err
+		}
+	}
+	p.ProductoPadreID = nullInt(padreID)
+	p.Disponible = p.Stock - p.Reservado
+	p.Descripcion = descripcion.String
+	p.SKU = sku.String
+	p.CodigoBarras = codigoBarras.String
+	p.CategoriaID = int(categoriaID.Int64)
+	// La categoría puede ser NULL si fue eliminada (ON DELETE SET NULL)
+	if cID.Valid {
+		p.Categoria = &models.Categoria{
+			ID:          int(cID.Int64),
+			Nombre:      cNombre.String,
+			Descripcion: cDescripcion.String,
+		}
+	}
+	return &p, nil
+}
+
+func (r *ProductoRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.Producto, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var productos []models.Producto
+	for rows.Next() {
+		p, err := scanProducto(rows)
+		if err != nil {
+			return nil, err
+		}
+		productos = append(productos, *p)
+	}
+	return productos, rows.Err()
+}
+
+// productoSortColumns traduce los campos de ordenamiento a columnas
+var productoSortColumns = map[string]string{
+	"nombre":     "p.nombre",
+	"precio":     "p.precio",
+	"stock":      productoStock,
+	"created_at": "p.created_at",
+}
+
+func (r *ProductoRepository) List(ctx context.Context, filtro repository.ProductoFiltro) ([]models.Producto, int, error) {
+	var where whereBuilder
+	if filtro.CategoriaID != nil {
+		where.add("p.categoria_id = ?", *filtro.CategoriaID)
+	}
+	if filtro.MinPrecio != nil {
+		where.add("p.precio >= ?", *filtro.MinPrecio)
+	}
+	if filtro.MaxPrecio != nil {
+		where.add("p.precio <= ?", *filtro.MaxPrecio)
+	}
+	// Los padres se filtran por el stock de sus variantes
+	if filtro.MinStock != nil {
+		where.add(productoStock+" >= ?", *filtro.MinStock)
+	}
+	if filtro.MaxStock != nil {
+		where.add(productoStock+" <= ?", *filtro.MaxStock)
+	}
+	if filtro.BajoStock {
+		where.add("((p.stock_minimo > 0 AND " + productoStock + " < p.stock_minimo) OR (p.punto_reorden > 0 AND " + productoStock + " <= p.punto_reorden))")
+	}
+	if filtro.Q != "" {
+		q := "%" + escapeLike(filtro.Q) + "%"
+		where.add("(p.nombre ILIKE ? OR p.descripcion ILIKE ?)", q, q)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM productos p"+where.String(), where.args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := productoSortColumns[filtro.Sort]
+	if !ok {
+		column = productoSortColumns["nombre"]
+	}
+	direction := "ASC"
+	if filtro.Desc {
+		direction = "DESC"
+	}
+	// El ID desempata para que la paginación sea estable
+	query := productoSelect + where.String() + " ORDER BY " + column + " " + direction + ", p.id " + direction
+	if filtro.Limit > 0 {
+		page := filtro.Page
+		if page < 1 {
+			page = 1
+		}
+		query += " LIMIT " + where.arg(filtro.Limit) + " OFFSET " + where.arg((page-1)*filtro.Limit)
+	}
+
+	productos, err := r.query(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := r.cargarStockAlmacenes(ctx, productos); err != nil {
+		return nil, 0, err
+	}
+	if err := r.cargarComponentes(ctx, productos); err != nil {
+		return nil, 0, err
+	}
+	return productos, total, nil
+}
+
+// cargarStockAlmacenes completa el desglose por almacén con una sola consulta
+func (r *ProductoRepository) cargarStockAlmacenes(ctx context.Context, productos []models.Producto) error {
+	ids := make([]int, len(productos))
+	for i, p := range productos {
+		ids[i] = p.ID
+	}
+	stock, err := stockPorAlmacen(ctx, r.db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range productos {
+		productos[i].StockAlmacenes = stock[productos[i].ID]
+	}
+	return nil
+}
+
+// getBy devuelve el único producto que cumple la condición, con su desglose
+// de stock por almacén y, si es un kit, sus componentes
+func (r *ProductoRepository) getBy(ctx context.Context, cond string, arg interface{}) (*models.Producto, error) {
+	p, err := scanProducto(r.db.QueryRowContext(ctx, productoSelect+" WHERE "+cond, arg))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	productos := []models.Producto{*p}
+	if err := r.cargarStockAlmacenes(ctx, productos); err != nil {
+		return nil, err
+	}
+	if err := r.cargarComponentes(ctx, productos); err != nil {
+		return nil, err
+	}
+	return &productos[0], nil
+}
+
+func (r *ProductoRepository) GetByID(ctx context.Context, id int) (*models.Producto, error) {
+	return r.getBy(ctx, "p.id = $1", id)
+}
+
+func (r *ProductoRepository) GetBySKU(ctx context.Context, sku string) (*models.Producto, error) {
+	return r.getBy(ctx, "p.sku = $1", sku)
+}
+
+func (r *ProductoRepository) GetByCodigoBarras(ctx context.Context, codigo string) (*models.Producto, error) {
+	return r.getBy(ctx, "p.codigo_barras = $1", codigo)
+}
+
+// categoriaExiste verifica que la categoría referenciada exista
+func (r *ProductoRepository) categoriaExiste(ctx context.Context, categoriaID int) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM categorias WHERE id = $1)
+	`, categoriaID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return repository.ErrCategoriaNoExiste
+	}
+	return nil
+}
+
+func (r *ProductoRepository) Create(ctx context.Context, req models.ProductoRequest) (*models.Producto, error) {
+	if err := r.categoriaExiste(ctx, req.CategoriaID); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := crearProducto(ctx, tx, req, models.Producto{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// crearProducto inserta el producto de req con los datos de variante de base
+// y registra su stock inicial
+func crearProducto(ctx context.Context, tx *sql.Tx, req models.ProductoRequest, base models.Producto) (int, error) {
+	var atributos []byte
+	if base.Atributos != nil {
+		var err error
+		if atributos, err = json.Marshal(base.Atributos); err != nil {
+			return 0, err
+		}
+	}
+
+	metodo := req.MetodoCosteo
+	if metodo == "" {
+		metodo = models.MetodoPromedio
+	}
+
+	var id int
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO productos (nombre, descripcion, sku, codigo_barras, precio, stock, categoria_id, metodo_costeo,
+		                       controla_lotes, controla_series, stock_minimo, punto_reorden, cantidad_reorden,
+		                       producto_padre_id, atributos, hereda_precio)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, 0, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
+	`, req.Nombre, req.Descripcion, req.SKU, req.CodigoBarras, req.Precio, req.CategoriaID, metodo,
+		req.ControlaLotes != nil && *req.ControlaLotes, req.ControlaSeries != nil && *req.ControlaSeries,
+		req.StockMinimo, req.PuntoReorden, req.CantidadReorden,
+		base.ProductoPadreID, atributos, base.HeredaPrecio).Scan(&id)
+	if err != nil {
+		return 0, traducirError(err)
+	}
+
+	// El stock inicial queda en el almacén principal como un ajuste
+	if req.Stock != 0 {
+		_, err := aplicarMovimiento(ctx, tx, models.MovimientoInventario{
+			ProductoID:    id,
+			Tipo:          models.TipoAjuste,
+			Cantidad:      req.Stock,
+			CodigoMotivo:  models.MotivoInicial,
+			Motivo:        "Stock inicial",
+			CostoUnitario: req.CostoUnitario,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+func (r *ProductoRepository) Update(ctx context.Context, id int, req models.ProductoRequest) (*models.Producto, error) {
+	if err := r.categoriaExiste(ctx, req.CategoriaID); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var stockActual int
+	var metodo models.MetodoCosteo
+	var controlaLotes, controlaSeries bool
+	var padreID sql.NullInt64
+	var enKit bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT stock, metodo_costeo, controla_lotes, controla_series, producto_padre_id,
+		       EXISTS(SELECT 1 FROM producto_componentes c
+		              WHERE c.producto_id = p.id
+		                 OR c.componente_id IN (SELECT v.id FROM productos v WHERE v.id = p.id OR v.producto_padre_id = p.id))
+		FROM productos p WHERE id = $1 FOR UPDATE
+	`, id).Scan(&stockActual, &metodo, &controlaLotes, &controlaSeries, &padreID, &enKit)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Una variante usa el control de su padre, que lo cambia para todas
+	actual := models.Producto{MetodoCosteo: metodo, ControlaLotes: controlaLotes, ControlaSeries: controlaSeries}
+	if padreID.Valid && repository.CambiaControl(actual, req) {
+		return nil, repository.ErrControlVariante
+	}
+	// El stock de las variantes cuenta como el del padre; se bloquean para
+	// que ningún movimiento les dé stock mientras cambia su control
+	var stockVariantes int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(stock), 0)
+		FROM (SELECT stock FROM productos WHERE producto_padre_id = $1 FOR UPDATE) v
+	`, id).Scan(&stockVariantes)
+	if err != nil {
+		return nil, err
+	}
+	stockActual += stockVariantes
+
+	// Cambiar de método con stock dejaría capas valoradas con el anterior
+	if req.MetodoCosteo != "" && req.MetodoCosteo != metodo {
+		if stockActual != 0 {
+			return nil, repository.ErrMetodoCosteoConStock
+		}
+		metodo = req.MetodoCosteo
+	}
+	// Los lotes deben cubrir todo el stock del producto
+	if req.ControlaLotes != nil && *req.ControlaLotes != controlaLotes {
+		if stockActual != 0 {
+			return nil, repository.ErrLotesConStock
+		}
+		controlaLotes = *req.ControlaLotes
+	}
+	// Igual con las series, que deben cubrir cada unidad
+	if req.ControlaSeries != nil && *req.ControlaSeries != controlaSeries {
+		if stockActual != 0 {
+			return nil, repository.ErrSeriesConStock
+		}
+		controlaSeries = *req.ControlaSeries
+	}
+	// Ensamblar no indica qué lotes o series mueve
+	if enKit && ((controlaLotes && !actual.ControlaLotes) || (controlaSeries && !actual.ControlaSeries)) {
+		return nil, repository.ErrControlEnKit
+	}
+
+	// Una variante hereda el precio mientras coincida con el de su padre
+	_, err = tx.ExecContext(ctx, `
+		UPDATE productos
+		SET nombre = $1, descripcion = $2,
+		    sku = CASE WHEN $3::text IS NULL THEN sku ELSE NULLIF($3, '') END,
+		    codigo_barras = CASE WHEN $4::text IS NULL THEN codigo_barras ELSE NULLIF($4, '') END,
+		    precio = $5, categoria_id = $6, metodo_costeo = $7, controla_lotes = $8, controla_series = $9,
+		    stock_minimo = $10, punto_reorden = $11, cantidad_reorden = $12,
+		    hereda_precio = COALESCE((SELECT pp.precio = $5 FROM productos pp WHERE pp.id = productos.producto_padre_id), FALSE),
+		    updated_at = NOW()
+		WHERE id = $13
+	`, req.Nombre, req.Descripcion, req.SKU, req.CodigoBarras, req.Precio, req.CategoriaID, metodo, controlaLotes,
+		controlaSeries, req.StockMinimo, req.PuntoReorden, req.CantidadReorden, id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	if !padreID.Valid {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE productos SET precio = $1, updated_at = NOW()
+			WHERE producto_padre_id = $2 AND hereda_precio
+		`, req.Precio, id)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE productos SET metodo_costeo = $1, controla_lotes = $2, controla_series = $3, updated_at = NOW()
+			WHERE producto_padre_id = $4
+			  AND (metodo_costeo <> $1 OR controla_lotes <> $2 OR controla_series <> $3)
+		`, metodo, controlaLotes, controlaSeries, id)
+		if err != nil {
+			return nil, traducirError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *ProductoRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM productos WHERE id = $1", id)
+	if err != nil {
+		return traducirError(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ProductoRepository) Valoracion(ctx context.Context, categoriaID *int) (*models.Valoracion, error) {
+	var where whereBuilder
+	where.add("p.stock <> 0")
+	if categoriaID != nil {
+		where.add("p.categoria_id = ?", *categoriaID)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.id, COALESCE(c.nombre, 'Sin categoría'), p.id, p.nombre, p.metodo_costeo, p.stock, p.valor_inventario
+		FROM productos p
+		LEFT JOIN categorias c ON p.categoria_id = c.id
+	`+where.String()+" ORDER BY c.nombre NULLS LAST, c.id, p.nombre, p.id", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	v := &models.Valoracion{Categorias: []models.ValoracionCategoria{}}
+	for rows.Next() {
+		var cID sql.NullInt64
+		var categoria string
+		var p models.ValoracionProducto
+		err := rows.Scan(&cID, &categoria, &p.ProductoID, &p.Producto, &p.MetodoCosteo, &p.Stock, &p.Valor)
+		if err != nil {
+			return nil, err
+		}
+		repository.AgregarValoracion(v, nullInt(cID), categoria, p)
+	}
+	return v, rows.Err()
+}
@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+const proveedorSelect = `
+	SELECT id, nombre, contacto, email, telefono, direccion, notas, created_at, updated_at
+	FROM proveedores
+`
+
+const productoProveedorSelect = `
+	SELECT pp.producto_id, p.nombre, pp.proveedor_id, pr.nombre, pp.sku_proveedor, pp.plazo_entrega_dias,
+	       pp.ultimo_costo, pp.ultima_entrada_at, pp.created_at, pp.updated_at
+	FROM producto_proveedores pp
+	JOIN productos p ON pp.producto_id = p.id
+	JOIN proveedores pr ON pp.proveedor_id = pr.id
+`
+
+type ProveedorRepository struct {
+	db *sql.DB
+}
+
+func NewProveedorRepository(db *sql.DB) *ProveedorRepository {
+	return &ProveedorRepository{db: db}
+}
+
+func scanProveedor(row scanner) (*models.Proveedor, error) {
+	var p models.Proveedor
+	var contacto, email, telefono, direccion, notas sql.NullString
+	err := row.Scan(&p.ID, &p.Nombre, &contacto, &email, &telefono, &direccion, &notas, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.Contacto = contacto.String
+	p.Email = email.String
+	p.Telefono = telefono.String
+	p.Direccion = direccion.String
+	p.Notas = notas.String
+	return &p, nil
+}
+
+func scanProductoProveedor(row scanner) (*models.ProductoProveedor, error) {
+	var pp models.ProductoProveedor
+	var sku sql.NullString
+	var ultimoCosto sql.NullFloat64
+	var ultimaEntrada sql.NullTime
+	err := row.Scan(&pp.ProductoID, &pp.Producto, &pp.ProveedorID, &pp.Proveedor, &sku, &pp.PlazoEntregaDias,
+		&ultimoCosto, &ultimaEntrada, &pp.CreatedAt, &pp.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	pp.SKUProveedor = sku.String
+	if ultimoCosto.Valid {
+		pp.UltimoCosto = &ultimoCosto.Float64
+	}
+	if ultimaEntrada.Valid {
+		pp.UltimaEntradaAt = &ultimaEntrada.Time
+	}
+	return &pp, nil
+}
+
+func (r *ProveedorRepository) List(ctx context.Context) ([]models.Proveedor, error) {
+	rows, err := r.db.QueryContext(ctx, proveedorSelect+" ORDER BY nombre")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var proveedores []models.Proveedor
+	for rows.Next() {
+		p, err := scanProveedor(rows)
+		if err != nil {
+			return nil, err
+		}
+		proveedores = append(proveedores, *p)
+	}
+	return proveedores, rows.Err()
+}
+
+func (r *ProveedorRepository) GetByID(ctx context.Context, id int) (*models.Proveedor, error) {
+	p, err := scanProveedor(r.db.QueryRowContext(ctx, proveedorSelect+" WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return p, err
+}
+
+func (r *ProveedorRepository) Create(ctx context.Context, req models.ProveedorRequest) (*models.Proveedor, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO proveedores (nombre, contacto, email, telefono, direccion, notas)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, req.Nombre, req.Contacto, req.Email, req.Telefono, req.Direccion, req.Notas).Scan(&id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *ProveedorRepository) Update(ctx context.Context, id int, req models.ProveedorRequest) (*models.Proveedor, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE proveedores
+		SET nombre = $1, contacto = $2, email = $3, telefono = $4, direccion = $5, notas = $6, updated_at = NOW()
+		WHERE id = $7
+	`, req.Nombre, req.Contacto, req.Email, req.Telefono, req.Direccion, req.Notas, id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *ProveedorRepository) Delete(ctx context.Context, id int) error {
+	var enUso bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM movimientos_inventario m WHERE m.proveedor_id = pr.id)
+		       OR EXISTS(SELECT 1 FROM ordenes_compra o WHERE o.proveedor_id = pr.id)
+		FROM proveedores pr
+		WHERE pr.id = $1
+	`, id).Scan(&enUso)
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if enUso {
+		return repository.ErrProveedorEnUso
+	}
+
+	// Los vínculos con productos se eliminan en cascada
+	_, err = r.db.ExecContext(ctx, "DELETE FROM proveedores WHERE id = $1", id)
+	return traducirError(err)
+}
+
+// productosProveedores devuelve los vínculos que cumplen cond, o ErrNotFound
+// si no existe la fila de tabla con el ID indicado
+func (r *ProveedorRepository) productosProveedores(ctx context.Context, tabla, cond, orden string, id int) ([]models.ProductoProveedor, error) {
+	var existe bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+tabla+" WHERE id = $1)", id).Scan(&existe)
+	if err != nil {
+		return nil, err
+	}
+	if !existe {
+		return nil, repository.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, productoProveedorSelect+" WHERE "+cond+" = $1 ORDER BY "+orden, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vinculos []models.ProductoProveedor
+	for rows.Next() {
+		pp, err := scanProductoProveedor(rows)
+		if err != nil {
+			return nil, err
+		}
+		vinculos = append(vinculos, *pp)
+	}
+	return vinculos, rows.Err()
+}
+
+func (r *ProveedorRepository) Productos(ctx context.Context, proveedorID int) ([]models.ProductoProveedor, error) {
+	return r.productosProveedores(ctx, "proveedores", "pp.proveedor_id", "p.nombre, p.id", proveedorID)
+}
+
+func (r *ProveedorRepository) ProveedoresDeProducto(ctx context.Context, productoID int) ([]models.ProductoProveedor, error) {
+	return r.productosProveedores(ctx, "productos", "pp.producto_id", "pr.nombre, pr.id", productoID)
+}
+
+func (r *ProveedorRepository) Vincular(ctx context.Context, proveedorID, productoID int, req models.ProductoProveedorRequest) (*models.ProductoProveedor, error) {
+	// ultima_entrada_at solo la actualizan las entradas
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO producto_proveedores (producto_id, proveedor_id, sku_proveedor, plazo_entrega_dias, ultimo_costo)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		ON CONFLICT (producto_id, proveedor_id) DO UPDATE
+		SET sku_proveedor = EXCLUDED.sku_proveedor,
+		    plazo_entrega_dias = EXCLUDED.plazo_entrega_dias,
+		    ultimo_costo = EXCLUDED.ultimo_costo,
+		    updated_at = NOW()
+	`, productoID, proveedorID, req.SKUProveedor, req.PlazoEntregaDias, req.UltimoCosto)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	pp, err := scanProductoProveedor(r.db.QueryRowContext(ctx,
+		productoProveedorSelect+" WHERE pp.producto_id = $1 AND pp.proveedor_id = $2", productoID, proveedorID))
+	if err == sql.ErrNoRows {
+		// Eliminado entre ambas consultas
+		return nil, repository.ErrNotFound
+	}
+	return pp, err
+}
+
+func (r *ProveedorRepository) Desvincular(ctx context.Context, proveedorID, productoID int) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM producto_proveedores WHERE producto_id = $1 AND proveedor_id = $2
+	`, productoID, proveedorID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// registrarEntradaProveedor crea o actualiza el vínculo entre el proveedor y
+// el producto de la entrada m, con su costo en moneda local si lo trae
+func registrarEntradaProveedor(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario) error {
+	var costo *float64
+	if m.CostoUnitario != nil {
+		c := repository.CostoLocal(m)
+		costo = &c
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO producto_proveedores (producto_id, proveedor_id, ultimo_costo, ultima_entrada_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (producto_id, proveedor_id) DO UPDATE
+		SET ultimo_costo = COALESCE(EXCLUDED.ultimo_costo, producto_proveedores.ultimo_costo),
+		    ultima_entrada_at = EXCLUDED.ultima_entrada_at,
+		    updated_at = NOW()
+	`, m.ProductoID, *m.ProveedorID, costo)
+	return traducirError(err)
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const serieSelect = `
+	SELECT s.id, s.producto_id, p.nombre, p.sku, s.numero, s.almacen_id, a.nombre, a.principal,
+	       s.created_at, s.updated_at
+	FROM series s
+	JOIN productos p ON s.producto_id = p.id
+	LEFT JOIN almacenes a ON s.almacen_id = a.id
+`
+
+type SerieRepository struct {
+	db *sql.DB
+}
+
+func NewSerieRepository(db *sql.DB) *SerieRepository {
+	return &SerieRepository{db: db}
+}
+
+func scanSerie(row scanner) (*models.Serie, error) {
+	var s models.Serie
+	var p models.Producto
+	var sku, almacen sql.NullString
+	var almacenID sql.NullInt64
+	var principal sql.NullBool
+	err := row.Scan(&s.ID, &s.ProductoID, &p.Nombre, &sku, &s.Numero, &almacenID, &almacen, &principal,
+		&s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = s.ProductoID
+	p.SKU = sku.String
+	s.Producto = &p
+	s.AlmacenID = nullInt(almacenID)
+	s.EnStock = s.AlmacenID != nil
+	if s.AlmacenID != nil {
+		s.Almacen = &models.Almacen{ID: *s.AlmacenID, Nombre: almacen.String, Principal: principal.Bool}
+	}
+	return &s, nil
+}
+
+func (r *SerieRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.Serie, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []models.Serie
+	for rows.Next() {
+		s, err := scanSerie(rows)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, *s)
+	}
+	return series, rows.Err()
+}
+
+// seriesMovimientos devuelve las series que movió cada uno de los
+// movimientos indicados, ordenadas por número
+func seriesMovimientos(ctx context.Context, q querier, movimientoIDs []int) (map[int][]string, error) {
+	series := make(map[int][]string)
+	if len(movimientoIDs) == 0 {
+		return series, nil
+	}
+
+	ids := make([]int64, len(movimientoIDs))
+	for i, id := range movimientoIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT ms.movimiento_id, s.numero
+		FROM movimiento_series ms
+		JOIN series s ON ms.serie_id = s.id
+		WHERE ms.movimiento_id = ANY($1)
+		ORDER BY s.numero
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movimientoID int
+		var numero string
+		if err := rows.Scan(&movimientoID, &numero); err != nil {
+			return nil, err
+		}
+		series[movimientoID] = append(series[movimientoID], numero)
+	}
+	return series, rows.Err()
+}
+
+// planSeries decide qué series mueve m, que cambia el stock en delta, y
+// verifica que las que entran no estén ya en stock y las que salen estén en
+// el almacén. La fila del producto debe estar bloqueada por la transacción,
+// lo que también protege sus series.
+func planSeries(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, controlaSeries bool, delta int) ([]string, error) {
+	var origen []string
+	var hayOrigen bool
+	if controlaSeries {
+		id, err := origenUnidades(ctx, tx, m)
+		if err != nil {
+			return nil, err
+		}
+		if id != 0 {
+			series, err := seriesMovimientos(ctx, tx, []int{id})
+			if err != nil {
+				return nil, err
+			}
+			origen, hayOrigen = series[id], true
+		}
+	}
+	series, err := repository.PlanSeries(m, controlaSeries, delta, origen, hayOrigen)
+	if err != nil || len(series) == 0 {
+		return series, err
+	}
+
+	var enStock, enAlmacen int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE almacen_id IS NOT NULL), COUNT(*) FILTER (WHERE almacen_id = $3)
+		FROM series
+		WHERE producto_id = $1 AND numero = ANY($2)
+	`, m.ProductoID, pq.Array(series), m.AlmacenID).Scan(&enStock, &enAlmacen)
+	if err != nil {
+		return nil, err
+	}
+	if delta > 0 && enStock > 0 {
+		return nil, repository.ErrSerieDuplicada
+	}
+	if delta < 0 && enAlmacen != len(series) {
+		return nil, repository.ErrSerieNoDisponible
+	}
+	return series, nil
+}
+
+// aplicarSeries ubica en el almacén de m las series que entran, creando las
+// nuevas, saca del stock las que salen y registra las que movió m, ya creado
+func aplicarSeries(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario, delta int, series []string) error {
+	if len(series) == 0 {
+		return nil
+	}
+	var err error
+	if delta > 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO series (producto_id, numero, almacen_id)
+			SELECT $1, unnest($2::text[]), $3
+			ON CONFLICT (producto_id, numero) DO UPDATE SET almacen_id = EXCLUDED.almacen_id, updated_at = NOW()
+		`, m.ProductoID, pq.Array(series), m.AlmacenID)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE series SET almacen_id = NULL, updated_at = NOW()
+			WHERE producto_id = $1 AND numero = ANY($2)
+		`, m.ProductoID, pq.Array(series))
+	}
+	if err != nil {
+		return traducirError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO movimiento_series (movimiento_id, serie_id)
+		SELECT $1, id FROM series WHERE producto_id = $2 AND numero = ANY($3)
+	`, m.ID, m.ProductoID, pq.Array(series))
+	return traducirError(err)
+}
+
+// List devuelve las series ordenadas por producto y número
+func (r *SerieRepository) List(ctx context.Context, filtro repository.SerieFiltro) ([]models.Serie, error) {
+	var where whereBuilder
+	if filtro.ProductoID != nil {
+		where.add("s.producto_id = ?", *filtro.ProductoID)
+	}
+	if filtro.AlmacenID != nil {
+		where.add("s.almacen_id = ?", *filtro.AlmacenID)
+	}
+	if filtro.EnStock {
+		where.add("s.almacen_id IS NOT NULL")
+	}
+	return r.query(ctx, serieSelect+where.String()+" ORDER BY s.producto_id, s.numero", where.args...)
+}
+
+func (r *SerieRepository) Rastrear(ctx context.Context, numero string) ([]models.Serie, error) {
+	series, err := r.query(ctx, serieSelect+" WHERE s.numero = $1 ORDER BY s.producto_id", numero)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	// Del más antiguo al más reciente, como se lee una historia
+	movimientos := &MovimientoRepository{db: r.db}
+	for i := range series {
+		series[i].Movimientos, err = movimientos.query(ctx, movimientoSelect+`
+			WHERE EXISTS(SELECT 1 FROM movimiento_series ms WHERE ms.movimiento_id = m.id AND ms.serie_id = $1)
+			ORDER BY m.created_at, m.id
+		`, series[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return series, nil
+}
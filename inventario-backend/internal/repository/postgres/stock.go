@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// almacenPrincipal devuelve el ID del almacén principal
+func almacenPrincipal(ctx context.Context, q querier) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx, "SELECT id FROM almacenes WHERE principal").Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, repository.ErrAlmacenNoExiste
+	}
+	return id, err
+}
+
+// aplicarMovimiento registra el movimiento dentro de tx y actualiza el stock
+// del producto en el almacén y su total. Si m.AlmacenID es 0 se usa el
+// almacén principal. Devuelve el ID del movimiento creado.
+func aplicarMovimiento(ctx context.Context, tx *sql.Tx, m models.MovimientoInventario) (int, error) {
+	almacenID := m.AlmacenID
+	if almacenID == 0 {
+		var err error
+		if almacenID, err = almacenPrincipal(ctx, tx); err != nil {
+			return 0, err
+		}
+	}
+	m.AlmacenID = almacenID
+
+	// Bloquear la fila del producto para que la verificación de stock y la
+	// actualización ocurran de forma atómica frente a peticiones concurrentes
+	var stockTotal int
+	var metodo models.MetodoCosteo
+	var valor float64
+	var controlaLotes, controlaSeries, conVariantes bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT stock, metodo_costeo, valor_inventario, controla_lotes, controla_series,
+		       EXISTS(SELECT 1 FROM productos v WHERE v.producto_padre_id = p.id)
+		FROM productos p
+		WHERE id = $1 FOR UPDATE
+	`, m.ProductoID).Scan(&stockTotal, &metodo, &valor, &controlaLotes, &controlaSeries, &conVariantes)
+	if err == sql.ErrNoRows {
+		return 0, repository.ErrProductoNoExiste
+	}
+	if err != nil {
+		return 0, err
+	}
+	if conVariantes {
+		return 0, repository.ErrProductoConVariantes
+	}
+
+	var existe bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM almacenes WHERE id = $1)
+	`, almacenID).Scan(&existe)
+	if err != nil {
+		return 0, err
+	}
+	if !existe {
+		return 0, repository.ErrAlmacenNoExiste
+	}
+
+	var stockAlmacen int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT cantidad FROM stock_almacen WHERE producto_id = $1 AND almacen_id = $2), 0)
+	`, m.ProductoID, almacenID).Scan(&stockAlmacen)
+	if err != nil {
+		return 0, err
+	}
+
+	delta := repository.EfectoStock(m)
+	if stockAlmacen+delta < 0 {
+		return 0, repository.ErrStockInsuficiente
+	}
+	// Las salidas no pueden tomar lo reservado por órdenes de venta; los
+	// ajustes registran lo que ya ocurrió y solo no pueden dejar el stock en
+	// negativo
+	if m.Tipo == models.TipoSalida {
+		reservado, err := reservadoAlmacen(ctx, tx, m.ProductoID, almacenID)
+		if err != nil {
+			return 0, err
+		}
+		if stockAlmacen-reservado+delta < 0 {
+			return 0, repository.ErrStockInsuficiente
+		}
+	}
+
+	lotes, err := planLotes(ctx, tx, m, controlaLotes, delta)
+	if err != nil {
+		return 0, err
+	}
+	series, err := planSeries(ctx, tx, m, controlaSeries, delta)
+	if err != nil {
+		return 0, err
+	}
+
+	// Valorar el movimiento en moneda local
+	var costoTotal, costoEntradaUnitario float64
+	var origen, sinCapa int
+	var plan []consumo
+	if delta > 0 {
+		if costoEntradaUnitario, origen, err = costoEntrada(ctx, tx, m, stockTotal, valor); err != nil {
+			return 0, err
+		}
+		costoTotal = float64(delta) * costoEntradaUnitario
+	} else {
+		if plan, sinCapa, err = planConsumo(ctx, tx, m, -delta); err != nil {
+			return 0, err
+		}
+		costoTotal, err = costoSalida(ctx, tx, m, metodo, -delta, stockTotal, valor, plan, sinCapa)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Crear el movimiento
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO movimientos_inventario
+			(producto_id, almacen_id, tipo, cantidad, codigo_motivo, motivo, costo_unitario, moneda, tipo_cambio,
+			 costo_total, proveedor_id, traslado_id, orden_compra_id, orden_venta_id, devolucion_id, ensamblaje_id,
+			 revierte_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id
+	`, m.ProductoID, almacenID, m.Tipo, m.Cantidad, m.CodigoMotivo, m.Motivo, m.CostoUnitario, m.Moneda, m.TipoCambio,
+		costoTotal, m.ProveedorID, m.TrasladoID, m.OrdenCompraID, m.OrdenVentaID, m.DevolucionID, m.EnsamblajeID,
+		m.RevierteID).Scan(&m.ID)
+	if err != nil {
+		return 0, traducirError(err)
+	}
+	if err := aplicarLotes(ctx, tx, m, delta, lotes); err != nil {
+		return 0, err
+	}
+	if err := aplicarSeries(ctx, tx, m, delta, series); err != nil {
+		return 0, err
+	}
+	if m.ProveedorID != nil {
+		if err := registrarEntradaProveedor(ctx, tx, m); err != nil {
+			return 0, err
+		}
+	}
+
+	if delta > 0 {
+		err = sumarCapas(ctx, tx, m, delta, costoEntradaUnitario, origen)
+	} else {
+		err = consumirCapas(ctx, tx, m.ID, plan)
+		costoTotal = -costoTotal
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := sumarStock(ctx, tx, m.ProductoID, almacenID, delta, costoTotal); err != nil {
+		return 0, err
+	}
+	return m.ID, nil
+}
+
+// reservadoAlmacen devuelve lo reservado del producto en el almacén por las
+// órdenes de venta confirmadas
+func reservadoAlmacen(ctx context.Context, q querier, productoID, almacenID int) (int, error) {
+	var reservado int
+	err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(l.cantidad), 0)
+		FROM orden_venta_lineas l
+		JOIN ordenes_venta o ON l.orden_id = o.id
+		WHERE l.producto_id = $1 AND o.almacen_id = $2 AND o.estado = 'confirmada'
+	`, productoID, almacenID).Scan(&reservado)
+	return reservado, err
+}
+
+// sumarStock suma delta al stock del producto en el almacén y a su total, y
+// valor a su valor de inventario. La fila del producto debe estar bloqueada
+// por la transacción.
+func sumarStock(ctx context.Context, tx *sql.Tx, productoID, almacenID, delta int, valor float64) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO stock_almacen (producto_id, almacen_id, cantidad)
+		VALUES ($1, $2, 0)
+		ON CONFLICT (producto_id, almacen_id) DO NOTHING
+	`, productoID, almacenID)
+	if err != nil {
+		return traducirError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE stock_almacen
+		SET cantidad = cantidad + $1
+		WHERE producto_id = $2 AND almacen_id = $3
+	`, delta, productoID, almacenID)
+	if err != nil {
+		return traducirError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE productos
+		SET stock = stock + $1,
+		    -- Sin existencias no queda valor, aunque los redondeos dejen restos
+		    valor_inventario = CASE WHEN stock + $1 = 0 THEN 0 ELSE valor_inventario + $2 END,
+		    updated_at = NOW()
+		WHERE id = $3
+	`, delta, valor, productoID)
+	return traducirError(err)
+}
+
+// stockPorAlmacen devuelve el desglose de stock de los productos indicados,
+// sumado al de sus variantes y sin los almacenes en cero
+func stockPorAlmacen(ctx context.Context, q querier, productoIDs []int) (map[int][]models.StockAlmacen, error) {
+	stock := make(map[int][]models.StockAlmacen)
+	if len(productoIDs) == 0 {
+		return stock, nil
+	}
+
+	ids := make([]int64, len(productoIDs))
+	for i, id := range productoIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT x.id, s.almacen_id, a.nombre, SUM(s.cantidad)
+		FROM unnest($1::bigint[]) AS x(id)
+		JOIN productos p ON p.id = x.id OR p.producto_padre_id = x.id
+		JOIN stock_almacen s ON s.producto_id = p.id
+		JOIN almacenes a ON s.almacen_id = a.id
+		GROUP BY x.id, s.almacen_id, a.nombre, a.principal
+		HAVING SUM(s.cantidad) > 0
+		ORDER BY a.principal DESC, a.nombre
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productoID int
+		var s models.StockAlmacen
+		if err := rows.Scan(&productoID, &s.AlmacenID, &s.Almacen, &s.Cantidad); err != nil {
+			return nil, err
+		}
+		stock[productoID] = append(stock[productoID], s)
+	}
+	return stock, rows.Err()
+}
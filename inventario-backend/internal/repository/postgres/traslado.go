@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+const trasladoSelect = `
+	SELECT t.id, t.producto_id, t.almacen_origen_id, t.almacen_destino_id, t.cantidad, t.estado,
+	       t.motivo, t.created_at, t.recibido_at,
+	       p.id, p.nombre, p.descripcion, p.precio, p.stock
+	FROM traslados t
+	JOIN productos p ON t.producto_id = p.id
+`
+
+type TrasladoRepository struct {
+	db *sql.DB
+}
+
+func NewTrasladoRepository(db *sql.DB) *TrasladoRepository {
+	return &TrasladoRepository{db: db}
+}
+
+func scanTraslado(row scanner) (*models.Traslado, error) {
+	var t models.Traslado
+	var p models.Producto
+	var motivo, descripcion sql.NullString
+	var recibidoAt sql.NullTime
+	err := row.Scan(&t.ID, &t.ProductoID, &t.AlmacenOrigenID, &t.AlmacenDestinoID, &t.Cantidad, &t.Estado,
+		&motivo, &t.CreatedAt, &recibidoAt,
+		&p.ID, &p.Nombre, &descripcion, &p.Precio, &p.Stock)
+	if err != nil {
+		return nil, err
+	}
+	t.Motivo = motivo.String
+	if recibidoAt.Valid {
+		t.RecibidoAt = &recibidoAt.Time
+	}
+	p.Descripcion = descripcion.String
+	t.Producto = &p
+	return &t, nil
+}
+
+func (r *TrasladoRepository) List(ctx context.Context, filtro repository.TrasladoFiltro) ([]models.Traslado, error) {
+	var where whereBuilder
+	if filtro.Estado != "" {
+		where.add("t.estado = ?", filtro.Estado)
+	}
+	if filtro.ProductoID != nil {
+		where.add("t.producto_id = ?", *filtro.ProductoID)
+	}
+	if filtro.AlmacenID != nil {
+		where.add("(t.almacen_origen_id = ? OR t.almacen_destino_id = ?)", *filtro.AlmacenID, *filtro.AlmacenID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, trasladoSelect+where.String()+" ORDER BY t.created_at DESC, t.id DESC", where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traslados []models.Traslado
+	for rows.Next() {
+		t, err := scanTraslado(rows)
+		if err != nil {
+			return nil, err
+		}
+		traslados = append(traslados, *t)
+	}
+	return traslados, rows.Err()
+}
+
+func (r *TrasladoRepository) GetByID(ctx context.Context, id int) (*models.Traslado, error) {
+	t, err := scanTraslado(r.db.QueryRowContext(ctx, trasladoSelect+" WHERE t.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, movimientoSelect+" WHERE m.traslado_id = $1 ORDER BY m.id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanMovimiento(rows)
+		if err != nil {
+			return nil, err
+		}
+		t.Movimientos = append(t.Movimientos, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := cargarUnidades(ctx, r.db, t.Movimientos); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// motivoTraslado es el motivo de los movimientos de un traslado
+func motivoTraslado(id int, motivo string) string {
+	if motivo == "" {
+		return fmt.Sprintf("Traslado #%d", id)
+	}
+	return fmt.Sprintf("Traslado #%d: %s", id, motivo)
+}
+
+func (r *TrasladoRepository) Create(ctx context.Context, req models.TrasladoRequest) (*models.Traslado, error) {
+	if req.AlmacenOrigenID == req.AlmacenDestinoID {
+		return nil, repository.ErrMismoAlmacen
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO traslados (producto_id, almacen_origen_id, almacen_destino_id, cantidad, motivo)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, req.ProductoID, req.AlmacenOrigenID, req.AlmacenDestinoID, req.Cantidad, req.Motivo).Scan(&id)
+	if err != nil {
+		return nil, traducirError(err)
+	}
+
+	_, err = aplicarMovimiento(ctx, tx, models.MovimientoInventario{
+		ProductoID: req.ProductoID,
+		AlmacenID:  req.AlmacenOrigenID,
+		Tipo:       models.TipoSalida,
+		Cantidad:   req.Cantidad,
+		Motivo:     motivoTraslado(id, req.Motivo),
+		TrasladoID: &id,
+		Series:     req.Series,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.EnTransito {
+		if err := recibirTraslado(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *TrasladoRepository) Recibir(ctx context.Context, id int) (*models.Traslado, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := recibirTraslado(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// recibirTraslado registra la entrada en el almacén de destino y marca el
+// traslado como recibido. La fila del traslado se bloquea para que no se
+// reciba dos veces.
+func recibirTraslado(ctx context.Context, tx *sql.Tx, id int) error {
+	var t models.Traslado
+	var motivo sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT producto_id, almacen_destino_id, cantidad, estado, motivo
+		FROM traslados
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&t.ProductoID, &t.AlmacenDestinoID, &t.Cantidad, &t.Estado, &motivo)
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if t.Estado == models.EstadoRecibido {
+		return repository.ErrTrasladoRecibido
+	}
+
+	_, err = aplicarMovimiento(ctx, tx, models.MovimientoInventario{
+		ProductoID: t.ProductoID,
+		AlmacenID:  t.AlmacenDestinoID,
+		Tipo:       models.TipoEntrada,
+		Cantidad:   t.Cantidad,
+		Motivo:     motivoTraslado(id, motivo.String),
+		TrasladoID: &id,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE traslados SET estado = $1, recibido_at = NOW() WHERE id = $2
+	`, models.EstadoRecibido, id)
+	return err
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"inventario-backend/internal/models"
+	"inventario-backend/internal/repository"
+)
+
+func (r *ProductoRepository) Variantes(ctx context.Context, id int) ([]models.Producto, error) {
+	var existe bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM productos WHERE id = $1)", id).Scan(&existe)
+	if err != nil {
+		return nil, err
+	}
+	if !existe {
+		return nil, repository.ErrNotFound
+	}
+
+	variantes, err := r.query(ctx, productoSelect+" WHERE p.producto_padre_id = $1 ORDER BY p.nombre, p.id", id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.cargarStockAlmacenes(ctx, variantes); err != nil {
+		return nil, err
+	}
+	if err := r.cargarComponentes(ctx, variantes); err != nil {
+		return nil, err
+	}
+	return variantes, nil
+}
+
+func (r *ProductoRepository) CreateVariante(ctx context.Context, padreID int, req models.VarianteRequest) (*models.Producto, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Bloquear al padre para que ningún movimiento le dé stock mientras
+	// se crea la variante
+	var padre models.Producto
+	var descripcion sql.NullString
+	var categoriaID, abueloID sql.NullInt64
+	var enKit bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, nombre, descripcion, precio, stock, categoria_id, metodo_costeo,
+		       controla_lotes, controla_series, producto_padre_id,
+		       EXISTS(SELECT 1 FROM producto_componentes c WHERE c.producto_id = p.id OR c.componente_id = p.id)
+		FROM productos p WHERE id = $1 FOR UPDATE
+	`, padreID).Scan(&padre.ID, &padre.Nombre, &descripcion, &padre.Precio, &padre.Stock, &categoriaID,
+		&padre.MetodoCosteo, &padre.ControlaLotes, &padre.ControlaSeries, &abueloID, &enKit)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if abueloID.Valid {
+		return nil, repository.ErrVarianteInvalida
+	}
+	// Los kits y sus componentes mueven stock propio
+	if enKit {
+		return nil, repository.ErrComponenteInvalido
+	}
+	// El stock del padre pasa a ser la suma del de sus variantes
+	if padre.Stock != 0 {
+		return nil, repository.ErrPadreConStock
+	}
+	padre.Descripcion = descripcion.String
+	padre.CategoriaID = int(categoriaID.Int64)
+
+	pr := repository.RequestVariante(padre, req)
+	id, err := crearProducto(ctx, tx, pr, models.Producto{
+		ProductoPadreID: &padre.ID,
+		Atributos:       req.Atributos,
+		HeredaPrecio:    pr.Precio == padre.Precio,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
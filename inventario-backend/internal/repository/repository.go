@@ -0,0 +1,586 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"inventario-backend/internal/models"
+	"time"
+)
+
+// Errores de dominio que devuelven las implementaciones de los repositorios.
+// Los handlers los traducen a la respuesta HTTP correspondiente.
+var (
+	ErrNotFound              = errors.New("registro no encontrado")
+	ErrProductoNoExiste      = errors.New("el producto especificado no existe")
+	ErrCategoriaNoExiste     = errors.New("la categoría especificada no existe")
+	ErrCategoriaDuplicada    = errors.New("ya existe una categoría con ese nombre")
+	ErrCategoriaConProductos = errors.New("la categoría tiene productos asociados")
+	ErrStockInsuficiente     = errors.New("stock insuficiente")
+	ErrMetodoCosteoConStock  = errors.New("el método de costeo solo se puede cambiar sin stock")
+	ErrValorNegativo         = errors.New("el precio y el stock no pueden ser negativos")
+	ErrAlmacenNoExiste       = errors.New("el almacén especificado no existe")
+	ErrAlmacenDuplicado      = errors.New("ya existe un almacén con ese nombre")
+	ErrAlmacenEnUso          = errors.New("el almacén es el principal o tiene stock, movimientos, traslados, conteos u órdenes")
+	ErrMismoAlmacen          = errors.New("el almacén de origen y el de destino deben ser distintos")
+	ErrTrasladoRecibido      = errors.New("el traslado ya fue recibido")
+	ErrMovimientoRevertido   = errors.New("el movimiento ya fue revertido")
+	ErrReversionNoPermitida  = errors.New("el movimiento es una reversión, parte de un traslado, de una orden, de una devolución o de un ensamblaje, o una salida con devoluciones")
+	ErrConteoCerrado         = errors.New("el conteo ya fue aprobado o cancelado")
+	ErrProductoFueraDeConteo = errors.New("el producto no forma parte del conteo")
+	ErrAlertaResuelta        = errors.New("la alerta ya fue resuelta")
+	ErrProveedorNoExiste     = errors.New("el proveedor especificado no existe")
+	ErrProveedorDuplicado    = errors.New("ya existe un proveedor con ese nombre")
+	ErrProveedorEnUso        = errors.New("el proveedor tiene entradas u órdenes de compra registradas")
+	ErrOrdenCompraEstado     = errors.New("la orden de compra no admite la operación en su estado actual")
+	ErrProductoFueraDeOrden  = errors.New("el producto no forma parte de la orden de compra")
+	ErrRecepcionExcedida     = errors.New("la cantidad recibida supera la pendiente de la orden de compra")
+	ErrOrdenVentaEstado      = errors.New("la orden de venta no admite la operación en su estado actual")
+	ErrOrigenDevolucion      = errors.New("la venta de origen no existe, no admite devoluciones o no incluye el producto")
+	ErrDevolucionExcedida    = errors.New("la cantidad devuelta supera la vendida")
+	ErrItemResuelto          = errors.New("el item de devolución ya no está en cuarentena")
+	ErrLoteInvalido          = errors.New("el lote no corresponde al movimiento o al producto")
+	ErrLoteNoExiste          = errors.New("el lote no existe en el almacén")
+	ErrLotesConStock         = errors.New("el control de lotes solo se puede cambiar sin stock")
+	ErrSerieInvalida         = errors.New("las series no corresponden a la cantidad, al movimiento o al producto")
+	ErrSerieDuplicada        = errors.New("la serie ya está en stock")
+	ErrSerieNoDisponible     = errors.New("la serie no está en el almacén")
+	ErrSeriesConStock        = errors.New("el control de series solo se puede cambiar sin stock")
+	ErrVarianteInvalida      = errors.New("una variante no puede tener variantes")
+	ErrVarianteDuplicada     = errors.New("el producto ya tiene una variante con esos atributos")
+	ErrPadreConStock         = errors.New("un producto con stock propio no puede tener variantes")
+	ErrControlVariante       = errors.New("el método de costeo y el control de lotes y series de una variante son los de su padre")
+	ErrProductoConVariantes  = errors.New("el producto tiene variantes; el stock se mueve en cada una")
+	ErrComponenteInvalido    = errors.New("un kit no puede incluirse a sí mismo, ni ser componente de otro kit, ni usar productos con variantes")
+	ErrComponentesConStock   = errors.New("los componentes de un kit solo se pueden cambiar sin stock")
+	ErrControlEnKit          = errors.New("los kits y sus componentes no pueden controlar lotes ni series")
+	ErrProductoEnKit         = errors.New("el producto es componente de un kit")
+	ErrNoEsKit               = errors.New("el producto no tiene componentes")
+	ErrSKUDuplicado          = errors.New("ya existe un producto con ese SKU")
+	ErrCodigoBarrasDuplicado = errors.New("ya existe un producto con ese código de barras")
+
+	// Violaciones de restricciones sin un error de dominio más específico
+	ErrDuplicado          = errors.New("ya existe un registro con esos datos")
+	ErrReferenciaInvalida = errors.New("el registro referenciado no existe o está en uso")
+	ErrValorInvalido      = errors.New("un valor no cumple las restricciones")
+)
+
+// ProductoSortFields son los campos por los que se puede ordenar el listado
+// de productos
+var ProductoSortFields = []string{"nombre", "precio", "stock", "created_at"}
+
+// ProductoFiltro restringe, ordena y pagina el listado de productos. Los
+// punteros nil y las cadenas vacías no filtran.
+type ProductoFiltro struct {
+	CategoriaID *int
+	MinPrecio   *float64
+	MaxPrecio   *float64
+	MinStock    *int
+	MaxStock    *int
+	// BajoStock deja solo los productos que cruzaron un umbral de reposición
+	// (ver models.NivelStock)
+	BajoStock bool
+	// Q busca el texto en el nombre o la descripción, sin distinguir mayúsculas
+	Q string
+
+	Sort string // uno de ProductoSortFields; por defecto "nombre"
+	Desc bool
+
+	Page  int // empieza en 1
+	Limit int // 0 devuelve todos los resultados
+}
+
+type ProductoRepository interface {
+	// List devuelve la página solicitada y el total de productos que
+	// cumplen el filtro
+	List(ctx context.Context, filtro ProductoFiltro) ([]models.Producto, int, error)
+	GetByID(ctx context.Context, id int) (*models.Producto, error)
+	// GetBySKU y GetByCodigoBarras devuelven ErrNotFound si ningún producto
+	// tiene ese identificador
+	GetBySKU(ctx context.Context, sku string) (*models.Producto, error)
+	GetByCodigoBarras(ctx context.Context, codigo string) (*models.Producto, error)
+	// Create registra el stock inicial como un ajuste en el almacén principal
+	Create(ctx context.Context, req models.ProductoRequest) (*models.Producto, error)
+	// Update ignora req.Stock, que solo cambia mediante movimientos, y
+	// devuelve ErrMetodoCosteoConStock, ErrLotesConStock o ErrSeriesConStock
+	// si cambia el método de costeo, el control de lotes o el de series de un
+	// producto con stock. Un producto no puede controlar
+	// lotes y series a la vez (ErrValorInvalido). El precio de un padre pasa
+	// a las variantes que lo heredan, y una variante hereda el precio del
+	// padre mientras tenga el mismo. El método de costeo y el control de
+	// lotes y series de un padre pasan a todas sus variantes, cuyo stock
+	// cuenta como el suyo; en una variante no cambian (ErrControlVariante).
+	// Los kits y sus componentes no pueden activar el control de lotes ni
+	// el de series (ErrControlEnKit).
+	Update(ctx context.Context, id int, req models.ProductoRequest) (*models.Producto, error)
+	// Delete devuelve ErrProductoConVariantes si el producto tiene variantes
+	// y ErrProductoEnKit si es componente de un kit
+	Delete(ctx context.Context, id int) error
+	// Variantes devuelve las variantes del producto por nombre, o ErrNotFound
+	// si el producto no existe
+	Variantes(ctx context.Context, id int) ([]models.Producto, error)
+	// CreateVariante crea una variante del producto padreID, que no puede ser
+	// una variante (ErrVarianteInvalida), un kit o un componente
+	// (ErrComponenteInvalido) ni tener stock propio (ErrPadreConStock). Los
+	// atributos no pueden repetir los de otra variante del mismo padre
+	// (ErrVarianteDuplicada).
+	CreateVariante(ctx context.Context, padreID int, req models.VarianteRequest) (*models.Producto, error)
+	// SetComponentes reemplaza la lista de materiales del kit id. Los
+	// componentes deben existir (ErrProductoNoExiste) y no pueden ser el kit,
+	// otro kit ni un producto con variantes, ni el kit tener variantes o ser
+	// componente (ErrComponenteInvalido). Ni el kit ni sus componentes pueden
+	// controlar lotes o series (ErrControlEnKit). Devuelve
+	// ErrComponentesConStock si el kit tiene stock.
+	SetComponentes(ctx context.Context, id int, componentes []models.ComponenteRequest) (*models.Producto, error)
+	// Valoracion devuelve el valor del inventario por categoría y producto,
+	// solo de la categoría indicada si categoriaID no es nil
+	Valoracion(ctx context.Context, categoriaID *int) (*models.Valoracion, error)
+}
+
+type CategoriaRepository interface {
+	List(ctx context.Context) ([]models.Categoria, error)
+	GetByID(ctx context.Context, id int) (*models.Categoria, error)
+	Create(ctx context.Context, req models.CategoriaRequest) (*models.Categoria, error)
+	Update(ctx context.Context, id int, req models.CategoriaRequest) (*models.Categoria, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// MovimientoCursor identifica el último movimiento de una página. Los
+// movimientos se ordenan por (created_at, id) descendente y la siguiente
+// página empieza justo después del cursor.
+type MovimientoCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// MovimientoFiltro restringe y pagina el historial de movimientos. Los
+// punteros nil y las cadenas vacías no filtran.
+type MovimientoFiltro struct {
+	Tipo         models.TipoMovimiento
+	CodigoMotivo models.CodigoMotivo
+	ProductoID   *int
+	CategoriaID  *int
+	AlmacenID    *int
+	ProveedorID  *int
+	// OrdenCompraID deja solo las entradas registradas al recibir la orden
+	OrdenCompraID *int
+	// OrdenVentaID deja solo las salidas registradas al despachar la orden
+	OrdenVentaID *int
+	// DevolucionID deja solo los ajustes que repusieron la devolución
+	DevolucionID *int
+	// LoteID deja solo los movimientos que tocaron el lote
+	LoteID *int
+	// Serie deja solo los movimientos de la unidad con ese número de serie
+	Serie string
+	Desde *time.Time // inclusive
+	Hasta *time.Time // exclusive
+	// Q busca el texto en el motivo, sin distinguir mayúsculas
+	Q string
+
+	After *MovimientoCursor
+	Limit int // 0 devuelve todos los resultados
+}
+
+// SiguientePagina recorta movimientos (obtenidos con limit+1 resultados) a
+// limit elementos y devuelve el cursor de la página siguiente, si existe.
+func SiguientePagina(movimientos []models.MovimientoInventario, limit int) ([]models.MovimientoInventario, *MovimientoCursor) {
+	if limit <= 0 || len(movimientos) <= limit {
+		return movimientos, nil
+	}
+	movimientos = movimientos[:limit]
+	ultimo := movimientos[limit-1]
+	return movimientos, &MovimientoCursor{CreatedAt: ultimo.CreatedAt, ID: ultimo.ID}
+}
+
+// Reversion construye el movimiento que compensa a m: una entrada se
+// revierte con una salida, una salida con una entrada y un ajuste con otro
+// de signo contrario.
+func Reversion(m models.MovimientoInventario, motivo string) models.MovimientoInventario {
+	r := models.MovimientoInventario{
+		ProductoID:   m.ProductoID,
+		AlmacenID:    m.AlmacenID,
+		Tipo:         m.Tipo,
+		Cantidad:     m.Cantidad,
+		CodigoMotivo: m.CodigoMotivo,
+		Motivo:       fmt.Sprintf("Reversión del movimiento #%d", m.ID),
+		RevierteID:   &m.ID,
+	}
+	switch m.Tipo {
+	case models.TipoEntrada:
+		r.Tipo = models.TipoSalida
+	case models.TipoSalida:
+		r.Tipo = models.TipoEntrada
+	case models.TipoAjuste:
+		r.Cantidad = -m.Cantidad
+	}
+	if motivo != "" {
+		r.Motivo += ": " + motivo
+	}
+	return r
+}
+
+// CostoLocal devuelve el costo unitario del movimiento convertido a moneda
+// local con su tipo de cambio. m.CostoUnitario no debe ser nil.
+func CostoLocal(m models.MovimientoInventario) float64 {
+	costo := *m.CostoUnitario
+	if m.TipoCambio != nil {
+		costo *= *m.TipoCambio
+	}
+	return costo
+}
+
+// EfectoStock devuelve cuánto cambia el stock del almacén con el movimiento:
+// las entradas suman, las salidas restan y los ajustes aplican su signo.
+func EfectoStock(m models.MovimientoInventario) int {
+	if m.Tipo == models.TipoSalida {
+		return -m.Cantidad
+	}
+	return m.Cantidad
+}
+
+type AlmacenRepository interface {
+	List(ctx context.Context) ([]models.Almacen, error)
+	GetByID(ctx context.Context, id int) (*models.Almacen, error)
+	Create(ctx context.Context, req models.AlmacenRequest) (*models.Almacen, error)
+	Update(ctx context.Context, id int, req models.AlmacenRequest) (*models.Almacen, error)
+	// Delete devuelve ErrAlmacenEnUso si es el principal o tiene stock,
+	// movimientos, traslados, conteos u órdenes de compra o de venta
+	Delete(ctx context.Context, id int) error
+}
+
+type ProveedorRepository interface {
+	List(ctx context.Context) ([]models.Proveedor, error)
+	GetByID(ctx context.Context, id int) (*models.Proveedor, error)
+	Create(ctx context.Context, req models.ProveedorRequest) (*models.Proveedor, error)
+	Update(ctx context.Context, id int, req models.ProveedorRequest) (*models.Proveedor, error)
+	// Delete elimina también sus vínculos con productos. Devuelve
+	// ErrProveedorEnUso si tiene entradas registradas.
+	Delete(ctx context.Context, id int) error
+
+	// Productos devuelve los productos que suministra el proveedor, o
+	// ErrNotFound si no existe
+	Productos(ctx context.Context, proveedorID int) ([]models.ProductoProveedor, error)
+	// ProveedoresDeProducto devuelve los proveedores del producto, o
+	// ErrNotFound si no existe
+	ProveedoresDeProducto(ctx context.Context, productoID int) ([]models.ProductoProveedor, error)
+	// Vincular crea o reemplaza el vínculo entre el proveedor y el producto
+	Vincular(ctx context.Context, proveedorID, productoID int, req models.ProductoProveedorRequest) (*models.ProductoProveedor, error)
+	Desvincular(ctx context.Context, proveedorID, productoID int) error
+}
+
+type MovimientoRepository interface {
+	// List devuelve la página solicitada y el cursor de la siguiente, o nil
+	// si no hay más resultados
+	List(ctx context.Context, filtro MovimientoFiltro) ([]models.MovimientoInventario, *MovimientoCursor, error)
+	GetByID(ctx context.Context, id int) (*models.MovimientoInventario, error)
+	// Create registra el movimiento y actualiza el stock del producto en el
+	// almacén indicado (o el principal) de forma atómica. Devuelve
+	// ErrStockInsuficiente si un ajuste negativo dejaría en negativo el
+	// stock de ese almacén o si una salida supera lo disponible en él, sin
+	// lo reservado por órdenes de venta confirmadas.
+	Create(ctx context.Context, req models.MovimientoInventarioRequest) (*models.MovimientoInventario, error)
+	// Revertir registra un movimiento con el efecto contrario al indicado y
+	// devuelve el nuevo movimiento. Devuelve ErrMovimientoRevertido si ya se
+	// revirtió, ErrReversionNoPermitida si es una reversión o parte de un
+	// traslado y ErrStockInsuficiente si el stock del almacén quedaría en
+	// negativo.
+	Revertir(ctx context.Context, id int, motivo string) (*models.MovimientoInventario, error)
+	// StockAl reconstruye el stock del producto sumando sus movimientos
+	// anteriores a hasta. Devuelve ErrNotFound si el producto no existe.
+	StockAl(ctx context.Context, productoID int, hasta time.Time) (*models.StockFecha, error)
+	// Conciliar devuelve los productos cuyo stock guardado, total o de algún
+	// almacén, no coincide con la suma de sus movimientos
+	Conciliar(ctx context.Context) ([]models.Conciliacion, error)
+	// Kardex devuelve la tarjeta de existencias valorada del producto.
+	// Devuelve ErrNotFound si el producto no existe.
+	Kardex(ctx context.Context, productoID int, filtro KardexFiltro) (*models.Kardex, error)
+}
+
+// TrasladoFiltro restringe el listado de traslados. Los punteros nil y las
+// cadenas vacías no filtran.
+type TrasladoFiltro struct {
+	Estado     models.EstadoTraslado
+	ProductoID *int
+	// AlmacenID filtra los traslados con origen o destino en el almacén
+	AlmacenID *int
+}
+
+type TrasladoRepository interface {
+	List(ctx context.Context, filtro TrasladoFiltro) ([]models.Traslado, error)
+	// GetByID devuelve el traslado con sus movimientos
+	GetByID(ctx context.Context, id int) (*models.Traslado, error)
+	// Create registra la salida del almacén de origen y, si no queda en
+	// tránsito, la entrada en el destino, todo en una misma transacción
+	Create(ctx context.Context, req models.TrasladoRequest) (*models.Traslado, error)
+	// Recibir registra la entrada de un traslado en tránsito. Devuelve
+	// ErrTrasladoRecibido si ya fue recibido.
+	Recibir(ctx context.Context, id int) (*models.Traslado, error)
+}
+
+// ConteoFiltro restringe el listado de conteos. Los punteros nil y las
+// cadenas vacías no filtran.
+type ConteoFiltro struct {
+	Estado    models.EstadoConteo
+	AlmacenID *int
+}
+
+type ConteoRepository interface {
+	// List devuelve los conteos sin sus items
+	List(ctx context.Context, filtro ConteoFiltro) ([]models.Conteo, error)
+	// GetByID devuelve el conteo con sus items y registros
+	GetByID(ctx context.Context, id int) (*models.Conteo, error)
+	// Create abre una sesión y guarda el stock esperado de cada producto del
+	// almacén (o de la categoría)
+	Create(ctx context.Context, req models.ConteoRequest) (*models.Conteo, error)
+	// Registrar guarda las cantidades contadas. Devuelve ErrConteoCerrado si
+	// la sesión no está abierta y ErrProductoFueraDeConteo si algún producto
+	// no forma parte de ella.
+	Registrar(ctx context.Context, id int, req models.ConteoRegistroRequest) (*models.Conteo, error)
+	// Aprobar registra en una transacción un ajuste por cada producto contado
+	// con diferencia y cierra la sesión
+	Aprobar(ctx context.Context, id int) (*models.Conteo, error)
+	Cancelar(ctx context.Context, id int) (*models.Conteo, error)
+}
+
+// OrdenCompraFiltro restringe el listado de órdenes de compra. Los punteros
+// nil y las cadenas vacías no filtran.
+type OrdenCompraFiltro struct {
+	Estado      models.EstadoOrdenCompra
+	ProveedorID *int
+}
+
+type OrdenCompraRepository interface {
+	// List devuelve las órdenes sin sus líneas
+	List(ctx context.Context, filtro OrdenCompraFiltro) ([]models.OrdenCompra, error)
+	GetByID(ctx context.Context, id int) (*models.OrdenCompra, error)
+	// Create guarda la orden en borrador
+	Create(ctx context.Context, req models.OrdenCompraRequest) (*models.OrdenCompra, error)
+	// Update reemplaza los datos y las líneas de una orden en borrador.
+	// Devuelve ErrOrdenCompraEstado en cualquier otro estado.
+	Update(ctx context.Context, id int, req models.OrdenCompraRequest) (*models.OrdenCompra, error)
+	// Enviar pasa la orden de borrador a enviada
+	Enviar(ctx context.Context, id int) (*models.OrdenCompra, error)
+	// Recibir registra en una transacción una entrada por producto recibido,
+	// con el proveedor y el costo de la orden (ver ValidarRecepcion)
+	Recibir(ctx context.Context, id int, req models.RecepcionRequest) (*models.OrdenCompra, error)
+	// Cancelar cierra una orden que no se recibió por completo; lo ya
+	// recibido se conserva
+	Cancelar(ctx context.Context, id int) (*models.OrdenCompra, error)
+}
+
+// OrdenVentaFiltro restringe el listado de órdenes de venta. Las cadenas
+// vacías no filtran.
+type OrdenVentaFiltro struct {
+	Estado models.EstadoOrdenVenta
+	// Cliente busca el texto en el nombre del cliente, sin distinguir mayúsculas
+	Cliente string
+}
+
+type OrdenVentaRepository interface {
+	// List devuelve las órdenes sin sus líneas
+	List(ctx context.Context, filtro OrdenVentaFiltro) ([]models.OrdenVenta, error)
+	GetByID(ctx context.Context, id int) (*models.OrdenVenta, error)
+	// Create guarda la orden en borrador
+	Create(ctx context.Context, req models.OrdenVentaRequest) (*models.OrdenVenta, error)
+	// Update reemplaza los datos y las líneas de una orden en borrador.
+	// Devuelve ErrOrdenVentaEstado en cualquier otro estado.
+	Update(ctx context.Context, id int, req models.OrdenVentaRequest) (*models.OrdenVenta, error)
+	// Confirmar reserva el stock de las líneas en el almacén de la orden.
+	// Devuelve ErrStockInsuficiente si alguna supera el disponible.
+	Confirmar(ctx context.Context, id int) (*models.OrdenVenta, error)
+	// Despachar registra en una transacción una salida por línea de una
+	// orden confirmada, consumiendo su reserva. req indica las series de los
+	// productos con control de series.
+	Despachar(ctx context.Context, id int, req models.DespachoRequest) (*models.OrdenVenta, error)
+	// Cancelar cierra una orden en borrador o confirmada y libera su reserva
+	Cancelar(ctx context.Context, id int) (*models.OrdenVenta, error)
+}
+
+// LoteFiltro restringe el listado de lotes. Los punteros nil no filtran.
+type LoteFiltro struct {
+	ProductoID *int
+	AlmacenID  *int
+	// ConStock omite los lotes agotados
+	ConStock bool
+	// VenceHasta deja solo los lotes que vencen ese día o antes, incluidos
+	// los ya vencidos
+	VenceHasta *time.Time
+}
+
+type LoteRepository interface {
+	// List devuelve los lotes en orden FEFO: primero los que vencen antes y
+	// al final los que no tienen vencimiento
+	List(ctx context.Context, filtro LoteFiltro) ([]models.Lote, error)
+}
+
+// SerieFiltro restringe el listado de series. Los punteros nil no filtran.
+type SerieFiltro struct {
+	ProductoID *int
+	AlmacenID  *int
+	// EnStock omite las series que salieron del stock
+	EnStock bool
+}
+
+type SerieRepository interface {
+	// List devuelve las series por producto y número
+	List(ctx context.Context, filtro SerieFiltro) ([]models.Serie, error)
+	// Rastrear devuelve las series con ese número, de cualquier producto,
+	// con sus movimientos. Devuelve ErrNotFound si no hay ninguna.
+	Rastrear(ctx context.Context, numero string) ([]models.Serie, error)
+}
+
+// EnsamblajeFiltro restringe el listado de ensamblajes. Los punteros nil y
+// las cadenas vacías no filtran.
+type EnsamblajeFiltro struct {
+	Tipo       models.TipoEnsamblaje
+	ProductoID *int
+	AlmacenID  *int
+}
+
+type EnsamblajeRepository interface {
+	// List devuelve los ensamblajes sin sus movimientos, del más reciente al
+	// más antiguo
+	List(ctx context.Context, filtro EnsamblajeFiltro) ([]models.Ensamblaje, error)
+	// GetByID devuelve el ensamblaje con sus movimientos
+	GetByID(ctx context.Context, id int) (*models.Ensamblaje, error)
+	// Create registra en una misma transacción las salidas de lo que se
+	// consume y las entradas de lo que se obtiene. Devuelve ErrNoEsKit si el
+	// producto no tiene componentes.
+	Create(ctx context.Context, req models.EnsamblajeRequest) (*models.Ensamblaje, error)
+}
+
+// DevolucionFiltro restringe el listado de devoluciones. Los punteros nil no
+// filtran.
+type DevolucionFiltro struct {
+	OrdenVentaID *int
+	MovimientoID *int
+}
+
+type DevolucionRepository interface {
+	// List devuelve las devoluciones sin sus items, de la más reciente a la
+	// más antigua
+	List(ctx context.Context, filtro DevolucionFiltro) ([]models.Devolucion, error)
+	GetByID(ctx context.Context, id int) (*models.Devolucion, error)
+	// Create registra la devolución y, en la misma transacción, repone al
+	// stock los items reutilizables con un ajuste de motivo devolucion al
+	// costo de la venta original. Devuelve ErrOrigenDevolucion si la venta no
+	// admite la devolución y ErrDevolucionExcedida si se devolvería más de
+	// lo vendido (ver ValidarDevolucion).
+	Create(ctx context.Context, req models.DevolucionRequest) (*models.Devolucion, error)
+	// Cuarentena devuelve los items pendientes de resolver, del más antiguo
+	// al más reciente
+	Cuarentena(ctx context.Context) ([]models.DevolucionItem, error)
+	// Resolver saca de cuarentena el item de la devolución; si pasa a
+	// repuesto registra su ajuste. Devuelve ErrItemResuelto si ya se resolvió.
+	Resolver(ctx context.Context, id, itemID int, estado models.EstadoItemDevolucion) (*models.Devolucion, error)
+}
+
+// AlertaFiltro restringe el listado de alertas. Los punteros nil y las
+// cadenas vacías no filtran.
+type AlertaFiltro struct {
+	Estado     models.EstadoAlerta
+	ProductoID *int
+}
+
+type AlertaRepository interface {
+	// List devuelve las alertas de la más reciente a la más antigua
+	List(ctx context.Context, filtro AlertaFiltro) ([]models.Alerta, error)
+	// Evaluar aplica EvaluarAlerta a los productos indicados, o a todos si no
+	// se indica ninguno, y devuelve las alertas generadas o escaladas
+	Evaluar(ctx context.Context, productoIDs ...int) ([]models.Alerta, error)
+	// Reconocer marca la alerta como vista para que deje de figurar como
+	// activa. Devuelve ErrAlertaResuelta si ya se resolvió.
+	Reconocer(ctx context.Context, id int) (*models.Alerta, error)
+}
+
+// ResolverConteoItem calcula la cantidad contada y la diferencia del item a
+// partir de sus registros: vale la última pasada, sumando lo que encontró
+// cada contador en ella.
+func ResolverConteoItem(item *models.ConteoItem) {
+	item.CantidadContada = nil
+	item.Diferencia = nil
+
+	ultima := ultimaPasada(item.Registros)
+	if ultima == 0 {
+		return
+	}
+
+	contada := 0
+	for _, r := range item.Registros {
+		if r.Pasada == ultima {
+			contada += r.Cantidad
+		}
+	}
+	diferencia := contada - item.StockEsperado
+	item.CantidadContada = &contada
+	item.Diferencia = &diferencia
+}
+
+// ultimaPasada devuelve la pasada más alta de los registros, o 0 si no hay
+func ultimaPasada(registros []models.ConteoRegistro) int {
+	ultima := 0
+	for _, r := range registros {
+		if r.Pasada > ultima {
+			ultima = r.Pasada
+		}
+	}
+	return ultima
+}
+
+// AjusteConteo arma el ajuste que registra la aprobación del conteo para un
+// item con diferencia. El lote, el vencimiento y las series salen de los
+// registros de la última pasada; si los contadores indicaron lotes o
+// vencimientos distintos devuelve ErrLoteInvalido.
+func AjusteConteo(c *models.Conteo, item models.ConteoItem) (models.MovimientoInventario, error) {
+	m := models.MovimientoInventario{
+		ProductoID:   item.ProductoID,
+		AlmacenID:    c.AlmacenID,
+		Tipo:         models.TipoAjuste,
+		Cantidad:     *item.Diferencia,
+		CodigoMotivo: models.MotivoConteo,
+		Motivo:       MotivoConteo(c.ID),
+	}
+	ultima := ultimaPasada(item.Registros)
+	for _, r := range item.Registros {
+		if r.Pasada != ultima {
+			continue
+		}
+		if r.Lote != "" {
+			if m.Lote != "" && m.Lote != r.Lote {
+				return m, ErrLoteInvalido
+			}
+			m.Lote = r.Lote
+		}
+		if r.Vencimiento != nil {
+			if m.Vencimiento != nil && !m.Vencimiento.Equal(r.Vencimiento.Time) {
+				return m, ErrLoteInvalido
+			}
+			m.Vencimiento = r.Vencimiento
+		}
+		m.Series = append(m.Series, r.Series...)
+	}
+	return m, nil
+}
+
+// MotivoConteo es el motivo de los ajustes que registra la aprobación de un conteo
+func MotivoConteo(id int) string {
+	return fmt.Sprintf("Conteo físico #%d", id)
+}
+
+// Repositories agrupa los repositorios que necesita la API.
+type Repositories struct {
+	Productos    ProductoRepository
+	Categorias   CategoriaRepository
+	Almacenes    AlmacenRepository
+	Movimientos  MovimientoRepository
+	Traslados    TrasladoRepository
+	Conteos      ConteoRepository
+	Alertas      AlertaRepository
+	Proveedores  ProveedorRepository
+	Compras      OrdenCompraRepository
+	Ventas       OrdenVentaRepository
+	Devoluciones DevolucionRepository
+	Lotes        LoteRepository
+	Series       SerieRepository
+	Ensamblajes  EnsamblajeRepository
+}
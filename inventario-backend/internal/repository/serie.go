@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"sort"
+)
+
+// ValidarSeries verifica que haya una serie por unidad, sin vacías ni
+// repetidas
+func ValidarSeries(series []string, cantidad int) error {
+	if len(series) != cantidad {
+		return ErrSerieInvalida
+	}
+	vistas := make(map[string]bool, len(series))
+	for _, s := range series {
+		if s == "" || vistas[s] {
+			return ErrSerieInvalida
+		}
+		vistas[s] = true
+	}
+	return nil
+}
+
+// PlanSeries decide qué series mueve m, que cambia el stock en delta.
+// origen son las series del movimiento cuyas unidades vuelven con m (el
+// revertido, la salida del traslado o la venta devuelta), si hayOrigen. Sin
+// series propias, las unidades que vuelven son todas las que salieron; con
+// ellas, deben estar entre las que salieron. Devuelve las series ordenadas.
+func PlanSeries(m models.MovimientoInventario, controlaSeries bool, delta int, origen []string, hayOrigen bool) ([]string, error) {
+	if !controlaSeries {
+		if len(m.Series) > 0 {
+			return nil, ErrSerieInvalida
+		}
+		return nil, nil
+	}
+
+	series := m.Series
+	if len(series) == 0 && hayOrigen {
+		series = origen
+	}
+	if err := ValidarSeries(series, max(delta, -delta)); err != nil {
+		return nil, err
+	}
+	if hayOrigen && !contenidas(series, origen) {
+		return nil, ErrSerieInvalida
+	}
+	series = append([]string(nil), series...)
+	sort.Strings(series)
+	return series, nil
+}
+
+// ValidarSeriesDevolucion verifica las series de un item devuelto. En un
+// producto con control de series debe haber una por unidad, entre las
+// vendidas y sin contar las devueltas antes; en los demás no se admiten.
+func ValidarSeriesDevolucion(series []string, cantidad int, controlaSeries bool, vendidas []string, devueltas map[string]bool) error {
+	if !controlaSeries {
+		if len(series) > 0 {
+			return ErrSerieInvalida
+		}
+		return nil
+	}
+	if err := ValidarSeries(series, cantidad); err != nil {
+		return err
+	}
+	if !contenidas(series, vendidas) {
+		return ErrSerieInvalida
+	}
+	for _, s := range series {
+		if devueltas[s] {
+			return ErrDevolucionExcedida
+		}
+	}
+	return nil
+}
+
+// SeriesDespacho agrupa por producto las series del despacho de la orden.
+// Cada producto puede aparecer una sola vez y debe ser una línea de la orden.
+func SeriesDespacho(o models.OrdenVenta, req models.DespachoRequest) (map[int][]string, error) {
+	enOrden := make(map[int]bool, len(o.Lineas))
+	for _, l := range o.Lineas {
+		enOrden[l.ProductoID] = true
+	}
+	series := make(map[int][]string, len(req.Items))
+	for _, item := range req.Items {
+		if _, repetido := series[item.ProductoID]; repetido || !enOrden[item.ProductoID] {
+			return nil, ErrSerieInvalida
+		}
+		series[item.ProductoID] = item.Series
+	}
+	return series, nil
+}
+
+// contenidas indica si todas las series están en el conjunto
+func contenidas(series, conjunto []string) bool {
+	en := make(map[string]bool, len(conjunto))
+	for _, s := range conjunto {
+		en[s] = true
+	}
+	for _, s := range series {
+		if !en[s] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"inventario-backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestPlanSeries(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		series    []string
+		controla  bool
+		delta     int
+		origen    []string
+		hayOrigen bool
+		want      []string
+		err       error
+	}{
+		{"entrada ordena las series", []string{"B", "A"}, true, 2, nil, false, []string{"A", "B"}, nil},
+		{"falta una serie", []string{"A"}, true, 2, nil, false, nil, ErrSerieInvalida},
+		{"serie repetida", []string{"A", "A"}, true, -2, nil, false, nil, ErrSerieInvalida},
+		{"sin control no admite series", []string{"A"}, false, 1, nil, false, nil, ErrSerieInvalida},
+		{"sin control ni series", nil, false, 3, nil, false, nil, nil},
+		{"vuelve todo el origen", nil, true, 2, []string{"X", "Y"}, true, []string{"X", "Y"}, nil},
+		{"vuelve parte del origen", []string{"Y"}, true, 1, []string{"X", "Y"}, true, []string{"Y"}, nil},
+		{"serie ajena al origen", []string{"Z"}, true, 1, []string{"X", "Y"}, true, nil, ErrSerieInvalida},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			m := models.MovimientoInventario{Series: c.series}
+			got, err := PlanSeries(m, c.controla, c.delta, c.origen, c.hayOrigen)
+			if !errors.Is(err, c.err) || !reflect.DeepEqual(got, c.want) {
+				t.Errorf("PlanSeries = %v, %v; se esperaba %v, %v", got, err, c.want, c.err)
+			}
+		})
+	}
+}
+
+func TestValidarSeriesDevolucion(t *testing.T) {
+	vendidas := []string{"A", "B", "C"}
+	devueltas := map[string]bool{"A": true}
+
+	casos := []struct {
+		nombre   string
+		series   []string
+		cantidad int
+		controla bool
+		err      error
+	}{
+		{"series vendidas", []string{"B", "C"}, 2, true, nil},
+		{"ya devuelta", []string{"A"}, 1, true, ErrDevolucionExcedida},
+		{"no vendida", []string{"D"}, 1, true, ErrSerieInvalida},
+		{"sin series", nil, 1, true, ErrSerieInvalida},
+		{"producto sin control", []string{"B"}, 1, false, ErrSerieInvalida},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			err := ValidarSeriesDevolucion(c.series, c.cantidad, c.controla, vendidas, devueltas)
+			if !errors.Is(err, c.err) {
+				t.Errorf("ValidarSeriesDevolucion = %v, se esperaba %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestSeriesDespacho(t *testing.T) {
+	o := models.OrdenVenta{Lineas: []models.OrdenVentaLinea{{ProductoID: 1}, {ProductoID: 2}}}
+
+	series, err := SeriesDespacho(o, models.DespachoRequest{Items: []models.DespachoItem{{ProductoID: 2, Series: []string{"S1"}}}})
+	if err != nil || !reflect.DeepEqual(series, map[int][]string{2: {"S1"}}) {
+		t.Errorf("SeriesDespacho = %v, %v", series, err)
+	}
+
+	for _, items := range [][]models.DespachoItem{
+		{{ProductoID: 3, Series: []string{"S1"}}},
+		{{ProductoID: 1, Series: []string{"S1"}}, {ProductoID: 1, Series: []string{"S2"}}},
+	} {
+		if _, err := SeriesDespacho(o, models.DespachoRequest{Items: items}); !errors.Is(err, ErrSerieInvalida) {
+			t.Errorf("SeriesDespacho(%v) = %v, se esperaba ErrSerieInvalida", items, err)
+		}
+	}
+}
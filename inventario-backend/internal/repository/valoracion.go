@@ -0,0 +1,34 @@
+package repository
+
+import "inventario-backend/internal/models"
+
+// AgregarValoracion suma el producto a la valoración, en su categoría. Los
+// productos deben llegar agrupados por categoría.
+func AgregarValoracion(v *models.Valoracion, categoriaID *int, categoria string, p models.ValoracionProducto) {
+	if p.Stock != 0 {
+		p.CostoPromedio = redondear(p.Valor/float64(p.Stock), 4)
+	}
+	p.Valor = redondear(p.Valor, 2)
+
+	n := len(v.Categorias)
+	if n == 0 || !mismoID(v.Categorias[n-1].CategoriaID, categoriaID) {
+		v.Categorias = append(v.Categorias, models.ValoracionCategoria{
+			CategoriaID: categoriaID,
+			Categoria:   categoria,
+			Productos:   []models.ValoracionProducto{},
+		})
+		n++
+	}
+	c := &v.Categorias[n-1]
+	c.Stock += p.Stock
+	c.Valor = redondear(c.Valor+p.Valor, 2)
+	c.Productos = append(c.Productos, p)
+	v.Valor = redondear(v.Valor+p.Valor, 2)
+}
+
+func mismoID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"sort"
+	"strings"
+)
+
+// NombreVariante arma el nombre de una variante a partir del de su padre y
+// sus atributos en orden alfabético, p. ej. "Camiseta Básica (color: Rojo,
+// talla: M)"
+func NombreVariante(padre string, atributos map[string]string) string {
+	claves := make([]string, 0, len(atributos))
+	for k := range atributos {
+		claves = append(claves, k)
+	}
+	sort.Strings(claves)
+	partes := make([]string, len(claves))
+	for i, k := range claves {
+		partes[i] = k + ": " + atributos[k]
+	}
+	return padre + " (" + strings.Join(partes, ", ") + ")"
+}
+
+// RequestVariante completa con los datos del padre la petición con que se
+// crea la variante como un producto más
+func RequestVariante(padre models.Producto, req models.VarianteRequest) models.ProductoRequest {
+	precio := padre.Precio
+	if req.Precio != nil {
+		precio = *req.Precio
+	}
+	return models.ProductoRequest{
+		Nombre:          NombreVariante(padre.Nombre, req.Atributos),
+		Descripcion:     padre.Descripcion,
+		SKU:             &req.SKU,
+		CodigoBarras:    &req.CodigoBarras,
+		Precio:          precio,
+		Stock:           req.Stock,
+		CostoUnitario:   req.CostoUnitario,
+		StockMinimo:     req.StockMinimo,
+		PuntoReorden:    req.PuntoReorden,
+		CantidadReorden: req.CantidadReorden,
+		MetodoCosteo:    padre.MetodoCosteo,
+		ControlaLotes:   &padre.ControlaLotes,
+		ControlaSeries:  &padre.ControlaSeries,
+		CategoriaID:     padre.CategoriaID,
+	}
+}
+
+// CambiaControl indica si req cambia el método de costeo o el control de
+// lotes o series de p
+func CambiaControl(p models.Producto, req models.ProductoRequest) bool {
+	return (req.MetodoCosteo != "" && req.MetodoCosteo != p.MetodoCosteo) ||
+		(req.ControlaLotes != nil && *req.ControlaLotes != p.ControlaLotes) ||
+		(req.ControlaSeries != nil && *req.ControlaSeries != p.ControlaSeries)
+}
+
+// MismosAtributos indica si dos variantes tienen los mismos atributos
+func MismosAtributos(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
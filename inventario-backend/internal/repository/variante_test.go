@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"inventario-backend/internal/models"
+	"testing"
+)
+
+func TestNombreVariante(t *testing.T) {
+	got := NombreVariante("Polo", map[string]string{"talla": "M", "color": "Rojo"})
+	if want := "Polo (color: Rojo, talla: M)"; got != want {
+		t.Errorf("NombreVariante = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestRequestVariante(t *testing.T) {
+	padre := models.Producto{Nombre: "Polo", Precio: 20, CategoriaID: 3, MetodoCosteo: models.MetodoFIFO, ControlaLotes: true}
+
+	req := RequestVariante(padre, models.VarianteRequest{Atributos: map[string]string{"talla": "M"}, Stock: 5})
+	if req.Precio != 20 || req.Stock != 5 || req.CategoriaID != 3 || req.MetodoCosteo != models.MetodoFIFO {
+		t.Errorf("RequestVariante = %+v", req)
+	}
+	if req.ControlaLotes == nil || !*req.ControlaLotes || req.ControlaSeries == nil || *req.ControlaSeries {
+		t.Errorf("RequestVariante no copia el control del padre: %+v", req)
+	}
+
+	precio := 25.0
+	if req := RequestVariante(padre, models.VarianteRequest{Precio: &precio}); req.Precio != 25 {
+		t.Errorf("RequestVariante.Precio = %v, se esperaba 25", req.Precio)
+	}
+}
+
+func TestMismosAtributos(t *testing.T) {
+	casos := []struct {
+		a, b map[string]string
+		want bool
+	}{
+		{map[string]string{"talla": "M", "color": "Rojo"}, map[string]string{"color": "Rojo", "talla": "M"}, true},
+		{map[string]string{"talla": "M"}, map[string]string{"talla": "L"}, false},
+		{map[string]string{"talla": "M"}, map[string]string{"talla": "M", "color": "Rojo"}, false},
+		{map[string]string{"talla": ""}, map[string]string{"color": ""}, false},
+	}
+	for _, c := range casos {
+		if got := MismosAtributos(c.a, c.b); got != c.want {
+			t.Errorf("MismosAtributos(%v, %v) = %v, se esperaba %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestCambiaControl(t *testing.T) {
+	p := models.Producto{MetodoCosteo: models.MetodoFIFO, ControlaLotes: true}
+	si, no := true, false
+	casos := []struct {
+		nombre string
+		req    models.ProductoRequest
+		want   bool
+	}{
+		{"sin indicar nada", models.ProductoRequest{}, false},
+		{"los mismos valores", models.ProductoRequest{MetodoCosteo: models.MetodoFIFO, ControlaLotes: &si, ControlaSeries: &no}, false},
+		{"otro método", models.ProductoRequest{MetodoCosteo: models.MetodoPromedio}, true},
+		{"sin lotes", models.ProductoRequest{ControlaLotes: &no}, true},
+		{"con series", models.ProductoRequest{ControlaSeries: &si}, true},
+	}
+	for _, c := range casos {
+		if got := CambiaControl(p, c.req); got != c.want {
+			t.Errorf("%s: CambiaControl = %v, se esperaba %v", c.nombre, got, c.want)
+		}
+	}
+}
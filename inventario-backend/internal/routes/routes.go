@@ -2,63 +2,151 @@ package routes
 
 import (
 	"inventario-backend/internal/handlers"
+	"inventario-backend/internal/repository"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-// corsMiddleware maneja las cabeceras CORS para todas las peticiones
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Configurar cabeceras CORS
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-
-		// Si es una petición OPTIONS (preflight), responder inmediatamente
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		// Continuar con el siguiente handler
-		next.ServeHTTP(w, r)
-	})
-}
-
-func SetupRoutes() *mux.Router {
+// SetupRoutes registra las rutas de la API. alertas recibe los productos de
+// cada movimiento registrado y puede ser nil. Las cabeceras CORS las agrega
+// main.go alrededor del router, para que cubran también las peticiones
+// OPTIONS de rutas que no las declaran.
+func SetupRoutes(repos repository.Repositories, alertas handlers.Notificador) *mux.Router {
 	r := mux.NewRouter()
 
-	// Middleware para CORS - aplicar a todas las rutas
-	r.Use(corsMiddleware)
+	productos := handlers.NewProductoHandler(repos.Productos)
+	categorias := handlers.NewCategoriaHandler(repos.Categorias)
+	almacenes := handlers.NewAlmacenHandler(repos.Almacenes)
+	movimientos := handlers.NewMovimientoHandler(repos.Movimientos, alertas)
+	traslados := handlers.NewTrasladoHandler(repos.Traslados, alertas)
+	conteos := handlers.NewConteoHandler(repos.Conteos, alertas)
+	alertasStock := handlers.NewAlertaHandler(repos.Alertas)
+	proveedores := handlers.NewProveedorHandler(repos.Proveedores)
+	compras := handlers.NewOrdenCompraHandler(repos.Compras, alertas)
+	ventas := handlers.NewOrdenVentaHandler(repos.Ventas, alertas)
+	devoluciones := handlers.NewDevolucionHandler(repos.Devoluciones, alertas)
+	lotes := handlers.NewLoteHandler(repos.Lotes)
+	series := handlers.NewSerieHandler(repos.Series)
+	ensamblajes := handlers.NewEnsamblajeHandler(repos.Ensamblajes, alertas)
+
+	// Identificador de petición para las respuestas de error y los logs
+	r.Use(handlers.RequestID)
+
+	// Respuestas JSON también para rutas y métodos inexistentes
+	r.NotFoundHandler = handlers.RequestID(http.HandlerFunc(handlers.NotFound))
+	r.MethodNotAllowedHandler = handlers.RequestID(http.HandlerFunc(handlers.MethodNotAllowed))
 
 	// Rutas de Productos
 	api := r.PathPrefix("/api").Subrouter()
-	// Aplicar CORS también al subrouter
-	api.Use(corsMiddleware)
-	
+
 	// Productos
-	api.HandleFunc("/productos", handlers.GetProductos).Methods("GET")
-	api.HandleFunc("/productos/{id}", handlers.GetProducto).Methods("GET")
-	api.HandleFunc("/productos", handlers.CreateProducto).Methods("POST")
-	api.HandleFunc("/productos/{id}", handlers.UpdateProducto).Methods("PUT")
-	api.HandleFunc("/productos/{id}", handlers.DeleteProducto).Methods("DELETE")
-	api.HandleFunc("/productos/categoria/{categoria_id}", handlers.GetProductosByCategoria).Methods("GET")
+	api.HandleFunc("/productos", productos.GetProductos).Methods("GET")
+	api.HandleFunc("/productos/lookup", productos.LookupProducto).Methods("GET")
+	api.HandleFunc("/productos/bajo-stock", productos.GetProductosBajoStock).Methods("GET")
+	api.HandleFunc("/productos/{id}", productos.GetProducto).Methods("GET")
+	api.HandleFunc("/productos/{id}/stock", movimientos.GetStockAl).Methods("GET")
+	api.HandleFunc("/productos/{id}/kardex", movimientos.GetKardex).Methods("GET")
+	api.HandleFunc("/productos/{id}/proveedores", proveedores.GetProveedoresProducto).Methods("GET")
+	api.HandleFunc("/productos/{id}/variantes", productos.GetVariantes).Methods("GET")
+	api.HandleFunc("/productos", productos.CreateProducto).Methods("POST")
+	api.HandleFunc("/productos/{id}/variantes", productos.CreateVariante).Methods("POST")
+	api.HandleFunc("/productos/{id}/componentes", productos.SetComponentes).Methods("PUT")
+	api.HandleFunc("/productos/{id}", productos.UpdateProducto).Methods("PUT")
+	api.HandleFunc("/productos/{id}", productos.DeleteProducto).Methods("DELETE")
+	api.HandleFunc("/productos/categoria/{categoria_id}", productos.GetProductosByCategoria).Methods("GET")
 
 	// Categorías
-	api.HandleFunc("/categorias", handlers.GetCategorias).Methods("GET")
-	api.HandleFunc("/categorias/{id}", handlers.GetCategoria).Methods("GET")
-	api.HandleFunc("/categorias", handlers.CreateCategoria).Methods("POST")
-	api.HandleFunc("/categorias/{id}", handlers.UpdateCategoria).Methods("PUT")
-	api.HandleFunc("/categorias/{id}", handlers.DeleteCategoria).Methods("DELETE")
+	api.HandleFunc("/categorias", categorias.GetCategorias).Methods("GET")
+	api.HandleFunc("/categorias/{id}", categorias.GetCategoria).Methods("GET")
+	api.HandleFunc("/categorias", categorias.CreateCategoria).Methods("POST")
+	api.HandleFunc("/categorias/{id}", categorias.UpdateCategoria).Methods("PUT")
+	api.HandleFunc("/categorias/{id}", categorias.DeleteCategoria).Methods("DELETE")
+
+	// Almacenes
+	api.HandleFunc("/almacenes", almacenes.GetAlmacenes).Methods("GET")
+	api.HandleFunc("/almacenes/{id}", almacenes.GetAlmacen).Methods("GET")
+	api.HandleFunc("/almacenes", almacenes.CreateAlmacen).Methods("POST")
+	api.HandleFunc("/almacenes/{id}", almacenes.UpdateAlmacen).Methods("PUT")
+	api.HandleFunc("/almacenes/{id}", almacenes.DeleteAlmacen).Methods("DELETE")
+
+	// Proveedores
+	api.HandleFunc("/proveedores", proveedores.GetProveedores).Methods("GET")
+	api.HandleFunc("/proveedores/{id}", proveedores.GetProveedor).Methods("GET")
+	api.HandleFunc("/proveedores", proveedores.CreateProveedor).Methods("POST")
+	api.HandleFunc("/proveedores/{id}", proveedores.UpdateProveedor).Methods("PUT")
+	api.HandleFunc("/proveedores/{id}", proveedores.DeleteProveedor).Methods("DELETE")
+	api.HandleFunc("/proveedores/{id}/productos", proveedores.GetProductosProveedor).Methods("GET")
+	api.HandleFunc("/proveedores/{id}/productos/{producto_id}", proveedores.VincularProducto).Methods("PUT")
+	api.HandleFunc("/proveedores/{id}/productos/{producto_id}", proveedores.DesvincularProducto).Methods("DELETE")
 
 	// Movimientos de Inventario
-	api.HandleFunc("/movimientos", handlers.GetMovimientos).Methods("GET")
-	api.HandleFunc("/movimientos/{id}", handlers.GetMovimiento).Methods("GET")
-	api.HandleFunc("/movimientos", handlers.CreateMovimiento).Methods("POST")
-	api.HandleFunc("/movimientos/producto/{producto_id}", handlers.GetMovimientosByProducto).Methods("GET")
+	api.HandleFunc("/movimientos", movimientos.GetMovimientos).Methods("GET")
+	api.HandleFunc("/movimientos/{id}", movimientos.GetMovimiento).Methods("GET")
+	api.HandleFunc("/movimientos", movimientos.CreateMovimiento).Methods("POST")
+	api.HandleFunc("/movimientos/{id}/revertir", movimientos.RevertirMovimiento).Methods("POST")
+	api.HandleFunc("/movimientos/producto/{producto_id}", movimientos.GetMovimientosByProducto).Methods("GET")
+	api.HandleFunc("/motivos-ajuste", movimientos.GetMotivosAjuste).Methods("GET")
+
+	// Traslados entre almacenes
+	api.HandleFunc("/traslados", traslados.GetTraslados).Methods("GET")
+	api.HandleFunc("/traslados/{id}", traslados.GetTraslado).Methods("GET")
+	api.HandleFunc("/traslados", traslados.CreateTraslado).Methods("POST")
+	api.HandleFunc("/traslados/{id}/recibir", traslados.RecibirTraslado).Methods("POST")
+
+	// Conteos físicos
+	api.HandleFunc("/conteos", conteos.GetConteos).Methods("GET")
+	api.HandleFunc("/conteos/{id}", conteos.GetConteo).Methods("GET")
+	api.HandleFunc("/conteos", conteos.CreateConteo).Methods("POST")
+	api.HandleFunc("/conteos/{id}/registros", conteos.RegistrarConteo).Methods("POST")
+	api.HandleFunc("/conteos/{id}/aprobar", conteos.AprobarConteo).Methods("POST")
+	api.HandleFunc("/conteos/{id}/cancelar", conteos.CancelarConteo).Methods("POST")
+
+	// Órdenes de compra
+	api.HandleFunc("/ordenes-compra", compras.GetOrdenesCompra).Methods("GET")
+	api.HandleFunc("/ordenes-compra/{id}", compras.GetOrdenCompra).Methods("GET")
+	api.HandleFunc("/ordenes-compra", compras.CreateOrdenCompra).Methods("POST")
+	api.HandleFunc("/ordenes-compra/{id}", compras.UpdateOrdenCompra).Methods("PUT")
+	api.HandleFunc("/ordenes-compra/{id}/enviar", compras.EnviarOrdenCompra).Methods("POST")
+	api.HandleFunc("/ordenes-compra/{id}/recibir", compras.RecibirOrdenCompra).Methods("POST")
+	api.HandleFunc("/ordenes-compra/{id}/cancelar", compras.CancelarOrdenCompra).Methods("POST")
+
+	// Órdenes de venta
+	api.HandleFunc("/ordenes-venta", ventas.GetOrdenesVenta).Methods("GET")
+	api.HandleFunc("/ordenes-venta/{id}", ventas.GetOrdenVenta).Methods("GET")
+	api.HandleFunc("/ordenes-venta", ventas.CreateOrdenVenta).Methods("POST")
+	api.HandleFunc("/ordenes-venta/{id}", ventas.UpdateOrdenVenta).Methods("PUT")
+	api.HandleFunc("/ordenes-venta/{id}/confirmar", ventas.ConfirmarOrdenVenta).Methods("POST")
+	api.HandleFunc("/ordenes-venta/{id}/despachar", ventas.DespacharOrdenVenta).Methods("POST")
+	api.HandleFunc("/ordenes-venta/{id}/cancelar", ventas.CancelarOrdenVenta).Methods("POST")
+
+	// Devoluciones de clientes
+	api.HandleFunc("/devoluciones", devoluciones.GetDevoluciones).Methods("GET")
+	api.HandleFunc("/devoluciones/cuarentena", devoluciones.GetCuarentena).Methods("GET")
+	api.HandleFunc("/devoluciones/{id}", devoluciones.GetDevolucion).Methods("GET")
+	api.HandleFunc("/devoluciones", devoluciones.CreateDevolucion).Methods("POST")
+	api.HandleFunc("/devoluciones/{id}/items/{item_id}/resolver", devoluciones.ResolverItem).Methods("POST")
+
+	// Ensamblajes de kits
+	api.HandleFunc("/ensamblajes", ensamblajes.GetEnsamblajes).Methods("GET")
+	api.HandleFunc("/ensamblajes/{id}", ensamblajes.GetEnsamblaje).Methods("GET")
+	api.HandleFunc("/ensamblajes", ensamblajes.CreateEnsamblaje).Methods("POST")
+
+	// Lotes y vencimientos
+	api.HandleFunc("/lotes", lotes.GetLotes).Methods("GET")
+	api.HandleFunc("/lotes/por-vencer", lotes.GetLotesPorVencer).Methods("GET")
+
+	// Números de serie
+	api.HandleFunc("/series", series.GetSeries).Methods("GET")
+	api.HandleFunc("/series/rastreo", series.RastrearSerie).Methods("GET")
+
+	// Alertas de stock bajo
+	api.HandleFunc("/alertas", alertasStock.GetAlertas).Methods("GET")
+	api.HandleFunc("/alertas/{id}/reconocer", alertasStock.ReconocerAlerta).Methods("POST")
+
+	// Reportes
+	api.HandleFunc("/reportes/conciliacion", movimientos.GetConciliacion).Methods("GET")
+	api.HandleFunc("/reportes/valoracion", productos.GetValoracion).Methods("GET")
 
 	// Ruta de salud
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -69,4 +157,3 @@ func SetupRoutes() *mux.Router {
 
 	return r
 }
-
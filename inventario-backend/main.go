@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"inventario-backend/internal/alertas"
 	"inventario-backend/internal/config"
 	"inventario-backend/internal/database"
+	"inventario-backend/internal/repository"
+	"inventario-backend/internal/repository/memory"
+	"inventario-backend/internal/repository/postgres"
 	"inventario-backend/internal/routes"
 	"log"
 	"net/http"
@@ -15,8 +21,8 @@ func corsHandler(next http.Handler) http.Handler {
 		// Configurar cabeceras CORS
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Length, X-Total-Count, X-Next-Cursor, X-Request-ID")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
 		// Si es una petición OPTIONS (preflight), responder inmediatamente
@@ -31,20 +37,61 @@ func corsHandler(next http.Handler) http.Handler {
 }
 
 func main() {
+	storage := flag.String("storage", "", "backend de almacenamiento: postgres o memory (por defecto $STORAGE o postgres)")
+	requireMigrations := flag.Bool("require-migrations", false, "no arrancar si hay migraciones pendientes (también $DB_REQUIRE_MIGRATIONS)")
+	flag.Parse()
+
 	// Cargar configuración
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Error al cargar la configuración: %v", err)
 	}
+	if *storage != "" {
+		cfg.Storage = *storage
+	}
+
+	// Inicializar el almacenamiento
+	var repos repository.Repositories
+	switch cfg.Storage {
+	case "postgres":
+		if err := cfg.ValidateDB(); err != nil {
+			log.Fatalf("Error al cargar la configuración: %v", err)
+		}
+		db, err := database.InitDB(cfg)
+		if err != nil {
+			log.Fatalf("Error al conectar con la base de datos: %v", err)
+		}
+		defer db.Close()
 
-	// Inicializar base de datos
-	if err := database.InitDB(cfg); err != nil {
-		log.Fatalf("Error al conectar con la base de datos: %v", err)
+		if *requireMigrations || cfg.RequireMigrations {
+			pending, err := database.Pending(context.Background(), db)
+			if err != nil {
+				log.Fatalf("Error al verificar las migraciones: %v", err)
+			}
+			if len(pending) > 0 {
+				for _, m := range pending {
+					log.Printf("⏳ Migración pendiente: %04d_%s", m.Version, m.Name)
+				}
+				log.Fatalf("Hay %d migración(es) pendiente(s). Ejecuta: go run ./cmd/setup-db up", len(pending))
+			}
+		}
+		repos = postgres.NewRepositories(db)
+	case "memory":
+		repos = memory.NewRepositories()
+		if err := memory.CargarDatosEjemplo(context.Background(), repos); err != nil {
+			log.Fatalf("Error al cargar los datos de ejemplo: %v", err)
+		}
+		fmt.Println("🧪 Usando almacenamiento en memoria (los datos se pierden al reiniciar)")
+	default:
+		log.Fatalf("Almacenamiento desconocido: %q (usa postgres o memory)", cfg.Storage)
 	}
-	defer database.CloseDB()
+
+	// Evaluar las alertas de stock bajo en segundo plano
+	evaluador := alertas.NewEvaluador(repos.Alertas)
+	go evaluador.Ejecutar(context.Background())
 
 	// Configurar rutas
-	router := routes.SetupRoutes()
+	router := routes.SetupRoutes(repos, evaluador)
 
 	// Envolver el router con middleware CORS a nivel de servidor
 	handler := corsHandler(router)
@@ -17,7 +17,7 @@ import {
   MovimientoInventarioRequest,
 } from "@/models/MovimientoInventario";
 import { MovimientoController } from "@/controllers/MovimientoController";
-import { Plus, ArrowUp, ArrowDown } from "lucide-react";
+import { Plus, ArrowUp, ArrowDown, SlidersHorizontal } from "lucide-react";
 import { ApiError } from "@/lib/api";
 import { useToast } from "@/components/ui/toast";
 
@@ -148,6 +148,13 @@ export default function MovimientosPage() {
                                 Entrada
                               </span>
                             </>
+                          ) : movimiento.tipo === "ajuste" ? (
+                            <>
+                              <SlidersHorizontal className="h-4 w-4 text-amber-600" />
+                              <span className="text-amber-600 font-semibold">
+                                Ajuste
+                              </span>
+                            </>
                           ) : (
                             <>
                               <ArrowDown className="h-4 w-4 text-red-600" />
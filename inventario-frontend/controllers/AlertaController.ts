@@ -0,0 +1,15 @@
+import fetchApi from "@/lib/api";
+import { Alerta, EstadoAlerta } from "@/models/Alerta";
+
+export class AlertaController {
+  static async getAll(estado?: EstadoAlerta): Promise<Alerta[]> {
+    const query = estado ? `?estado=${estado}` : "";
+    return fetchApi<Alerta[]>(`/alertas${query}`);
+  }
+
+  static async reconocer(id: number): Promise<Alerta> {
+    return fetchApi<Alerta>(`/alertas/${id}/reconocer`, {
+      method: "POST",
+    });
+  }
+}
@@ -0,0 +1,33 @@
+import fetchApi from "@/lib/api";
+import { Almacen, AlmacenRequest } from "@/models/Almacen";
+
+export class AlmacenController {
+  static async getAll(): Promise<Almacen[]> {
+    return fetchApi<Almacen[]>("/almacenes");
+  }
+
+  static async getById(id: number): Promise<Almacen> {
+    return fetchApi<Almacen>(`/almacenes/${id}`);
+  }
+
+  static async create(data: AlmacenRequest): Promise<Almacen> {
+    return fetchApi<Almacen>("/almacenes", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async update(id: number, data: AlmacenRequest): Promise<Almacen> {
+    return fetchApi<Almacen>(`/almacenes/${id}`, {
+      method: "PUT",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async delete(id: number): Promise<void> {
+    return fetchApi<void>(`/almacenes/${id}`, {
+      method: "DELETE",
+    });
+  }
+}
+
@@ -0,0 +1,39 @@
+import fetchApi from "@/lib/api";
+import { Conteo, ConteoRegistroRequest, ConteoRequest } from "@/models/Conteo";
+
+export class ConteoController {
+  static async getAll(): Promise<Conteo[]> {
+    return fetchApi<Conteo[]>("/conteos");
+  }
+
+  static async getById(id: number, soloDiferencias = false): Promise<Conteo> {
+    const query = soloDiferencias ? "?diferencias=true" : "";
+    return fetchApi<Conteo>(`/conteos/${id}${query}`);
+  }
+
+  static async create(data: ConteoRequest): Promise<Conteo> {
+    return fetchApi<Conteo>("/conteos", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async registrar(id: number, data: ConteoRegistroRequest): Promise<Conteo> {
+    return fetchApi<Conteo>(`/conteos/${id}/registros`, {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async aprobar(id: number): Promise<Conteo> {
+    return fetchApi<Conteo>(`/conteos/${id}/aprobar`, {
+      method: "POST",
+    });
+  }
+
+  static async cancelar(id: number): Promise<Conteo> {
+    return fetchApi<Conteo>(`/conteos/${id}/cancelar`, {
+      method: "POST",
+    });
+  }
+}
@@ -0,0 +1,31 @@
+import fetchApi from "@/lib/api";
+import { Devolucion, DevolucionItem, DevolucionRequest, EstadoItemDevolucion } from "@/models/Devolucion";
+
+export class DevolucionController {
+  static async getAll(ordenVentaId?: number): Promise<Devolucion[]> {
+    const query = ordenVentaId ? `?orden_venta_id=${ordenVentaId}` : "";
+    return fetchApi<Devolucion[]>(`/devoluciones${query}`);
+  }
+
+  static async getById(id: number): Promise<Devolucion> {
+    return fetchApi<Devolucion>(`/devoluciones/${id}`);
+  }
+
+  static async getCuarentena(): Promise<DevolucionItem[]> {
+    return fetchApi<DevolucionItem[]>("/devoluciones/cuarentena");
+  }
+
+  static async create(data: DevolucionRequest): Promise<Devolucion> {
+    return fetchApi<Devolucion>("/devoluciones", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async resolver(id: number, itemId: number, estado: EstadoItemDevolucion): Promise<Devolucion> {
+    return fetchApi<Devolucion>(`/devoluciones/${id}/items/${itemId}/resolver`, {
+      method: "POST",
+      body: JSON.stringify({ estado }),
+    });
+  }
+}
@@ -0,0 +1,20 @@
+import fetchApi from "@/lib/api";
+import { Ensamblaje, EnsamblajeRequest } from "@/models/Ensamblaje";
+
+export class EnsamblajeController {
+  static async getAll(productoId?: number): Promise<Ensamblaje[]> {
+    const query = productoId ? `?producto_id=${productoId}` : "";
+    return fetchApi<Ensamblaje[]>(`/ensamblajes${query}`);
+  }
+
+  static async getById(id: number): Promise<Ensamblaje> {
+    return fetchApi<Ensamblaje>(`/ensamblajes/${id}`);
+  }
+
+  static async create(data: EnsamblajeRequest): Promise<Ensamblaje> {
+    return fetchApi<Ensamblaje>("/ensamblajes", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+}
@@ -0,0 +1,14 @@
+import fetchApi from "@/lib/api";
+import { Lote } from "@/models/Lote";
+
+export class LoteController {
+  static async getByProducto(productoId: number, conStock = false): Promise<Lote[]> {
+    const query = conStock ? "&con_stock=true" : "";
+    return fetchApi<Lote[]>(`/lotes?producto_id=${productoId}${query}`);
+  }
+
+  static async getPorVencer(dias?: number): Promise<Lote[]> {
+    const query = dias !== undefined ? `?dias=${dias}` : "";
+    return fetchApi<Lote[]>(`/lotes/por-vencer${query}`);
+  }
+}
@@ -1,5 +1,8 @@
-import fetchApi from "@/lib/api";
+import fetchApi, { API_URL } from "@/lib/api";
+import { Conciliacion, StockFecha } from "@/models/Conciliacion";
+import { Kardex, KardexParams } from "@/models/Kardex";
 import {
+  MotivoAjuste,
   MovimientoInventario,
   MovimientoInventarioRequest,
 } from "@/models/MovimientoInventario";
@@ -27,5 +30,47 @@ export class MovimientoController {
       body: JSON.stringify(data),
     });
   }
+
+  static async revertir(id: number, motivo?: string): Promise<MovimientoInventario> {
+    return fetchApi<MovimientoInventario>(`/movimientos/${id}/revertir`, {
+      method: "POST",
+      body: JSON.stringify({ motivo }),
+    });
+  }
+
+  static async getMotivosAjuste(): Promise<MotivoAjuste[]> {
+    return fetchApi<MotivoAjuste[]>("/motivos-ajuste");
+  }
+
+  static async getStockAl(productoId: number, fecha?: string): Promise<StockFecha> {
+    const query = fecha ? `?fecha=${encodeURIComponent(fecha)}` : "";
+    return fetchApi<StockFecha>(`/productos/${productoId}/stock${query}`);
+  }
+
+  static async getKardex(productoId: number, params: KardexParams = {}): Promise<Kardex> {
+    return fetchApi<Kardex>(`/productos/${productoId}/kardex${kardexQuery(params)}`);
+  }
+
+  // URL del kardex en CSV o en versión imprimible, para enlazarla o abrirla
+  // en otra pestaña
+  static kardexUrl(
+    productoId: number,
+    formato: "csv" | "html",
+    params: KardexParams = {}
+  ): string {
+    return `${API_URL}/productos/${productoId}/kardex${kardexQuery({ ...params, formato })}`;
+  }
+
+  static async getConciliacion(): Promise<Conciliacion[]> {
+    return fetchApi<Conciliacion[]>("/reportes/conciliacion");
+  }
 }
 
+function kardexQuery(params: KardexParams & { formato?: string }): string {
+  const query = new URLSearchParams();
+  Object.entries(params).forEach(([clave, valor]) => {
+    if (valor) query.set(clave, valor);
+  });
+  const texto = query.toString();
+  return texto ? `?${texto}` : "";
+}
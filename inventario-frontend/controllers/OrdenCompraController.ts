@@ -0,0 +1,51 @@
+import fetchApi from "@/lib/api";
+import {
+  EstadoOrdenCompra,
+  OrdenCompra,
+  OrdenCompraRequest,
+  RecepcionRequest,
+} from "@/models/OrdenCompra";
+
+export class OrdenCompraController {
+  static async getAll(estado?: EstadoOrdenCompra): Promise<OrdenCompra[]> {
+    const query = estado ? `?estado=${estado}` : "";
+    return fetchApi<OrdenCompra[]>(`/ordenes-compra${query}`);
+  }
+
+  static async getById(id: number): Promise<OrdenCompra> {
+    return fetchApi<OrdenCompra>(`/ordenes-compra/${id}`);
+  }
+
+  static async create(data: OrdenCompraRequest): Promise<OrdenCompra> {
+    return fetchApi<OrdenCompra>("/ordenes-compra", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async update(id: number, data: OrdenCompraRequest): Promise<OrdenCompra> {
+    return fetchApi<OrdenCompra>(`/ordenes-compra/${id}`, {
+      method: "PUT",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async enviar(id: number): Promise<OrdenCompra> {
+    return fetchApi<OrdenCompra>(`/ordenes-compra/${id}/enviar`, {
+      method: "POST",
+    });
+  }
+
+  static async recibir(id: number, data: RecepcionRequest): Promise<OrdenCompra> {
+    return fetchApi<OrdenCompra>(`/ordenes-compra/${id}/recibir`, {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async cancelar(id: number): Promise<OrdenCompra> {
+    return fetchApi<OrdenCompra>(`/ordenes-compra/${id}/cancelar`, {
+      method: "POST",
+    });
+  }
+}
@@ -0,0 +1,46 @@
+import fetchApi from "@/lib/api";
+import { DespachoRequest, EstadoOrdenVenta, OrdenVenta, OrdenVentaRequest } from "@/models/OrdenVenta";
+
+export class OrdenVentaController {
+  static async getAll(estado?: EstadoOrdenVenta): Promise<OrdenVenta[]> {
+    const query = estado ? `?estado=${estado}` : "";
+    return fetchApi<OrdenVenta[]>(`/ordenes-venta${query}`);
+  }
+
+  static async getById(id: number): Promise<OrdenVenta> {
+    return fetchApi<OrdenVenta>(`/ordenes-venta/${id}`);
+  }
+
+  static async create(data: OrdenVentaRequest): Promise<OrdenVenta> {
+    return fetchApi<OrdenVenta>("/ordenes-venta", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async update(id: number, data: OrdenVentaRequest): Promise<OrdenVenta> {
+    return fetchApi<OrdenVenta>(`/ordenes-venta/${id}`, {
+      method: "PUT",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async confirmar(id: number): Promise<OrdenVenta> {
+    return fetchApi<OrdenVenta>(`/ordenes-venta/${id}/confirmar`, {
+      method: "POST",
+    });
+  }
+
+  static async despachar(id: number, data?: DespachoRequest): Promise<OrdenVenta> {
+    return fetchApi<OrdenVenta>(`/ordenes-venta/${id}/despachar`, {
+      method: "POST",
+      body: data ? JSON.stringify(data) : undefined,
+    });
+  }
+
+  static async cancelar(id: number): Promise<OrdenVenta> {
+    return fetchApi<OrdenVenta>(`/ordenes-venta/${id}/cancelar`, {
+      method: "POST",
+    });
+  }
+}
@@ -1,5 +1,7 @@
 import fetchApi from "@/lib/api";
-import { Producto, ProductoRequest } from "@/models/Producto";
+import { ComponenteRequest } from "@/models/Ensamblaje";
+import { Producto, ProductoRequest, VarianteRequest } from "@/models/Producto";
+import { Valoracion } from "@/models/Valoracion";
 
 export class ProductoController {
   static async getAll(): Promise<Producto[]> {
@@ -14,6 +16,19 @@ export class ProductoController {
     return fetchApi<Producto[]>(`/productos/categoria/${categoriaId}`);
   }
 
+  static async getBajoStock(): Promise<Producto[]> {
+    return fetchApi<Producto[]>("/productos/bajo-stock");
+  }
+
+  static async lookup(params: { barcode?: string; sku?: string }): Promise<Producto> {
+    const query = new URLSearchParams(params as Record<string, string>);
+    return fetchApi<Producto>(`/productos/lookup?${query.toString()}`);
+  }
+
+  static async getVariantes(id: number): Promise<Producto[]> {
+    return fetchApi<Producto[]>(`/productos/${id}/variantes`);
+  }
+
   static async create(data: ProductoRequest): Promise<Producto> {
     return fetchApi<Producto>("/productos", {
       method: "POST",
@@ -21,6 +36,21 @@ export class ProductoController {
     });
   }
 
+  static async createVariante(id: number, data: VarianteRequest): Promise<Producto> {
+    return fetchApi<Producto>(`/productos/${id}/variantes`, {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  // Una lista vacía vuelve el kit un producto común
+  static async setComponentes(id: number, componentes: ComponenteRequest[]): Promise<Producto> {
+    return fetchApi<Producto>(`/productos/${id}/componentes`, {
+      method: "PUT",
+      body: JSON.stringify({ componentes }),
+    });
+  }
+
   static async update(id: number, data: ProductoRequest): Promise<Producto> {
     return fetchApi<Producto>(`/productos/${id}`, {
       method: "PUT",
@@ -33,5 +63,10 @@ export class ProductoController {
       method: "DELETE",
     });
   }
+
+  static async getValoracion(categoriaId?: number): Promise<Valoracion> {
+    const query = categoriaId ? `?categoria_id=${categoriaId}` : "";
+    return fetchApi<Valoracion>(`/reportes/valoracion${query}`);
+  }
 }
 
@@ -0,0 +1,62 @@
+import fetchApi from "@/lib/api";
+import {
+  ProductoProveedor,
+  ProductoProveedorRequest,
+  Proveedor,
+  ProveedorRequest,
+} from "@/models/Proveedor";
+
+export class ProveedorController {
+  static async getAll(): Promise<Proveedor[]> {
+    return fetchApi<Proveedor[]>("/proveedores");
+  }
+
+  static async getById(id: number): Promise<Proveedor> {
+    return fetchApi<Proveedor>(`/proveedores/${id}`);
+  }
+
+  static async create(data: ProveedorRequest): Promise<Proveedor> {
+    return fetchApi<Proveedor>("/proveedores", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async update(id: number, data: ProveedorRequest): Promise<Proveedor> {
+    return fetchApi<Proveedor>(`/proveedores/${id}`, {
+      method: "PUT",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async delete(id: number): Promise<void> {
+    return fetchApi<void>(`/proveedores/${id}`, {
+      method: "DELETE",
+    });
+  }
+
+  static async getProductos(id: number): Promise<ProductoProveedor[]> {
+    return fetchApi<ProductoProveedor[]>(`/proveedores/${id}/productos`);
+  }
+
+  static async getByProducto(productoId: number): Promise<ProductoProveedor[]> {
+    return fetchApi<ProductoProveedor[]>(`/productos/${productoId}/proveedores`);
+  }
+
+  static async vincular(
+    id: number,
+    productoId: number,
+    data: ProductoProveedorRequest
+  ): Promise<ProductoProveedor> {
+    return fetchApi<ProductoProveedor>(`/proveedores/${id}/productos/${productoId}`, {
+      method: "PUT",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async desvincular(id: number, productoId: number): Promise<void> {
+    return fetchApi<void>(`/proveedores/${id}/productos/${productoId}`, {
+      method: "DELETE",
+    });
+  }
+}
@@ -0,0 +1,13 @@
+import fetchApi from "@/lib/api";
+import { Serie } from "@/models/Serie";
+
+export class SerieController {
+  static async getByProducto(productoId: number, enStock = false): Promise<Serie[]> {
+    const query = enStock ? "&en_stock=true" : "";
+    return fetchApi<Serie[]>(`/series?producto_id=${productoId}${query}`);
+  }
+
+  static async rastrear(numero: string): Promise<Serie[]> {
+    return fetchApi<Serie[]>(`/series/rastreo?numero=${encodeURIComponent(numero)}`);
+  }
+}
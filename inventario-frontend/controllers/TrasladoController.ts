@@ -0,0 +1,25 @@
+import fetchApi from "@/lib/api";
+import { Traslado, TrasladoRequest } from "@/models/Traslado";
+
+export class TrasladoController {
+  static async getAll(): Promise<Traslado[]> {
+    return fetchApi<Traslado[]>("/traslados");
+  }
+
+  static async getById(id: number): Promise<Traslado> {
+    return fetchApi<Traslado>(`/traslados/${id}`);
+  }
+
+  static async create(data: TrasladoRequest): Promise<Traslado> {
+    return fetchApi<Traslado>("/traslados", {
+      method: "POST",
+      body: JSON.stringify(data),
+    });
+  }
+
+  static async recibir(id: number): Promise<Traslado> {
+    return fetchApi<Traslado>(`/traslados/${id}/recibir`, {
+      method: "POST",
+    });
+  }
+}
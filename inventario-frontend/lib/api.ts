@@ -1,10 +1,26 @@
-const API_URL = process.env.NEXT_PUBLIC_API_URL || "https://juang.makerstech.co/api";
+export const API_URL = process.env.NEXT_PUBLIC_API_URL || "https://juang.makerstech.co/api";
+
+export interface ApiErrorDetail {
+  field: string;
+  message: string;
+}
+
+// Cuerpo de error estándar de la API
+export interface ApiErrorBody {
+  code: string;
+  message: string;
+  details?: ApiErrorDetail[];
+  request_id?: string;
+}
 
 export class ApiError extends Error {
   constructor(
     message: string,
     public status: number,
-    public response?: any
+    public response?: any,
+    public code?: string,
+    public details?: ApiErrorDetail[],
+    public requestId?: string
   ) {
     super(message);
     this.name = "ApiError";
@@ -30,13 +46,21 @@ async function fetchApi<T>(
     
     if (!response.ok) {
       let errorMessage = `Error ${response.status}: ${response.statusText}`;
+      let errorData: ApiErrorBody | undefined;
       try {
-        const errorData = await response.json();
-        errorMessage = errorData.error || errorMessage;
+        errorData = await response.json();
+        errorMessage = errorData?.message || errorMessage;
       } catch {
         // Si no se puede parsear el error, usar el mensaje por defecto
       }
-      throw new ApiError(errorMessage, response.status);
+      throw new ApiError(
+        errorMessage,
+        response.status,
+        errorData,
+        errorData?.code,
+        errorData?.details,
+        errorData?.request_id
+      );
     }
 
     // Si la respuesta es 204 No Content, retornar void
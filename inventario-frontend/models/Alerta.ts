@@ -0,0 +1,17 @@
+export type NivelAlerta = "reorden" | "minimo";
+
+export type EstadoAlerta = "activa" | "reconocida" | "resuelta";
+
+export interface Alerta {
+  id: number;
+  producto_id: number;
+  producto: string;
+  nivel: NivelAlerta;
+  estado: EstadoAlerta;
+  stock: number;
+  umbral: number;
+  cantidad_reorden: number;
+  created_at: string;
+  reconocida_at?: string;
+  resuelta_at?: string;
+}
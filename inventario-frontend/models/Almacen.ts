@@ -0,0 +1,20 @@
+export interface Almacen {
+  id: number;
+  nombre: string;
+  descripcion: string;
+  principal: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface AlmacenRequest {
+  nombre: string;
+  descripcion: string;
+  principal?: boolean;
+}
+
+export interface StockAlmacen {
+  almacen_id: number;
+  almacen: string;
+  cantidad: number;
+}
@@ -0,0 +1,25 @@
+import { StockAlmacen } from "./Almacen";
+
+export interface StockFecha {
+  producto_id: number;
+  hasta: string;
+  stock: number;
+  almacenes: StockAlmacen[];
+}
+
+export interface ConciliacionAlmacen {
+  almacen_id: number;
+  almacen: string;
+  stock_registrado: number;
+  stock_movimientos: number;
+  diferencia: number;
+}
+
+export interface Conciliacion {
+  producto_id: number;
+  producto: string;
+  stock_registrado: number;
+  stock_movimientos: number;
+  diferencia: number;
+  almacenes?: ConciliacionAlmacen[];
+}
@@ -0,0 +1,47 @@
+import { Producto } from "./Producto";
+
+export type EstadoConteo = "abierto" | "aprobado" | "cancelado";
+
+export interface ConteoRegistro {
+  producto_id: number;
+  pasada: number;
+  contador: string;
+  cantidad: number;
+  lote?: string;
+  vencimiento?: string;
+  series?: string[];
+  created_at: string;
+}
+
+export interface ConteoItem {
+  producto_id: number;
+  producto?: Producto;
+  stock_esperado: number;
+  cantidad_contada: number | null;
+  diferencia: number | null;
+  movimiento_id?: number;
+  registros?: ConteoRegistro[];
+}
+
+export interface Conteo {
+  id: number;
+  almacen_id: number;
+  categoria_id?: number;
+  estado: EstadoConteo;
+  descripcion: string;
+  created_at: string;
+  cerrado_at?: string;
+  items?: ConteoItem[];
+}
+
+export interface ConteoRequest {
+  almacen_id?: number;
+  categoria_id?: number;
+  descripcion: string;
+}
+
+export interface ConteoRegistroRequest {
+  pasada?: number;
+  contador: string;
+  items: { producto_id: number; cantidad: number; lote?: string; vencimiento?: string; series?: string[] }[];
+}
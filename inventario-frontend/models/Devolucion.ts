@@ -0,0 +1,41 @@
+import { Producto } from "./Producto";
+
+export type CondicionDevolucion = "reutilizable" | "danado" | "devolver_proveedor";
+
+export type EstadoItemDevolucion = "repuesto" | "cuarentena" | "baja" | "devuelto_proveedor";
+
+export interface DevolucionItem {
+  id: number;
+  devolucion_id: number;
+  producto_id: number;
+  producto?: Producto;
+  cantidad: number;
+  condicion: CondicionDevolucion;
+  estado: EstadoItemDevolucion;
+  series?: string[];
+  movimiento_id?: number;
+  resuelto_at?: string;
+}
+
+export interface Devolucion {
+  id: number;
+  orden_venta_id?: number;
+  movimiento_id?: number;
+  almacen_id: number;
+  motivo: string;
+  created_at: string;
+  items?: DevolucionItem[];
+}
+
+export interface DevolucionRequest {
+  orden_venta_id?: number;
+  movimiento_id?: number;
+  motivo: string;
+  items: {
+    producto_id: number;
+    cantidad: number;
+    condicion: CondicionDevolucion;
+    destino?: EstadoItemDevolucion;
+    series?: string[];
+  }[];
+}
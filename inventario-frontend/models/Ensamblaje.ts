@@ -0,0 +1,36 @@
+import { MovimientoInventario } from "./MovimientoInventario";
+import { Producto } from "./Producto";
+
+export interface Componente {
+  producto_id: number;
+  producto?: Producto;
+  cantidad: number;
+}
+
+export interface ComponenteRequest {
+  producto_id: number;
+  cantidad: number;
+}
+
+export type TipoEnsamblaje = "ensamblaje" | "desensamblaje";
+
+export interface Ensamblaje {
+  id: number;
+  tipo: TipoEnsamblaje;
+  producto_id: number;
+  producto?: Producto;
+  almacen_id: number;
+  cantidad: number;
+  motivo: string;
+  created_at: string;
+  movimientos?: MovimientoInventario[];
+}
+
+export interface EnsamblajeRequest {
+  tipo: TipoEnsamblaje;
+  producto_id: number;
+  // Por defecto el almacén principal
+  almacen_id?: number;
+  cantidad: number;
+  motivo?: string;
+}
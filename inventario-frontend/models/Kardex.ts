@@ -0,0 +1,42 @@
+import { CodigoMotivo, TipoMovimiento } from "./MovimientoInventario";
+
+export type MetodoCosteo = "promedio" | "fifo";
+
+export interface KardexSaldo {
+  cantidad: number;
+  costo_unitario: number;
+  valor: number;
+}
+
+export interface KardexLinea {
+  movimiento_id: number;
+  fecha: string;
+  tipo: TipoMovimiento;
+  codigo_motivo?: CodigoMotivo;
+  motivo: string;
+  almacen_id: number;
+  almacen: string;
+  entrada: number;
+  salida: number;
+  costo_unitario: number;
+  costo_total: number;
+  costo_estimado?: boolean;
+  saldo: KardexSaldo;
+}
+
+export interface Kardex {
+  producto_id: number;
+  producto: string;
+  metodo: MetodoCosteo;
+  desde?: string;
+  hasta?: string;
+  saldo_inicial: KardexSaldo;
+  lineas: KardexLinea[];
+  saldo_final: KardexSaldo;
+}
+
+export interface KardexParams {
+  desde?: string;
+  hasta?: string;
+  metodo?: MetodoCosteo;
+}
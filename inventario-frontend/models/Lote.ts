@@ -0,0 +1,19 @@
+import { Producto } from "./Producto";
+
+export interface Lote {
+  id: number;
+  producto_id: number;
+  producto?: Producto;
+  almacen_id: number;
+  codigo: string;
+  vencimiento?: string;
+  cantidad: number;
+  created_at: string;
+}
+
+export interface MovimientoLote {
+  lote_id: number;
+  codigo: string;
+  vencimiento?: string;
+  cantidad: number;
+}
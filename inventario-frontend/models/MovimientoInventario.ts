@@ -1,21 +1,65 @@
+import { Almacen } from "./Almacen";
+import { MovimientoLote } from "./Lote";
 import { Producto } from "./Producto";
+import { Proveedor } from "./Proveedor";
 
-export type TipoMovimiento = "entrada" | "salida";
+export type TipoMovimiento = "entrada" | "salida" | "ajuste";
+
+export type CodigoMotivo =
+  | "merma"
+  | "dano"
+  | "conteo"
+  | "devolucion"
+  | "hallazgo"
+  | "inicial";
+
+export interface MotivoAjuste {
+  codigo: CodigoMotivo;
+  nombre: string;
+  descripcion: string;
+}
 
 export interface MovimientoInventario {
   id: number;
   producto_id: number;
   producto?: Producto;
+  almacen_id: number;
+  almacen?: Almacen;
   tipo: TipoMovimiento;
   cantidad: number;
+  codigo_motivo?: CodigoMotivo;
   motivo: string;
+  costo_unitario?: number;
+  moneda?: string;
+  tipo_cambio?: number;
+  costo_total?: number;
+  proveedor_id?: number;
+  proveedor?: Proveedor;
+  traslado_id?: number;
+  orden_compra_id?: number;
+  orden_venta_id?: number;
+  devolucion_id?: number;
+  ensamblaje_id?: number;
+  revierte_id?: number;
+  revertido_por_id?: number;
+  lotes?: MovimientoLote[];
+  series?: string[];
   created_at: string;
 }
 
 export interface MovimientoInventarioRequest {
   producto_id: number;
+  almacen_id?: number;
   tipo: TipoMovimiento;
   cantidad: number;
+  codigo_motivo?: CodigoMotivo;
   motivo: string;
+  costo_unitario?: number;
+  moneda?: string;
+  tipo_cambio?: number;
+  proveedor_id?: number;
+  lote?: string;
+  vencimiento?: string;
+  series?: string[];
 }
 
@@ -0,0 +1,44 @@
+import { Producto } from "./Producto";
+
+export type EstadoOrdenCompra =
+  | "borrador"
+  | "enviada"
+  | "recibida_parcial"
+  | "recibida"
+  | "cancelada";
+
+export interface OrdenCompraLinea {
+  producto_id: number;
+  producto?: Producto;
+  cantidad: number;
+  costo_unitario: number;
+  cantidad_recibida: number;
+  cantidad_pendiente: number;
+}
+
+export interface OrdenCompra {
+  id: number;
+  proveedor_id: number;
+  proveedor: string;
+  almacen_id: number;
+  estado: EstadoOrdenCompra;
+  notas: string;
+  total: number;
+  created_at: string;
+  updated_at: string;
+  enviada_at?: string;
+  cerrada_at?: string;
+  lineas?: OrdenCompraLinea[];
+}
+
+export interface OrdenCompraRequest {
+  proveedor_id: number;
+  almacen_id?: number;
+  notas: string;
+  lineas: { producto_id: number; cantidad: number; costo_unitario: number }[];
+}
+
+export interface RecepcionRequest {
+  items: { producto_id: number; cantidad: number; lote?: string; vencimiento?: string; series?: string[] }[];
+  permitir_exceso?: boolean;
+}
@@ -0,0 +1,35 @@
+import { Producto } from "./Producto";
+
+export type EstadoOrdenVenta = "borrador" | "confirmada" | "despachada" | "cancelada";
+
+export interface OrdenVentaLinea {
+  producto_id: number;
+  producto?: Producto;
+  cantidad: number;
+  precio_unitario: number;
+}
+
+export interface OrdenVenta {
+  id: number;
+  cliente: string;
+  almacen_id: number;
+  estado: EstadoOrdenVenta;
+  notas: string;
+  total: number;
+  created_at: string;
+  updated_at: string;
+  confirmada_at?: string;
+  cerrada_at?: string;
+  lineas?: OrdenVentaLinea[];
+}
+
+export interface OrdenVentaRequest {
+  cliente: string;
+  almacen_id?: number;
+  notas: string;
+  lineas: { producto_id: number; cantidad: number; precio_unitario?: number }[];
+}
+
+export interface DespachoRequest {
+  items: { producto_id: number; series: string[] }[];
+}
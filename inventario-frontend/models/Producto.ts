@@ -1,13 +1,37 @@
+import { StockAlmacen } from "./Almacen";
 import { Categoria } from "./Categoria";
+import { Componente } from "./Ensamblaje";
+import { MetodoCosteo } from "./Kardex";
 
 export interface Producto {
   id: number;
   nombre: string;
   descripcion: string;
+  sku?: string;
+  codigo_barras?: string;
   precio: number;
   stock: number;
+  stock_almacenes?: StockAlmacen[];
+  reservado: number;
+  disponible: number;
+  stock_minimo: number;
+  punto_reorden: number;
+  cantidad_reorden: number;
   categoria_id: number;
   categoria?: Categoria;
+  metodo_costeo: MetodoCosteo;
+  controla_lotes: boolean;
+  controla_series: boolean;
+  valor_inventario: number;
+  // Solo en las variantes
+  producto_padre_id?: number;
+  atributos?: Record<string, string>;
+  hereda_precio?: boolean;
+  // Solo en los padres: cuántas variantes tiene; su stock es la suma del de ellas
+  variantes?: number;
+  // Solo en los kits: su lista de materiales y cuántos se pueden armar con ella
+  componentes?: Componente[];
+  ensamblables?: number;
   created_at: string;
   updated_at: string;
 }
@@ -15,8 +39,31 @@ export interface Producto {
 export interface ProductoRequest {
   nombre: string;
   descripcion: string;
+  // Al editar, omitirlos conserva los actuales y "" los quita
+  sku?: string;
+  codigo_barras?: string;
   precio: number;
   stock: number;
+  costo_unitario?: number;
+  stock_minimo?: number;
+  punto_reorden?: number;
+  cantidad_reorden?: number;
   categoria_id: number;
+  metodo_costeo?: MetodoCosteo;
+  controla_lotes?: boolean;
+  controla_series?: boolean;
 }
 
+
+// Sin precio, la variante hereda el del padre
+export interface VarianteRequest {
+  atributos: Record<string, string>;
+  sku?: string;
+  codigo_barras?: string;
+  precio?: number;
+  stock?: number;
+  costo_unitario?: number;
+  stock_minimo?: number;
+  punto_reorden?: number;
+  cantidad_reorden?: number;
+}
@@ -0,0 +1,39 @@
+export interface Proveedor {
+  id: number;
+  nombre: string;
+  contacto: string;
+  email: string;
+  telefono: string;
+  direccion: string;
+  notas: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ProveedorRequest {
+  nombre: string;
+  contacto?: string;
+  email?: string;
+  telefono?: string;
+  direccion?: string;
+  notas?: string;
+}
+
+export interface ProductoProveedor {
+  producto_id: number;
+  producto: string;
+  proveedor_id: number;
+  proveedor: string;
+  sku_proveedor: string;
+  plazo_entrega_dias: number;
+  ultimo_costo?: number;
+  ultima_entrada_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ProductoProveedorRequest {
+  sku_proveedor?: string;
+  plazo_entrega_dias?: number;
+  ultimo_costo?: number;
+}
@@ -0,0 +1,16 @@
+import { Almacen } from "./Almacen";
+import { MovimientoInventario } from "./MovimientoInventario";
+import { Producto } from "./Producto";
+
+export interface Serie {
+  id: number;
+  producto_id: number;
+  producto?: Producto;
+  numero: string;
+  almacen_id?: number;
+  almacen?: Almacen;
+  en_stock: boolean;
+  created_at: string;
+  updated_at: string;
+  movimientos?: MovimientoInventario[];
+}
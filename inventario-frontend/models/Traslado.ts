@@ -0,0 +1,28 @@
+import { MovimientoInventario } from "./MovimientoInventario";
+import { Producto } from "./Producto";
+
+export type EstadoTraslado = "en_transito" | "recibido";
+
+export interface Traslado {
+  id: number;
+  producto_id: number;
+  producto?: Producto;
+  almacen_origen_id: number;
+  almacen_destino_id: number;
+  cantidad: number;
+  estado: EstadoTraslado;
+  motivo: string;
+  created_at: string;
+  recibido_at?: string;
+  movimientos?: MovimientoInventario[];
+}
+
+export interface TrasladoRequest {
+  producto_id: number;
+  almacen_origen_id: number;
+  almacen_destino_id: number;
+  cantidad: number;
+  motivo: string;
+  en_transito?: boolean;
+  series?: string[];
+}
@@ -0,0 +1,23 @@
+import { MetodoCosteo } from "./Kardex";
+
+export interface ValoracionProducto {
+  producto_id: number;
+  producto: string;
+  metodo_costeo: MetodoCosteo;
+  stock: number;
+  costo_promedio: number;
+  valor: number;
+}
+
+export interface ValoracionCategoria {
+  categoria_id: number | null;
+  categoria: string;
+  stock: number;
+  valor: number;
+  productos: ValoracionProducto[];
+}
+
+export interface Valoracion {
+  valor: number;
+  categorias: ValoracionCategoria[];
+}
@@ -54,8 +54,13 @@ export function ProductoForm({
         setFormData({
           nombre: producto.nombre,
           descripcion: producto.descripcion,
+          sku: producto.sku ?? "",
+          codigo_barras: producto.codigo_barras ?? "",
           precio: producto.precio,
           stock: producto.stock,
+          stock_minimo: producto.stock_minimo,
+          punto_reorden: producto.punto_reorden,
+          cantidad_reorden: producto.cantidad_reorden,
           categoria_id: producto.categoria_id,
         });
       } else {
@@ -96,6 +101,14 @@ export function ProductoForm({
       if (formData.stock < 0) {
         throw new Error("El stock no puede ser negativo");
       }
+      const minimo = formData.stock_minimo ?? 0;
+      const reorden = formData.punto_reorden ?? 0;
+      if (minimo < 0 || reorden < 0 || (formData.cantidad_reorden ?? 0) < 0) {
+        throw new Error("Los umbrales de reposición no pueden ser negativos");
+      }
+      if (reorden > 0 && reorden < minimo) {
+        throw new Error("El punto de reorden no puede ser menor que el stock mínimo");
+      }
       if (formData.categoria_id === 0) {
         throw new Error("Debe seleccionar una categoría");
       }
@@ -145,6 +158,30 @@ export function ProductoForm({
                 }
               />
             </div>
+            <div className="grid grid-cols-2 gap-2">
+              <div className="grid gap-2">
+                <Label htmlFor="sku">SKU</Label>
+                <Input
+                  id="sku"
+                  maxLength={64}
+                  value={formData.sku ?? ""}
+                  onChange={(e) =>
+                    setFormData({ ...formData, sku: e.target.value })
+                  }
+                />
+              </div>
+              <div className="grid gap-2">
+                <Label htmlFor="codigo_barras">Código de barras</Label>
+                <Input
+                  id="codigo_barras"
+                  inputMode="numeric"
+                  value={formData.codigo_barras ?? ""}
+                  onChange={(e) =>
+                    setFormData({ ...formData, codigo_barras: e.target.value })
+                  }
+                />
+              </div>
+            </div>
             <div className="grid gap-2">
               <Label htmlFor="precio">Precio *</Label>
               <Input
@@ -163,12 +200,15 @@ export function ProductoForm({
               />
             </div>
             <div className="grid gap-2">
-              <Label htmlFor="stock">Stock *</Label>
+              <Label htmlFor="stock">
+                {producto ? "Stock (se modifica con movimientos)" : "Stock inicial *"}
+              </Label>
               <Input
                 id="stock"
                 type="number"
                 min="0"
                 value={formData.stock}
+                disabled={!!producto}
                 onChange={(e) =>
                   setFormData({
                     ...formData,
@@ -178,6 +218,31 @@ export function ProductoForm({
                 required
               />
             </div>
+            <div className="grid grid-cols-3 gap-2">
+              {(
+                [
+                  ["stock_minimo", "Stock mínimo"],
+                  ["punto_reorden", "Punto de reorden"],
+                  ["cantidad_reorden", "Cant. a reponer"],
+                ] as const
+              ).map(([campo, etiqueta]) => (
+                <div key={campo} className="grid gap-2">
+                  <Label htmlFor={campo}>{etiqueta}</Label>
+                  <Input
+                    id={campo}
+                    type="number"
+                    min="0"
+                    value={formData[campo] ?? 0}
+                    onChange={(e) =>
+                      setFormData({
+                        ...formData,
+                        [campo]: parseInt(e.target.value) || 0,
+                      })
+                    }
+                  />
+                </div>
+              ))}
+            </div>
             <div className="grid gap-2">
               <Label htmlFor="categoria">Categoría *</Label>
               <Select